@@ -95,9 +95,17 @@ const (
 	// ArchiveOperationFailedReason signals a failure in archive operation.
 	ArchiveOperationFailedReason string = "ArchiveOperationFailed"
 
+	// DiskFullReason signals a failure caused by the storage device running
+	// out of space.
+	DiskFullReason string = "DiskFull"
+
 	// SymlinkUpdateFailedReason signals a failure in updating a symlink.
 	SymlinkUpdateFailedReason string = "SymlinkUpdateFailed"
 
+	// BackupOperationFailedReason signals a failure in mirroring an Artifact
+	// to the backup storage.
+	BackupOperationFailedReason string = "BackupOperationFailed"
+
 	// ArtifactUpToDateReason signals that an existing Artifact is up-to-date
 	// with the Source.
 	ArtifactUpToDateReason string = "ArtifactUpToDate"
@@ -99,6 +99,13 @@ type BucketSpec struct {
 	// +optional
 	Ignore *string `json:"ignore,omitempty"`
 
+	// TagFilter selects the objects of the bucket by their tags. Only objects
+	// carrying all the given tags with the given values are included in the
+	// Artifact. For the 'gcp' provider, the custom metadata of the objects is
+	// matched instead.
+	// +optional
+	TagFilter map[string]string `json:"tagFilter,omitempty"`
+
 	// Suspend tells the controller to suspend the reconciliation of this
 	// Bucket.
 	// +optional
@@ -32,11 +32,45 @@ const (
 	// HelmRepositoryURLIndexKey is the key used for indexing HelmRepository
 	// objects by their HelmRepositorySpec.URL.
 	HelmRepositoryURLIndexKey = ".metadata.helmRepositoryURL"
+	// HelmRepositorySecretRefIndexKey is the key used for indexing
+	// HelmRepository objects by the names of the Secrets they reference.
+	HelmRepositorySecretRefIndexKey = ".metadata.helmRepositorySecretRef"
 	// HelmRepositoryTypeDefault is the default HelmRepository type.
 	// It is used when no type is specified and corresponds to a Helm repository.
 	HelmRepositoryTypeDefault = "default"
 	// HelmRepositoryTypeOCI is the type for an OCI repository.
 	HelmRepositoryTypeOCI = "oci"
+	// HelmRepositoryIndexFormatYAML is the default format of the index
+	// Artifact, stored with a ".yaml" extension.
+	HelmRepositoryIndexFormatYAML = "yaml"
+	// HelmRepositoryIndexFormatJSON is the format of the index Artifact when
+	// it is stored with a ".json" extension.
+	HelmRepositoryIndexFormatJSON = "json"
+	// HelmRepositoryRevisionComparisonStrong is the default revision
+	// comparison, which calculates the revision over the complete index.
+	HelmRepositoryRevisionComparisonStrong = "Strong"
+	// HelmRepositoryRevisionComparisonWeak is the revision comparison which
+	// calculates the revision over the index excluding its volatile fields.
+	HelmRepositoryRevisionComparisonWeak = "Weak"
+	// HelmRepositoryRevisionFormatDigest is the default revision format,
+	// "<algorithm>:<hex>".
+	HelmRepositoryRevisionFormatDigest = "Digest"
+	// HelmRepositoryRevisionFormatHex is the revision format without the
+	// algorithm, "<hex>".
+	HelmRepositoryRevisionFormatHex = "Hex"
+	// HelmRepositoryRevisionFormatShort is the revision format of the first
+	// 12 characters of "<hex>".
+	HelmRepositoryRevisionFormatShort = "Short"
+	// HelmRepositoryProvenanceAnnotation is the annotation of the index
+	// Artifact recording its provenance, when enabled with
+	// HelmRepositorySpec.EmbedProvenance.
+	HelmRepositoryProvenanceAnnotation = "source.toolkit.fluxcd.io/provenance"
+	// DefaultMaxChartNameLength is the default maximum length of a chart
+	// name when validating the chart names of the index.
+	DefaultMaxChartNameLength = 250
+	// DefaultIndexPolicyKey is the default key of the ConfigMap data holding
+	// the policy referenced in HelmRepositorySpec.Policy.
+	DefaultIndexPolicyKey = "policy.rego"
 )
 
 // HelmRepositorySpec specifies the required configuration to produce an
@@ -47,6 +81,25 @@ type HelmRepositorySpec struct {
 	// +required
 	URL string `json:"url"`
 
+	// AdditionalURLs of Helm repositories of which the indexes are fetched
+	// and merged into the index of the URL, in order. The same secretRef and
+	// certSecretRef are used to fetch the additional indexes, but their
+	// credentials are only sent to an additional URL with the same scheme
+	// and host as the URL, unless .spec.passCredentials is set.
+	// This field is only taken into account if the .spec.type field is not
+	// set to 'oci'.
+	// +optional
+	AdditionalURLs []string `json:"additionalURLs,omitempty"`
+
+	// MergePolicy defines which versions of a chart are kept when the chart
+	// is listed in multiple indexes. 'FirstWins' keeps the versions of the
+	// first index listing the chart, 'HighestVersionWins' keeps the versions
+	// of the index listing the highest version of the chart.
+	// When not specified, defaults to 'FirstWins'.
+	// +kubebuilder:validation:Enum=FirstWins;HighestVersionWins
+	// +optional
+	MergePolicy string `json:"mergePolicy,omitempty"`
+
 	// SecretRef specifies the Secret containing authentication credentials
 	// for the HelmRepository.
 	// For HTTP/S basic auth the secret must contain 'username' and 'password'
@@ -83,6 +136,90 @@ type HelmRepositorySpec struct {
 	// +optional
 	PassCredentials bool `json:"passCredentials,omitempty"`
 
+	// InsecureSkipTLSVerify disables the verification of the TLS certificate
+	// of the Helm repository, for the fetches of this HelmRepository only.
+	// This requires the controller to allow insecure TLS, and is reflected
+	// in the InsecureTLS Condition.
+	// This field is only taken into account if the .spec.type field is not
+	// set to 'oci'.
+	// +optional
+	InsecureSkipTLSVerify bool `json:"insecureSkipTLSVerify,omitempty"`
+
+	// MinTLSVersion is the minimum TLS version of the connections to the
+	// Helm repository. Defaults to the minimum version of the controller.
+	// This field is only taken into account if the .spec.type field is not
+	// set to 'oci'.
+	// +kubebuilder:validation:Enum="1.0";"1.1";"1.2";"1.3"
+	// +optional
+	MinTLSVersion string `json:"minTLSVersion,omitempty"`
+
+	// TLSCipherSuites restricts the cipher suites of the connections to the
+	// Helm repository to the given IANA names, e.g.
+	// 'TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256'. The cipher suites only
+	// apply to TLS 1.2 and lower. Defaults to the cipher suites of the
+	// controller.
+	// This field is only taken into account if the .spec.type field is not
+	// set to 'oci'.
+	// +optional
+	TLSCipherSuites []string `json:"tlsCipherSuites,omitempty"`
+
+	// FollowRedirects allows redirect responses to be followed while fetching
+	// the index. When false, a redirect results in a fetch failure.
+	// Credentials are never passed on to a different host while following
+	// redirects, unless PassCredentials is set.
+	// This field is only taken into account if the .spec.type field is not
+	// set to 'oci'. Defaults to true.
+	// +kubebuilder:default:=true
+	// +optional
+	FollowRedirects *bool `json:"followRedirects,omitempty"`
+
+	// CacheIndex allows the index to be kept in the in-memory cache of the
+	// controller, if enabled. When false, the index is only stored as an
+	// Artifact, which avoids the memory usage of caching large indexes.
+	// This field is only taken into account if the .spec.type field is not
+	// set to 'oci'. Defaults to true.
+	// +kubebuilder:default:=true
+	// +optional
+	CacheIndex *bool `json:"cacheIndex,omitempty"`
+
+	// ResolverAddress is the address of the DNS server used to resolve the
+	// host names of the index and chart URLs, in the form of 'host:port' or
+	// 'host' to use the default DNS port. The system resolver is used when
+	// not set.
+	// This field is only taken into account if the .spec.type field is not
+	// set to 'oci'.
+	// +optional
+	ResolverAddress string `json:"resolverAddress,omitempty"`
+
+	// ProxySecretRef specifies the Secret containing the proxy configuration
+	// to use while fetching the index and charts. The Secret must contain
+	// an 'address' key, and can contain 'username' and 'password' keys to
+	// authenticate with the proxy. These credentials are only sent to the
+	// proxy, and are separate from the credentials of the .spec.secretRef.
+	// This field is only taken into account if the .spec.type field is not
+	// set to 'oci'.
+	// +optional
+	ProxySecretRef *meta.LocalObjectReference `json:"proxySecretRef,omitempty"`
+
+	// HeadersSecretRef specifies the Secret containing the headers set on the
+	// requests of the index, and of the additional URLs with the same scheme
+	// and host, for repositories authenticating with e.g. an API key header.
+	// Every key of the Secret is the name of a header, and its value the
+	// value of the header. The headers are only sent to the host of the
+	// index, unless .spec.passCredentials is set.
+	// This field is only taken into account if the .spec.type field is not
+	// set to 'oci'.
+	// +optional
+	HeadersSecretRef *meta.LocalObjectReference `json:"headersSecretRef,omitempty"`
+
+	// ForceHTTP1 disables HTTP/2 for the requests of the index and charts,
+	// for servers which mishandle it. When unset, the protocol is negotiated
+	// with the server.
+	// This field is only taken into account if the .spec.type field is not
+	// set to 'oci'.
+	// +optional
+	ForceHTTP1 bool `json:"forceHTTP1,omitempty"`
+
 	// Interval at which the HelmRepository URL is checked for updates.
 	// This interval is approximate and may be subject to jitter to ensure
 	// efficient use of resources.
@@ -91,6 +228,38 @@ type HelmRepositorySpec struct {
 	// +required
 	Interval metav1.Duration `json:"interval"`
 
+	// RetryInterval is the interval at which a failed reconciliation of the
+	// HelmRepository is requeued, instead of the .spec.interval and the
+	// exponential backoff of the controller.
+	// A reconciliation failed if it returned an error, or served a stale
+	// Artifact.
+	// This field is only taken into account if the .spec.type field is not
+	// set to 'oci'.
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Pattern="^([0-9]+(\\.[0-9]+)?(ms|s|m|h))+$"
+	// +optional
+	RetryInterval *metav1.Duration `json:"retryInterval,omitempty"`
+
+	// IntervalFromCacheControl defers the interval at which the HelmRepository
+	// URL is checked for updates to the max-age directive of the
+	// Cache-Control header of the index response, clamped to the bounds
+	// configured on the controller. The Interval is used when the response
+	// has no max-age directive.
+	// This field is only taken into account if the .spec.type field is not
+	// set to 'oci'.
+	// +optional
+	IntervalFromCacheControl bool `json:"intervalFromCacheControl,omitempty"`
+
+	// Schedule is a cron expression (e.g. '0 2 * * *'), evaluated in UTC, at
+	// which the HelmRepository URL is checked for updates instead of at the
+	// Interval. A change to the object or a reconcile request annotation
+	// still results in an immediate reconciliation, and failures are retried
+	// regardless of the schedule.
+	// This field is only taken into account if the .spec.type field is not
+	// set to 'oci'.
+	// +optional
+	Schedule string `json:"schedule,omitempty"`
+
 	// Timeout is used for the index fetch operation for an HTTPS helm repository,
 	// and for remote OCI Repository operations like pulling for an OCI helm repository.
 	// Its default value is 60s.
@@ -100,11 +269,30 @@ type HelmRepositorySpec struct {
 	// +optional
 	Timeout *metav1.Duration `json:"timeout,omitempty"`
 
+	// Private makes the file server require the bearer token of the
+	// namespace of the HelmRepository, as reported in
+	// .status.artifactToken, to download the Artifact.
+	// +optional
+	Private bool `json:"private,omitempty"`
+
+	// ArtifactHost overrides the hostname the Artifact URLs of the
+	// HelmRepository are advertised with in the status, e.g. to serve
+	// consumers in different clusters. Defaults to the storage advertise
+	// address of the controller.
+	// +optional
+	ArtifactHost string `json:"artifactHost,omitempty"`
+
 	// Suspend tells the controller to suspend the reconciliation of this
 	// HelmRepository.
 	// +optional
 	Suspend bool `json:"suspend,omitempty"`
 
+	// PauseUntil tells the controller to suspend the reconciliation of this
+	// HelmRepository until the given time (RFC3339), after which the
+	// reconciliation resumes automatically.
+	// +optional
+	PauseUntil *metav1.Time `json:"pauseUntil,omitempty"`
+
 	// AccessFrom specifies an Access Control List for allowing cross-namespace
 	// references to this object.
 	// NOTE: Not implemented, provisional as of https://github.com/fluxcd/flux2/pull/2092
@@ -118,12 +306,380 @@ type HelmRepositorySpec struct {
 	Type string `json:"type,omitempty"`
 
 	// Provider used for authentication, can be 'aws', 'azure', 'gcp' or 'generic'.
-	// This field is optional, and only taken into account if the .spec.type field is set to 'oci'.
+	// This field is optional, and only taken into account if the .spec.type field is set to 'oci',
+	// except for 'aws', which signs the index requests with SigV4 otherwise.
 	// When not specified, defaults to 'generic'.
 	// +kubebuilder:validation:Enum=generic;aws;azure;gcp
 	// +kubebuilder:default:=generic
 	// +optional
 	Provider string `json:"provider,omitempty"`
+
+	// BlockVersions maps chart names to semver constraints of chart versions
+	// which are removed from the index before the Artifact is produced.
+	// This field is only taken into account if the .spec.type field is not
+	// set to 'oci'.
+	// +optional
+	BlockVersions map[string]string `json:"blockVersions,omitempty"`
+
+	// MaxVersionsPerChart is the maximum number of versions retained per
+	// chart in the index. When set, only the highest semver versions of each
+	// chart are kept before the Artifact is produced.
+	// This field is only taken into account if the .spec.type field is not
+	// set to 'oci'.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxVersionsPerChart int `json:"maxVersionsPerChart,omitempty"`
+
+	// CanonicalizeIndex sorts the chart versions in the index and drops its
+	// generated timestamp before the revision is calculated, so that indexes
+	// which only differ in ordering or generation time result in the same
+	// revision. Enabling this changes the revision of existing Artifacts.
+	// This field is only taken into account if the .spec.type field is not
+	// set to 'oci'.
+	// +optional
+	CanonicalizeIndex bool `json:"canonicalizeIndex,omitempty"`
+
+	// IgnoreGeneratedTimestamp drops the generated timestamp of the index
+	// before the revision is calculated, so that an index which is
+	// republished without changes does not result in a new Artifact. Unlike
+	// .spec.canonicalizeIndex, the order of the chart versions is kept.
+	// Enabling this changes the revision of existing Artifacts.
+	// This field is only taken into account if the .spec.type field is not
+	// set to 'oci'.
+	// +optional
+	IgnoreGeneratedTimestamp bool `json:"ignoreGeneratedTimestamp,omitempty"`
+
+	// IndexFormat is the format the index Artifact is stored in, and which
+	// is reflected in the name of the index symlink. Defaults to 'yaml'.
+	// This field is only taken into account if the .spec.type field is not
+	// set to 'oci'.
+	// +kubebuilder:validation:Enum=yaml;json
+	// +optional
+	IndexFormat string `json:"indexFormat,omitempty"`
+
+	// SymlinkName is the name of the symlink to the latest index Artifact,
+	// of which the URL is reported in the .status.url. It must be a plain
+	// file name. Defaults to 'index.yaml', or 'index.json' if the
+	// .spec.indexFormat field is set to 'json'.
+	// This field is only taken into account if the .spec.type field is not
+	// set to 'oci'.
+	// +kubebuilder:validation:MaxLength=253
+	// +kubebuilder:validation:Pattern="^[a-zA-Z0-9][a-zA-Z0-9._-]*$"
+	// +optional
+	SymlinkName string `json:"symlinkName,omitempty"`
+
+	// RevisionComparison determines how the revision of the index is
+	// calculated. 'Strong' calculates the revision over the complete index.
+	// 'Weak' excludes the volatile fields of the index, i.e. the generated
+	// timestamp, the server info and the annotations, so that an index of
+	// which only these fields changed does not result in a new Artifact.
+	// Defaults to 'Strong'. Changing this changes the revision of existing
+	// Artifacts.
+	// This field is only taken into account if the .spec.type field is not
+	// set to 'oci'.
+	// +kubebuilder:validation:Enum=Strong;Weak
+	// +optional
+	RevisionComparison string `json:"revisionComparison,omitempty"`
+
+	// RevisionFormat determines how the revision of the Artifact is
+	// reported. 'Digest' reports '<algorithm>:<hex>', 'Hex' reports '<hex>'
+	// and 'Short' reports the first 12 characters of '<hex>'. Regardless of
+	// the format, revisions are compared using the full digest. Defaults to
+	// 'Digest'.
+	// This field is only taken into account if the .spec.type field is not
+	// set to 'oci'.
+	// +kubebuilder:validation:Enum=Digest;Hex;Short
+	// +optional
+	RevisionFormat string `json:"revisionFormat,omitempty"`
+
+	// Treat404AsStalling marks the object as stalled when the index is not
+	// found (HTTP 404), instead of retrying the fetch at the interval.
+	// A change to the object is required to retry the fetch.
+	// This field is only taken into account if the .spec.type field is not
+	// set to 'oci'.
+	// +optional
+	Treat404AsStalling bool `json:"treat404AsStalling,omitempty"`
+
+	// AcceptHeader is the Accept header of the requests made to download the
+	// index, e.g. 'application/json' to negotiate a JSON index. When set, the
+	// Content-Type of the response must match it.
+	// This field is only taken into account if the .spec.type field is not
+	// set to 'oci'.
+	// +optional
+	AcceptHeader string `json:"acceptHeader,omitempty"`
+
+	// DigestHeader is the name of a response header in which the server
+	// advertises the digest of the index, e.g. 'Docker-Content-Digest'. When
+	// the response carries a digest of the canonical algorithm of the
+	// controller in it, the downloaded index must match it.
+	// This field is only taken into account if the .spec.type field is not
+	// set to 'oci'.
+	// +optional
+	DigestHeader string `json:"digestHeader,omitempty"`
+
+	// FailIfEmpty fails the reconciliation when the index has no entries,
+	// instead of producing an Artifact for the empty index. The current
+	// Artifact, if any, is kept.
+	// This field is only taken into account if the .spec.type field is not
+	// set to 'oci'.
+	// +optional
+	FailIfEmpty bool `json:"failIfEmpty,omitempty"`
+
+	// EmbedProvenance records the provenance of the index in the Artifact,
+	// as a JSON document with the URL the index was fetched from, the time it
+	// was fetched and the name of the controller, in the
+	// 'source.toolkit.fluxcd.io/provenance' annotation of the index.
+	// As the digest of the Artifact covers the provenance, enabling it
+	// changes the digest of existing Artifacts.
+	// This field is only taken into account if the .spec.type field is not
+	// set to 'oci'.
+	// +optional
+	EmbedProvenance bool `json:"embedProvenance,omitempty"`
+
+	// PreflightURL is the URL of an endpoint which is requested before the
+	// index, to obtain a token (e.g. a nonce or CSRF token) which is attached
+	// to the index request. It is requested with the same authentication and
+	// TLS configuration as the index.
+	// This field is only taken into account if the .spec.type field is not
+	// set to 'oci'.
+	// +kubebuilder:validation:Pattern="^(http|https)://.*$"
+	// +optional
+	PreflightURL string `json:"preflightURL,omitempty"`
+
+	// PreflightToken configures how the token is extracted from the response
+	// of the .spec.preflightURL, and how it is attached to the index request.
+	// Defaults to the complete response body, attached with the
+	// 'X-CSRF-Token' header.
+	// +optional
+	PreflightToken *PreflightToken `json:"preflightToken,omitempty"`
+
+	// ShardManifestURL is the URL of a manifest listing the URLs of the
+	// shards the index is split into, under a 'shards' key. Relative URLs are
+	// resolved against the URL of the manifest, and the shards must be served
+	// from the same host. When set, the shards are fetched and merged into a
+	// single index instead of fetching the index from the .spec.url, and the
+	// reconciliation fails if any of the shards can not be fetched.
+	// This field is only taken into account if the .spec.type field is not
+	// set to 'oci'.
+	// +kubebuilder:validation:Pattern="^(http|https)://.*$"
+	// +optional
+	ShardManifestURL string `json:"shardManifestURL,omitempty"`
+
+	// MaxArtifactAge is the maximum age of the Artifact after which the
+	// fetched index is loaded and validated again, even when it is an exact
+	// match to the current Artifact. The age is counted from the last time
+	// the index was validated, as reported in .status.lastFetchTime.
+	// This field is only taken into account if the .spec.type field is not
+	// set to 'oci'.
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Pattern="^([0-9]+(\\.[0-9]+)?(ms|s|m|h))+$"
+	// +optional
+	MaxArtifactAge *metav1.Duration `json:"maxArtifactAge,omitempty"`
+
+	// DigestSpotCheck enables the verification of the digests advertised in
+	// the index for a random sample of chart versions, by downloading the
+	// charts and comparing their digests.
+	// This field is only taken into account if the .spec.type field is not
+	// set to 'oci'.
+	// +optional
+	DigestSpotCheck *DigestSpotCheck `json:"digestSpotCheck,omitempty"`
+
+	// CanaryFetch enables fetching the index a second time after a delay,
+	// and only applying the index when the digests of both fetches agree.
+	// This guards against load balanced repositories of which the backends
+	// serve different indexes. On a mismatch, the current Artifact is kept.
+	// This field is only taken into account if the .spec.type field is not
+	// set to 'oci'.
+	// +optional
+	CanaryFetch *CanaryFetch `json:"canaryFetch,omitempty"`
+
+	// ChartURLCheck enables a check of the reachability of the chart URLs
+	// in the index for a random sample of chart versions, which records a
+	// ChartsUnreachable Condition without failing the reconciliation.
+	// This field is only taken into account if the .spec.type field is not
+	// set to 'oci'.
+	// +optional
+	ChartURLCheck *ChartURLCheck `json:"chartURLCheck,omitempty"`
+
+	// ServeStaleOnError keeps serving the last Artifact when the index can
+	// not be fetched, instead of marking the object as not ready, for at most
+	// the configured staleness.
+	// This field is only taken into account if the .spec.type field is not
+	// set to 'oci'.
+	// +optional
+	ServeStaleOnError *ServeStaleOnError `json:"serveStaleOnError,omitempty"`
+
+	// SummarizeDependencies enables the summary of the dependencies between
+	// the charts in the index, which is reported in the
+	// .status.chartDependencies. This does not affect the Artifact.
+	// This field is only taken into account if the .spec.type field is not
+	// set to 'oci'.
+	// +optional
+	SummarizeDependencies bool `json:"summarizeDependencies,omitempty"`
+
+	// ChartNameValidation enables the validation of the chart names in the
+	// index, removing or rejecting the charts with an empty or too long name
+	// before the Artifact is produced.
+	// This field is only taken into account if the .spec.type field is not
+	// set to 'oci'.
+	// +optional
+	ChartNameValidation *ChartNameValidation `json:"chartNameValidation,omitempty"`
+
+	// Policy references a Rego policy which is evaluated against the index
+	// before the Artifact is produced. The fetch fails when the policy
+	// denies the index.
+	// This field is only taken into account if the .spec.type field is not
+	// set to 'oci'.
+	// +optional
+	Policy *IndexPolicy `json:"policy,omitempty"`
+}
+
+// DigestSpotCheck configures the verification of the digests advertised in
+// a Helm repository index.
+type DigestSpotCheck struct {
+	// SampleSize is the number of randomly sampled chart versions of which
+	// the digest is verified, each time a new index revision is fetched.
+	// +kubebuilder:validation:Minimum=1
+	// +required
+	SampleSize int `json:"sampleSize"`
+
+	// MaxMismatches is the number of sampled chart versions with a
+	// mismatching digest which is tolerated, before the index is considered
+	// to be corrupt. A sampled chart version which can not be downloaded
+	// counts as a mismatch. Defaults to 0.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxMismatches int `json:"maxMismatches,omitempty"`
+}
+
+// CanaryFetch configures the second fetch of a Helm repository index, which
+// has to agree with the first.
+type CanaryFetch struct {
+	// Delay is the time waited between the two fetches. Defaults to 1s.
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Pattern="^([0-9]+(\\.[0-9]+)?(ms|s|m))+$"
+	// +optional
+	Delay *metav1.Duration `json:"delay,omitempty"`
+}
+
+// GetDelay returns the time waited between the two fetches, which defaults to
+// one second.
+func (in CanaryFetch) GetDelay() time.Duration {
+	if in.Delay == nil {
+		return time.Second
+	}
+	return in.Delay.Duration
+}
+
+// ChartURLCheck configures the check of the reachability of the chart URLs
+// in a Helm repository index.
+type ChartURLCheck struct {
+	// SampleSize is the number of randomly sampled chart versions of which
+	// the URL is checked, each time a new index revision is fetched.
+	// +kubebuilder:validation:Minimum=1
+	// +required
+	SampleSize int `json:"sampleSize"`
+
+	// MaxUnreachable is the number of sampled chart versions with an
+	// unreachable URL which is tolerated, before the ChartsUnreachable
+	// Condition is recorded. Defaults to 0.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxUnreachable int `json:"maxUnreachable,omitempty"`
+}
+
+// PreflightToken configures the extraction of the token from the response of
+// a preflight request, and the header it is attached to the index request
+// with.
+type PreflightToken struct {
+	// Header is the name of the response header holding the token.
+	// +optional
+	Header string `json:"header,omitempty"`
+
+	// JSONPath is the dot-separated path of the string field holding the
+	// token in the JSON response body, e.g. 'data.token'. It is only used
+	// when .header is not set.
+	// +optional
+	JSONPath string `json:"jsonPath,omitempty"`
+
+	// RequestHeader is the name of the header the token is attached to the
+	// index request with. Defaults to 'X-CSRF-Token'.
+	// +optional
+	RequestHeader string `json:"requestHeader,omitempty"`
+}
+
+// ChartNameValidation configures the validation of the chart names in a Helm
+// repository index.
+type ChartNameValidation struct {
+	// MaxLength is the maximum length of a chart name. Defaults to 250.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxLength int `json:"maxLength,omitempty"`
+
+	// Strict fails the fetch when the index contains invalid chart names,
+	// instead of removing the charts from the index.
+	// +optional
+	Strict bool `json:"strict,omitempty"`
+}
+
+// IndexPolicy references a Rego policy evaluated against a Helm repository
+// index.
+type IndexPolicy struct {
+	// ConfigMapRef references the ConfigMap in the same namespace as the
+	// HelmRepository holding the policy.
+	// +required
+	ConfigMapRef meta.LocalObjectReference `json:"configMapRef"`
+
+	// Key is the key of the ConfigMap data holding the policy. Defaults to
+	// 'policy.rego'.
+	// +optional
+	Key string `json:"key,omitempty"`
+}
+
+// GetKey returns the key of the ConfigMap data holding the policy, which
+// defaults to DefaultIndexPolicyKey.
+func (in IndexPolicy) GetKey() string {
+	if in.Key == "" {
+		return DefaultIndexPolicyKey
+	}
+	return in.Key
+}
+
+// GetMaxLength returns the maximum length of a chart name, which defaults to
+// DefaultMaxChartNameLength.
+func (in ChartNameValidation) GetMaxLength() int {
+	if in.MaxLength <= 0 {
+		return DefaultMaxChartNameLength
+	}
+	return in.MaxLength
+}
+
+// ChartDependencies summarizes the dependencies between the charts in a Helm
+// repository index, as declared by the latest version of each chart.
+type ChartDependencies struct {
+	// Dependencies is the total number of dependencies declared by the
+	// latest versions of the charts.
+	Dependencies int64 `json:"dependencies"`
+
+	// InternalDependencies is the number of Dependencies on a chart in the
+	// index itself.
+	InternalDependencies int64 `json:"internalDependencies"`
+
+	// DependentCharts is the number of charts of which the latest version
+	// declares at least one dependency.
+	DependentCharts int64 `json:"dependentCharts"`
+}
+
+// ServeStaleOnError configures the serving of the last Artifact of a
+// HelmRepository when its index can not be fetched.
+type ServeStaleOnError struct {
+	// MaxStaleness is the maximum duration for which the last Artifact is
+	// served after the first failure to fetch the index. Once exceeded, the
+	// object is marked as not ready until the index is fetched again.
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Pattern="^([0-9]+(\\.[0-9]+)?(ms|s|m|h))+$"
+	// +required
+	MaxStaleness metav1.Duration `json:"maxStaleness"`
 }
 
 // HelmRepositoryStatus records the observed state of the HelmRepository.
@@ -147,13 +703,242 @@ type HelmRepositoryStatus struct {
 	// +optional
 	Artifact *apiv1.Artifact `json:"artifact,omitempty"`
 
+	// SignatureURL is the URL of the detached signature of the Artifact,
+	// made with the signing key of the controller. It is empty when the
+	// controller does not sign Artifacts.
+	// +optional
+	SignatureURL string `json:"signatureURL,omitempty"`
+
+	// ObservedURL is the effective Helm repository URL the index was last
+	// fetched from.
+	// +optional
+	ObservedURL string `json:"observedURL,omitempty"`
+
+	// ChartCount is the number of charts in the last loaded index.
+	// +optional
+	ChartCount *int64 `json:"chartCount,omitempty"`
+
+	// ChartVersionCount is the total number of chart versions in the last
+	// loaded index.
+	// +optional
+	ChartVersionCount *int64 `json:"chartVersionCount,omitempty"`
+
+	// ChartDependencies summarizes the dependencies between the charts in the
+	// last loaded index, when .spec.summarizeDependencies is true.
+	// +optional
+	ChartDependencies *ChartDependencies `json:"chartDependencies,omitempty"`
+
+	// History holds the most recent fetch failures of the HelmRepository,
+	// oldest first. The number of retained entries is configured on the
+	// controller.
+	// +optional
+	History []FetchFailure `json:"history,omitempty"`
+
+	// StaleSince is the time of the first failure to fetch the index after
+	// the last successful fetch, while the last Artifact is served stale.
+	// +optional
+	StaleSince *metav1.Time `json:"staleSince,omitempty"`
+
+	// LastFetchTime is the last time the index was fetched, loaded and
+	// validated, in contrast to fetches which matched the current Artifact.
+	// +optional
+	LastFetchTime *metav1.Time `json:"lastFetchTime,omitempty"`
+
+	// LastSuccessfulFetchTime is the last time the index was fetched
+	// successfully, including fetches which matched the current Artifact.
+	// +optional
+	LastSuccessfulFetchTime *metav1.Time `json:"lastSuccessfulFetchTime,omitempty"`
+
+	// LastFetchStatusCode is the HTTP status code of the response to the
+	// last attempt to fetch the index, whether it succeeded or not. It is
+	// empty when no response was received, e.g. due to a connection error.
+	// +optional
+	LastFetchStatusCode int `json:"lastFetchStatusCode,omitempty"`
+
+	// ConsecutiveFailures is the number of consecutive reconciliations of
+	// the HelmRepository which failed, including those which served a stale
+	// Artifact. It is reset by the first successful reconciliation.
+	// +optional
+	ConsecutiveFailures int64 `json:"consecutiveFailures,omitempty"`
+
+	// LastReconcileDuration is the duration of the last reconciliation of
+	// the HelmRepository, up to the update of its status.
+	// +optional
+	LastReconcileDuration *metav1.Duration `json:"lastReconcileDuration,omitempty"`
+
+	// NextScheduledTime is the time of the next scheduled reconciliation,
+	// when the HelmRepository is reconciled on a .spec.schedule.
+	// +optional
+	NextScheduledTime *metav1.Time `json:"nextScheduledTime,omitempty"`
+
+	// ArtifactToken is the bearer token with which the Artifact can be
+	// downloaded from the file server when .spec.private is true. The token
+	// is shared by all private HelmRepositories in the namespace.
+	// +optional
+	ArtifactToken string `json:"artifactToken,omitempty"`
+
 	meta.ReconcileRequestStatus `json:",inline"`
 }
 
+// FetchFailure records a failed attempt to fetch a Helm repository index.
+type FetchFailure struct {
+	// Time is the time of the failure.
+	// +required
+	Time metav1.Time `json:"time"`
+
+	// Reason is the reason of the FetchFailed condition of the failure.
+	// +required
+	Reason string `json:"reason"`
+
+	// Message is the (possibly truncated) message of the FetchFailed
+	// condition of the failure.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
 const (
 	// IndexationFailedReason signals that the HelmRepository index fetch
 	// failed.
 	IndexationFailedReason string = "IndexationFailed"
+
+	// RedirectNotAllowedReason signals that the HelmRepository index fetch
+	// failed due to a redirect, while redirects are not allowed.
+	RedirectNotAllowedReason string = "RedirectNotAllowed"
+
+	// NetworkErrorReason signals that the HelmRepository index fetch failed
+	// due to a network timeout, including the .spec.timeout elapsing.
+	NetworkErrorReason string = "NetworkError"
+
+	// BlockedVersionsReason signals that chart versions were removed from the
+	// HelmRepository index as they match the blocked versions.
+	BlockedVersionsReason string = "BlockedVersions"
+
+	// IndexNotFoundReason signals that the HelmRepository index could not be
+	// found at the URL.
+	IndexNotFoundReason string = "IndexNotFound"
+
+	// UnsupportedEncodingReason signals that the HelmRepository index
+	// response has a Content-Encoding which can not be decoded.
+	UnsupportedEncodingReason string = "UnsupportedEncoding"
+
+	// UnexpectedContentTypeReason signals that the HelmRepository index
+	// response has a Content-Type which does not match the .spec.acceptHeader.
+	UnexpectedContentTypeReason string = "UnexpectedContentType"
+
+	// TruncatedResponseReason signals that the HelmRepository index response
+	// ended before its full body was received.
+	TruncatedResponseReason string = "TruncatedResponse"
+
+	// DigestMismatchReason signals that the HelmRepository index does not
+	// match the digest advertised in the .spec.digestHeader of the response.
+	DigestMismatchReason string = "DigestMismatch"
+
+	// PolicyViolationReason signals that the HelmRepository index is denied
+	// by the policy referenced in the .spec.policy.
+	PolicyViolationReason string = "PolicyViolation"
+
+	// EmptyIndexReason signals that the HelmRepository index has no
+	// entries, while .spec.failIfEmpty is set.
+	EmptyIndexReason string = "EmptyIndex"
+
+	// SignatureOperationFailedReason signals that the Artifact could not be
+	// signed.
+	SignatureOperationFailedReason string = "SignatureOperationFailed"
+
+	// IndexChangedReason signals that chart versions were added to or
+	// removed from the HelmRepository index since the current Artifact.
+	IndexChangedReason string = "IndexChanged"
+
+	// InsecureCredentialTransportReason signals that the credentials of the
+	// HelmRepository are (or would be) sent over plaintext HTTP.
+	InsecureCredentialTransportReason string = "InsecureCredentialTransport"
+
+	// HostNotAllowedReason signals that the host of a HelmRepository URL is
+	// not in the allowlist of upstream hosts configured on the controller.
+	HostNotAllowedReason string = "HostNotAllowed"
+
+	// InsecureTLSCondition indicates that the TLS certificate of the Helm
+	// repository is not verified while fetching the index.
+	InsecureTLSCondition string = "InsecureTLS"
+
+	// InsecureSkipTLSVerifyReason signals that the verification of the TLS
+	// certificate of the Helm repository is (or would be) skipped, as
+	// configured in the .spec.insecureSkipTLSVerify field.
+	InsecureSkipTLSVerifyReason string = "InsecureSkipTLSVerify"
+
+	// InvalidTLSPolicyReason signals that the .spec.minTLSVersion or
+	// .spec.tlsCipherSuites of the HelmRepository are not supported.
+	InvalidTLSPolicyReason string = "InvalidTLSPolicy"
+
+	// TLSHandshakeFailedReason signals that the TLS handshake with the Helm
+	// repository failed, while the HelmRepository restricts the TLS version
+	// or cipher suites.
+	TLSHandshakeFailedReason string = "TLSHandshakeFailed"
+
+	// InvalidChartNamesReason signals that the HelmRepository index contains
+	// charts with an empty or too long name.
+	InvalidChartNamesReason string = "InvalidChartNames"
+
+	// IndexIntegrityFailedReason signals that the digests advertised in the
+	// HelmRepository index do not match the digests of the charts.
+	IndexIntegrityFailedReason string = "IndexIntegrityFailed"
+
+	// ChartsUnreachableCondition indicates that the URLs of a sample of the
+	// chart versions in the HelmRepository index could not be reached.
+	ChartsUnreachableCondition string = "ChartsUnreachable"
+
+	// ChartURLsUnreachableReason signals that more sampled chart URLs of the
+	// HelmRepository index could not be reached than tolerated.
+	ChartURLsUnreachableReason string = "ChartURLsUnreachable"
+
+	// PausedCondition indicates that the reconciliation of the HelmRepository
+	// is paused until the time of the .spec.pauseUntil field.
+	PausedCondition string = "Paused"
+
+	// PauseUntilReason signals that the reconciliation of the HelmRepository
+	// is paused until the time of the .spec.pauseUntil field.
+	PauseUntilReason string = "PauseUntil"
+
+	// InvalidScheduleReason signals that the .spec.schedule of the
+	// HelmRepository is not a valid cron expression.
+	InvalidScheduleReason string = "InvalidSchedule"
+
+	// StaleArtifactCondition indicates that the Artifact of the
+	// HelmRepository is served while the index can not be fetched.
+	StaleArtifactCondition string = "StaleArtifact"
+
+	// ServingStaleArtifactReason signals that the last Artifact of the
+	// HelmRepository is served after a failure to fetch the index.
+	ServingStaleArtifactReason string = "ServingStaleArtifact"
+
+	// MaxStalenessExceededReason signals that the index of the HelmRepository
+	// could not be fetched for longer than the maximum staleness.
+	MaxStalenessExceededReason string = "MaxStalenessExceeded"
+
+	// SuspiciousIndexChangeCondition indicates that a fetched index of the
+	// HelmRepository was not applied, as it differs suspiciously from the
+	// index of the current Artifact.
+	SuspiciousIndexChangeCondition string = "SuspiciousIndexChange"
+
+	// ChartCountDecreasedReason signals that the number of charts in the
+	// fetched index decreased below the configured ratio of the number of
+	// charts in the index of the current Artifact.
+	ChartCountDecreasedReason string = "ChartCountDecreased"
+
+	// UpstreamInconsistentCondition indicates that a fetched index of the
+	// HelmRepository was not applied, as a canary fetch of the index
+	// resulted in a different digest.
+	UpstreamInconsistentCondition string = "UpstreamInconsistent"
+
+	// UpstreamInconsistentReason signals that two fetches of the index of
+	// the HelmRepository resulted in different digests.
+	UpstreamInconsistentReason string = "UpstreamInconsistent"
+
+	// MinChartCountRatioAnnotation is the annotation used to configure the
+	// minimum ratio of the number of charts in a fetched index to the number
+	// of charts in the index of the current Artifact, below which the
+	// fetched index is not applied.
+	MinChartCountRatioAnnotation string = "source.toolkit.fluxcd.io/min-chart-count-ratio"
 )
 
 // GetConditions returns the status conditions of the object.
@@ -166,6 +951,42 @@ func (in *HelmRepository) SetConditions(conditions []metav1.Condition) {
 	in.Status.Conditions = conditions
 }
 
+// GetFollowRedirects returns if redirects must be followed while fetching
+// the index, which defaults to true.
+func (in HelmRepository) GetFollowRedirects() bool {
+	if in.Spec.FollowRedirects == nil {
+		return true
+	}
+	return *in.Spec.FollowRedirects
+}
+
+// GetCacheIndex returns if the index may be kept in the in-memory cache,
+// which defaults to true.
+func (in HelmRepository) GetCacheIndex() bool {
+	if in.Spec.CacheIndex == nil {
+		return true
+	}
+	return *in.Spec.CacheIndex
+}
+
+// GetIndexFormat returns the format the index Artifact is stored in, which
+// defaults to HelmRepositoryIndexFormatYAML.
+func (in HelmRepository) GetIndexFormat() string {
+	if in.Spec.IndexFormat == "" {
+		return HelmRepositoryIndexFormatYAML
+	}
+	return in.Spec.IndexFormat
+}
+
+// GetSymlinkName returns the name of the symlink to the latest index
+// Artifact, which defaults to "index." followed by the index format.
+func (in HelmRepository) GetSymlinkName() string {
+	if in.Spec.SymlinkName == "" {
+		return "index." + in.GetIndexFormat()
+	}
+	return in.Spec.SymlinkName
+}
+
 // GetRequeueAfter returns the duration after which the source must be
 // reconciled again.
 func (in HelmRepository) GetRequeueAfter() time.Duration {
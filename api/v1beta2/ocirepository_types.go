@@ -165,8 +165,8 @@ type OCIRepositoryRef struct {
 // OCILayerSelector specifies which layer should be extracted from an OCI Artifact
 type OCILayerSelector struct {
 	// MediaType specifies the OCI media type of the layer
-	// which should be extracted from the OCI Artifact. The
-	// first layer matching this type is selected.
+	// which should be extracted from the OCI Artifact. Exactly one
+	// layer must match this type.
 	// +optional
 	MediaType string `json:"mediaType,omitempty"`
 
@@ -243,6 +243,10 @@ const (
 
 	// OCILayerOperationFailedReason signals that an OCI layer operation failed.
 	OCILayerOperationFailedReason string = "OCIArtifactLayerOperationFailed"
+
+	// OCILayerSelectionAmbiguousReason signals that more than one layer of
+	// the OCI artifact matches the layer selector.
+	OCILayerSelectionAmbiguousReason string = "OCIArtifactLayerSelectionAmbiguous"
 )
 
 // GetConditions returns the status conditions of the object.
@@ -135,6 +135,13 @@ func (in *BucketSpec) DeepCopyInto(out *BucketSpec) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.TagFilter != nil {
+		in, out := &in.TagFilter, &out.TagFilter
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	if in.AccessFrom != nil {
 		in, out := &in.AccessFrom, &out.AccessFrom
 		*out = new(acl.AccessFrom)
@@ -185,6 +192,102 @@ func (in *BucketStatus) DeepCopy() *BucketStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CanaryFetch) DeepCopyInto(out *CanaryFetch) {
+	*out = *in
+	if in.Delay != nil {
+		in, out := &in.Delay, &out.Delay
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryFetch.
+func (in *CanaryFetch) DeepCopy() *CanaryFetch {
+	if in == nil {
+		return nil
+	}
+	out := new(CanaryFetch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChartDependencies) DeepCopyInto(out *ChartDependencies) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChartDependencies.
+func (in *ChartDependencies) DeepCopy() *ChartDependencies {
+	if in == nil {
+		return nil
+	}
+	out := new(ChartDependencies)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChartNameValidation) DeepCopyInto(out *ChartNameValidation) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChartNameValidation.
+func (in *ChartNameValidation) DeepCopy() *ChartNameValidation {
+	if in == nil {
+		return nil
+	}
+	out := new(ChartNameValidation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChartURLCheck) DeepCopyInto(out *ChartURLCheck) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChartURLCheck.
+func (in *ChartURLCheck) DeepCopy() *ChartURLCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(ChartURLCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DigestSpotCheck) DeepCopyInto(out *DigestSpotCheck) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DigestSpotCheck.
+func (in *DigestSpotCheck) DeepCopy() *DigestSpotCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(DigestSpotCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FetchFailure) DeepCopyInto(out *FetchFailure) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FetchFailure.
+func (in *FetchFailure) DeepCopy() *FetchFailure {
+	if in == nil {
+		return nil
+	}
+	out := new(FetchFailure)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GitRepository) DeepCopyInto(out *GitRepository) {
 	*out = *in
@@ -572,6 +675,11 @@ func (in *HelmRepositoryList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HelmRepositorySpec) DeepCopyInto(out *HelmRepositorySpec) {
 	*out = *in
+	if in.AdditionalURLs != nil {
+		in, out := &in.AdditionalURLs, &out.AdditionalURLs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.SecretRef != nil {
 		in, out := &in.SecretRef, &out.SecretRef
 		*out = new(meta.LocalObjectReference)
@@ -582,17 +690,98 @@ func (in *HelmRepositorySpec) DeepCopyInto(out *HelmRepositorySpec) {
 		*out = new(meta.LocalObjectReference)
 		**out = **in
 	}
+	if in.TLSCipherSuites != nil {
+		in, out := &in.TLSCipherSuites, &out.TLSCipherSuites
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.FollowRedirects != nil {
+		in, out := &in.FollowRedirects, &out.FollowRedirects
+		*out = new(bool)
+		**out = **in
+	}
+	if in.CacheIndex != nil {
+		in, out := &in.CacheIndex, &out.CacheIndex
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ProxySecretRef != nil {
+		in, out := &in.ProxySecretRef, &out.ProxySecretRef
+		*out = new(meta.LocalObjectReference)
+		**out = **in
+	}
+	if in.HeadersSecretRef != nil {
+		in, out := &in.HeadersSecretRef, &out.HeadersSecretRef
+		*out = new(meta.LocalObjectReference)
+		**out = **in
+	}
 	out.Interval = in.Interval
+	if in.RetryInterval != nil {
+		in, out := &in.RetryInterval, &out.RetryInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
 	if in.Timeout != nil {
 		in, out := &in.Timeout, &out.Timeout
 		*out = new(v1.Duration)
 		**out = **in
 	}
+	if in.PauseUntil != nil {
+		in, out := &in.PauseUntil, &out.PauseUntil
+		*out = (*in).DeepCopy()
+	}
 	if in.AccessFrom != nil {
 		in, out := &in.AccessFrom, &out.AccessFrom
 		*out = new(acl.AccessFrom)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.BlockVersions != nil {
+		in, out := &in.BlockVersions, &out.BlockVersions
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.PreflightToken != nil {
+		in, out := &in.PreflightToken, &out.PreflightToken
+		*out = new(PreflightToken)
+		**out = **in
+	}
+	if in.MaxArtifactAge != nil {
+		in, out := &in.MaxArtifactAge, &out.MaxArtifactAge
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.DigestSpotCheck != nil {
+		in, out := &in.DigestSpotCheck, &out.DigestSpotCheck
+		*out = new(DigestSpotCheck)
+		**out = **in
+	}
+	if in.CanaryFetch != nil {
+		in, out := &in.CanaryFetch, &out.CanaryFetch
+		*out = new(CanaryFetch)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ChartURLCheck != nil {
+		in, out := &in.ChartURLCheck, &out.ChartURLCheck
+		*out = new(ChartURLCheck)
+		**out = **in
+	}
+	if in.ServeStaleOnError != nil {
+		in, out := &in.ServeStaleOnError, &out.ServeStaleOnError
+		*out = new(ServeStaleOnError)
+		**out = **in
+	}
+	if in.ChartNameValidation != nil {
+		in, out := &in.ChartNameValidation, &out.ChartNameValidation
+		*out = new(ChartNameValidation)
+		**out = **in
+	}
+	if in.Policy != nil {
+		in, out := &in.Policy, &out.Policy
+		*out = new(IndexPolicy)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HelmRepositorySpec.
@@ -620,6 +809,49 @@ func (in *HelmRepositoryStatus) DeepCopyInto(out *HelmRepositoryStatus) {
 		*out = new(apiv1.Artifact)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ChartCount != nil {
+		in, out := &in.ChartCount, &out.ChartCount
+		*out = new(int64)
+		**out = **in
+	}
+	if in.ChartVersionCount != nil {
+		in, out := &in.ChartVersionCount, &out.ChartVersionCount
+		*out = new(int64)
+		**out = **in
+	}
+	if in.ChartDependencies != nil {
+		in, out := &in.ChartDependencies, &out.ChartDependencies
+		*out = new(ChartDependencies)
+		**out = **in
+	}
+	if in.History != nil {
+		in, out := &in.History, &out.History
+		*out = make([]FetchFailure, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.StaleSince != nil {
+		in, out := &in.StaleSince, &out.StaleSince
+		*out = (*in).DeepCopy()
+	}
+	if in.LastFetchTime != nil {
+		in, out := &in.LastFetchTime, &out.LastFetchTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastSuccessfulFetchTime != nil {
+		in, out := &in.LastSuccessfulFetchTime, &out.LastSuccessfulFetchTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastReconcileDuration != nil {
+		in, out := &in.LastReconcileDuration, &out.LastReconcileDuration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.NextScheduledTime != nil {
+		in, out := &in.NextScheduledTime, &out.NextScheduledTime
+		*out = (*in).DeepCopy()
+	}
 	out.ReconcileRequestStatus = in.ReconcileRequestStatus
 }
 
@@ -633,6 +865,22 @@ func (in *HelmRepositoryStatus) DeepCopy() *HelmRepositoryStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IndexPolicy) DeepCopyInto(out *IndexPolicy) {
+	*out = *in
+	out.ConfigMapRef = in.ConfigMapRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IndexPolicy.
+func (in *IndexPolicy) DeepCopy() *IndexPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(IndexPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LocalHelmChartSourceReference) DeepCopyInto(out *LocalHelmChartSourceReference) {
 	*out = *in
@@ -845,3 +1093,34 @@ func (in *OCIRepositoryVerification) DeepCopy() *OCIRepositoryVerification {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreflightToken) DeepCopyInto(out *PreflightToken) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreflightToken.
+func (in *PreflightToken) DeepCopy() *PreflightToken {
+	if in == nil {
+		return nil
+	}
+	out := new(PreflightToken)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServeStaleOnError) DeepCopyInto(out *ServeStaleOnError) {
+	*out = *in
+	out.MaxStaleness = in.MaxStaleness
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServeStaleOnError.
+func (in *ServeStaleOnError) DeepCopy() *ServeStaleOnError {
+	if in == nil {
+		return nil
+	}
+	out := new(ServeStaleOnError)
+	in.DeepCopyInto(out)
+	return out
+}
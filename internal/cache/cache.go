@@ -8,14 +8,17 @@
 // package
 // It has been modified in order to keep a small set of functions
 // and to add a maxItems parameter in order to limit the number of,
-// and thus the size of, items in the cache.
+// and thus the size of, items in the cache, and a MaxBytes option
+// to limit their total size by evicting the least recently used items.
 
 package cache
 
 import (
+	"encoding/json"
 	"fmt"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -30,6 +33,11 @@ type Item struct {
 	Object interface{}
 	// Expiration is the item's expiration time.
 	Expiration int64
+	// Size is the item's size in bytes, as accounted for MaxBytes.
+	Size int64
+
+	// lastAccess is the time the item was last set or retrieved.
+	lastAccess *atomic.Int64
 }
 
 type cache struct {
@@ -37,6 +45,15 @@ type cache struct {
 	Items map[string]Item
 	// MaxItems is the maximum number of items the cache can hold.
 	MaxItems int
+	// MaxBytes is the maximum total size in bytes of the items the cache
+	// can hold, after which the least recently used items are evicted.
+	// Zero means the size is not bounded.
+	MaxBytes int64
+	// SizeFunc returns the size in bytes of an item's value. It defaults to
+	// the size of the JSON serialization of the value, and is only used when
+	// MaxBytes is set.
+	SizeFunc func(value interface{}) int64
+	bytes    int64
 	mu       sync.RWMutex
 	janitor  *janitor
 }
@@ -50,32 +67,100 @@ func (c *cache) ItemCount() int {
 	return n
 }
 
-func (c *cache) set(key string, value interface{}, expiration time.Duration) {
+// Bytes returns the total size in bytes of the items in the cache, as
+// accounted for MaxBytes. It is zero if MaxBytes is not set.
+func (c *cache) Bytes() int64 {
+	c.mu.RLock()
+	n := c.bytes
+	c.mu.RUnlock()
+	return n
+}
+
+func (c *cache) set(key string, value interface{}, expiration time.Duration, size int64) {
 	var e int64
 	if expiration > 0 {
 		e = time.Now().Add(expiration).UnixNano()
 	}
 
+	c.remove(key)
+	lastAccess := &atomic.Int64{}
+	lastAccess.Store(time.Now().UnixNano())
 	c.Items[key] = Item{
 		Object:     value,
 		Expiration: e,
+		Size:       size,
+		lastAccess: lastAccess,
+	}
+	c.bytes += size
+}
+
+// remove deletes the item with the given key, and releases its size.
+func (c *cache) remove(key string) {
+	if item, ok := c.Items[key]; ok {
+		c.bytes -= item.Size
+		delete(c.Items, key)
+	}
+}
+
+// size returns the size of the given value, or zero if MaxBytes is not set.
+func (c *cache) size(value interface{}) int64 {
+	if c.MaxBytes <= 0 {
+		return 0
+	}
+	if c.SizeFunc != nil {
+		return c.SizeFunc(value)
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return 0
 	}
+	return int64(len(b))
+}
+
+// evict removes the least recently used items other than the given key,
+// until an item of the given size fits within MaxBytes. It returns false if
+// the item does not fit in the cache at all.
+func (c *cache) evict(key string, size int64) bool {
+	if c.MaxBytes <= 0 {
+		return true
+	}
+	if size > c.MaxBytes {
+		return false
+	}
+	used := c.bytes
+	if item, ok := c.Items[key]; ok {
+		used -= item.Size
+	}
+	for used+size > c.MaxBytes {
+		var oldestKey string
+		var oldest int64
+		for k, item := range c.Items {
+			if k == key {
+				continue
+			}
+			if t := item.lastAccess.Load(); oldestKey == "" || t < oldest {
+				oldestKey, oldest = k, t
+			}
+		}
+		if oldestKey == "" {
+			return false
+		}
+		used -= c.Items[oldestKey].Size
+		c.remove(oldestKey)
+	}
+	return true
 }
 
 // Set adds an item to the cache, replacing any existing item.
 // If expiration is zero, the item never expires.
 // If the cache is full, Set will return an error.
 func (c *cache) Set(key string, value interface{}, expiration time.Duration) error {
+	size := c.size(value)
+
 	c.mu.Lock()
 	_, found := c.Items[key]
-	if found {
-		c.set(key, value, expiration)
-		c.mu.Unlock()
-		return nil
-	}
-
-	if c.MaxItems > 0 && len(c.Items) < c.MaxItems {
-		c.set(key, value, expiration)
+	if (found || (c.MaxItems > 0 && len(c.Items) < c.MaxItems)) && c.evict(key, size) {
+		c.set(key, value, expiration, size)
 		c.mu.Unlock()
 		return nil
 	}
@@ -88,6 +173,8 @@ func (c *cache) Set(key string, value interface{}, expiration time.Duration) err
 // To overwrite existing items, use Set.
 // If the cache is full, Add will return an error.
 func (c *cache) Add(key string, value interface{}, expiration time.Duration) error {
+	size := c.size(value)
+
 	c.mu.Lock()
 	_, found := c.Items[key]
 	if found {
@@ -95,8 +182,8 @@ func (c *cache) Add(key string, value interface{}, expiration time.Duration) err
 		return fmt.Errorf("Item %s already exists", key)
 	}
 
-	if c.MaxItems > 0 && len(c.Items) < c.MaxItems {
-		c.set(key, value, expiration)
+	if c.MaxItems > 0 && len(c.Items) < c.MaxItems && c.evict(key, size) {
+		c.set(key, value, expiration, size)
 		c.mu.Unlock()
 		return nil
 	}
@@ -120,6 +207,7 @@ func (c *cache) Get(key string) (interface{}, bool) {
 			return nil, false
 		}
 	}
+	item.lastAccess.Store(time.Now().UnixNano())
 	c.mu.RUnlock()
 	return item.Object, true
 }
@@ -127,7 +215,7 @@ func (c *cache) Get(key string) (interface{}, bool) {
 // Delete an item from the cache. Does nothing if the key is not in the cache.
 func (c *cache) Delete(key string) {
 	c.mu.Lock()
-	delete(c.Items, key)
+	c.remove(key)
 	c.mu.Unlock()
 }
 
@@ -137,6 +225,7 @@ func (c *cache) Delete(key string) {
 func (c *cache) Clear() {
 	c.mu.Lock()
 	c.Items = make(map[string]Item)
+	c.bytes = 0
 	c.mu.Unlock()
 }
 
@@ -195,7 +284,7 @@ func (c *cache) DeleteExpired() {
 	c.mu.Lock()
 	for k, v := range c.Items {
 		if v.Expiration > 0 && v.Expiration < time.Now().UnixNano() {
-			delete(c.Items, k)
+			c.remove(k)
 		}
 	}
 	c.mu.Unlock()
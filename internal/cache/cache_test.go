@@ -85,3 +85,39 @@ func TestCache(t *testing.T) {
 	g.Expect(found).To(BeFalse())
 	g.Expect(item).To(BeNil())
 }
+
+func TestCache_MaxBytes(t *testing.T) {
+	g := NewWithT(t)
+	cache := New(10, 0)
+	cache.MaxBytes = 10
+	cache.SizeFunc = func(value interface{}) int64 {
+		return int64(len(value.(string)))
+	}
+
+	g.Expect(cache.Set("key1", "1234", 0)).To(Succeed())
+	g.Expect(cache.Set("key2", "1234", 0)).To(Succeed())
+	g.Expect(cache.Bytes()).To(Equal(int64(8)))
+
+	// Access key1, making key2 the least recently used item.
+	time.Sleep(time.Millisecond)
+	_, found := cache.Get("key1")
+	g.Expect(found).To(BeTrue())
+
+	// Adding an item exceeding the budget evicts the least recently used item.
+	g.Expect(cache.Add("key3", "1234", 0)).To(Succeed())
+	_, found = cache.Get("key2")
+	g.Expect(found).To(BeFalse())
+	g.Expect(cache.ItemCount()).To(Equal(2))
+	g.Expect(cache.Bytes()).To(Equal(int64(8)))
+
+	// Replacing an item accounts for the size of the new value.
+	g.Expect(cache.Set("key3", "12", 0)).To(Succeed())
+	g.Expect(cache.Bytes()).To(Equal(int64(6)))
+
+	// An item exceeding the budget on its own is rejected.
+	g.Expect(cache.Set("key4", "12345678901", 0)).ToNot(Succeed())
+	g.Expect(cache.ItemCount()).To(Equal(2))
+
+	cache.Delete("key1")
+	g.Expect(cache.Bytes()).To(Equal(int64(2)))
+}
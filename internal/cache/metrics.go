@@ -72,6 +72,18 @@ func (r *CacheRecorder) DeleteCacheEvent(event, name, namespace string) {
 	r.cacheEventsCounter.DeleteLabelValues(event, name, namespace)
 }
 
+// NewBytesCollector returns a prometheus.Collector reporting the total size in
+// bytes of the items in the given Cache, as accounted for its MaxBytes.
+func NewBytesCollector(c *Cache) prometheus.Collector {
+	return prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Name: "gotk_cache_bytes",
+			Help: "Total size in bytes of the items in the cache.",
+		},
+		func() float64 { return float64(c.Bytes()) },
+	)
+}
+
 // MustMakeMetrics creates a new CacheRecorder, and registers the metrics collectors in the controller-runtime metrics registry.
 func MustMakeMetrics() *CacheRecorder {
 	r := NewCacheRecorder()
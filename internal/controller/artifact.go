@@ -16,7 +16,13 @@ limitations under the License.
 
 package controller
 
-import sourcev1 "github.com/fluxcd/source-controller/api/v1"
+import (
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+)
 
 type artifactSet []*sourcev1.Artifact
 
@@ -37,3 +43,30 @@ outer:
 	}
 	return false
 }
+
+// artifactMetadataFromObject returns the labels and annotations of the given
+// object of which the key starts with any of the given prefixes, to be
+// embedded in the metadata of an Artifact. Annotations take precedence over
+// labels with the same key. It returns nil if no prefixes are given, or if
+// nothing matches.
+func artifactMetadataFromObject(obj metav1.Object, prefixes []string) map[string]string {
+	if len(prefixes) == 0 {
+		return nil
+	}
+
+	var metadata map[string]string
+	for _, m := range []map[string]string{obj.GetLabels(), obj.GetAnnotations()} {
+		for k, v := range m {
+			for _, p := range prefixes {
+				if strings.HasPrefix(k, p) {
+					if metadata == nil {
+						metadata = make(map[string]string)
+					}
+					metadata[k] = v
+					break
+				}
+			}
+		}
+	}
+	return metadata
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	v1 "github.com/fluxcd/source-controller/api/v1"
+)
+
+// ArtifactSigner creates detached signatures of artifacts with a private key
+// held by the controller.
+//
+// RSA (PKCS #1 v1.5) and ECDSA signatures are made over the SHA-256 digest
+// of the artifact, while Ed25519 signatures are made over the artifact
+// itself. The signatures can thus be verified with e.g.
+// `openssl dgst -sha256 -verify` and `openssl pkeyutl -verify -rawin`
+// respectively.
+type ArtifactSigner struct {
+	key crypto.Signer
+}
+
+// NewArtifactSigner returns an ArtifactSigner for the given PEM encoded
+// PKCS #1, SEC 1 or PKCS #8 RSA, ECDSA or Ed25519 private key.
+func NewArtifactSigner(pemKey []byte) (*ArtifactSigner, error) {
+	block, _ := pem.Decode(pemKey)
+	if block == nil {
+		return nil, errors.New("no PEM encoded private key found")
+	}
+	var key any
+	var err error
+	if key, err = x509.ParsePKCS8PrivateKey(block.Bytes); err != nil {
+		if key, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+			if key, err = x509.ParseECPrivateKey(block.Bytes); err != nil {
+				return nil, fmt.Errorf("failed to parse private key of PEM block type '%s'", block.Type)
+			}
+		}
+	}
+	switch k := key.(type) {
+	case *rsa.PrivateKey, *ecdsa.PrivateKey, ed25519.PrivateKey:
+		return &ArtifactSigner{key: k.(crypto.Signer)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+}
+
+// Sign returns the detached signature of the content of the given reader.
+func (s *ArtifactSigner) Sign(r io.Reader) ([]byte, error) {
+	if _, ok := s.key.(ed25519.PrivateKey); ok {
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		return s.key.Sign(rand.Reader, b, crypto.Hash(0))
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return nil, err
+	}
+	return s.key.Sign(rand.Reader, h.Sum(nil), crypto.SHA256)
+}
+
+// SignArtifact signs the given v1.Artifact with the given ArtifactSigner, and
+// writes the signature next to the artifact file with the SignatureExtension.
+// It returns the URL of the signature.
+func (s Storage) SignArtifact(artifact v1.Artifact, signer *ArtifactSigner) (string, error) {
+	f, err := os.Open(s.LocalPath(artifact))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	sig, err := signer.Sign(f)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign artifact: %w", err)
+	}
+
+	sigPath := s.LocalPath(artifact) + SignatureExtension
+	tmp, err := os.CreateTemp(filepath.Dir(sigPath), filepath.Base(sigPath)+".tmp*")
+	if err != nil {
+		return "", err
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName)
+	if _, err = tmp.Write(sig); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err = tmp.Close(); err != nil {
+		return "", err
+	}
+	if err = os.Chmod(tmpName, s.fileMode()); err != nil {
+		return "", err
+	}
+	if err = os.Rename(tmpName, sigPath); err != nil {
+		return "", err
+	}
+	return artifact.URL + SignatureExtension, nil
+}
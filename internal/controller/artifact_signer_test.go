@@ -0,0 +1,149 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestArtifactSigner_Sign(t *testing.T) {
+	content := []byte("apiVersion: v1\nentries: {}\n")
+	digest := sha256.Sum256(content)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		key    crypto.Signer
+		verify func(sig []byte) bool
+	}{
+		{
+			name: "RSA",
+			key:  rsaKey,
+			verify: func(sig []byte) bool {
+				return rsa.VerifyPKCS1v15(&rsaKey.PublicKey, crypto.SHA256, digest[:], sig) == nil
+			},
+		},
+		{
+			name: "ECDSA",
+			key:  ecKey,
+			verify: func(sig []byte) bool {
+				return ecdsa.VerifyASN1(&ecKey.PublicKey, digest[:], sig)
+			},
+		},
+		{
+			name: "Ed25519",
+			key:  edKey,
+			verify: func(sig []byte) bool {
+				return ed25519.Verify(edKey.Public().(ed25519.PublicKey), content, sig)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			der, err := x509.MarshalPKCS8PrivateKey(tt.key)
+			g.Expect(err).ToNot(HaveOccurred())
+			signer, err := NewArtifactSigner(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+			g.Expect(err).ToNot(HaveOccurred())
+
+			sig, err := signer.Sign(strings.NewReader(string(content)))
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(tt.verify(sig)).To(BeTrue())
+		})
+	}
+}
+
+func TestNewArtifactSigner_Invalid(t *testing.T) {
+	g := NewWithT(t)
+
+	_, err := NewArtifactSigner([]byte("invalid"))
+	g.Expect(err).To(HaveOccurred())
+	_, err = NewArtifactSigner(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("invalid")}))
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestStorage_SignArtifact(t *testing.T) {
+	g := NewWithT(t)
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	g.Expect(err).ToNot(HaveOccurred())
+	der, err := x509.MarshalPKCS8PrivateKey(edKey)
+	g.Expect(err).ToNot(HaveOccurred())
+	signer, err := NewArtifactSigner(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	g.Expect(err).ToNot(HaveOccurred())
+
+	s, err := NewStorage(t.TempDir(), "hostname", time.Minute, 1)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	old := s.NewArtifactFor("HelmRepository", &metav1.ObjectMeta{Name: "foo", Namespace: "bar"}, "1", "index-1.yaml")
+	g.Expect(s.MkdirAll(old)).To(Succeed())
+	g.Expect(s.AtomicWriteFile(&old, strings.NewReader("old"), 0o600)).To(Succeed())
+	_, err = s.SignArtifact(old, signer)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	cur := s.NewArtifactFor("HelmRepository", &metav1.ObjectMeta{Name: "foo", Namespace: "bar"}, "2", "index-2.yaml")
+	g.Expect(s.AtomicWriteFile(&cur, strings.NewReader("current"), 0o600)).To(Succeed())
+	sigURL, err := s.SignArtifact(cur, signer)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(sigURL).To(Equal(cur.URL + SignatureExtension))
+
+	sig, err := os.ReadFile(s.LocalPath(cur) + SignatureExtension)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(ed25519.Verify(edKey.Public().(ed25519.PublicKey), []byte("current"), sig)).To(BeTrue())
+
+	// The signature of the current artifact is retained, while the signature
+	// of a garbage artifact is removed along with it.
+	_, err = s.GarbageCollect(context.TODO(), cur, time.Second)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(s.LocalPath(cur) + SignatureExtension).To(BeAnExistingFile())
+	g.Expect(s.LocalPath(old)).ToNot(BeAnExistingFile())
+	g.Expect(s.LocalPath(old) + SignatureExtension).ToNot(BeAnExistingFile())
+
+	entries, err := os.ReadDir(filepath.Dir(s.LocalPath(cur)))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(entries).To(HaveLen(2))
+}
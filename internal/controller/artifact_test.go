@@ -18,6 +18,9 @@ package controller
 
 import (
 	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func Test_artifactSet_Diff(t *testing.T) {
@@ -122,3 +125,56 @@ func Test_artifactSet_Diff(t *testing.T) {
 		})
 	}
 }
+
+func Test_artifactMetadataFromObject(t *testing.T) {
+	obj := &metav1.ObjectMeta{
+		Labels: map[string]string{
+			"example.com/team": "label-team",
+			"example.com/env":  "production",
+			"app":              "foo",
+		},
+		Annotations: map[string]string{
+			"example.com/team": "annotation-team",
+			"other.com/owner":  "bar",
+		},
+	}
+
+	tests := []struct {
+		name     string
+		prefixes []string
+		want     map[string]string
+	}{
+		{
+			name:     "no prefixes",
+			prefixes: nil,
+			want:     nil,
+		},
+		{
+			name:     "no match",
+			prefixes: []string{"unknown.com/"},
+			want:     nil,
+		},
+		{
+			name:     "annotations take precedence over labels",
+			prefixes: []string{"example.com/"},
+			want: map[string]string{
+				"example.com/team": "annotation-team",
+				"example.com/env":  "production",
+			},
+		},
+		{
+			name:     "multiple prefixes",
+			prefixes: []string{"app", "other.com/"},
+			want: map[string]string{
+				"app":             "foo",
+				"other.com/owner": "bar",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(artifactMetadataFromObject(obj, tt.prefixes)).To(Equal(tt.want))
+		})
+	}
+}
@@ -33,6 +33,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	kuberecorder "k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -55,6 +56,7 @@ import (
 	intdigest "github.com/fluxcd/source-controller/internal/digest"
 	serror "github.com/fluxcd/source-controller/internal/error"
 	"github.com/fluxcd/source-controller/internal/index"
+	smetrics "github.com/fluxcd/source-controller/internal/metrics"
 	sreconcile "github.com/fluxcd/source-controller/internal/reconcile"
 	"github.com/fluxcd/source-controller/internal/reconcile/summarize"
 	"github.com/fluxcd/source-controller/pkg/azure"
@@ -120,15 +122,23 @@ type BucketReconciler struct {
 	client.Client
 	kuberecorder.EventRecorder
 	helper.Metrics
+	SourceMetrics *smetrics.Recorder
 
 	Storage        *Storage
 	ControllerName string
 
+	// DisableFinalizers skips adding and removing the source finalizer, for
+	// the finalizers of objects to be managed externally.
+	DisableFinalizers bool
+
 	patchOptions []patch.Option
 }
 
 type BucketReconcilerOptions struct {
 	RateLimiter ratelimiter.RateLimiter
+	// ReconcileTrigger enqueues the reconciles triggered through the
+	// ReconcileTriggerHandler, if not nil.
+	ReconcileTrigger *ReconcileTriggerHandler
 }
 
 // BucketProvider is an interface for fetching objects from a storage provider
@@ -153,6 +163,26 @@ type BucketProvider interface {
 	Close(context.Context)
 }
 
+// TagFilterBucketProvider is implemented by BucketProviders which can filter
+// the objects of a bucket by their tags while listing them.
+type TagFilterBucketProvider interface {
+	// VisitObjectsWithTags iterates over the items in the provided object
+	// storage bucket which carry all the provided tags, calling visit for
+	// every item.
+	// If the underlying client or the visit callback returns an error,
+	// it returns early.
+	VisitObjectsWithTags(ctx context.Context, bucketName string, tags map[string]string, visit func(key, etag string) error) error
+}
+
+// ObjectTagsBucketProvider is implemented by BucketProviders which can
+// retrieve the tags of a single object. It is used to filter the objects of
+// a bucket by their tags, when the provider can not do so while listing them.
+type ObjectTagsBucketProvider interface {
+	// ObjectTags returns the tags of the object in the provided object
+	// storage bucket, or any error.
+	ObjectTags(ctx context.Context, bucketName, objectKey string) (map[string]string, error)
+}
+
 // bucketReconcileFunc is the function type for all the v1beta2.Bucket
 // (sub)reconcile functions. The type implementations are grouped and
 // executed serially to perform the complete reconcile of the object.
@@ -164,18 +194,22 @@ func (r *BucketReconciler) SetupWithManager(mgr ctrl.Manager) error {
 
 func (r *BucketReconciler) SetupWithManagerAndOptions(mgr ctrl.Manager, opts BucketReconcilerOptions) error {
 	r.patchOptions = getPatchOptions(bucketReadyCondition.Owned, r.ControllerName)
+	queue := r.SourceMetrics.QueueTracker(bucketv1.BucketKind)
+
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&bucketv1.Bucket{}, builder.WithPredicates(queue.Predicate())).
+		WithEventFilter(predicate.Or(predicate.GenerationChangedPredicate{}, predicates.ReconcileRequestedPredicate{}))
 
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&bucketv1.Bucket{}).
-		WithEventFilter(predicate.Or(predicate.GenerationChangedPredicate{}, predicates.ReconcileRequestedPredicate{})).
+	return watchReconcileTrigger(b, opts.ReconcileTrigger, "buckets", queue.Predicate()).
 		WithOptions(controller.Options{
-			RateLimiter: opts.RateLimiter,
+			RateLimiter: queue.RateLimiter(opts.RateLimiter),
 		}).
-		Complete(r)
+		Complete(queue.Reconciler(r))
 }
 
 func (r *BucketReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, retErr error) {
 	start := time.Now()
+	defer r.SourceMetrics.RecordInFlight(bucketv1.BucketKind)()
 	log := ctrl.LoggerFrom(ctx)
 
 	// Fetch the Bucket
@@ -214,6 +248,7 @@ func (r *BucketReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res
 		r.Metrics.RecordSuspend(ctx, obj, obj.Spec.Suspend)
 		r.Metrics.RecordReadiness(ctx, obj)
 		r.Metrics.RecordDuration(ctx, obj, start)
+		recordLastSuccess(ctx, r.Metrics, r.SourceMetrics, obj)
 	}()
 
 	// Examine if the object is under deletion.
@@ -226,7 +261,7 @@ func (r *BucketReconciler) Reconcile(ctx context.Context, req ctrl.Request) (res
 	// and delete.
 	// Note: Finalizers in general can only be added when the deletionTimestamp
 	// is not set.
-	if !controllerutil.ContainsFinalizer(obj, sourcev1.SourceFinalizer) {
+	if !r.DisableFinalizers && !controllerutil.ContainsFinalizer(obj, sourcev1.SourceFinalizer) {
 		controllerutil.AddFinalizer(obj, sourcev1.SourceFinalizer)
 		recResult = sreconcile.ResultRequeue
 		return
@@ -309,6 +344,7 @@ func (r *BucketReconciler) reconcile(ctx context.Context, sp *patch.SerialPatche
 		// If an error is received, prioritize the returned results because an
 		// error also means immediate requeue.
 		if err != nil {
+			recordFailure(r.SourceMetrics, bucketv1.BucketKind, err)
 			resErr = err
 			res = recResult
 			break
@@ -584,6 +620,7 @@ func (r *BucketReconciler) reconcileArtifact(ctx context.Context, sp *patch.Seri
 	defer unlock()
 
 	// Archive directory to storage
+	writeStart := time.Now()
 	if err := r.Storage.Archive(&artifact, dir, nil); err != nil {
 		e := serror.NewGeneric(
 			fmt.Errorf("unable to archive artifact to storage: %s", err),
@@ -592,6 +629,13 @@ func (r *BucketReconciler) reconcileArtifact(ctx context.Context, sp *patch.Seri
 		conditions.MarkTrue(obj, sourcev1.StorageOperationFailedCondition, e.Reason, e.Err.Error())
 		return sreconcile.ResultEmpty, e
 	}
+	recordArtifactWrite(ctx, r.Metrics, r.SourceMetrics, obj, artifact, writeStart)
+
+	// Mirror the artifact to the backup storage on a "best effort" basis
+	if err := r.Storage.BackupArtifact(artifact); err != nil {
+		r.eventLogf(ctx, obj, corev1.EventTypeWarning, sourcev1.BackupOperationFailedReason,
+			"failed to mirror artifact to backup storage: %s", err)
+	}
 
 	// Record it on the object
 	obj.Status.Artifact = artifact.DeepCopy()
@@ -621,7 +665,9 @@ func (r *BucketReconciler) reconcileDelete(ctx context.Context, obj *bucketv1.Bu
 	}
 
 	// Remove our finalizer from the list
-	controllerutil.RemoveFinalizer(obj, sourcev1.SourceFinalizer)
+	if !r.DisableFinalizers {
+		controllerutil.RemoveFinalizer(obj, sourcev1.SourceFinalizer)
+	}
 
 	// Stop reconciliation as the object is being deleted
 	return sreconcile.ResultEmpty, nil
@@ -742,7 +788,7 @@ func fetchEtagIndex(ctx context.Context, provider BucketProvider, obj *bucketv1.
 	matcher := sourceignore.NewMatcher(ps)
 
 	// Build up index
-	err = provider.VisitObjects(ctxTimeout, obj.Spec.BucketName, func(key, etag string) error {
+	visit := func(key, etag string) error {
 		if strings.HasSuffix(key, "/") || key == sourceignore.IgnoreFile {
 			return nil
 		}
@@ -753,13 +799,53 @@ func fetchEtagIndex(ctx context.Context, provider BucketProvider, obj *bucketv1.
 
 		index.Add(key, etag)
 		return nil
-	})
+	}
+	err = visitObjects(ctxTimeout, provider, obj, visit)
 	if err != nil {
 		return fmt.Errorf("indexation of objects from bucket '%s' failed: %w", obj.Spec.BucketName, err)
 	}
 	return nil
 }
 
+// visitObjects iterates over the items in the obj specified bucket
+// using the given provider, calling visit for every item. When the obj
+// specifies a tag filter, only the items carrying all the tags are visited.
+// The filter is applied by the provider while listing the items if supported,
+// or by retrieving the tags of every item otherwise.
+func visitObjects(ctx context.Context, provider BucketProvider, obj *bucketv1.Bucket, visit func(key, etag string) error) error {
+	tags := obj.Spec.TagFilter
+	if len(tags) == 0 {
+		return provider.VisitObjects(ctx, obj.Spec.BucketName, visit)
+	}
+
+	if p, ok := provider.(TagFilterBucketProvider); ok {
+		return p.VisitObjectsWithTags(ctx, obj.Spec.BucketName, tags, visit)
+	}
+
+	p, ok := provider.(ObjectTagsBucketProvider)
+	if !ok {
+		return fmt.Errorf("filtering objects by tags is not supported by the '%s' provider", obj.Spec.Provider)
+	}
+	return provider.VisitObjects(ctx, obj.Spec.BucketName, func(key, etag string) error {
+		if strings.HasSuffix(key, "/") {
+			return nil
+		}
+		objTags, err := p.ObjectTags(ctx, obj.Spec.BucketName, key)
+		if err != nil {
+			if provider.ObjectIsNotFound(err) {
+				return nil
+			}
+			return fmt.Errorf("failed to get tags of '%s' object: %w", key, err)
+		}
+		for k, v := range tags {
+			if objTags[k] != v {
+				return nil
+			}
+		}
+		return visit(key, etag)
+	})
+}
+
 // fetchIndexFiles fetches the object files for the keys from the given etagIndex
 // using the given provider, and stores them into tempDir. It downloads in
 // parallel, but limited to the maxConcurrentBucketFetches.
@@ -34,6 +34,7 @@ import (
 type mockBucketObject struct {
 	etag string
 	data string
+	tags map[string]string
 }
 
 type mockBucketClient struct {
@@ -78,6 +79,14 @@ func (m mockBucketClient) VisitObjects(_ context.Context, _ string, f func(key,
 	return nil
 }
 
+func (m mockBucketClient) ObjectTags(_ context.Context, _ string, key string) (map[string]string, error) {
+	object, ok := m.objects[key]
+	if !ok {
+		return nil, mockNotFound
+	}
+	return object.tags, nil
+}
+
 func (m mockBucketClient) Close(_ context.Context) {
 	return
 }
@@ -184,6 +193,29 @@ func Test_fetchEtagIndex(t *testing.T) {
 			t.Error(fmt.Errorf("expected 'foo.txt' index item to exist"))
 		}
 	})
+
+	t.Run("filters with tags", func(t *testing.T) {
+		tmp := t.TempDir()
+
+		client := mockBucketClient{bucketName: bucketName}
+		client.addObject("foo.yaml", mockBucketObject{etag: "etag1", data: "foo.yaml", tags: map[string]string{"env": "prod", "team": "a"}})
+		client.addObject("bar.yaml", mockBucketObject{etag: "etag2", data: "bar.yaml", tags: map[string]string{"env": "dev"}})
+		client.addObject("baz.yaml", mockBucketObject{etag: "etag3", data: "baz.yaml"})
+
+		bucket := bucket.DeepCopy()
+		bucket.Spec.TagFilter = map[string]string{"env": "prod"}
+
+		index := index.NewDigester()
+		err := fetchEtagIndex(context.TODO(), client, bucket, index, tmp)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, index.Len(), 1)
+		if ok := index.Has("foo.yaml"); !ok {
+			t.Error(fmt.Errorf("expected 'foo.yaml' index item to exist"))
+		}
+	})
 }
 
 func Test_fetchFiles(t *testing.T) {
@@ -0,0 +1,123 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	kuberecorder "k8s.io/client-go/tools/record"
+)
+
+// failureEventThrottle is a kuberecorder.EventRecorder which coalesces
+// repeated identical warning events of an object. The first warning event is
+// emitted immediately, after which the same event is emitted at most once per
+// interval, with the number of times it was suppressed. A different warning
+// event is emitted immediately, and a normal event (e.g. on recovery) is
+// always emitted and resets the throttle of the object.
+type failureEventThrottle struct {
+	kuberecorder.EventRecorder
+
+	interval time.Duration
+	now      func() time.Time
+
+	mu       sync.Mutex
+	failures map[string]*throttledEvent
+}
+
+// throttledEvent records the last emitted warning event of an object.
+type throttledEvent struct {
+	reason     string
+	message    string
+	emittedAt  time.Time
+	suppressed int
+}
+
+// newFailureEventThrottle returns a failureEventThrottle emitting the events
+// to the given recorder, emitting repeated warning events at most once per
+// interval.
+func newFailureEventThrottle(recorder kuberecorder.EventRecorder, interval time.Duration) *failureEventThrottle {
+	return &failureEventThrottle{
+		EventRecorder: recorder,
+		interval:      interval,
+		now:           time.Now,
+		failures:      make(map[string]*throttledEvent),
+	}
+}
+
+// Event implements kuberecorder.EventRecorder.
+func (t *failureEventThrottle) Event(object runtime.Object, eventtype, reason, message string) {
+	if message, ok := t.admit(object, eventtype, reason, message); ok {
+		t.EventRecorder.Event(object, eventtype, reason, message)
+	}
+}
+
+// Eventf implements kuberecorder.EventRecorder.
+func (t *failureEventThrottle) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	t.Event(object, eventtype, reason, fmt.Sprintf(messageFmt, args...))
+}
+
+// AnnotatedEventf implements kuberecorder.EventRecorder.
+func (t *failureEventThrottle) AnnotatedEventf(object runtime.Object, annotations map[string]string, eventtype, reason, messageFmt string, args ...interface{}) {
+	if message, ok := t.admit(object, eventtype, reason, fmt.Sprintf(messageFmt, args...)); ok {
+		t.EventRecorder.AnnotatedEventf(object, annotations, eventtype, reason, "%s", message)
+	}
+}
+
+// admit returns if the event must be emitted, and the message to emit it
+// with.
+func (t *failureEventThrottle) admit(object runtime.Object, eventtype, reason, message string) (string, bool) {
+	o, err := apimeta.Accessor(object)
+	if err != nil {
+		return message, true
+	}
+	key := fmt.Sprintf("%T/%s/%s", object, o.GetNamespace(), o.GetName())
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	switch eventtype {
+	case corev1.EventTypeWarning:
+	case corev1.EventTypeNormal:
+		delete(t.failures, key)
+		return message, true
+	default:
+		return message, true
+	}
+
+	now := t.now()
+	last, ok := t.failures[key]
+	if !ok || last.reason != reason || last.message != message {
+		t.failures[key] = &throttledEvent{reason: reason, message: message, emittedAt: now}
+		return message, true
+	}
+	if now.Sub(last.emittedAt) < t.interval {
+		last.suppressed++
+		return "", false
+	}
+	if last.suppressed > 0 {
+		message = fmt.Sprintf("%s (repeated %d times in the last %s)", message,
+			last.suppressed+1, now.Sub(last.emittedAt).Round(time.Second))
+	}
+	last.emittedAt = now
+	last.suppressed = 0
+	return message, true
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	eventv1 "github.com/fluxcd/pkg/apis/event/v1beta1"
+
+	helmv1 "github.com/fluxcd/source-controller/api/v1beta2"
+)
+
+func TestFailureEventThrottle(t *testing.T) {
+	g := NewWithT(t)
+
+	recorder := record.NewFakeRecorder(32)
+	throttle := newFailureEventThrottle(recorder, time.Minute)
+	now := time.Now()
+	throttle.now = func() time.Time { return now }
+
+	obj := &helmv1.HelmRepository{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"}}
+	other := &helmv1.HelmRepository{ObjectMeta: metav1.ObjectMeta{Name: "bar", Namespace: "default"}}
+
+	// The first failure is emitted, repeated failures are suppressed.
+	throttle.Eventf(obj, corev1.EventTypeWarning, "Failed", "failed to fetch %s", "index")
+	g.Expect(recorder.Events).To(Receive(Equal("Warning Failed failed to fetch index")))
+	throttle.Eventf(obj, corev1.EventTypeWarning, "Failed", "failed to fetch %s", "index")
+	throttle.AnnotatedEventf(obj, nil, corev1.EventTypeWarning, "Failed", "failed to fetch %s", "index")
+	g.Expect(recorder.Events).ToNot(Receive())
+
+	// The failures of other objects, different failures and trace events
+	// are emitted.
+	throttle.Eventf(other, corev1.EventTypeWarning, "Failed", "failed to fetch index")
+	g.Expect(recorder.Events).To(Receive(Equal("Warning Failed failed to fetch index")))
+	throttle.Eventf(obj, eventv1.EventTypeTrace, "Progressing", "fetching index")
+	g.Expect(recorder.Events).To(Receive(Equal("Trace Progressing fetching index")))
+
+	// A repeated failure is emitted with a count after the interval.
+	now = now.Add(time.Minute)
+	throttle.Eventf(obj, corev1.EventTypeWarning, "Failed", "failed to fetch index")
+	g.Expect(recorder.Events).To(Receive(Equal("Warning Failed failed to fetch index (repeated 3 times in the last 1m0s)")))
+
+	// A different failure is emitted immediately.
+	throttle.Eventf(obj, corev1.EventTypeWarning, "StorageOperationFailed", "failed to write artifact")
+	g.Expect(recorder.Events).To(Receive(Equal("Warning StorageOperationFailed failed to write artifact")))
+
+	// A normal event resets the throttle.
+	throttle.Eventf(obj, corev1.EventTypeNormal, "Succeeded", "stored artifact")
+	g.Expect(recorder.Events).To(Receive(Equal("Normal Succeeded stored artifact")))
+	throttle.Eventf(obj, corev1.EventTypeWarning, "StorageOperationFailed", "failed to write artifact")
+	g.Expect(recorder.Events).To(Receive(Equal("Warning StorageOperationFailed failed to write artifact")))
+}
@@ -59,6 +59,7 @@ import (
 	sourcev1 "github.com/fluxcd/source-controller/api/v1"
 	serror "github.com/fluxcd/source-controller/internal/error"
 	"github.com/fluxcd/source-controller/internal/features"
+	smetrics "github.com/fluxcd/source-controller/internal/metrics"
 	sreconcile "github.com/fluxcd/source-controller/internal/reconcile"
 	"github.com/fluxcd/source-controller/internal/reconcile/summarize"
 	"github.com/fluxcd/source-controller/internal/util"
@@ -125,6 +126,7 @@ type GitRepositoryReconciler struct {
 	client.Client
 	kuberecorder.EventRecorder
 	helper.Metrics
+	SourceMetrics *smetrics.Recorder
 
 	Storage        *Storage
 	ControllerName string
@@ -132,12 +134,19 @@ type GitRepositoryReconciler struct {
 	requeueDependency time.Duration
 	features          map[string]bool
 
+	// DisableFinalizers skips adding and removing the source finalizer, for
+	// the finalizers of objects to be managed externally.
+	DisableFinalizers bool
+
 	patchOptions []patch.Option
 }
 
 type GitRepositoryReconcilerOptions struct {
 	DependencyRequeueInterval time.Duration
 	RateLimiter               ratelimiter.RateLimiter
+	// ReconcileTrigger enqueues the reconciles triggered through the
+	// ReconcileTriggerHandler, if not nil.
+	ReconcileTrigger *ReconcileTriggerHandler
 }
 
 // gitRepositoryReconcileFunc is the function type for all the
@@ -152,23 +161,28 @@ func (r *GitRepositoryReconciler) SetupWithManagerAndOptions(mgr ctrl.Manager, o
 	r.patchOptions = getPatchOptions(gitRepositoryReadyCondition.Owned, r.ControllerName)
 
 	r.requeueDependency = opts.DependencyRequeueInterval
+	queue := r.SourceMetrics.QueueTracker(sourcev1.GitRepositoryKind)
 
 	if r.features == nil {
 		r.features = features.FeatureGates()
 	}
 
-	return ctrl.NewControllerManagedBy(mgr).
+	b := ctrl.NewControllerManagedBy(mgr).
 		For(&sourcev1.GitRepository{}, builder.WithPredicates(
 			predicate.Or(predicate.GenerationChangedPredicate{}, predicates.ReconcileRequestedPredicate{}),
-		)).
+			queue.Predicate(),
+		))
+
+	return watchReconcileTrigger(b, opts.ReconcileTrigger, "gitrepositories", queue.Predicate()).
 		WithOptions(controller.Options{
-			RateLimiter: opts.RateLimiter,
+			RateLimiter: queue.RateLimiter(opts.RateLimiter),
 		}).
-		Complete(r)
+		Complete(queue.Reconciler(r))
 }
 
 func (r *GitRepositoryReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, retErr error) {
 	start := time.Now()
+	defer r.SourceMetrics.RecordInFlight(sourcev1.GitRepositoryKind)()
 	log := ctrl.LoggerFrom(ctx)
 
 	// Fetch the GitRepository
@@ -208,6 +222,7 @@ func (r *GitRepositoryReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		r.Metrics.RecordSuspend(ctx, obj, obj.Spec.Suspend)
 		r.Metrics.RecordReadiness(ctx, obj)
 		r.Metrics.RecordDuration(ctx, obj, start)
+		recordLastSuccess(ctx, r.Metrics, r.SourceMetrics, obj)
 	}()
 
 	// Examine if the object is under deletion.
@@ -220,7 +235,7 @@ func (r *GitRepositoryReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 	// between init and delete.
 	// Note: Finalizers in general can only be added when the deletionTimestamp
 	// is not set.
-	if !controllerutil.ContainsFinalizer(obj, sourcev1.SourceFinalizer) {
+	if !r.DisableFinalizers && !controllerutil.ContainsFinalizer(obj, sourcev1.SourceFinalizer) {
 		controllerutil.AddFinalizer(obj, sourcev1.SourceFinalizer)
 		recResult = sreconcile.ResultRequeue
 		return
@@ -306,6 +321,7 @@ func (r *GitRepositoryReconciler) reconcile(ctx context.Context, sp *patch.Seria
 		// If an error is received, prioritize the returned results because an
 		// error also means immediate requeue.
 		if err != nil {
+			recordFailure(r.SourceMetrics, sourcev1.GitRepositoryKind, err)
 			resErr = err
 			res = recResult
 			break
@@ -746,6 +762,7 @@ func (r *GitRepositoryReconciler) reconcileArtifact(ctx context.Context, sp *pat
 	}
 
 	// Archive directory to storage
+	writeStart := time.Now()
 	if err := r.Storage.Archive(&artifact, dir, SourceIgnoreFilter(ps, ignoreDomain)); err != nil {
 		e := serror.NewGeneric(
 			fmt.Errorf("unable to archive artifact to storage: %w", err),
@@ -754,6 +771,13 @@ func (r *GitRepositoryReconciler) reconcileArtifact(ctx context.Context, sp *pat
 		conditions.MarkTrue(obj, sourcev1.StorageOperationFailedCondition, e.Reason, e.Err.Error())
 		return sreconcile.ResultEmpty, e
 	}
+	recordArtifactWrite(ctx, r.Metrics, r.SourceMetrics, obj, artifact, writeStart)
+
+	// Mirror the artifact to the backup storage on a "best effort" basis
+	if err := r.Storage.BackupArtifact(artifact); err != nil {
+		r.eventLogf(ctx, obj, corev1.EventTypeWarning, sourcev1.BackupOperationFailedReason,
+			"failed to mirror artifact to backup storage: %s", err)
+	}
 
 	// Record the observations on the object.
 	obj.Status.Artifact = artifact.DeepCopy()
@@ -1043,7 +1067,9 @@ func (r *GitRepositoryReconciler) reconcileDelete(ctx context.Context, obj *sour
 	}
 
 	// Remove our finalizer from the list
-	controllerutil.RemoveFinalizer(obj, sourcev1.SourceFinalizer)
+	if !r.DisableFinalizers {
+		controllerutil.RemoveFinalizer(obj, sourcev1.SourceFinalizer)
+	}
 
 	// Stop reconciliation as the object is being deleted
 	return sreconcile.ResultEmpty, nil
@@ -67,9 +67,11 @@ import (
 	"github.com/fluxcd/source-controller/internal/helm/chart"
 	"github.com/fluxcd/source-controller/internal/helm/getter"
 	"github.com/fluxcd/source-controller/internal/helm/repository"
+	smetrics "github.com/fluxcd/source-controller/internal/metrics"
 	soci "github.com/fluxcd/source-controller/internal/oci"
 	sreconcile "github.com/fluxcd/source-controller/internal/reconcile"
 	"github.com/fluxcd/source-controller/internal/reconcile/summarize"
+	"github.com/fluxcd/source-controller/internal/transport"
 	"github.com/fluxcd/source-controller/internal/util"
 )
 
@@ -125,16 +127,26 @@ type HelmChartReconciler struct {
 	client.Client
 	kuberecorder.EventRecorder
 	helper.Metrics
+	SourceMetrics *smetrics.Recorder
 
 	RegistryClientGenerator RegistryClientGeneratorFunc
 	Storage                 *Storage
 	Getters                 helmgetter.Providers
 	ControllerName          string
 
+	// DefaultSecretName is the name of the Secret in the namespace of a
+	// HelmRepository used for authentication when the HelmRepository has no
+	// .spec.secretRef. Empty disables the fallback.
+	DefaultSecretName string
+
 	Cache *cache.Cache
 	TTL   time.Duration
 	*cache.CacheRecorder
 
+	// DisableFinalizers skips adding and removing the source finalizer, for
+	// the finalizers of objects to be managed externally.
+	DisableFinalizers bool
+
 	patchOptions []patch.Option
 }
 
@@ -144,6 +156,9 @@ func (r *HelmChartReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Man
 
 type HelmChartReconcilerOptions struct {
 	RateLimiter ratelimiter.RateLimiter
+	// ReconcileTrigger enqueues the reconciles triggered through the
+	// ReconcileTriggerHandler, if not nil.
+	ReconcileTrigger *ReconcileTriggerHandler
 }
 
 // helmChartReconcileFunc is the function type for all the v1beta2.HelmChart
@@ -153,6 +168,7 @@ type helmChartReconcileFunc func(ctx context.Context, sp *patch.SerialPatcher, o
 
 func (r *HelmChartReconciler) SetupWithManagerAndOptions(ctx context.Context, mgr ctrl.Manager, opts HelmChartReconcilerOptions) error {
 	r.patchOptions = getPatchOptions(helmChartReadyCondition.Owned, r.ControllerName)
+	queue := r.SourceMetrics.QueueTracker(helmv1.HelmChartKind)
 
 	if err := mgr.GetCache().IndexField(ctx, &helmv1.HelmRepository{}, helmv1.HelmRepositoryURLIndexKey,
 		r.indexHelmRepositoryByURL); err != nil {
@@ -163,9 +179,10 @@ func (r *HelmChartReconciler) SetupWithManagerAndOptions(ctx context.Context, mg
 		return fmt.Errorf("failed setting index fields: %w", err)
 	}
 
-	return ctrl.NewControllerManagedBy(mgr).
+	b := ctrl.NewControllerManagedBy(mgr).
 		For(&helmv1.HelmChart{}, builder.WithPredicates(
 			predicate.Or(predicate.GenerationChangedPredicate{}, predicates.ReconcileRequestedPredicate{}),
+			queue.Predicate(),
 		)).
 		Watches(
 			&helmv1.HelmRepository{},
@@ -181,15 +198,18 @@ func (r *HelmChartReconciler) SetupWithManagerAndOptions(ctx context.Context, mg
 			&helmv1.Bucket{},
 			handler.EnqueueRequestsFromMapFunc(r.requestsForBucketChange),
 			builder.WithPredicates(SourceRevisionChangePredicate{}),
-		).
+		)
+
+	return watchReconcileTrigger(b, opts.ReconcileTrigger, "helmcharts", queue.Predicate()).
 		WithOptions(controller.Options{
-			RateLimiter: opts.RateLimiter,
+			RateLimiter: queue.RateLimiter(opts.RateLimiter),
 		}).
-		Complete(r)
+		Complete(queue.Reconciler(r))
 }
 
 func (r *HelmChartReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, retErr error) {
 	start := time.Now()
+	defer r.SourceMetrics.RecordInFlight(helmv1.HelmChartKind)()
 	log := ctrl.LoggerFrom(ctx)
 
 	// Fetch the HelmChart
@@ -229,6 +249,7 @@ func (r *HelmChartReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		r.Metrics.RecordSuspend(ctx, obj, obj.Spec.Suspend)
 		r.Metrics.RecordReadiness(ctx, obj)
 		r.Metrics.RecordDuration(ctx, obj, start)
+		recordLastSuccess(ctx, r.Metrics, r.SourceMetrics, obj)
 	}()
 
 	// Examine if the object is under deletion.
@@ -241,7 +262,7 @@ func (r *HelmChartReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 	// between init and delete.
 	// Note: Finalizers in general can only be added when the deletionTimestamp
 	// is not set.
-	if !controllerutil.ContainsFinalizer(obj, sourcev1.SourceFinalizer) {
+	if !r.DisableFinalizers && !controllerutil.ContainsFinalizer(obj, sourcev1.SourceFinalizer) {
 		controllerutil.AddFinalizer(obj, sourcev1.SourceFinalizer)
 		recResult = sreconcile.ResultRequeue
 		return
@@ -306,6 +327,7 @@ func (r *HelmChartReconciler) reconcile(ctx context.Context, sp *patch.SerialPat
 		// If an error is received, prioritize the returned results because an
 		// error also means immediate requeue.
 		if err != nil {
+			recordFailure(r.SourceMetrics, helmv1.HelmChartKind, err)
 			resErr = err
 			res = recResult
 			break
@@ -514,7 +536,16 @@ func (r *HelmChartReconciler) buildFromHelmRepository(ctx context.Context, obj *
 		return chartRepoConfigErrorReturn(err, obj)
 	}
 
-	clientOpts, certsTmpDir, err := getter.GetClientOpts(ctxTimeout, r.Client, repo, normalizedURL)
+	authRepo, err := withDefaultSecretRef(ctxTimeout, r.Client, repo, r.DefaultSecretName)
+	if err != nil {
+		e := serror.NewGeneric(
+			err,
+			sourcev1.AuthenticationFailedReason,
+		)
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+		return sreconcile.ResultEmpty, e
+	}
+	clientOpts, certsTmpDir, err := getter.GetClientOpts(ctxTimeout, r.Client, authRepo, normalizedURL)
 	if err != nil && !errors.Is(err, getter.ErrDeprecatedTLSConfig) {
 		e := serror.NewGeneric(
 			err,
@@ -531,6 +562,9 @@ func (r *HelmChartReconciler) buildFromHelmRepository(ctx context.Context, obj *
 			}
 		}()
 	}
+	if clientOpts.SPNEGO != nil {
+		defer clientOpts.SPNEGO.Close()
+	}
 
 	getterOpts := clientOpts.GetterOpts
 
@@ -613,6 +647,18 @@ func (r *HelmChartReconciler) buildFromHelmRepository(ctx context.Context, obj *
 		if err != nil {
 			return chartRepoConfigErrorReturn(err, obj)
 		}
+		httpChartRepo.RedirectPolicy = &transport.RedirectPolicy{
+			Follow:          repo.GetFollowRedirects(),
+			PassCredentials: repo.Spec.PassCredentials,
+		}
+		httpChartRepo.ResolverAddress = repo.Spec.ResolverAddress
+		httpChartRepo.ProxyURL = clientOpts.ProxyURL
+		httpChartRepo.ForceHTTP1 = repo.Spec.ForceHTTP1
+		httpChartRepo.AuthMethods = clientOpts.AuthMethods
+		httpChartRepo.RequestSigner = clientOpts.RequestSigner
+		httpChartRepo.SigV4Signer = clientOpts.SigV4Signer
+		httpChartRepo.SPNEGO = clientOpts.SPNEGO
+		httpChartRepo.Headers = clientOpts.Headers
 
 		// NB: this needs to be deferred first, as otherwise the Index will disappear
 		// before we had a chance to cache it.
@@ -853,6 +899,7 @@ func (r *HelmChartReconciler) reconcileArtifact(ctx context.Context, _ *patch.Se
 	defer unlock()
 
 	// Copy the packaged chart to the artifact path
+	writeStart := time.Now()
 	if err = r.Storage.CopyFromPath(&artifact, b.Path); err != nil {
 		e := serror.NewGeneric(
 			fmt.Errorf("unable to copy Helm chart to storage: %w", err),
@@ -861,6 +908,13 @@ func (r *HelmChartReconciler) reconcileArtifact(ctx context.Context, _ *patch.Se
 		conditions.MarkTrue(obj, sourcev1.StorageOperationFailedCondition, e.Reason, e.Err.Error())
 		return sreconcile.ResultEmpty, e
 	}
+	recordArtifactWrite(ctx, r.Metrics, r.SourceMetrics, obj, artifact, writeStart)
+
+	// Mirror the artifact to the backup storage on a "best effort" basis
+	if err := r.Storage.BackupArtifact(artifact); err != nil {
+		r.eventLogf(ctx, obj, corev1.EventTypeWarning, sourcev1.BackupOperationFailedReason,
+			"failed to mirror artifact to backup storage: %s", err)
+	}
 
 	// Record it on the object
 	obj.Status.Artifact = artifact.DeepCopy()
@@ -924,7 +978,9 @@ func (r *HelmChartReconciler) reconcileDelete(ctx context.Context, obj *helmv1.H
 	}
 
 	// Remove our finalizer from the list
-	controllerutil.RemoveFinalizer(obj, sourcev1.SourceFinalizer)
+	if !r.DisableFinalizers {
+		controllerutil.RemoveFinalizer(obj, sourcev1.SourceFinalizer)
+	}
 
 	// Stop reconciliation as the object is being deleted
 	return sreconcile.ResultEmpty, nil
@@ -994,10 +1050,22 @@ func (r *HelmChartReconciler) namespacedChartRepositoryCallback(ctx context.Cont
 		ctxTimeout, cancel := context.WithTimeout(ctx, obj.Spec.Timeout.Duration)
 		defer cancel()
 
-		clientOpts, certsTmpDir, err := getter.GetClientOpts(ctxTimeout, r.Client, obj, normalizedURL)
+		authObj, err := withDefaultSecretRef(ctxTimeout, r.Client, obj, r.DefaultSecretName)
+		if err != nil {
+			return nil, err
+		}
+		clientOpts, certsTmpDir, err := getter.GetClientOpts(ctxTimeout, r.Client, authObj, normalizedURL)
 		if err != nil && !errors.Is(err, getter.ErrDeprecatedTLSConfig) {
 			return nil, err
 		}
+		// The SPNEGO negotiator is closed when the returned chart repository
+		// is cleared, or else on return.
+		spnego := clientOpts.SPNEGO
+		defer func() {
+			if spnego != nil {
+				spnego.Close()
+			}
+		}()
 		getterOpts := clientOpts.GetterOpts
 
 		var chartRepo repository.Downloader
@@ -1044,6 +1112,18 @@ func (r *HelmChartReconciler) namespacedChartRepositoryCallback(ctx context.Cont
 			if err != nil {
 				return nil, err
 			}
+			httpChartRepo.RedirectPolicy = &transport.RedirectPolicy{
+				Follow:          obj.GetFollowRedirects(),
+				PassCredentials: obj.Spec.PassCredentials,
+			}
+			httpChartRepo.ResolverAddress = obj.Spec.ResolverAddress
+			httpChartRepo.ProxyURL = clientOpts.ProxyURL
+			httpChartRepo.ForceHTTP1 = obj.Spec.ForceHTTP1
+			httpChartRepo.AuthMethods = clientOpts.AuthMethods
+			httpChartRepo.RequestSigner = clientOpts.RequestSigner
+			httpChartRepo.SigV4Signer = clientOpts.SigV4Signer
+			httpChartRepo.SPNEGO = clientOpts.SPNEGO
+			httpChartRepo.Headers = clientOpts.Headers
 
 			if artifact := obj.GetArtifact(); artifact != nil {
 				httpChartRepo.Path = r.Storage.LocalPath(*artifact)
@@ -1065,12 +1145,29 @@ func (r *HelmChartReconciler) namespacedChartRepositoryCallback(ctx context.Cont
 			}
 
 			chartRepo = httpChartRepo
+			if spnego != nil {
+				chartRepo = &spnegoDownloader{Downloader: httpChartRepo, negotiator: spnego}
+				spnego = nil
+			}
 		}
 
 		return chartRepo, nil
 	}
 }
 
+// spnegoDownloader is a repository.Downloader which closes the SPNEGO
+// negotiator of the chart repository once it is cleared.
+type spnegoDownloader struct {
+	repository.Downloader
+	negotiator *transport.SPNEGONegotiator
+}
+
+// Clear clears the chart repository, and closes the SPNEGO negotiator.
+func (d *spnegoDownloader) Clear() error {
+	defer d.negotiator.Close()
+	return d.Downloader.Clear()
+}
+
 func (r *HelmChartReconciler) resolveDependencyRepository(ctx context.Context, url string, namespace string) (*helmv1.HelmRepository, error) {
 	listOpts := []client.ListOption{
 		client.InNamespace(namespace),
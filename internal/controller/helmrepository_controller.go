@@ -19,29 +19,51 @@ package controller
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
+	"net"
 	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/Masterminds/semver/v3"
 	"github.com/docker/go-units"
 	"github.com/opencontainers/go-digest"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	helmgetter "helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/repo"
 	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	kuberecorder "k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/ratelimiter"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	eventv1 "github.com/fluxcd/pkg/apis/event/v1beta1"
 	"github.com/fluxcd/pkg/apis/meta"
 	"github.com/fluxcd/pkg/runtime/conditions"
 	helper "github.com/fluxcd/pkg/runtime/controller"
 	"github.com/fluxcd/pkg/runtime/jitter"
+	"github.com/fluxcd/pkg/runtime/logger"
 	"github.com/fluxcd/pkg/runtime/patch"
 	"github.com/fluxcd/pkg/runtime/predicates"
 	rreconcile "github.com/fluxcd/pkg/runtime/reconcile"
@@ -49,13 +71,17 @@ import (
 	sourcev1 "github.com/fluxcd/source-controller/api/v1"
 	helmv1 "github.com/fluxcd/source-controller/api/v1beta2"
 	"github.com/fluxcd/source-controller/internal/cache"
+	"github.com/fluxcd/source-controller/internal/cron"
 	intdigest "github.com/fluxcd/source-controller/internal/digest"
 	serror "github.com/fluxcd/source-controller/internal/error"
 	"github.com/fluxcd/source-controller/internal/helm/getter"
 	"github.com/fluxcd/source-controller/internal/helm/repository"
+	smetrics "github.com/fluxcd/source-controller/internal/metrics"
 	intpredicates "github.com/fluxcd/source-controller/internal/predicates"
 	sreconcile "github.com/fluxcd/source-controller/internal/reconcile"
 	"github.com/fluxcd/source-controller/internal/reconcile/summarize"
+	stls "github.com/fluxcd/source-controller/internal/tls"
+	"github.com/fluxcd/source-controller/internal/transport"
 )
 
 // helmRepositoryReadyCondition contains the information required to summarize a
@@ -70,6 +96,12 @@ var helmRepositoryReadyCondition = summarize.Conditions{
 		meta.ReadyCondition,
 		meta.ReconcilingCondition,
 		meta.StalledCondition,
+		helmv1.PausedCondition,
+		helmv1.StaleArtifactCondition,
+		helmv1.SuspiciousIndexChangeCondition,
+		helmv1.UpstreamInconsistentCondition,
+		helmv1.InsecureTLSCondition,
+		helmv1.ChartsUnreachableCondition,
 	},
 	Summarize: []string{
 		sourcev1.StorageOperationFailedCondition,
@@ -99,12 +131,14 @@ var helmRepositoryFailConditions = []string{
 // +kubebuilder:rbac:groups=source.toolkit.fluxcd.io,resources=helmrepositories/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=source.toolkit.fluxcd.io,resources=helmrepositories/finalizers,verbs=get;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
 
 // HelmRepositoryReconciler reconciles a v1beta2.HelmRepository object.
 type HelmRepositoryReconciler struct {
 	client.Client
 	kuberecorder.EventRecorder
 	helper.Metrics
+	SourceMetrics *smetrics.Recorder
 
 	Getters        helmgetter.Providers
 	Storage        *Storage
@@ -114,11 +148,134 @@ type HelmRepositoryReconciler struct {
 	TTL   time.Duration
 	*cache.CacheRecorder
 
+	// DisableFinalizers skips adding and removing the source finalizer, for
+	// the finalizers of objects to be managed externally.
+	DisableFinalizers bool
+
+	// MinRefreshInterval is the window after the last successful fetch of
+	// the index of a Ready object in which the index is not fetched again,
+	// unless a new generation or reconcile request has been observed. Zero
+	// disables the window.
+	MinRefreshInterval time.Duration
+
+	// IndexParseTimeout is the maximum duration for loading a fetched index
+	// from disk. It is applied separately from the fetch timeout of the
+	// object. Zero disables the timeout.
+	IndexParseTimeout time.Duration
+
+	// ArtifactMetadataPrefixes are the label and annotation key prefixes of
+	// the object which are embedded in the Artifact metadata, and added to
+	// the annotations of emitted events.
+	ArtifactMetadataPrefixes []string
+
+	// ArtifactCompression is the compression algorithm used to store the
+	// index artifact. Supported values are ArtifactCompressionZstd, or empty
+	// to store the index uncompressed.
+	ArtifactCompression string
+
+	// DenyHTTPCredentials refuses to send the credentials of the object to a
+	// Helm repository over plaintext HTTP, unless the object explicitly opts
+	// in. When false, a warning event is emitted instead.
+	DenyHTTPCredentials bool
+
+	// AllowInsecureTLS allows objects to skip the verification of the TLS
+	// certificate of the Helm repository using .spec.insecureSkipTLSVerify.
+	// When false, the index fetch of such objects fails instead.
+	AllowInsecureTLS bool
+
+	// HostAllowlist restricts the hosts of the URLs of the objects. When
+	// nil, any host is allowed.
+	HostAllowlist *HostAllowlist
+
+	// DefaultSecretName is the name of the Secret in the namespace of an
+	// object used for authentication when the object has no .spec.secretRef.
+	// Empty disables the fallback.
+	DefaultSecretName string
+
+	// LockBackoff is the backoff after which the object is requeued when
+	// the storage lock for the Artifact could not be acquired. It doubles
+	// with every consecutive failure, up to LockBackoffMax. Zero disables
+	// the backoff, in which case the failure is retried as any other error.
+	LockBackoff time.Duration
+	// LockBackoffMax is the upper bound of LockBackoff. Zero means the
+	// backoff is not bounded.
+	LockBackoffMax time.Duration
+
+	// FailureBackoffThreshold is the number of consecutive failures of an
+	// object after which its requeue interval is doubled with every further
+	// failure, up to FailureBackoffMax. Zero disables the backoff.
+	FailureBackoffThreshold int64
+	// FailureBackoffMax is the upper bound of the requeue interval of an
+	// object backing off from failures. Zero means the interval is not
+	// bounded.
+	FailureBackoffMax time.Duration
+
+	// HistoryLimit is the number of fetch failures retained in the
+	// .status.history of the object. Zero disables the history.
+	HistoryLimit int
+
+	// ConditionsLimit is the maximum number of owned Conditions retained in
+	// the status of the object. When exceeded, the informational Conditions
+	// which transitioned the longest ago are removed first. The Ready
+	// Condition and the Conditions it is summarized from are always retained.
+	// Zero means no limit.
+	ConditionsLimit int
+
+	// FailureResponseHeaders are the names of the response headers included
+	// in the message of the FetchFailed condition when fetching the index
+	// fails. Credentials and cookies are redacted.
+	FailureResponseHeaders []string
+
+	// FailureEventInterval is the interval at which repeated identical
+	// warning events of an object are emitted, with the number of times the
+	// event occurred in the meantime. Zero emits every warning event.
+	FailureEventInterval time.Duration
+
+	// IndexDeltaLimit is the maximum number of added and removed chart
+	// versions listed in the event and log entry emitted when the index
+	// changes. Zero disables computing the delta.
+	IndexDeltaLimit int
+
+	// CacheControlMinInterval and CacheControlMaxInterval are the bounds of
+	// the requeue interval of objects with .spec.intervalFromCacheControl set
+	// to true. A zero CacheControlMaxInterval means the interval is not
+	// bounded from above.
+	CacheControlMinInterval time.Duration
+	CacheControlMaxInterval time.Duration
+
+	// ArtifactTokens mints the token reported in the .status.artifactToken
+	// of objects with .spec.private set to true.
+	ArtifactTokens *ArtifactTokens
+
+	// ArtifactSigner signs the Artifacts, of which the detached signature
+	// is stored next to them, if not nil.
+	ArtifactSigner *ArtifactSigner
+
+	// ReconcileOnStartup fully reconciles every object once after the
+	// controller started, regardless of its schedule and the
+	// MinRefreshInterval. These reconciliations are limited to
+	// ReconcileOnStartupRate per second, unless zero.
+	ReconcileOnStartup     bool
+	ReconcileOnStartupRate float64
+
 	patchOptions []patch.Option
+	lockFailures lockBackoff
+	// upstreamIntervals holds the requeue intervals advertised by the
+	// upstream of objects, until they are applied to the reconcile result.
+	upstreamIntervals sync.Map
+	// startup tracks the pending startup reconciliations of objects, if
+	// ReconcileOnStartup is set.
+	startup *startupReconciles
+	// trigger tracks the pending reconciles of objects triggered through the
+	// ReconcileTriggerHandler, if configured.
+	trigger *ReconcileTriggerHandler
 }
 
 type HelmRepositoryReconcilerOptions struct {
 	RateLimiter ratelimiter.RateLimiter
+	// ReconcileTrigger enqueues the reconciles triggered through the
+	// ReconcileTriggerHandler, if not nil.
+	ReconcileTrigger *ReconcileTriggerHandler
 }
 
 // helmRepositoryReconcileFunc is the function type for all the
@@ -133,10 +290,22 @@ func (r *HelmRepositoryReconciler) SetupWithManager(mgr ctrl.Manager) error {
 
 func (r *HelmRepositoryReconciler) SetupWithManagerAndOptions(mgr ctrl.Manager, opts HelmRepositoryReconcilerOptions) error {
 	r.patchOptions = getPatchOptions(helmRepositoryReadyCondition.Owned, r.ControllerName)
+	queue := r.SourceMetrics.QueueTracker(helmv1.HelmRepositoryKind)
+	if r.FailureEventInterval > 0 {
+		r.EventRecorder = newFailureEventThrottle(r.EventRecorder, r.FailureEventInterval)
+	}
+	if r.ReconcileOnStartup {
+		r.startup = newStartupReconciles(r.ReconcileOnStartupRate)
+	}
+	r.trigger = opts.ReconcileTrigger
+
+	if err := mgr.GetCache().IndexField(context.Background(), &helmv1.HelmRepository{}, helmv1.HelmRepositorySecretRefIndexKey,
+		r.indexHelmRepositoryBySecretRef); err != nil {
+		return fmt.Errorf("failed setting index fields: %w", err)
+	}
 
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&helmv1.HelmRepository{}).
-		WithEventFilter(
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&helmv1.HelmRepository{}, builder.WithPredicates(
 			predicate.And(
 				predicate.Or(
 					intpredicates.HelmRepositoryTypePredicate{RepositoryType: helmv1.HelmRepositoryTypeDefault},
@@ -144,17 +313,251 @@ func (r *HelmRepositoryReconciler) SetupWithManagerAndOptions(mgr ctrl.Manager,
 				),
 				predicate.Or(predicate.GenerationChangedPredicate{}, predicates.ReconcileRequestedPredicate{}),
 			),
-		).
+			queue.Predicate(),
+		)).
+		Watches(
+			&corev1.Secret{},
+			handler.EnqueueRequestsFromMapFunc(r.requestsForSecretChange),
+			builder.OnlyMetadata,
+			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{}),
+		)
+
+	return watchReconcileTrigger(b, opts.ReconcileTrigger, "helmrepositories",
+		predicate.Or(
+			intpredicates.HelmRepositoryTypePredicate{RepositoryType: helmv1.HelmRepositoryTypeDefault},
+			intpredicates.HelmRepositoryTypePredicate{RepositoryType: ""},
+		),
+		queue.Predicate(),
+	).
 		WithOptions(controller.Options{
-			RateLimiter: opts.RateLimiter,
+			RateLimiter: queue.RateLimiter(opts.RateLimiter),
 		}).
-		Complete(r)
+		Complete(queue.Reconciler(r))
+}
+
+// requeueAfter returns the interval after which the object is reconciled
+// again, which is the retry interval of the object if the reconciliation
+// failed with the given error or served a stale Artifact, the interval
+// advertised by the upstream of the object during the reconciliation if any,
+// or the interval of the object.
+func (r *HelmRepositoryReconciler) requeueAfter(obj *helmv1.HelmRepository, err error) time.Duration {
+	d, upstream := r.upstreamIntervals.LoadAndDelete(client.ObjectKeyFromObject(obj))
+	if obj.Spec.RetryInterval != nil && (err != nil || conditions.IsTrue(obj, helmv1.StaleArtifactCondition)) {
+		return obj.Spec.RetryInterval.Duration
+	}
+	if upstream {
+		return d.(time.Duration)
+	}
+	return obj.GetRequeueAfter()
+}
+
+// retryWaiting returns the given reconcile error as a Waiting error which
+// requeues the object after the given interval, if the object has a retry
+// interval or its consecutive failures reached the FailureBackoffThreshold.
+// Contrary to the Generic error, the Waiting error is not returned
+// to the runtime, which would otherwise ignore the interval and retry with its
+// own backoff. The reason and event of the error are kept, and it is logged
+// instead of the runtime. It returns nil for any other error.
+func (r *HelmRepositoryReconciler) retryWaiting(obj *helmv1.HelmRepository, err error, requeueAfter time.Duration) *serror.Waiting {
+	backoff := r.FailureBackoffThreshold > 0 && obj.Status.ConsecutiveFailures >= r.FailureBackoffThreshold
+	if obj.Spec.RetryInterval == nil && !backoff {
+		return nil
+	}
+	e, ok := err.(*serror.Generic)
+	if !ok || e.Ignore {
+		return nil
+	}
+	w := &serror.Waiting{RequeueAfter: requeueAfter, Reason: e.Reason, Err: e.Err, Config: e.Config}
+	w.Log = true
+	return w
+}
+
+// retryResultBuilder is an AlwaysRequeueResultBuilder which does not consider
+// the result of a failed reconciliation which is retried as a success, even
+// if it is requeued after the same interval.
+type retryResultBuilder struct {
+	sreconcile.AlwaysRequeueResultBuilder
+	retrying bool
+}
+
+// IsSuccess returns true if the reconciliation is not retried, and the given
+// Result has the same RequeueAfter value as of the AlwaysRequeueResultBuilder.
+func (r retryResultBuilder) IsSuccess(result ctrl.Result) bool {
+	return !r.retrying && r.AlwaysRequeueResultBuilder.IsSuccess(result)
+}
+
+// recordConsecutiveFailures increments the .status.consecutiveFailures of the
+// object if the reconciliation failed or served a stale Artifact, and resets
+// it otherwise.
+func (r *HelmRepositoryReconciler) recordConsecutiveFailures(obj *helmv1.HelmRepository, err error) {
+	if err != nil || conditions.IsTrue(obj, helmv1.StaleArtifactCondition) {
+		obj.Status.ConsecutiveFailures++
+		return
+	}
+	obj.Status.ConsecutiveFailures = 0
+}
+
+// failureBackoff returns the given requeue interval of the object, doubled
+// for every consecutive failure from the FailureBackoffThreshold onwards, and
+// capped at the FailureBackoffMax. An interval exceeding the cap by itself is
+// returned as is.
+func (r *HelmRepositoryReconciler) failureBackoff(obj *helmv1.HelmRepository, interval time.Duration) time.Duration {
+	if r.FailureBackoffThreshold <= 0 || obj.Status.ConsecutiveFailures < r.FailureBackoffThreshold {
+		return interval
+	}
+	if r.FailureBackoffMax > 0 && interval >= r.FailureBackoffMax {
+		return interval
+	}
+	d := interval
+	for i := r.FailureBackoffThreshold; i <= obj.Status.ConsecutiveFailures && d <= math.MaxInt64/2; i++ {
+		d *= 2
+		if r.FailureBackoffMax > 0 && d >= r.FailureBackoffMax {
+			return r.FailureBackoffMax
+		}
+	}
+	return d
+}
+
+// recordUpstreamInterval records the max-age of the Cache-Control header of
+// the index response as the requeue interval of the object, clamped to the
+// CacheControlMinInterval and CacheControlMaxInterval, if the object defers
+// its interval to the upstream.
+func (r *HelmRepositoryReconciler) recordUpstreamInterval(ctx context.Context, obj *helmv1.HelmRepository, chartRepo *repository.ChartRepository) {
+	if !obj.Spec.IntervalFromCacheControl {
+		return
+	}
+	maxAge, ok := chartRepo.CacheMaxAge()
+	if !ok {
+		return
+	}
+	interval := maxAge
+	if interval < r.CacheControlMinInterval {
+		interval = r.CacheControlMinInterval
+	}
+	if r.CacheControlMaxInterval > 0 && interval > r.CacheControlMaxInterval {
+		interval = r.CacheControlMaxInterval
+	}
+	r.upstreamIntervals.Store(client.ObjectKeyFromObject(obj), interval)
+	r.eventLogf(ctx, obj, eventv1.EventTypeTrace, "UpstreamIntervalApplied",
+		"requeue interval set to %s from Cache-Control max-age of %s", interval, maxAge)
+}
+
+// maxFetchFailureMessageLength is the maximum length of the message of a
+// fetch failure recorded in the .status.history of the object.
+const maxFetchFailureMessageLength = 256
+
+// recordFetchFailure appends the FetchFailed condition of the object to its
+// .status.history if the reconciliation failed, retaining at most
+// HistoryLimit entries.
+func (r *HelmRepositoryReconciler) recordFetchFailure(obj *helmv1.HelmRepository, err error) {
+	if r.HistoryLimit <= 0 {
+		obj.Status.History = nil
+		return
+	}
+
+	if c := conditions.Get(obj, sourcev1.FetchFailedCondition); err != nil && c != nil && c.Status == metav1.ConditionTrue {
+		msg := c.Message
+		if len(msg) > maxFetchFailureMessageLength {
+			msg = strings.ToValidUTF8(msg[:maxFetchFailureMessageLength-3], "") + "..."
+		}
+		obj.Status.History = append(obj.Status.History, helmv1.FetchFailure{
+			Time:    metav1.Now(),
+			Reason:  c.Reason,
+			Message: msg,
+		})
+	}
+	if n := len(obj.Status.History); n > r.HistoryLimit {
+		obj.Status.History = append([]helmv1.FetchFailure(nil), obj.Status.History[n-r.HistoryLimit:]...)
+	}
+}
+
+// limitConditions removes the owned informational Conditions of the object
+// which transitioned the longest ago, until at most ConditionsLimit owned
+// Conditions remain. Conditions not owned by the reconciler are not touched.
+func (r *HelmRepositoryReconciler) limitConditions(obj *helmv1.HelmRepository) {
+	if r.ConditionsLimit <= 0 {
+		return
+	}
+
+	ownedTypes := sets.New(helmRepositoryReadyCondition.Owned...)
+	retainedTypes := sets.New(helmRepositoryReadyCondition.Summarize...).Insert(meta.ReadyCondition)
+
+	var owned int
+	var removable []metav1.Condition
+	for _, c := range obj.Status.Conditions {
+		if !ownedTypes.Has(c.Type) {
+			continue
+		}
+		owned++
+		if !retainedTypes.Has(c.Type) {
+			removable = append(removable, c)
+		}
+	}
+	sort.SliceStable(removable, func(i, j int) bool {
+		return removable[i].LastTransitionTime.Before(&removable[j].LastTransitionTime)
+	})
+	for i := 0; i < len(removable) && owned > r.ConditionsLimit; i++ {
+		conditions.Delete(obj, removable[i].Type)
+		owned--
+	}
+}
+
+// indexHelmRepositoryBySecretRef indexes the HelmRepository by the names of
+// the Secrets referenced by its .spec.secretRef, .spec.certSecretRef and
+// .spec.proxySecretRef, or the DefaultSecretName in place of a missing
+// .spec.secretRef.
+func (r *HelmRepositoryReconciler) indexHelmRepositoryBySecretRef(o client.Object) []string {
+	repo, ok := o.(*helmv1.HelmRepository)
+	if !ok {
+		panic(fmt.Sprintf("Expected a HelmRepository, got %T", o))
+	}
+	secretRef := repo.Spec.SecretRef
+	if secretRef == nil && r.DefaultSecretName != "" {
+		secretRef = &meta.LocalObjectReference{Name: r.DefaultSecretName}
+	}
+	var names []string
+	seen := sets.New[string]()
+	for _, ref := range []*meta.LocalObjectReference{secretRef, repo.Spec.CertSecretRef, repo.Spec.ProxySecretRef, repo.Spec.HeadersSecretRef} {
+		if ref != nil && !seen.Has(ref.Name) {
+			seen.Insert(ref.Name)
+			names = append(names, ref.Name)
+		}
+	}
+	return names
+}
+
+// requestsForSecretChange returns the requests for the HelmRepository objects
+// in the namespace of the changed Secret which reference it.
+func (r *HelmRepositoryReconciler) requestsForSecretChange(ctx context.Context, o client.Object) []reconcile.Request {
+	var list helmv1.HelmRepositoryList
+	if err := r.List(ctx, &list, client.InNamespace(o.GetNamespace()), client.MatchingFields{
+		helmv1.HelmRepositorySecretRefIndexKey: o.GetName(),
+	}); err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "failed to list HelmRepositories for Secret change")
+		return nil
+	}
+
+	var reqs []reconcile.Request
+	for _, i := range list.Items {
+		if i.Spec.Type != "" && i.Spec.Type != helmv1.HelmRepositoryTypeDefault {
+			continue
+		}
+		reqs = append(reqs, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&i)})
+	}
+	return reqs
 }
 
 func (r *HelmRepositoryReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, retErr error) {
 	start := time.Now()
+	defer r.SourceMetrics.RecordInFlight(helmv1.HelmRepositoryKind)()
 	log := ctrl.LoggerFrom(ctx)
 
+	ctx, span := tracer.Start(ctx, "HelmRepository.Reconcile", trace.WithAttributes(
+		attribute.String("namespace", req.Namespace),
+		attribute.String("name", req.Name),
+	))
+	defer func() { endSpan(span, retErr) }()
+
 	// Fetch the HelmRepository
 	obj := &helmv1.HelmRepository{}
 	if err := r.Get(ctx, req.NamespacedName, obj); err != nil {
@@ -166,10 +569,34 @@ func (r *HelmRepositoryReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 
 	// recResult stores the abstracted reconcile result.
 	var recResult sreconcile.Result
+	// pausedFor stores the remaining duration of the pause of the object.
+	var pausedFor time.Duration
+	// scheduledIn stores the duration until the next scheduled run of the
+	// object.
+	var scheduledIn time.Duration
+	// startupIn stores the duration until the startup reconciliation of the
+	// object may be performed.
+	var startupIn time.Duration
+	// reconciled is true if the sub-reconcilers of the object ran.
+	var reconciled bool
 
 	// Always attempt to patch the object after each reconciliation.
 	// NOTE: The final runtime result and error are set in this block.
 	defer func() {
+		r.recordFetchFailure(obj, retErr)
+		if reconciled {
+			r.recordConsecutiveFailures(obj, retErr)
+		}
+		r.limitConditions(obj)
+		obj.Status.LastReconcileDuration = &metav1.Duration{Duration: time.Since(start).Round(time.Millisecond)}
+
+		requeueAfter := jitter.JitteredIntervalDuration(r.failureBackoff(obj, r.requeueAfter(obj, retErr)))
+		// Retry a failed reconciliation at the retry interval or failure
+		// backoff of the object, instead of with the backoff of the controller.
+		var retrying bool
+		if w := r.retryWaiting(obj, retErr, requeueAfter); w != nil {
+			retErr, retrying = w, true
+		}
 		summarizeHelper := summarize.NewHelper(r.EventRecorder, serialPatcher)
 		summarizeOpts := []summarize.Option{
 			summarize.WithConditions(helmRepositoryReadyCondition),
@@ -180,17 +607,32 @@ func (r *HelmRepositoryReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 				summarize.ErrorActionHandler,
 				summarize.RecordReconcileReq,
 			),
-			summarize.WithResultBuilder(sreconcile.AlwaysRequeueResultBuilder{
-				RequeueAfter: jitter.JitteredIntervalDuration(obj.GetRequeueAfter()),
+			summarize.WithResultBuilder(retryResultBuilder{
+				AlwaysRequeueResultBuilder: sreconcile.AlwaysRequeueResultBuilder{RequeueAfter: requeueAfter},
+				retrying:                   retrying,
 			}),
 			summarize.WithPatchFieldOwner(r.ControllerName),
 		}
 		result, retErr = summarizeHelper.SummarizeAndPatch(ctx, obj, summarizeOpts...)
 
+		// Resume the reconciliation at the expiry of the pause.
+		if pausedFor > 0 && retErr == nil {
+			result = ctrl.Result{RequeueAfter: pausedFor}
+		}
+		// Requeue at the next scheduled run of the object, without jitter.
+		if scheduledIn > 0 && retErr == nil && !retrying {
+			result = ctrl.Result{RequeueAfter: scheduledIn}
+		}
+		// Requeue when the startup reconciliation of the object is due.
+		if startupIn > 0 && retErr == nil {
+			result = ctrl.Result{RequeueAfter: startupIn}
+		}
+
 		// Always record suspend, readiness and duration metrics.
 		r.Metrics.RecordSuspend(ctx, obj, obj.Spec.Suspend)
 		r.Metrics.RecordReadiness(ctx, obj)
 		r.Metrics.RecordDuration(ctx, obj, start)
+		recordLastSuccess(ctx, r.Metrics, r.SourceMetrics, obj)
 	}()
 
 	// Examine if the object is under deletion or if a type change has happened.
@@ -203,7 +645,7 @@ func (r *HelmRepositoryReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 	// between init and delete.
 	// Note: Finalizers in general can only be added when the deletionTimestamp
 	// is not set.
-	if !controllerutil.ContainsFinalizer(obj, sourcev1.SourceFinalizer) {
+	if !r.DisableFinalizers && !controllerutil.ContainsFinalizer(obj, sourcev1.SourceFinalizer) {
 		controllerutil.AddFinalizer(obj, sourcev1.SourceFinalizer)
 		recResult = sreconcile.ResultRequeue
 		return
@@ -216,6 +658,50 @@ func (r *HelmRepositoryReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		return
 	}
 
+	// Return if the object is paused, and requeue at the expiry of the pause.
+	if pauseUntil := obj.Spec.PauseUntil; pauseUntil != nil {
+		if pausedFor = time.Until(pauseUntil.Time); pausedFor > 0 {
+			resumeAt := pauseUntil.UTC().Format(time.RFC3339)
+			log.Info("reconciliation is paused for this object", "until", resumeAt)
+			conditions.MarkTrue(obj, helmv1.PausedCondition, helmv1.PauseUntilReason,
+				"reconciliation is paused until %s", resumeAt)
+			recResult, retErr = sreconcile.ResultEmpty, nil
+			return
+		}
+	}
+	conditions.Delete(obj, helmv1.PausedCondition)
+
+	// Return if the startup reconciliation of the object is pending but not
+	// due yet, and requeue when it is.
+	var startupPending bool
+	if startupPending, startupIn = r.startup.dueIn(req.NamespacedName); startupIn > 0 {
+		log.V(1).Info("startup reconciliation is not due yet", "after", startupIn.String())
+		recResult, retErr = sreconcile.ResultEmpty, nil
+		return
+	}
+
+	// Return if the object is reconciled on a schedule of which the next run
+	// is not due yet, unless the object changed, a reconcile is requested or
+	// triggered, or the startup reconciliation is pending.
+	triggered := r.trigger.isPending("helmrepositories", req.NamespacedName)
+	var schedule *cron.Schedule
+	if obj.Spec.Schedule != "" {
+		var err error
+		if schedule, err = cron.Parse(obj.Spec.Schedule); err != nil {
+			recResult = sreconcile.ResultEmpty
+			retErr = serror.NewStalling(fmt.Errorf("invalid schedule '%s': %w", obj.Spec.Schedule, err),
+				helmv1.InvalidScheduleReason)
+			return
+		}
+		if !startupPending && !triggered {
+			if scheduledIn = scheduledRunDueIn(obj); scheduledIn > 0 {
+				log.V(1).Info("reconciliation is not scheduled yet", "next", obj.Status.NextScheduledTime.UTC().Format(time.RFC3339))
+				recResult, retErr = sreconcile.ResultEmpty, nil
+				return
+			}
+		}
+	}
+
 	// Reconcile actual object
 	reconcilers := []helmRepositoryReconcileFunc{
 		r.reconcileStorage,
@@ -223,9 +709,38 @@ func (r *HelmRepositoryReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		r.reconcileArtifact,
 	}
 	recResult, retErr = r.reconcile(ctx, serialPatcher, obj, reconcilers)
+	reconciled = true
+	r.startup.markDone(req.NamespacedName)
+	r.trigger.markDone("helmrepositories", req.NamespacedName)
+
+	// Record the next scheduled run of the object.
+	obj.Status.NextScheduledTime = nil
+	if schedule != nil {
+		if next := schedule.Next(time.Now()); !next.IsZero() {
+			obj.Status.NextScheduledTime = &metav1.Time{Time: next}
+			scheduledIn = time.Until(next)
+		}
+	}
 	return
 }
 
+// scheduledRunDueIn returns the duration until the next scheduled run of the
+// object, or zero if the object must be reconciled now. This is the case if
+// the object is not ready, changed, or a reconcile is requested.
+func scheduledRunDueIn(obj *helmv1.HelmRepository) time.Duration {
+	next := obj.Status.NextScheduledTime
+	if next == nil || !conditions.IsReady(obj) || obj.Generation != obj.Status.ObservedGeneration {
+		return 0
+	}
+	if v, ok := meta.ReconcileAnnotationValue(obj.GetAnnotations()); ok && v != obj.Status.GetLastHandledReconcileRequest() {
+		return 0
+	}
+	if d := time.Until(next.Time); d > 0 {
+		return d
+	}
+	return 0
+}
+
 // reconcile iterates through the helmRepositoryReconcileFunc tasks for the
 // object. It returns early on the first call that returns
 // reconcile.ResultRequeue, or produces an error.
@@ -269,6 +784,7 @@ func (r *HelmRepositoryReconciler) reconcile(ctx context.Context, sp *patch.Seri
 		// If an error is received, prioritize the returned results because an
 		// error also means immediate requeue.
 		if err != nil {
+			recordFailure(r.SourceMetrics, helmv1.HelmRepositoryKind, err)
 			resErr = err
 			res = recResult
 			break
@@ -279,9 +795,59 @@ func (r *HelmRepositoryReconciler) reconcile(ctx context.Context, sp *patch.Seri
 
 	r.notify(ctx, oldObj, obj, &chartRepo, res, resErr)
 
+	// Serve the current Artifact if the index could not be fetched.
+	if resErr != nil && r.serveStaleArtifact(ctx, obj, resErr) {
+		return sreconcile.ResultSuccess, nil
+	}
+	if resErr == nil {
+		obj.Status.StaleSince = nil
+		conditions.Delete(obj, helmv1.StaleArtifactCondition)
+	}
+
 	return res, resErr
 }
 
+// serveStaleArtifact determines if the current Artifact of the object can be
+// served after a failure to fetch the index, as configured by
+// .spec.serveStaleOnError. It returns true if the failure is replaced by a
+// StaleArtifact Condition, or false if the failure should be reported, which
+// is the case once the Artifact has been stale for longer than the maximum
+// staleness.
+func (r *HelmRepositoryReconciler) serveStaleArtifact(ctx context.Context, obj *helmv1.HelmRepository, err error) bool {
+	if obj.Spec.ServeStaleOnError == nil {
+		obj.Status.StaleSince = nil
+		conditions.Delete(obj, helmv1.StaleArtifactCondition)
+		return false
+	}
+
+	// Only transient failures to fetch the index are taken into account.
+	if _, ok := err.(*serror.Generic); !ok || obj.GetArtifact() == nil ||
+		!conditions.IsTrue(obj, sourcev1.FetchFailedCondition) ||
+		conditions.IsTrue(obj, sourcev1.StorageOperationFailedCondition) {
+		return false
+	}
+
+	if obj.Status.StaleSince == nil {
+		now := metav1.Now()
+		obj.Status.StaleSince = &now
+	}
+
+	fetchErr := conditions.GetMessage(obj, sourcev1.FetchFailedCondition)
+	maxStaleness := obj.Spec.ServeStaleOnError.MaxStaleness.Duration
+	if time.Since(obj.Status.StaleSince.Time) > maxStaleness {
+		conditions.MarkTrue(obj, helmv1.StaleArtifactCondition, helmv1.MaxStalenessExceededReason,
+			"artifact has been stale for more than %s: %s", maxStaleness, fetchErr)
+		return false
+	}
+
+	conditions.Delete(obj, sourcev1.FetchFailedCondition)
+	conditions.MarkTrue(obj, helmv1.StaleArtifactCondition, helmv1.ServingStaleArtifactReason,
+		"serving stale artifact for revision '%s': %s", obj.GetArtifact().Revision, fetchErr)
+	r.eventLogf(ctx, obj, corev1.EventTypeWarning, helmv1.ServingStaleArtifactReason,
+		"serving stale artifact for revision '%s': %s", obj.GetArtifact().Revision, err.Error())
+	return true
+}
+
 // notify emits notification related to the reconciliation.
 func (r *HelmRepositoryReconciler) notify(ctx context.Context, oldObj, newObj *helmv1.HelmRepository, chartRepo *repository.ChartRepository, res sreconcile.Result, resErr error) {
 	// Notify successful reconciliation for new artifact and recovery from any
@@ -297,6 +863,10 @@ func (r *HelmRepositoryReconciler) notify(ctx context.Context, oldObj, newObj *h
 			humanReadableSize = fmt.Sprintf("size %s", units.HumanSize(float64(*size)))
 		}
 
+		for k, v := range newObj.Status.Artifact.Metadata {
+			annotations[fmt.Sprintf("%s/%s", sourcev1.GroupVersion.Group, k)] = v
+		}
+
 		message := fmt.Sprintf("stored fetched index of %s from '%s'", humanReadableSize, chartRepo.URL)
 
 		// Notify on new artifact and failure recovery.
@@ -328,6 +898,9 @@ func (r *HelmRepositoryReconciler) notify(ctx context.Context, oldObj, newObj *h
 // they match the Storage server hostname of current runtime.
 func (r *HelmRepositoryReconciler) reconcileStorage(ctx context.Context, sp *patch.SerialPatcher,
 	obj *helmv1.HelmRepository, _ *sourcev1.Artifact, _ *repository.ChartRepository) (sreconcile.Result, error) {
+	ctx, span := tracer.Start(ctx, "reconcileStorage")
+	defer span.End()
+
 	// Garbage collect previous advertised artifact(s) from storage
 	_ = r.garbageCollect(ctx, obj)
 
@@ -375,8 +948,15 @@ func (r *HelmRepositoryReconciler) reconcileStorage(ctx context.Context, sp *pat
 
 	// Always update URLs to ensure hostname is up-to-date
 	// TODO(hidde): we may want to send out an event only if we notice the URL has changed
-	r.Storage.SetArtifactURL(obj.GetArtifact())
-	obj.Status.URL = r.Storage.SetHostname(obj.Status.URL)
+	storage := r.Storage.WithHostname(obj.Spec.ArtifactHost)
+	storage.SetArtifactURL(obj.GetArtifact())
+	obj.Status.URL = storage.SetHostname(obj.Status.URL)
+
+	// Always update the token to ensure it matches the current key.
+	obj.Status.ArtifactToken = ""
+	if obj.Spec.Private && r.ArtifactTokens != nil {
+		obj.Status.ArtifactToken = r.ArtifactTokens.Token(obj.GetNamespace())
+	}
 
 	return sreconcile.ResultSuccess, nil
 }
@@ -391,6 +971,18 @@ func (r *HelmRepositoryReconciler) reconcileStorage(ctx context.Context, sp *pat
 // pointer is set to the newly fetched index.
 func (r *HelmRepositoryReconciler) reconcileSource(ctx context.Context, sp *patch.SerialPatcher,
 	obj *helmv1.HelmRepository, artifact *sourcev1.Artifact, chartRepo *repository.ChartRepository) (sreconcile.Result, error) {
+	ctx, span := tracer.Start(ctx, "reconcileSource")
+	defer span.End()
+
+	// Short-circuit if the object became Ready within the minimum refresh
+	// window, and nothing has changed since.
+	if r.withinMinRefreshWindow(obj) {
+		*artifact = *obj.GetArtifact()
+		ctrl.LoggerFrom(ctx).V(logger.DebugLevel).Info("skipping index fetch within minimum refresh window",
+			"window", r.MinRefreshInterval.String())
+		return sreconcile.ResultSuccess, nil
+	}
+
 	normalizedURL, err := repository.NormalizeURL(obj.Spec.URL)
 	if err != nil {
 		e := serror.NewStalling(
@@ -401,7 +993,29 @@ func (r *HelmRepositoryReconciler) reconcileSource(ctx context.Context, sp *patc
 		return sreconcile.ResultEmpty, e
 	}
 
-	clientOpts, _, err := getter.GetClientOpts(ctx, r.Client, obj, normalizedURL)
+	// Refuse to fetch from hosts which are not allowed, before any request
+	// is made.
+	if err := r.checkAllowedHosts(ctx, obj); err != nil {
+		return sreconcile.ResultEmpty, err
+	}
+
+	// Fall back to the default authentication Secret of the namespace, if
+	// configured and present.
+	authObj, err := withDefaultSecretRef(ctx, r.Client, obj, r.DefaultSecretName)
+	if err != nil {
+		e := serror.NewGeneric(
+			err,
+			sourcev1.AuthenticationFailedReason,
+		)
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+		return sreconcile.ResultEmpty, e
+	}
+	if authObj != obj {
+		r.eventLogf(ctx, obj, eventv1.EventTypeTrace, "DefaultSecretRef",
+			"using default authentication secret '%s'", r.DefaultSecretName)
+	}
+
+	clientOpts, _, err := getter.GetClientOpts(ctx, r.Client, authObj, normalizedURL)
 	if err != nil {
 		if errors.Is(err, getter.ErrDeprecatedTLSConfig) {
 			ctrl.LoggerFrom(ctx).
@@ -415,6 +1029,53 @@ func (r *HelmRepositoryReconciler) reconcileSource(ctx context.Context, sp *patc
 			return sreconcile.ResultEmpty, e
 		}
 	}
+	if clientOpts.SPNEGO != nil {
+		defer clientOpts.SPNEGO.Close()
+	}
+	if clientOpts.SecretKeys != nil {
+		r.eventLogf(ctx, obj, eventv1.EventTypeTrace, "AuthSecretKeys",
+			"authentication secret '%s' %s", authObj.Spec.SecretRef.Name, clientOpts.SecretKeys)
+	}
+	if len(clientOpts.Headers) > 0 {
+		// Only the names of the headers are recorded, as their values may
+		// carry credentials.
+		names := make([]string, 0, len(clientOpts.Headers))
+		for name := range clientOpts.Headers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		r.eventLogf(ctx, obj, eventv1.EventTypeTrace, "RequestHeaders",
+			"setting request headers from secret '%s': %s", obj.Spec.HeadersSecretRef.Name, strings.Join(names, ", "))
+	}
+
+	// Guard against credentials leaking over plaintext HTTP.
+	if err := r.checkCredentialTransport(ctx, obj, clientOpts, normalizedURL); err != nil {
+		return sreconcile.ResultEmpty, err
+	}
+
+	// Skip the verification of the TLS certificate for this object only.
+	if err := r.checkInsecureTLS(ctx, obj); err != nil {
+		return sreconcile.ResultEmpty, err
+	}
+	if obj.Spec.InsecureSkipTLSVerify {
+		tlsConfig := &tls.Config{}
+		if clientOpts.TlsConfig != nil {
+			tlsConfig = clientOpts.TlsConfig.Clone()
+		}
+		tlsConfig.InsecureSkipVerify = true
+		clientOpts.TlsConfig = tlsConfig
+	}
+	// Restrict the TLS version and cipher suites for this object only.
+	tlsPolicy := obj.Spec.MinTLSVersion != "" || len(obj.Spec.TLSCipherSuites) > 0
+	if tlsPolicy {
+		tlsConfig, err := stls.WithPolicy(clientOpts.TlsConfig, obj.Spec.MinTLSVersion, obj.Spec.TLSCipherSuites)
+		if err != nil {
+			e := serror.NewStalling(err, helmv1.InvalidTLSPolicyReason)
+			conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+			return sreconcile.ResultEmpty, e
+		}
+		clientOpts.TlsConfig = tlsConfig
+	}
 
 	// Construct Helm chart repository with options and download index
 	newChartRepo, err := repository.NewChartRepository(obj.Spec.URL, "", r.Getters, clientOpts.TlsConfig, clientOpts.GetterOpts...)
@@ -437,25 +1098,131 @@ func (r *HelmRepositoryReconciler) reconcileSource(ctx context.Context, sp *patc
 		}
 	}
 
-	// Fetch the repository index from remote.
-	if err := newChartRepo.CacheIndex(); err != nil {
+	newChartRepo.RedirectPolicy = &transport.RedirectPolicy{
+		Follow:          obj.GetFollowRedirects(),
+		PassCredentials: obj.Spec.PassCredentials,
+	}
+	newChartRepo.ResolverAddress = obj.Spec.ResolverAddress
+	newChartRepo.ProxyURL = clientOpts.ProxyURL
+	newChartRepo.ForceHTTP1 = obj.Spec.ForceHTTP1
+	newChartRepo.ResponseHeaders = r.FailureResponseHeaders
+	newChartRepo.AuthMethods = clientOpts.AuthMethods
+	newChartRepo.RequestSigner = clientOpts.RequestSigner
+	newChartRepo.SigV4Signer = clientOpts.SigV4Signer
+	newChartRepo.SPNEGO = clientOpts.SPNEGO
+	newChartRepo.AcceptHeader = obj.Spec.AcceptHeader
+	newChartRepo.DigestHeader = obj.Spec.DigestHeader
+	newChartRepo.Headers = clientOpts.Headers
+	newChartRepo.ShardManifestURL = obj.Spec.ShardManifestURL
+	if obj.Spec.PreflightURL != "" {
+		newChartRepo.Preflight = &repository.Preflight{URL: obj.Spec.PreflightURL}
+		if t := obj.Spec.PreflightToken; t != nil {
+			newChartRepo.Preflight.TokenHeader = t.Header
+			newChartRepo.Preflight.TokenJSONPath = t.JSONPath
+			newChartRepo.Preflight.RequestHeader = t.RequestHeader
+		}
+	}
+
+	// Fetch the repository index from remote, bounded by the timeout of the
+	// object down to the dialing and TLS handshake.
+	fetchCtx, cancel := context.WithTimeout(ctx, obj.Spec.Timeout.Duration)
+	defer cancel()
+	fetchCtx, fetchSpan := tracer.Start(fetchCtx, "fetch index", trace.WithAttributes(
+		attribute.String("url", newChartRepo.URL),
+	))
+	err = newChartRepo.CacheIndexContext(fetchCtx)
+	endSpan(fetchSpan, err)
+	obj.Status.LastFetchStatusCode = newChartRepo.StatusCode()
+	if chain := newChartRepo.RedirectChain(); len(chain) > 0 {
+		hops := make([]string, 0, len(chain))
+		for _, h := range chain {
+			hops = append(hops, h.String())
+		}
+		r.eventLogf(ctx, obj, eventv1.EventTypeTrace, "IndexRedirected",
+			"index fetch followed redirects: %s", strings.Join(hops, " -> "))
+	}
+	if err != nil {
+		if errors.Is(err, repository.ErrIndexNotFound) && obj.Spec.Treat404AsStalling {
+			e := serror.NewStalling(
+				fmt.Errorf("failed to fetch Helm repository index: %w", err),
+				helmv1.IndexNotFoundReason,
+			)
+			conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+			return sreconcile.ResultEmpty, e
+		}
+		reason := meta.FailedReason
+		if errors.Is(err, transport.ErrRedirectNotAllowed) {
+			reason = helmv1.RedirectNotAllowedReason
+		} else if errors.Is(err, transport.ErrSPNEGO) || errors.Is(err, repository.ErrPreflight) {
+			reason = sourcev1.AuthenticationFailedReason
+		} else if errors.Is(err, repository.ErrUnsupportedEncoding) {
+			reason = helmv1.UnsupportedEncodingReason
+		} else if errors.Is(err, repository.ErrUnexpectedContentType) {
+			reason = helmv1.UnexpectedContentTypeReason
+		} else if errors.Is(err, repository.ErrTruncatedResponse) {
+			reason = helmv1.TruncatedResponseReason
+		} else if errors.Is(err, repository.ErrDigestMismatch) {
+			reason = helmv1.DigestMismatchReason
+		} else if tlsPolicy && isTLSHandshakeError(err) {
+			reason = helmv1.TLSHandshakeFailedReason
+		} else if isNetworkTimeout(err) {
+			reason = helmv1.NetworkErrorReason
+		}
 		e := serror.NewGeneric(
 			fmt.Errorf("failed to fetch Helm repository index: %w", err),
-			meta.FailedReason,
+			reason,
 		)
 		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
 		// Coin flip on transient or persistent error, return error and hope for the best
 		return sreconcile.ResultEmpty, e
 	}
+	r.recordUpstreamInterval(ctx, obj, newChartRepo)
+	if m := newChartRepo.AuthMethodUsed(); m != "" {
+		r.eventLogf(ctx, obj, eventv1.EventTypeTrace, "AuthenticationSucceeded", "fetched index using '%s' auth method", m)
+	}
 	*chartRepo = *newChartRepo
+	fetchTime := metav1.Now()
+
+	// Fetch the index a second time, and keep the current Artifact if the
+	// upstream serves a different index.
+	if obj.Spec.CanaryFetch != nil {
+		consistent, err := r.canaryFetch(ctx, obj, clientOpts, chartRepo)
+		if err != nil {
+			return sreconcile.ResultEmpty, err
+		}
+		if !consistent {
+			*artifact = *obj.GetArtifact()
+			conditions.Delete(obj, sourcev1.FetchFailedCondition)
+			return sreconcile.ResultSuccess, nil
+		}
+	} else {
+		conditions.Delete(obj, helmv1.UpstreamInconsistentCondition)
+	}
+	// Only an index which passed the canary fetch is observed.
+	obj.Status.ObservedURL = chartRepo.URL
+	obj.Status.LastSuccessfulFetchTime = &fetchTime
+
+	// The weak revision is calculated over the parsed index, which is
+	// therefore loaded before the early comparison.
+	weak := obj.Spec.RevisionComparison == helmv1.HelmRepositoryRevisionComparisonWeak
+	if weak {
+		if err := r.loadFetchedIndex(ctx, obj, chartRepo); err != nil {
+			return sreconcile.ResultEmpty, err
+		}
+	}
 
-	// Early comparison to current Artifact.
-	if curArtifact := obj.GetArtifact(); curArtifact != nil {
-		curRev := digest.Digest(curArtifact.Revision)
-		if curRev.Validate() == nil {
+	// Early comparison to current Artifact, unless other indexes are merged
+	// or the index may be modified, in which case the revision is based on
+	// the result.
+	if curArtifact := obj.GetArtifact(); curArtifact != nil && len(obj.Spec.AdditionalURLs) == 0 &&
+		len(obj.Spec.BlockVersions) == 0 && obj.Spec.MaxVersionsPerChart == 0 && obj.Spec.ChartNameValidation == nil &&
+		!obj.Spec.CanonicalizeIndex && !obj.Spec.IgnoreGeneratedTimestamp && obj.Spec.Policy == nil &&
+		!indexFormatChanged(*curArtifact, obj.GetIndexFormat()) && !artifactExpired(obj) {
+		curRev := artifactRevision(*curArtifact)
+		if curRev.Validate() == nil && formatRevision(obj, curRev) == curArtifact.Revision {
 			// Short-circuit based on the fetched index being an exact match to the
 			// stored Artifact.
-			if newRev := chartRepo.Digest(curRev.Algorithm()); newRev.Validate() == nil && (newRev == curRev) {
+			if newRev := indexRevision(obj, chartRepo, curRev.Algorithm()); newRev.Validate() == nil && (newRev == curRev) {
 				*artifact = *curArtifact
 				conditions.Delete(obj, sourcev1.FetchFailedCondition)
 				return sreconcile.ResultSuccess, nil
@@ -464,19 +1231,129 @@ func (r *HelmRepositoryReconciler) reconcileSource(ctx context.Context, sp *patc
 	}
 
 	// Load the cached repository index to ensure it passes validation.
-	if err := chartRepo.LoadFromPath(); err != nil {
+	if !weak {
+		if err := r.loadFetchedIndex(ctx, obj, chartRepo); err != nil {
+			return sreconcile.ResultEmpty, err
+		}
+	}
+	// Merge the indexes of the additional URLs, before the revision is
+	// calculated.
+	if len(obj.Spec.AdditionalURLs) > 0 {
+		if err := r.mergeAdditionalIndexes(ctx, obj, clientOpts, chartRepo); err != nil {
+			return sreconcile.ResultEmpty, err
+		}
+	}
+	// Validate the chart names in the index, before the revision is
+	// calculated.
+	if obj.Spec.ChartNameValidation != nil {
+		if err := r.validateChartNames(ctx, obj, chartRepo); err != nil {
+			return sreconcile.ResultEmpty, err
+		}
+	}
+	// Remove the blocked chart versions from the index, before the revision
+	// is calculated.
+	if len(obj.Spec.BlockVersions) > 0 {
+		if err := r.removeBlockedVersions(ctx, obj, chartRepo); err != nil {
+			return sreconcile.ResultEmpty, err
+		}
+	}
+	// Truncate the versions per chart in the index, before the revision is
+	// calculated.
+	if obj.Spec.MaxVersionsPerChart > 0 {
+		if err := r.truncateVersions(ctx, obj, chartRepo); err != nil {
+			return sreconcile.ResultEmpty, err
+		}
+	}
+	// Canonicalize the index, before the revision is calculated.
+	if obj.Spec.CanonicalizeIndex {
+		if err := chartRepo.Canonicalize(); err != nil {
+			e := serror.NewGeneric(
+				fmt.Errorf("failed to canonicalize Helm repository index: %w", err),
+				helmv1.IndexationFailedReason,
+			)
+			conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+			return sreconcile.ResultEmpty, e
+		}
+	}
+	// Drop the generated timestamp of the index, before the revision is
+	// calculated.
+	if obj.Spec.IgnoreGeneratedTimestamp && !obj.Spec.CanonicalizeIndex {
+		if err := chartRepo.ResetGenerated(); err != nil {
+			e := serror.NewGeneric(
+				fmt.Errorf("failed to drop generated timestamp of Helm repository index: %w", err),
+				helmv1.IndexationFailedReason,
+			)
+			conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+			return sreconcile.ResultEmpty, e
+		}
+	}
+	// Verify the digests of a sample of the chart versions in the index.
+	if obj.Spec.DigestSpotCheck != nil {
+		if err := r.spotCheckDigests(obj, chartRepo); err != nil {
+			return sreconcile.ResultEmpty, err
+		}
+	}
+	// Evaluate the policy against the index, before the Artifact is
+	// produced.
+	if obj.Spec.Policy != nil {
+		if err := r.evaluatePolicy(ctx, obj, chartRepo); err != nil {
+			return sreconcile.ResultEmpty, err
+		}
+	}
+	// Check the reachability of a sample of the chart URLs in the index.
+	if obj.Spec.ChartURLCheck != nil {
+		if err := r.checkChartURLs(ctx, obj, chartRepo); err != nil {
+			return sreconcile.ResultEmpty, err
+		}
+	} else {
+		conditions.Delete(obj, helmv1.ChartsUnreachableCondition)
+	}
+
+	// Fail on an empty index, keeping the current Artifact.
+	if obj.Spec.FailIfEmpty && len(chartRepo.Index.Entries) == 0 {
 		e := serror.NewGeneric(
-			fmt.Errorf("failed to load Helm repository from index YAML: %w", err),
-			helmv1.IndexationFailedReason,
+			errors.New("Helm repository index has no entries"),
+			helmv1.EmptyIndexReason,
 		)
 		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
 		return sreconcile.ResultEmpty, e
 	}
+
+	// Keep the current Artifact if the number of charts in the index dropped
+	// suspiciously.
+	suspicious, err := r.isSuspiciousIndexChange(ctx, obj, chartRepo)
+	if err != nil {
+		return sreconcile.ResultEmpty, err
+	}
+	if suspicious {
+		*artifact = *obj.GetArtifact()
+		conditions.Delete(obj, sourcev1.FetchFailedCondition)
+		return sreconcile.ResultSuccess, nil
+	}
+
 	// Delete any stale failure observation
 	conditions.Delete(obj, sourcev1.FetchFailedCondition)
 
+	// Record the number of charts and chart versions in the index.
+	chartCount, chartVersionCount := chartRepo.EntriesCount()
+	obj.Status.ChartCount = &chartCount
+	obj.Status.ChartVersionCount = &chartVersionCount
+
+	// Summarize the dependencies between the charts in the index.
+	obj.Status.ChartDependencies = nil
+	if obj.Spec.SummarizeDependencies {
+		dependencies, internal, dependents := chartRepo.DependencyCounts(append([]string{obj.Spec.URL}, obj.Spec.AdditionalURLs...)...)
+		obj.Status.ChartDependencies = &helmv1.ChartDependencies{
+			Dependencies:         dependencies,
+			InternalDependencies: internal,
+			DependentCharts:      dependents,
+		}
+	}
+
 	// Calculate revision.
-	revision := chartRepo.Digest(intdigest.Canonical)
+	_, digestSpan := tracer.Start(ctx, "calculate revision")
+	revision := indexRevision(obj, chartRepo, intdigest.Canonical)
+	digestSpan.End()
 	if revision.Validate() != nil {
 		e := serror.NewGeneric(
 			fmt.Errorf("failed to calculate revision: %w", err),
@@ -486,6 +1363,11 @@ func (r *HelmRepositoryReconciler) reconcileSource(ctx context.Context, sp *patc
 		return sreconcile.ResultEmpty, e
 	}
 
+	// Record the chart versions which changed since the current Artifact.
+	if r.IndexDeltaLimit > 0 && obj.GetArtifact() != nil && artifactRevision(*obj.GetArtifact()) != revision {
+		r.logIndexDelta(ctx, obj, chartRepo)
+	}
+
 	// Mark observations about the revision on the object.
 	message := fmt.Sprintf("new index revision '%s'", revision)
 	if obj.GetArtifact() != nil {
@@ -497,52 +1379,898 @@ func (r *HelmRepositoryReconciler) reconcileSource(ctx context.Context, sp *patc
 	}
 
 	// Create potential new artifact.
-	*artifact = r.Storage.NewArtifactFor(obj.Kind,
+	fileName := fmt.Sprintf("index-%s.%s", revision.Encoded(), obj.GetIndexFormat())
+	if r.ArtifactCompression == ArtifactCompressionZstd {
+		fileName += ZstdExtension
+	}
+	*artifact = r.Storage.WithHostname(obj.Spec.ArtifactHost).NewArtifactFor(obj.Kind,
 		obj.ObjectMeta.GetObjectMeta(),
-		revision.String(),
-		fmt.Sprintf("index-%s.yaml", revision.Encoded()),
+		formatRevision(obj, revision),
+		fileName,
 	)
 
 	return sreconcile.ResultSuccess, nil
 }
 
-// reconcileArtifact archives a new Artifact to the Storage, if the current
-// (Status) data on the object does not match the given.
-//
-// The inspection of the given data to the object is differed, ensuring any
-// stale observations like v1beta2.ArtifactOutdatedCondition are removed.
-// If the given Artifact does not differ from the object's current, it returns
-// early.
-// On a successful archive, the Artifact in the Status of the object is set,
-// and the symlink in the Storage is updated to its path.
-func (r *HelmRepositoryReconciler) reconcileArtifact(ctx context.Context, sp *patch.SerialPatcher, obj *helmv1.HelmRepository, artifact *sourcev1.Artifact, chartRepo *repository.ChartRepository) (sreconcile.Result, error) {
-	// Set the ArtifactInStorageCondition if there's no drift.
-	defer func() {
-		if obj.GetArtifact().HasRevision(artifact.Revision) {
-			conditions.Delete(obj, sourcev1.ArtifactOutdatedCondition)
-			conditions.MarkTrue(obj, sourcev1.ArtifactInStorageCondition, meta.SucceededReason,
-				"stored artifact: revision '%s'", artifact.Revision)
-		}
-		if err := chartRepo.Clear(); err != nil {
-			ctrl.LoggerFrom(ctx).Error(err, "failed to remove temporary cached index file")
-		}
-	}()
+// loadFetchedIndex loads the fetched index of the given
+// repository.ChartRepository to ensure it passes validation, and records the
+// .status.lastFetchTime of the object. On failure, it records
+// v1.FetchFailedCondition=True.
+func (r *HelmRepositoryReconciler) loadFetchedIndex(ctx context.Context, obj *helmv1.HelmRepository, chartRepo *repository.ChartRepository) error {
+	if err := r.loadIndex(ctx, chartRepo); err != nil {
+		e := serror.NewGeneric(
+			fmt.Errorf("failed to load Helm repository from index YAML: %w", err),
+			helmv1.IndexationFailedReason,
+		)
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+		return e
+	}
+	now := metav1.Now()
+	obj.Status.LastFetchTime = &now
+	return nil
+}
 
-	if obj.GetArtifact().HasRevision(artifact.Revision) && obj.GetArtifact().HasDigest(artifact.Digest) {
-		// Extend TTL of the Index in the cache (if present).
-		if r.Cache != nil {
-			r.Cache.SetExpiration(artifact.Path, r.TTL)
+// indexRevision returns the revision of the index of the given
+// repository.ChartRepository using the given algorithm, according to the
+// .spec.revisionComparison of the object.
+func indexRevision(obj *helmv1.HelmRepository, chartRepo *repository.ChartRepository, algorithm digest.Algorithm) digest.Digest {
+	if obj.Spec.RevisionComparison == helmv1.HelmRepositoryRevisionComparisonWeak {
+		return chartRepo.WeakDigest(algorithm)
+	}
+	return chartRepo.Digest(algorithm)
+}
+
+// formatRevision returns the given revision in the .spec.revisionFormat of
+// the object.
+func formatRevision(obj *helmv1.HelmRepository, revision digest.Digest) string {
+	switch obj.Spec.RevisionFormat {
+	case helmv1.HelmRepositoryRevisionFormatHex:
+		return revision.Encoded()
+	case helmv1.HelmRepositoryRevisionFormatShort:
+		if enc := revision.Encoded(); len(enc) > 12 {
+			return enc[:12]
 		}
+		return revision.Encoded()
+	default:
+		return revision.String()
+	}
+}
 
-		r.eventLogf(ctx, obj, eventv1.EventTypeTrace, sourcev1.ArtifactUpToDateReason, "artifact up-to-date with remote revision: '%s'", artifact.Revision)
-		return sreconcile.ResultSuccess, nil
+// artifactRevision returns the full revision of the given index Artifact.
+// When the revision of the Artifact is not formatted as a digest, the
+// revision is derived from its file name, which contains the encoded
+// revision as "index-<hex>.<format>".
+func artifactRevision(artifact sourcev1.Artifact) digest.Digest {
+	if d := digest.Digest(artifact.Revision); d.Validate() == nil {
+		return d
+	}
+	enc := strings.TrimPrefix(path.Base(artifact.Path), "index-")
+	if i := strings.Index(enc, "."); i >= 0 {
+		enc = enc[:i]
 	}
+	return digest.NewDigestFromEncoded(intdigest.Canonical, enc)
+}
 
-	// Create artifact dir
-	if err := r.Storage.MkdirAll(*artifact); err != nil {
-		e := serror.NewGeneric(
+// sameRevision returns true if the given current Artifact has the revision
+// of the given Artifact. Short revisions are ambiguous, in which case the
+// full revisions are compared as well.
+func sameRevision(obj *helmv1.HelmRepository, cur *sourcev1.Artifact, artifact sourcev1.Artifact) bool {
+	if !cur.HasRevision(artifact.Revision) {
+		return false
+	}
+	if obj.Spec.RevisionFormat == helmv1.HelmRepositoryRevisionFormatShort {
+		return artifactRevision(*cur) == artifactRevision(artifact)
+	}
+	return true
+}
+
+// isTLSHandshakeError returns true if the given error is caused by a failed
+// TLS handshake, e.g. because no TLS version or cipher suite could be
+// negotiated with the server.
+func isTLSHandshakeError(err error) bool {
+	var recordErr tls.RecordHeaderError
+	if errors.As(err, &recordErr) {
+		return true
+	}
+	// The TLS alerts of the server and the negotiation errors of the client
+	// are not exported as types.
+	return strings.Contains(err.Error(), "tls: ")
+}
+
+// isNetworkTimeout returns true if the given error is caused by a network
+// timeout, or by a context deadline being exceeded.
+func isNetworkTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// indexFormatChanged returns true if the given index Artifact was stored in
+// a different format than the given format, based on its file extension and
+// ignoring any compression extension.
+func indexFormatChanged(artifact sourcev1.Artifact, format string) bool {
+	isJSON := strings.HasSuffix(strings.TrimSuffix(artifact.Path, ZstdExtension), "."+helmv1.HelmRepositoryIndexFormatJSON)
+	return isJSON != (format == helmv1.HelmRepositoryIndexFormatJSON)
+}
+
+// checkCredentialTransport guards against the credentials of the given
+// client options, e.g. the data of the authentication Secret, the headers or
+// the SPNEGO tokens, being sent over plaintext HTTP to the given URL. If
+// DenyHTTPCredentials is set, and the object does not explicitly opt in with
+// .spec.passCredentials or .spec.insecureSkipTLSVerify, it records
+// v1beta2.FetchFailedCondition=True and returns a stalling error. Otherwise,
+// it emits a warning event.
+func (r *HelmRepositoryReconciler) checkCredentialTransport(ctx context.Context, obj *helmv1.HelmRepository,
+	clientOpts *getter.ClientOpts, u string) error {
+	if !clientOpts.HasCredentials() || !strings.HasPrefix(strings.ToLower(u), "http://") {
+		return nil
+	}
+	optIn := obj.Spec.PassCredentials || obj.Spec.InsecureSkipTLSVerify
+	if r.DenyHTTPCredentials && !optIn {
+		e := serror.NewStalling(
+			fmt.Errorf("refusing to send credentials over plaintext HTTP to '%s'", u),
+			helmv1.InsecureCredentialTransportReason,
+		)
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+		return e
+	}
+	r.eventLogf(ctx, obj, corev1.EventTypeWarning, helmv1.InsecureCredentialTransportReason,
+		"credentials are sent over plaintext HTTP to '%s'", u)
+	return nil
+}
+
+// withDefaultSecretRef returns the given HelmRepository with its
+// .spec.secretRef set to the Secret with the given name in its namespace, if
+// the object has no .spec.secretRef and the Secret exists. Otherwise, or if
+// the name is empty or the object is of the OCI type, the object is returned
+// as is. The given object is never modified, for the fallback to not be
+// persisted.
+func withDefaultSecretRef(ctx context.Context, c client.Reader, obj *helmv1.HelmRepository, name string) (*helmv1.HelmRepository, error) {
+	if name == "" || obj.Spec.SecretRef != nil || obj.Spec.Type == helmv1.HelmRepositoryTypeOCI || obj.GetNamespace() == "" {
+		return obj, nil
+	}
+	var secret corev1.Secret
+	if err := c.Get(ctx, types.NamespacedName{Namespace: obj.GetNamespace(), Name: name}, &secret); err != nil {
+		if apierrs.IsNotFound(err) {
+			return obj, nil
+		}
+		return nil, fmt.Errorf("failed to get default authentication secret '%s/%s': %w", obj.GetNamespace(), name, err)
+	}
+	authObj := obj.DeepCopy()
+	authObj.Spec.SecretRef = &meta.LocalObjectReference{Name: name}
+	return authObj, nil
+}
+
+// checkAllowedHosts verifies the hosts of the URL, additional URLs and shard
+// manifest URL of the object against the HostAllowlist. If a host is not allowed, it records
+// v1beta2.FetchFailedCondition=True and returns a stalling error. If the
+// allowlist can not be loaded, it returns a generic error instead.
+func (r *HelmRepositoryReconciler) checkAllowedHosts(ctx context.Context, obj *helmv1.HelmRepository) error {
+	if r.HostAllowlist == nil {
+		return nil
+	}
+	urls := append([]string{obj.Spec.URL}, obj.Spec.AdditionalURLs...)
+	if obj.Spec.ShardManifestURL != "" {
+		urls = append(urls, obj.Spec.ShardManifestURL)
+	}
+	for _, u := range urls {
+		parsed, err := url.Parse(u)
+		if err != nil {
+			// Invalid URLs are reported when the index is fetched.
+			continue
+		}
+		allowed, err := r.HostAllowlist.Allowed(ctx, parsed.Hostname())
+		if err != nil {
+			e := serror.NewGeneric(err, helmv1.HostNotAllowedReason)
+			conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+			return e
+		}
+		if !allowed {
+			e := serror.NewStalling(
+				fmt.Errorf("host '%s' of URL '%s' is not allowed by the controller", parsed.Hostname(), u),
+				helmv1.HostNotAllowedReason,
+			)
+			conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+			return e
+		}
+	}
+	return nil
+}
+
+// checkInsecureTLS records v1beta2.InsecureTLSCondition=True if the object
+// skips the verification of the TLS certificate of the Helm repository, and
+// emits a warning event. If AllowInsecureTLS is not set, it records
+// v1beta2.FetchFailedCondition=True and returns a stalling error instead.
+func (r *HelmRepositoryReconciler) checkInsecureTLS(ctx context.Context, obj *helmv1.HelmRepository) error {
+	if !obj.Spec.InsecureSkipTLSVerify {
+		conditions.Delete(obj, helmv1.InsecureTLSCondition)
+		return nil
+	}
+	if !r.AllowInsecureTLS {
+		conditions.Delete(obj, helmv1.InsecureTLSCondition)
+		e := serror.NewStalling(
+			errors.New("refusing to skip TLS certificate verification: insecure TLS is not allowed by the controller"),
+			helmv1.InsecureSkipTLSVerifyReason,
+		)
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+		return e
+	}
+	conditions.MarkTrue(obj, helmv1.InsecureTLSCondition, helmv1.InsecureSkipTLSVerifyReason,
+		"TLS certificate verification is skipped for '%s'", obj.Spec.URL)
+	r.eventLogf(ctx, obj, corev1.EventTypeWarning, helmv1.InsecureSkipTLSVerifyReason,
+		"TLS certificate verification is skipped for '%s'", obj.Spec.URL)
+	return nil
+}
+
+// mergeAdditionalIndexes fetches the indexes of the .spec.additionalURLs of
+// the object, and merges them into the index of the given
+// repository.ChartRepository according to the .spec.mergePolicy. The
+// credentials of the given client options are only used for additional URLs
+// with the same origin as the URL of the object, unless .spec.passCredentials
+// is set. On failure, it records v1beta2.FetchFailedCondition=True.
+func (r *HelmRepositoryReconciler) mergeAdditionalIndexes(ctx context.Context, obj *helmv1.HelmRepository,
+	clientOpts *getter.ClientOpts, chartRepo *repository.ChartRepository) error {
+	var others []*repository.ChartRepository
+	defer func() {
+		for _, o := range others {
+			if err := o.Clear(); err != nil {
+				ctrl.LoggerFrom(ctx).Error(err, "failed to clear additional Helm repository index")
+			}
+		}
+	}()
+
+	for _, u := range obj.Spec.AdditionalURLs {
+		normalizedURL, err := repository.NormalizeURL(u)
+		if err != nil {
+			e := serror.NewStalling(
+				fmt.Errorf("invalid additional Helm repository URL: %w", err),
+				sourcev1.URLInvalidReason,
+			)
+			conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+			return e
+		}
+
+		// The credentials are only passed on to an additional URL on the
+		// same host as the URL of the object, unless PassCredentials is set.
+		passCredentials := obj.Spec.PassCredentials || sameOrigin(normalizedURL, chartRepo.URL)
+		getterOpts, tlsConfig := clientOpts.GetterOpts, clientOpts.TlsConfig
+		if passCredentials {
+			if err := r.checkCredentialTransport(ctx, obj, clientOpts, normalizedURL); err != nil {
+				return err
+			}
+		} else {
+			getterOpts = []helmgetter.Option{
+				helmgetter.WithURL(normalizedURL),
+				helmgetter.WithTimeout(obj.Spec.Timeout.Duration),
+			}
+			tlsConfig = withoutClientCertificates(tlsConfig)
+		}
+
+		other, err := repository.NewChartRepository(normalizedURL, "", r.Getters, tlsConfig, getterOpts...)
+		if err != nil {
+			e := serror.NewStalling(
+				fmt.Errorf("failed to construct Helm client for additional URL '%s': %w", u, err),
+				meta.FailedReason,
+			)
+			conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+			return e
+		}
+		other.RedirectPolicy = chartRepo.RedirectPolicy
+		other.ResolverAddress = chartRepo.ResolverAddress
+		other.ProxyURL = chartRepo.ProxyURL
+		other.ForceHTTP1 = chartRepo.ForceHTTP1
+		if passCredentials {
+			other.AuthMethods = chartRepo.AuthMethods
+			other.RequestSigner = chartRepo.RequestSigner
+			other.SigV4Signer = chartRepo.SigV4Signer
+			other.SPNEGO = chartRepo.SPNEGO
+			other.Headers = chartRepo.Headers
+			other.Preflight = chartRepo.Preflight
+		}
+		other.AcceptHeader = chartRepo.AcceptHeader
+		other.DigestHeader = chartRepo.DigestHeader
+		others = append(others, other)
+
+		fetchCtx, cancel := context.WithTimeout(ctx, obj.Spec.Timeout.Duration)
+		err = other.CacheIndexContext(fetchCtx)
+		cancel()
+		if err != nil {
+			e := serror.NewGeneric(
+				fmt.Errorf("failed to fetch additional Helm repository index from '%s': %w", u, err),
+				meta.FailedReason,
+			)
+			conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+			return e
+		}
+		if err := r.loadIndex(ctx, other); err != nil {
+			e := serror.NewGeneric(
+				fmt.Errorf("failed to load additional Helm repository from index YAML of '%s': %w", u, err),
+				helmv1.IndexationFailedReason,
+			)
+			conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+			return e
+		}
+	}
+
+	policy := repository.MergePolicyFirstWins
+	if obj.Spec.MergePolicy != "" {
+		policy = repository.MergePolicy(obj.Spec.MergePolicy)
+	}
+	if err := chartRepo.MergeIndexes(policy, others...); err != nil {
+		e := serror.NewGeneric(
+			fmt.Errorf("failed to merge additional Helm repository indexes: %w", err),
+			helmv1.IndexationFailedReason,
+		)
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+		return e
+	}
+	return nil
+}
+
+// sameOrigin returns true if the given URLs have the same scheme and host,
+// including the port.
+func sameOrigin(a, b string) bool {
+	ua, err := url.Parse(a)
+	if err != nil {
+		return false
+	}
+	ub, err := url.Parse(b)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(ua.Scheme, ub.Scheme) && strings.EqualFold(ua.Host, ub.Host)
+}
+
+// withoutClientCertificates returns a clone of the given TLS configuration
+// without the client certificates, and without the server name to verify
+// the certificate of the server against, for connections to a host other
+// than the one it was constructed for. It returns nil if the given
+// configuration is nil.
+func withoutClientCertificates(tlsConfig *tls.Config) *tls.Config {
+	if tlsConfig == nil {
+		return nil
+	}
+	c := tlsConfig.Clone()
+	c.Certificates = nil
+	c.GetClientCertificate = nil
+	c.ServerName = ""
+	return c
+}
+
+// validateChartNames removes the charts with an empty name, or a name longer
+// than the maximum length of the .spec.chartNameValidation of the object, from
+// the index of the given repository.ChartRepository, and emits a warning event
+// listing the removed charts. If the validation is strict, or on failure, it
+// records v1beta2.FetchFailedCondition=True instead.
+func (r *HelmRepositoryReconciler) validateChartNames(ctx context.Context, obj *helmv1.HelmRepository, chartRepo *repository.ChartRepository) error {
+	invalid, err := chartRepo.InvalidChartNames(obj.Spec.ChartNameValidation.GetMaxLength())
+	if err == nil && len(invalid) == 0 {
+		return nil
+	}
+	if err == nil && obj.Spec.ChartNameValidation.Strict {
+		err = fmt.Errorf("index contains invalid chart names: %s", quoteChartNames(invalid))
+	}
+	if err == nil {
+		err = chartRepo.RemoveCharts(invalid...)
+	}
+	if err != nil {
+		e := serror.NewGeneric(
+			fmt.Errorf("failed to validate chart names of Helm repository index: %w", err),
+			helmv1.InvalidChartNamesReason,
+		)
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+		return e
+	}
+	r.eventLogf(ctx, obj, corev1.EventTypeWarning, helmv1.InvalidChartNamesReason,
+		"removed charts with invalid names from index: %s", quoteChartNames(invalid))
+	return nil
+}
+
+const (
+	// policyEvaluationTimeout bounds the evaluation of the policy of an
+	// object against its index.
+	policyEvaluationTimeout = 10 * time.Second
+	// maxPolicyDenials is the maximum number of denial messages of a policy
+	// included in the message of the FetchFailed Condition.
+	maxPolicyDenials = 10
+)
+
+// evaluatePolicy evaluates the Rego policy referenced in the .spec.policy of
+// the object against the index of the given repository.ChartRepository,
+// aborting the evaluation after policyEvaluationTimeout. When the policy
+// denies the index, it records v1beta2.FetchFailedCondition=True with the
+// v1beta2.PolicyViolationReason and the denial messages. When the policy can
+// not be read or evaluated, it records the same Condition with the failure.
+func (r *HelmRepositoryReconciler) evaluatePolicy(ctx context.Context, obj *helmv1.HelmRepository, chartRepo *repository.ChartRepository) error {
+	ref := obj.Spec.Policy
+	var cm corev1.ConfigMap
+	if err := r.Get(ctx, types.NamespacedName{Namespace: obj.GetNamespace(), Name: ref.ConfigMapRef.Name}, &cm); err != nil {
+		e := serror.NewGeneric(
+			fmt.Errorf("failed to get policy ConfigMap '%s/%s': %w", obj.GetNamespace(), ref.ConfigMapRef.Name, err),
+			meta.FailedReason,
+		)
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+		return e
+	}
+	policy, ok := cm.Data[ref.GetKey()]
+	if !ok {
+		e := serror.NewGeneric(
+			fmt.Errorf("policy ConfigMap '%s/%s' has no key '%s'", obj.GetNamespace(), ref.ConfigMapRef.Name, ref.GetKey()),
+			meta.FailedReason,
+		)
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+		return e
+	}
+
+	evalCtx, cancel := context.WithTimeout(ctx, policyEvaluationTimeout)
+	defer cancel()
+	denials, err := chartRepo.EvaluatePolicy(evalCtx, policy)
+	if err != nil {
+		e := serror.NewGeneric(
+			fmt.Errorf("failed to evaluate policy '%s/%s' against Helm repository index: %w", obj.GetNamespace(), ref.ConfigMapRef.Name, err),
+			meta.FailedReason,
+		)
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+		return e
+	}
+	if len(denials) == 0 {
+		return nil
+	}
+	msg := strings.Join(denials, "; ")
+	if len(denials) > maxPolicyDenials {
+		msg = fmt.Sprintf("%s; and %d more", strings.Join(denials[:maxPolicyDenials], "; "), len(denials)-maxPolicyDenials)
+	}
+	e := serror.NewGeneric(
+		fmt.Errorf("Helm repository index denied by policy '%s/%s': %s", obj.GetNamespace(), ref.ConfigMapRef.Name, msg),
+		helmv1.PolicyViolationReason,
+	)
+	conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+	return e
+}
+
+// quoteChartNames returns the quoted chart names, truncated to at most 64
+// characters each, as a comma-separated list.
+func quoteChartNames(names []string) string {
+	quoted := make([]string, 0, len(names))
+	for _, name := range names {
+		quoted = append(quoted, fmt.Sprintf("%.64q", name))
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// removeBlockedVersions removes the chart versions matching the
+// .spec.blockVersions of the object from the index of the given
+// repository.ChartRepository, and emits an event listing the removed
+// versions. On failure, it records v1beta2.FetchFailedCondition=True.
+func (r *HelmRepositoryReconciler) removeBlockedVersions(ctx context.Context, obj *helmv1.HelmRepository, chartRepo *repository.ChartRepository) error {
+	constraints := make(map[string]*semver.Constraints, len(obj.Spec.BlockVersions))
+	for name, v := range obj.Spec.BlockVersions {
+		c, err := semver.NewConstraint(v)
+		if err != nil {
+			e := serror.NewStalling(
+				fmt.Errorf("invalid blocked versions constraint '%s' for chart '%s': %w", v, name, err),
+				meta.FailedReason,
+			)
+			conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+			return e
+		}
+		constraints[name] = c
+	}
+
+	removed, err := chartRepo.RemoveVersions(constraints)
+	if err != nil {
+		e := serror.NewGeneric(
+			fmt.Errorf("failed to remove blocked versions from Helm repository index: %w", err),
+			helmv1.IndexationFailedReason,
+		)
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+		return e
+	}
+	if len(removed) > 0 {
+		names := make([]string, 0, len(removed))
+		for name := range removed {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		var blocked []string
+		for _, name := range names {
+			blocked = append(blocked, fmt.Sprintf("%s (%s)", name, strings.Join(removed[name], ", ")))
+		}
+		r.eventLogf(ctx, obj, corev1.EventTypeNormal, helmv1.BlockedVersionsReason,
+			"removed blocked chart versions from index: %s", strings.Join(blocked, ", "))
+	}
+	return nil
+}
+
+// truncateVersions retains at most .spec.maxVersionsPerChart versions per
+// chart in the index of the given repository.ChartRepository, and logs the
+// number of removed versions. On failure, it records
+// v1beta2.FetchFailedCondition=True.
+func (r *HelmRepositoryReconciler) truncateVersions(ctx context.Context, obj *helmv1.HelmRepository, chartRepo *repository.ChartRepository) error {
+	removed, err := chartRepo.TruncateVersions(obj.Spec.MaxVersionsPerChart)
+	if err != nil {
+		e := serror.NewGeneric(
+			fmt.Errorf("failed to truncate chart versions of Helm repository index: %w", err),
+			helmv1.IndexationFailedReason,
+		)
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+		return e
+	}
+	if len(removed) > 0 {
+		var total int
+		for _, n := range removed {
+			total += n
+		}
+		ctrl.LoggerFrom(ctx).V(logger.DebugLevel).Info("truncated chart versions in index",
+			"removed", total, "charts", len(removed), "maxVersionsPerChart", obj.Spec.MaxVersionsPerChart)
+	}
+	return nil
+}
+
+// isSuspiciousIndexChange returns true if the number of charts in the index
+// dropped below the ratio configured with the MinChartCountRatioAnnotation
+// of the number of charts in the index of the current Artifact, in which case
+// the SuspiciousIndexChange Condition is marked on the object. Otherwise, the
+// Condition is removed.
+func (r *HelmRepositoryReconciler) isSuspiciousIndexChange(ctx context.Context, obj *helmv1.HelmRepository, chartRepo *repository.ChartRepository) (bool, error) {
+	v, ok := obj.GetAnnotations()[helmv1.MinChartCountRatioAnnotation]
+	if !ok || obj.GetArtifact() == nil || obj.Status.ChartCount == nil || *obj.Status.ChartCount == 0 {
+		conditions.Delete(obj, helmv1.SuspiciousIndexChangeCondition)
+		return false, nil
+	}
+
+	ratio, err := strconv.ParseFloat(v, 64)
+	if err != nil || ratio <= 0 || ratio > 1 {
+		e := serror.NewGeneric(
+			fmt.Errorf("invalid '%s' annotation value '%s': must be a number greater than 0 and at most 1",
+				helmv1.MinChartCountRatioAnnotation, v),
+			helmv1.IndexationFailedReason,
+		)
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+		return false, e
+	}
+
+	curCount := *obj.Status.ChartCount
+	newCount, _ := chartRepo.EntriesCount()
+	if float64(newCount) >= ratio*float64(curCount) {
+		conditions.Delete(obj, helmv1.SuspiciousIndexChangeCondition)
+		return false, nil
+	}
+
+	msg := fmt.Sprintf("index with %d charts not applied, as the current artifact has %d charts (minimum ratio %s)",
+		newCount, curCount, v)
+	conditions.MarkTrue(obj, helmv1.SuspiciousIndexChangeCondition, helmv1.ChartCountDecreasedReason, msg)
+	r.eventLogf(ctx, obj, corev1.EventTypeWarning, helmv1.ChartCountDecreasedReason, msg)
+	return true, nil
+}
+
+// canaryFetch fetches the index of the given repository.ChartRepository a
+// second time after the delay of the .spec.canaryFetch of the object, and
+// returns true if the digests of both fetches agree, in which case
+// v1beta2.UpstreamInconsistentCondition is removed. On a mismatch, it records
+// v1beta2.UpstreamInconsistentCondition=True and emits a warning event,
+// after which the current Artifact is kept. Without a current
+// Artifact, or if the second fetch fails, it records
+// v1beta2.FetchFailedCondition=True and returns a generic error instead.
+func (r *HelmRepositoryReconciler) canaryFetch(ctx context.Context, obj *helmv1.HelmRepository,
+	clientOpts *getter.ClientOpts, chartRepo *repository.ChartRepository) (bool, error) {
+	select {
+	case <-ctx.Done():
+		return false, serror.NewGeneric(ctx.Err(), meta.FailedReason)
+	case <-time.After(obj.Spec.CanaryFetch.GetDelay()):
+	}
+
+	canary, err := repository.NewChartRepository(chartRepo.URL, "", r.Getters, clientOpts.TlsConfig, clientOpts.GetterOpts...)
+	if err != nil {
+		e := serror.NewGeneric(
+			fmt.Errorf("failed to construct Helm client for canary fetch: %w", err),
+			meta.FailedReason,
+		)
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+		return false, e
+	}
+	defer func() {
+		if err := canary.Clear(); err != nil {
+			ctrl.LoggerFrom(ctx).Error(err, "failed to clear canary Helm repository index")
+		}
+	}()
+	canary.RedirectPolicy = chartRepo.RedirectPolicy
+	canary.ResolverAddress = chartRepo.ResolverAddress
+	canary.ProxyURL = chartRepo.ProxyURL
+	canary.ForceHTTP1 = chartRepo.ForceHTTP1
+	canary.AuthMethods = chartRepo.AuthMethods
+	canary.RequestSigner = chartRepo.RequestSigner
+	canary.SigV4Signer = chartRepo.SigV4Signer
+	canary.SPNEGO = chartRepo.SPNEGO
+	canary.AcceptHeader = chartRepo.AcceptHeader
+	canary.DigestHeader = chartRepo.DigestHeader
+	canary.Headers = chartRepo.Headers
+	canary.Preflight = chartRepo.Preflight
+	canary.ShardManifestURL = chartRepo.ShardManifestURL
+
+	fetchCtx, cancel := context.WithTimeout(ctx, obj.Spec.Timeout.Duration)
+	err = canary.CacheIndexContext(fetchCtx)
+	cancel()
+	if err != nil {
+		e := serror.NewGeneric(
+			fmt.Errorf("failed to fetch Helm repository index for canary fetch: %w", err),
+			meta.FailedReason,
+		)
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+		return false, e
+	}
+
+	first, second := chartRepo.Digest(intdigest.Canonical), canary.Digest(intdigest.Canonical)
+	if first == second {
+		conditions.Delete(obj, helmv1.UpstreamInconsistentCondition)
+		return true, nil
+	}
+
+	msg := fmt.Sprintf("index not applied, as two fetches %s apart resulted in different digests '%s' and '%s'",
+		obj.Spec.CanaryFetch.GetDelay(), first, second)
+	if obj.GetArtifact() == nil {
+		e := serror.NewGeneric(errors.New(msg), helmv1.UpstreamInconsistentReason)
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+		return false, e
+	}
+	conditions.MarkTrue(obj, helmv1.UpstreamInconsistentCondition, helmv1.UpstreamInconsistentReason, msg)
+	r.eventLogf(ctx, obj, corev1.EventTypeWarning, helmv1.UpstreamInconsistentReason, msg)
+	return false, nil
+}
+
+// logIndexDelta emits an event and a structured log entry with the chart
+// versions added and removed in the index of the given
+// repository.ChartRepository, compared to the index of the current Artifact.
+// The previous index is taken from the cache if present, or loaded from
+// storage otherwise. The number of listed versions is capped at the
+// IndexDeltaLimit.
+func (r *HelmRepositoryReconciler) logIndexDelta(ctx context.Context, obj *helmv1.HelmRepository, chartRepo *repository.ChartRepository) {
+	curArtifact := obj.GetArtifact()
+	var previous *repo.IndexFile
+	if r.Cache != nil {
+		if cached, ok := r.Cache.Get(curArtifact.Path); ok {
+			previous, _ = cached.(*repo.IndexFile)
+		}
+	}
+	if previous == nil {
+		var err error
+		if previous, err = repository.IndexFromFile(r.Storage.LocalPath(*curArtifact)); err != nil {
+			ctrl.LoggerFrom(ctx).V(logger.DebugLevel).Info("failed to load index of current artifact to compute delta",
+				"error", err.Error())
+			return
+		}
+	}
+
+	chartRepo.RLock()
+	added, removed := repository.IndexDelta(previous, chartRepo.Index)
+	chartRepo.RUnlock()
+	if len(added) == 0 && len(removed) == 0 {
+		return
+	}
+
+	ctrl.LoggerFrom(ctx).Info("index changed",
+		"added", capList(added, r.IndexDeltaLimit), "addedCount", len(added),
+		"removed", capList(removed, r.IndexDeltaLimit), "removedCount", len(removed))
+	r.Eventf(obj, eventv1.EventTypeTrace, helmv1.IndexChangedReason, "index changed: added %s; removed %s",
+		formatDelta(added, r.IndexDeltaLimit), formatDelta(removed, r.IndexDeltaLimit))
+}
+
+// capList returns at most max elements of the given list.
+func capList(l []string, max int) []string {
+	if len(l) > max {
+		return l[:max]
+	}
+	return l
+}
+
+// formatDelta formats the given list of chart versions for an event message,
+// listing at most max elements.
+func formatDelta(l []string, max int) string {
+	if len(l) == 0 {
+		return "none"
+	}
+	s := strings.Join(capList(l, max), ", ")
+	if len(l) > max {
+		s += fmt.Sprintf(" (and %d more)", len(l)-max)
+	}
+	return s
+}
+
+// spotCheckDigests verifies the digests of a random sample of the chart
+// versions in the index of the given repository.ChartRepository, as
+// configured by the .spec.digestSpotCheck of the object. A sampled chart
+// version which can not be downloaded counts as a mismatch. On failure, or if
+// the number of mismatches exceeds the tolerated number, it records
+// v1beta2.FetchFailedCondition=True.
+func (r *HelmRepositoryReconciler) spotCheckDigests(obj *helmv1.HelmRepository, chartRepo *repository.ChartRepository) error {
+	check := obj.Spec.DigestSpotCheck
+	mismatches, sampled, err := chartRepo.VerifyDigests(check.SampleSize)
+	if err != nil {
+		e := serror.NewGeneric(
+			fmt.Errorf("failed to verify chart digests: %w", err),
+			meta.FailedReason,
+		)
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+		return e
+	}
+	if len(mismatches) > check.MaxMismatches {
+		e := serror.NewGeneric(
+			fmt.Errorf("%d of %d sampled chart versions could not be verified against the digest in the index: %s",
+				len(mismatches), sampled, strings.Join(mismatches, ", ")),
+			helmv1.IndexIntegrityFailedReason,
+		)
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+		return e
+	}
+	return nil
+}
+
+// checkChartURLs checks the reachability of the URLs of a random sample of
+// the chart versions in the index of the given repository.ChartRepository, as
+// configured by the .spec.chartURLCheck of the object. If the number of
+// unreachable URLs exceeds the tolerated number, it records
+// v1beta2.ChartsUnreachableCondition=True and emits a warning event, without
+// failing the reconciliation. Otherwise, the Condition is removed.
+func (r *HelmRepositoryReconciler) checkChartURLs(ctx context.Context, obj *helmv1.HelmRepository, chartRepo *repository.ChartRepository) error {
+	check := obj.Spec.ChartURLCheck
+	checkCtx, cancel := context.WithTimeout(ctx, obj.Spec.Timeout.Duration)
+	defer cancel()
+	unreachable, sampled, err := chartRepo.CheckChartURLs(checkCtx, check.SampleSize)
+	if err != nil {
+		e := serror.NewGeneric(
+			fmt.Errorf("failed to check chart URLs: %w", err),
+			meta.FailedReason,
+		)
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
+		return e
+	}
+	if len(unreachable) <= check.MaxUnreachable {
+		conditions.Delete(obj, helmv1.ChartsUnreachableCondition)
+		return nil
+	}
+	msg := fmt.Sprintf("%d of %d sampled chart versions have an unreachable URL: %s",
+		len(unreachable), sampled, strings.Join(unreachable, ", "))
+	conditions.MarkTrue(obj, helmv1.ChartsUnreachableCondition, helmv1.ChartURLsUnreachableReason, msg)
+	r.eventLogf(ctx, obj, corev1.EventTypeWarning, helmv1.ChartURLsUnreachableReason, msg)
+	return nil
+}
+
+// loadIndex loads the cached index of the given repository.ChartRepository.
+// When IndexParseTimeout is set, the load is aborted once the timeout is
+// exceeded, with an error wrapping repository.ErrParseTimeout.
+func (r *HelmRepositoryReconciler) loadIndex(ctx context.Context, chartRepo *repository.ChartRepository) (err error) {
+	ctx, span := tracer.Start(ctx, "load index")
+	defer func() { endSpan(span, err) }()
+
+	if r.IndexParseTimeout <= 0 {
+		return chartRepo.LoadFromPath()
+	}
+
+	parseCtx, cancel := context.WithTimeout(ctx, r.IndexParseTimeout)
+	defer cancel()
+
+	err = chartRepo.LoadFromPathContext(parseCtx)
+	if errors.Is(err, repository.ErrParseTimeout) {
+		return fmt.Errorf("%w: index could not be parsed within %s", repository.ErrParseTimeout, r.IndexParseTimeout.String())
+	}
+	return err
+}
+
+// withinMinRefreshWindow returns true if the MinRefreshInterval is configured,
+// the object is Ready with an Artifact of which the index was last fetched
+// successfully less than the interval ago, and no new generation, reconcile
+// request, triggered reconcile or pending startup reconciliation has been
+// observed since. Objects which have never reported a
+// .status.lastSuccessfulFetchTime count from the .status.lastFetchTime, or
+// else from the last update of their Artifact.
+func (r *HelmRepositoryReconciler) withinMinRefreshWindow(obj *helmv1.HelmRepository) bool {
+	if r.MinRefreshInterval <= 0 || obj.GetArtifact() == nil {
+		return false
+	}
+	key := client.ObjectKeyFromObject(obj)
+	if r.startup.pending(key) || r.trigger.isPending("helmrepositories", key) || artifactExpired(obj) {
+		return false
+	}
+	if obj.Generation != obj.Status.ObservedGeneration {
+		return false
+	}
+	if v, ok := meta.ReconcileAnnotationValue(obj.GetAnnotations()); ok && v != obj.Status.GetLastHandledReconcileRequest() {
+		return false
+	}
+	if !conditions.IsTrue(obj, meta.ReadyCondition) {
+		return false
+	}
+	since := obj.GetArtifact().LastUpdateTime
+	switch {
+	case obj.Status.LastSuccessfulFetchTime != nil:
+		since = *obj.Status.LastSuccessfulFetchTime
+	case obj.Status.LastFetchTime != nil:
+		since = *obj.Status.LastFetchTime
+	}
+	return time.Since(since.Time) < r.MinRefreshInterval
+}
+
+// artifactExpired returns true if the object has a .spec.maxArtifactAge, and
+// the index was last validated longer ago than that age. Objects which have
+// never reported a .status.lastFetchTime count from the last update of their
+// Artifact.
+func artifactExpired(obj *helmv1.HelmRepository) bool {
+	if obj.Spec.MaxArtifactAge == nil || obj.GetArtifact() == nil {
+		return false
+	}
+	since := obj.GetArtifact().LastUpdateTime
+	if obj.Status.LastFetchTime != nil {
+		since = *obj.Status.LastFetchTime
+	}
+	return time.Since(since.Time) > obj.Spec.MaxArtifactAge.Duration
+}
+
+// reconcileArtifact archives a new Artifact to the Storage, if the current
+// (Status) data on the object does not match the given.
+//
+// The inspection of the given data to the object is differed, ensuring any
+// stale observations like v1beta2.ArtifactOutdatedCondition are removed.
+// If the given Artifact does not differ from the object's current, it returns
+// early.
+// On a successful archive, the Artifact in the Status of the object is set,
+// and the symlink in the Storage is updated to its path.
+func (r *HelmRepositoryReconciler) reconcileArtifact(ctx context.Context, sp *patch.SerialPatcher, obj *helmv1.HelmRepository, artifact *sourcev1.Artifact, chartRepo *repository.ChartRepository) (sreconcile.Result, error) {
+	ctx, span := tracer.Start(ctx, "reconcileArtifact")
+	defer span.End()
+
+	// Set the ArtifactInStorageCondition if there's no drift.
+	defer func() {
+		if sameRevision(obj, obj.GetArtifact(), *artifact) {
+			conditions.Delete(obj, sourcev1.ArtifactOutdatedCondition)
+			conditions.MarkTrue(obj, sourcev1.ArtifactInStorageCondition, meta.SucceededReason,
+				"stored artifact: revision '%s'", artifact.Revision)
+		}
+		if err := chartRepo.Clear(); err != nil {
+			ctrl.LoggerFrom(ctx).Error(err, "failed to remove temporary cached index file")
+		}
+	}()
+
+	// Embed the selected labels and annotations of the object.
+	artifact.Metadata = artifactMetadataFromObject(obj, r.ArtifactMetadataPrefixes)
+
+	// With a weak revision comparison, a matching revision suffices, as the
+	// index may differ in its volatile fields.
+	weak := obj.Spec.RevisionComparison == helmv1.HelmRepositoryRevisionComparisonWeak
+	if sameRevision(obj, obj.GetArtifact(), *artifact) && (weak || obj.GetArtifact().HasDigest(artifact.Digest)) &&
+		!indexFormatChanged(*obj.GetArtifact(), obj.GetIndexFormat()) {
+		// Keep the metadata in sync with the object.
+		obj.Status.Artifact.Metadata = artifact.Metadata
+
+		// Extend TTL of the Index in the cache (if present), or evict it if
+		// caching has been disabled for the object.
+		if r.Cache != nil {
+			if obj.GetCacheIndex() {
+				r.Cache.SetExpiration(artifact.Path, r.TTL)
+			} else {
+				r.Cache.Delete(artifact.Path)
+			}
+		}
+
+		// Sign the Artifact if it has not been signed yet.
+		if err := r.signArtifact(obj, *obj.GetArtifact(), false); err != nil {
+			return sreconcile.ResultEmpty, err
+		}
+
+		r.eventLogf(ctx, obj, eventv1.EventTypeTrace, sourcev1.ArtifactUpToDateReason, "artifact up-to-date with remote revision: '%s'", artifact.Revision)
+		return sreconcile.ResultSuccess, nil
+	}
+
+	// Create artifact dir
+	if err := r.Storage.MkdirAll(*artifact); err != nil {
+		reason := sourcev1.DirCreationFailedReason
+		if IsDiskFull(err) {
+			reason = sourcev1.DiskFullReason
+		}
+		e := serror.NewGeneric(
 			fmt.Errorf("failed to create artifact directory: %w", err),
-			sourcev1.DirCreationFailedReason,
+			reason,
 		)
 		conditions.MarkTrue(obj, sourcev1.StorageOperationFailedCondition, e.Reason, e.Err.Error())
 		return sreconcile.ResultEmpty, e
@@ -551,15 +2279,26 @@ func (r *HelmRepositoryReconciler) reconcileArtifact(ctx context.Context, sp *pa
 	// Acquire lock.
 	unlock, err := r.Storage.Lock(*artifact)
 	if err != nil {
-		return sreconcile.ResultEmpty, serror.NewGeneric(
-			fmt.Errorf("failed to acquire lock for artifact: %w", err),
-			meta.FailedReason,
-		)
+		err = fmt.Errorf("failed to acquire lock for artifact: %w", err)
+		if r.LockBackoff <= 0 {
+			return sreconcile.ResultEmpty, serror.NewGeneric(err, meta.FailedReason)
+		}
+		// Back off to reduce contention, rather than retrying right away.
+		backoff, attempts := r.lockFailures.next(client.ObjectKeyFromObject(obj), r.LockBackoff, r.LockBackoffMax)
+		r.eventLogf(ctx, obj, eventv1.EventTypeTrace, sourcev1.AcquireLockFailedReason,
+			"storage lock contention after %d attempt(s), retrying in %s (backoff %s, max %s)",
+			attempts, backoff, r.LockBackoff, r.LockBackoffMax)
+		e := serror.NewWaiting(err, sourcev1.AcquireLockFailedReason)
+		e.RequeueAfter = backoff
+		return sreconcile.ResultEmpty, e
 	}
+	r.lockFailures.reset(client.ObjectKeyFromObject(obj))
 	defer unlock()
 
 	// Save artifact to storage in JSON format.
-	b, err := chartRepo.ToJSON()
+	_, archiveSpan := tracer.Start(ctx, "archive index")
+	defer archiveSpan.End()
+	b, err := r.indexJSON(obj, chartRepo)
 	if err != nil {
 		e := serror.NewGeneric(
 			fmt.Errorf("unable to get JSON index from chart repo: %w", err),
@@ -568,20 +2307,38 @@ func (r *HelmRepositoryReconciler) reconcileArtifact(ctx context.Context, sp *pa
 		conditions.MarkTrue(obj, sourcev1.StorageOperationFailedCondition, e.Reason, e.Err.Error())
 		return sreconcile.ResultEmpty, e
 	}
+	writeStart := time.Now()
 	if err = r.Storage.Copy(artifact, bytes.NewBuffer(b)); err != nil {
+		reason := sourcev1.ArchiveOperationFailedReason
+		if IsDiskFull(err) {
+			reason = sourcev1.DiskFullReason
+		}
 		e := serror.NewGeneric(
 			fmt.Errorf("unable to save artifact to storage: %w", err),
-			sourcev1.ArchiveOperationFailedReason,
+			reason,
 		)
 		conditions.MarkTrue(obj, sourcev1.StorageOperationFailedCondition, e.Reason, e.Err.Error())
 		return sreconcile.ResultEmpty, e
 	}
+	recordArtifactWrite(ctx, r.Metrics, r.SourceMetrics, obj, *artifact, writeStart)
+
+	// Sign the artifact, before it is recorded on the object.
+	if err := r.signArtifact(obj, *artifact, true); err != nil {
+		return sreconcile.ResultEmpty, err
+	}
+
+	// Mirror the artifact to the backup storage on a "best effort" basis
+	if err := r.Storage.BackupArtifact(*artifact); err != nil {
+		r.eventLogf(ctx, obj, corev1.EventTypeWarning, sourcev1.BackupOperationFailedReason,
+			"failed to mirror artifact to backup storage: %s", err)
+	}
 
 	// Record it on the object.
 	obj.Status.Artifact = artifact.DeepCopy()
 
-	// Cache the index if it was successfully retrieved.
-	if r.Cache != nil && chartRepo.Index != nil {
+	// Cache the index if it was successfully retrieved, unless caching has
+	// been disabled for the object.
+	if r.Cache != nil && chartRepo.Index != nil && obj.GetCacheIndex() {
 		// The cache keys have to be safe in multi-tenancy environments, as
 		// otherwise it could be used as a vector to bypass the repository's
 		// authentication. Using the Artifact.Path is safe as the path is in
@@ -592,7 +2349,7 @@ func (r *HelmRepositoryReconciler) reconcileArtifact(ctx context.Context, sp *pa
 	}
 
 	// Update index symlink.
-	indexURL, err := r.Storage.Symlink(*artifact, "index.yaml")
+	indexURL, err := r.Storage.WithHostname(obj.Spec.ArtifactHost).Symlink(*artifact, obj.GetSymlinkName())
 	if err != nil {
 		r.eventLogf(ctx, obj, eventv1.EventTypeTrace, sourcev1.SymlinkUpdateFailedReason,
 			"failed to update status URL symlink: %s", err)
@@ -604,6 +2361,67 @@ func (r *HelmRepositoryReconciler) reconcileArtifact(ctx context.Context, sp *pa
 	return sreconcile.ResultSuccess, nil
 }
 
+// indexProvenance is the provenance of the index, recorded in the
+// v1beta2.HelmRepositoryProvenanceAnnotation of the index Artifact.
+type indexProvenance struct {
+	// URL is the URL the index was fetched from.
+	URL string `json:"url"`
+	// FetchTime is the time the index was fetched.
+	FetchTime metav1.Time `json:"fetchTime"`
+	// Controller is the name of the controller which fetched the index.
+	Controller string `json:"controller"`
+}
+
+// indexJSON returns the index of the given repository.ChartRepository in
+// JSON format. If the object enables .spec.embedProvenance, the provenance of
+// the index is recorded in its annotations.
+func (r *HelmRepositoryReconciler) indexJSON(obj *helmv1.HelmRepository, chartRepo *repository.ChartRepository) ([]byte, error) {
+	if !obj.Spec.EmbedProvenance {
+		return chartRepo.ToJSON()
+	}
+	fetchTime := metav1.Now()
+	if obj.Status.LastFetchTime != nil {
+		fetchTime = *obj.Status.LastFetchTime
+	}
+	p, err := json.Marshal(indexProvenance{
+		URL:        chartRepo.URL,
+		FetchTime:  fetchTime,
+		Controller: r.ControllerName,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return chartRepo.ToJSONWithAnnotations(map[string]string{
+		helmv1.HelmRepositoryProvenanceAnnotation: string(p),
+	})
+}
+
+// signArtifact writes the detached signature of the given Artifact with the
+// ArtifactSigner, and records its URL in the .status.signatureURL of the
+// object. Unless force is true, an existing signature is kept. Without an
+// ArtifactSigner, the .status.signatureURL is cleared. On failure, it records
+// v1beta2.StorageOperationFailedCondition=True.
+func (r *HelmRepositoryReconciler) signArtifact(obj *helmv1.HelmRepository, artifact sourcev1.Artifact, force bool) error {
+	if r.ArtifactSigner == nil {
+		obj.Status.SignatureURL = ""
+		return nil
+	}
+	if _, err := os.Stat(r.Storage.LocalPath(artifact) + SignatureExtension); err == nil && !force && obj.Status.SignatureURL != "" {
+		return nil
+	}
+	sigURL, err := r.Storage.SignArtifact(artifact, r.ArtifactSigner)
+	if err != nil {
+		e := serror.NewGeneric(
+			fmt.Errorf("unable to sign artifact: %w", err),
+			helmv1.SignatureOperationFailedReason,
+		)
+		conditions.MarkTrue(obj, sourcev1.StorageOperationFailedCondition, e.Reason, e.Err.Error())
+		return e
+	}
+	obj.Status.SignatureURL = sigURL
+	return nil
+}
+
 // reconcileDelete handles the deletion of the object.
 // It first garbage collects all Artifacts for the object from the Storage.
 // Removing the finalizer from the object if successful.
@@ -615,7 +2433,7 @@ func (r *HelmRepositoryReconciler) reconcileDelete(ctx context.Context, obj *hel
 	}
 
 	// Remove our finalizer from the list if we are deleting the object
-	if !obj.DeletionTimestamp.IsZero() {
+	if !r.DisableFinalizers && !obj.DeletionTimestamp.IsZero() {
 		controllerutil.RemoveFinalizer(obj, sourcev1.SourceFinalizer)
 	}
 
@@ -625,6 +2443,9 @@ func (r *HelmRepositoryReconciler) reconcileDelete(ctx context.Context, obj *hel
 		r.DeleteCacheEvent(cache.CacheEventTypeMiss, obj.Name, obj.Namespace)
 	}
 
+	// Forget any storage lock contention.
+	r.lockFailures.reset(client.ObjectKeyFromObject(obj))
+
 	// Stop reconciliation as the object is being deleted
 	return sreconcile.ResultEmpty, nil
 }
@@ -649,6 +2470,12 @@ func (r *HelmRepositoryReconciler) garbageCollect(ctx context.Context, obj *helm
 		// Clean status sub-resource
 		obj.Status.Artifact = nil
 		obj.Status.URL = ""
+		obj.Status.ObservedURL = ""
+		obj.Status.ChartCount = nil
+		obj.Status.ChartVersionCount = nil
+		obj.Status.ChartDependencies = nil
+		obj.Status.SignatureURL = ""
+		obj.Status.LastFetchStatusCode = 0
 		// Remove any stale conditions.
 		obj.Status.Conditions = nil
 		return nil
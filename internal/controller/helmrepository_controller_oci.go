@@ -33,6 +33,7 @@ import (
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
 	kuberecorder "k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -51,6 +52,7 @@ import (
 	helmv1 "github.com/fluxcd/source-controller/api/v1beta2"
 	"github.com/fluxcd/source-controller/internal/helm/getter"
 	"github.com/fluxcd/source-controller/internal/helm/repository"
+	smetrics "github.com/fluxcd/source-controller/internal/metrics"
 	"github.com/fluxcd/source-controller/internal/object"
 	intpredicates "github.com/fluxcd/source-controller/internal/predicates"
 )
@@ -76,10 +78,15 @@ type HelmRepositoryOCIReconciler struct {
 	client.Client
 	kuberecorder.EventRecorder
 	helper.Metrics
+	SourceMetrics *smetrics.Recorder
 
 	ControllerName          string
 	RegistryClientGenerator RegistryClientGeneratorFunc
 
+	// DisableFinalizers skips adding and removing the source finalizer, for
+	// the finalizers of objects to be managed externally.
+	DisableFinalizers bool
+
 	patchOptions []patch.Option
 
 	// unmanagedConditions are the conditions that are not managed by this
@@ -101,23 +108,27 @@ func (r *HelmRepositoryOCIReconciler) SetupWithManager(mgr ctrl.Manager) error {
 func (r *HelmRepositoryOCIReconciler) SetupWithManagerAndOptions(mgr ctrl.Manager, opts HelmRepositoryReconcilerOptions) error {
 	r.unmanagedConditions = conditionsDiff(helmRepositoryReadyCondition.Owned, helmRepositoryOCIOwnedConditions)
 	r.patchOptions = getPatchOptions(helmRepositoryOCIOwnedConditions, r.ControllerName)
+	queue := r.SourceMetrics.QueueTracker(helmv1.HelmRepositoryKind)
 
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&helmv1.HelmRepository{}).
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&helmv1.HelmRepository{}, builder.WithPredicates(queue.Predicate())).
 		WithEventFilter(
 			predicate.And(
 				intpredicates.HelmRepositoryTypePredicate{RepositoryType: helmv1.HelmRepositoryTypeOCI},
 				predicate.Or(predicate.GenerationChangedPredicate{}, predicates.ReconcileRequestedPredicate{}),
 			),
-		).
+		)
+
+	return watchReconcileTrigger(b, opts.ReconcileTrigger, "helmrepositories", queue.Predicate()).
 		WithOptions(controller.Options{
-			RateLimiter: opts.RateLimiter,
+			RateLimiter: queue.RateLimiter(opts.RateLimiter),
 		}).
-		Complete(r)
+		Complete(queue.Reconciler(r))
 }
 
 func (r *HelmRepositoryOCIReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, retErr error) {
 	start := time.Now()
+	defer r.SourceMetrics.RecordInFlight(helmv1.HelmRepositoryKind)()
 	log := ctrl.LoggerFrom(ctx)
 
 	// Fetch the HelmRepository
@@ -146,6 +157,7 @@ func (r *HelmRepositoryOCIReconciler) Reconcile(ctx context.Context, req ctrl.Re
 		if v, ok := meta.ReconcileAnnotationValue(obj.GetAnnotations()); ok {
 			object.SetStatusLastHandledReconcileAt(obj, v)
 		}
+		obj.Status.LastReconcileDuration = &metav1.Duration{Duration: time.Since(start).Round(time.Millisecond)}
 
 		patchOpts := []patch.Option{}
 		patchOpts = append(patchOpts, r.patchOptions...)
@@ -168,6 +180,7 @@ func (r *HelmRepositoryOCIReconciler) Reconcile(ctx context.Context, req ctrl.Re
 		r.Metrics.RecordSuspend(ctx, obj, obj.Spec.Suspend)
 		r.Metrics.RecordReadiness(ctx, obj)
 		r.Metrics.RecordDuration(ctx, obj, start)
+		recordLastSuccess(ctx, r.Metrics, r.SourceMetrics, obj)
 	}()
 
 	// Examine if the object is under deletion.
@@ -179,7 +192,7 @@ func (r *HelmRepositoryOCIReconciler) Reconcile(ctx context.Context, req ctrl.Re
 	// between init and delete.
 	// Note: Finalizers in general can only be added when the deletionTimestamp
 	// is not set.
-	if !controllerutil.ContainsFinalizer(obj, sourcev1.SourceFinalizer) {
+	if !r.DisableFinalizers && !controllerutil.ContainsFinalizer(obj, sourcev1.SourceFinalizer) {
 		controllerutil.AddFinalizer(obj, sourcev1.SourceFinalizer)
 		return ctrl.Result{Requeue: true}, nil
 	}
@@ -380,7 +393,9 @@ func (r *HelmRepositoryOCIReconciler) reconcile(ctx context.Context, sp *patch.S
 
 func (r *HelmRepositoryOCIReconciler) reconcileDelete(ctx context.Context, obj *helmv1.HelmRepository) (ctrl.Result, error) {
 	// Remove our finalizer from the list
-	controllerutil.RemoveFinalizer(obj, sourcev1.SourceFinalizer)
+	if !r.DisableFinalizers {
+		controllerutil.RemoveFinalizer(obj, sourcev1.SourceFinalizer)
+	}
 
 	// Stop reconciliation as the object is being deleted
 	return ctrl.Result{}, nil
@@ -22,21 +22,27 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/minio/minio-go/v7/pkg/credentials"
 	. "github.com/onsi/gomega"
 	"github.com/opencontainers/go-digest"
+	"helm.sh/helm/v3/pkg/chart"
 	helmgetter "helm.sh/helm/v3/pkg/getter"
 	"helm.sh/helm/v3/pkg/repo"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
 	kstatus "sigs.k8s.io/cli-utils/pkg/kstatus/status"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -52,11 +58,13 @@ import (
 	helmv1 "github.com/fluxcd/source-controller/api/v1beta2"
 	"github.com/fluxcd/source-controller/internal/cache"
 	intdigest "github.com/fluxcd/source-controller/internal/digest"
+	serror "github.com/fluxcd/source-controller/internal/error"
 	"github.com/fluxcd/source-controller/internal/helm/getter"
 	"github.com/fluxcd/source-controller/internal/helm/repository"
 	sreconcile "github.com/fluxcd/source-controller/internal/reconcile"
 	"github.com/fluxcd/source-controller/internal/reconcile/summarize"
 	stls "github.com/fluxcd/source-controller/internal/tls"
+	"github.com/fluxcd/source-controller/internal/transport"
 )
 
 func TestHelmRepositoryReconciler_deleteBeforeFinalizer(t *testing.T) {
@@ -344,6 +352,40 @@ func TestHelmRepositoryReconciler_reconcileStorage(t *testing.T) {
 				*conditions.TrueCondition(meta.ReadyCondition, "foo", "bar"),
 			},
 		},
+		{
+			name: "updates hostname to artifact host override",
+			beforeFunc: func(obj *helmv1.HelmRepository, storage *Storage) error {
+				obj.Spec.ArtifactHost = "http://cluster-b.example.com"
+				obj.Status.Artifact = &sourcev1.Artifact{
+					Path:     "/reconcile-storage/artifact-host.txt",
+					Revision: "f",
+					Digest:   "sha256:3b9c358f36f0a31b6ad3e14f309c7cf198ac9246e8316f9ce543d5b19ac02b80",
+					URL:      testStorage.Hostname + "/reconcile-storage/artifact-host.txt",
+				}
+				if err := storage.MkdirAll(*obj.Status.Artifact); err != nil {
+					return err
+				}
+				if err := storage.AtomicWriteFile(obj.Status.Artifact, strings.NewReader("file"), 0o640); err != nil {
+					return err
+				}
+				conditions.MarkTrue(obj, meta.ReadyCondition, "foo", "bar")
+				return nil
+			},
+			want: sreconcile.ResultSuccess,
+			assertPaths: []string{
+				"/reconcile-storage/artifact-host.txt",
+			},
+			assertArtifact: &sourcev1.Artifact{
+				Path:     "/reconcile-storage/artifact-host.txt",
+				Revision: "f",
+				Digest:   "sha256:3b9c358f36f0a31b6ad3e14f309c7cf198ac9246e8316f9ce543d5b19ac02b80",
+				URL:      "http://cluster-b.example.com/reconcile-storage/artifact-host.txt",
+				Size:     int64p(int64(len("file"))),
+			},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(meta.ReadyCondition, "foo", "bar"),
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -414,16 +456,17 @@ func TestHelmRepositoryReconciler_reconcileSource(t *testing.T) {
 	}
 
 	tests := []struct {
-		name             string
-		protocol         string
-		server           options
-		url              string
-		secret           *corev1.Secret
-		beforeFunc       func(t *WithT, obj *helmv1.HelmRepository, rev digest.Digest)
-		afterFunc        func(t *WithT, obj *helmv1.HelmRepository, artifact sourcev1.Artifact, chartRepo *repository.ChartRepository)
-		want             sreconcile.Result
-		wantErr          bool
-		assertConditions []metav1.Condition
+		name                string
+		protocol            string
+		server              options
+		url                 string
+		secret              *corev1.Secret
+		denyHTTPCredentials bool
+		beforeFunc          func(t *WithT, obj *helmv1.HelmRepository, rev digest.Digest)
+		afterFunc           func(t *WithT, obj *helmv1.HelmRepository, artifact sourcev1.Artifact, chartRepo *repository.ChartRepository)
+		want                sreconcile.Result
+		wantErr             bool
+		assertConditions    []metav1.Condition
 	}{
 		{
 			name:     "HTTPS with certSecretRef pointing to CA cert but public repo URL succeeds",
@@ -614,6 +657,105 @@ func TestHelmRepositoryReconciler_reconcileSource(t *testing.T) {
 				t.Expect(artifact.Revision).ToNot(BeEmpty())
 			},
 		},
+		{
+			name:                "HTTP with Basic Auth secret when denying HTTP credentials makes FetchFailed=True",
+			protocol:            "http",
+			denyHTTPCredentials: true,
+			server: options{
+				username: "git",
+				password: "1234",
+			},
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "basic-auth",
+				},
+				Data: map[string][]byte{
+					"username": []byte("git"),
+					"password": []byte("1234"),
+				},
+			},
+			beforeFunc: func(t *WithT, obj *helmv1.HelmRepository, rev digest.Digest) {
+				obj.Spec.SecretRef = &meta.LocalObjectReference{Name: "basic-auth"}
+				conditions.MarkReconciling(obj, meta.ProgressingReason, "foo")
+				conditions.MarkUnknown(obj, meta.ReadyCondition, "foo", "bar")
+			},
+			wantErr: true,
+			want:    sreconcile.ResultEmpty,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.FetchFailedCondition, helmv1.InsecureCredentialTransportReason, "refusing to send credentials over plaintext HTTP"),
+				*conditions.TrueCondition(meta.ReconcilingCondition, meta.ProgressingReason, "foo"),
+				*conditions.UnknownCondition(meta.ReadyCondition, "foo", "bar"),
+			},
+			afterFunc: func(t *WithT, obj *helmv1.HelmRepository, artifact sourcev1.Artifact, chartRepo *repository.ChartRepository) {
+				// No repo index due to fetch fail.
+				t.Expect(chartRepo.Path).To(BeEmpty())
+				t.Expect(chartRepo.Index).To(BeNil())
+				t.Expect(artifact.Revision).To(BeEmpty())
+			},
+		},
+		{
+			name:                "HTTP with Basic Auth secret and passCredentials when denying HTTP credentials makes ArtifactOutdated=True",
+			protocol:            "http",
+			denyHTTPCredentials: true,
+			server: options{
+				username: "git",
+				password: "1234",
+			},
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "basic-auth",
+				},
+				Data: map[string][]byte{
+					"username": []byte("git"),
+					"password": []byte("1234"),
+				},
+			},
+			beforeFunc: func(t *WithT, obj *helmv1.HelmRepository, rev digest.Digest) {
+				obj.Spec.SecretRef = &meta.LocalObjectReference{Name: "basic-auth"}
+				obj.Spec.PassCredentials = true
+			},
+			want: sreconcile.ResultSuccess,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(meta.ReconcilingCondition, meta.ProgressingReason, "building artifact: new index revision"),
+				*conditions.UnknownCondition(meta.ReadyCondition, meta.ProgressingReason, "building artifact: new index revision"),
+			},
+			afterFunc: func(t *WithT, obj *helmv1.HelmRepository, artifact sourcev1.Artifact, chartRepo *repository.ChartRepository) {
+				t.Expect(chartRepo.Path).ToNot(BeEmpty())
+				t.Expect(chartRepo.Index).ToNot(BeNil())
+				t.Expect(artifact.Revision).ToNot(BeEmpty())
+			},
+		},
+		{
+			name:                "HTTP with headers secret when denying HTTP credentials makes FetchFailed=True",
+			protocol:            "http",
+			denyHTTPCredentials: true,
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "api-key",
+				},
+				Data: map[string][]byte{
+					"X-Api-Key": []byte("token"),
+				},
+			},
+			beforeFunc: func(t *WithT, obj *helmv1.HelmRepository, rev digest.Digest) {
+				obj.Spec.HeadersSecretRef = &meta.LocalObjectReference{Name: "api-key"}
+				conditions.MarkReconciling(obj, meta.ProgressingReason, "foo")
+				conditions.MarkUnknown(obj, meta.ReadyCondition, "foo", "bar")
+			},
+			wantErr: true,
+			want:    sreconcile.ResultEmpty,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.FetchFailedCondition, helmv1.InsecureCredentialTransportReason, "refusing to send credentials over plaintext HTTP"),
+				*conditions.TrueCondition(meta.ReconcilingCondition, meta.ProgressingReason, "foo"),
+				*conditions.UnknownCondition(meta.ReadyCondition, "foo", "bar"),
+			},
+			afterFunc: func(t *WithT, obj *helmv1.HelmRepository, artifact sourcev1.Artifact, chartRepo *repository.ChartRepository) {
+				// No repo index due to fetch fail.
+				t.Expect(chartRepo.Path).To(BeEmpty())
+				t.Expect(chartRepo.Index).To(BeNil())
+				t.Expect(artifact.Revision).To(BeEmpty())
+			},
+		},
 		{
 			name:     "HTTPS with invalid CAFile in certSecretRef makes FetchFailed=True and returns error",
 			protocol: "https",
@@ -692,6 +834,27 @@ func TestHelmRepositoryReconciler_reconcileSource(t *testing.T) {
 				t.Expect(artifact.Revision).To(BeEmpty())
 			},
 		},
+		{
+			name:     "Unsupported TLS cipher suite makes FetchFailed=True and returns stalling error",
+			protocol: "http",
+			beforeFunc: func(t *WithT, obj *helmv1.HelmRepository, rev digest.Digest) {
+				obj.Spec.MinTLSVersion = "1.2"
+				obj.Spec.TLSCipherSuites = []string{"TLS_RSA_WITH_RC4_128_SHA"}
+				conditions.MarkReconciling(obj, meta.ProgressingReason, "foo")
+				conditions.MarkUnknown(obj, meta.ReadyCondition, "foo", "bar")
+			},
+			want:    sreconcile.ResultEmpty,
+			wantErr: true,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.FetchFailedCondition, helmv1.InvalidTLSPolicyReason, "unsupported or insecure TLS cipher suite 'TLS_RSA_WITH_RC4_128_SHA'"),
+				*conditions.TrueCondition(meta.ReconcilingCondition, meta.ProgressingReason, "foo"),
+				*conditions.UnknownCondition(meta.ReadyCondition, "foo", "bar"),
+			},
+			afterFunc: func(t *WithT, obj *helmv1.HelmRepository, artifact sourcev1.Artifact, chartRepo *repository.ChartRepository) {
+				t.Expect(chartRepo.Path).To(BeEmpty())
+				t.Expect(artifact.Revision).To(BeEmpty())
+			},
+		},
 		{
 			name:     "Missing secret returns FetchFailed=True and returns error",
 			protocol: "http",
@@ -763,6 +926,81 @@ func TestHelmRepositoryReconciler_reconcileSource(t *testing.T) {
 				t.Expect(chartRepo.Index).To(BeNil())
 
 				t.Expect(&artifact).To(BeEquivalentTo(obj.Status.Artifact))
+				// A fetch matching the current Artifact counts as a
+				// successful fetch, but does not update the last fetch time.
+				t.Expect(obj.Status.LastSuccessfulFetchTime).ToNot(BeNil())
+				t.Expect(obj.Status.LastFetchTime).To(BeNil())
+			},
+			want: sreconcile.ResultSuccess,
+		},
+		{
+			name:     "Stored index with same revision older than maxArtifactAge is loaded",
+			protocol: "http",
+			beforeFunc: func(t *WithT, obj *helmv1.HelmRepository, rev digest.Digest) {
+				obj.Spec.MaxArtifactAge = &metav1.Duration{Duration: time.Hour}
+				obj.Status.Artifact = &sourcev1.Artifact{
+					Revision:       rev.String(),
+					LastUpdateTime: metav1.NewTime(time.Now().Add(-2 * time.Hour)),
+				}
+
+			},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactOutdatedCondition, "NewRevision", "new index revision"),
+				*conditions.TrueCondition(meta.ReconcilingCondition, meta.ProgressingReason, "building artifact: new index revision"),
+				*conditions.UnknownCondition(meta.ReadyCondition, meta.ProgressingReason, "building artifact: new index revision"),
+			},
+			afterFunc: func(t *WithT, obj *helmv1.HelmRepository, artifact sourcev1.Artifact, chartRepo *repository.ChartRepository) {
+				t.Expect(chartRepo.Index).ToNot(BeNil())
+
+				t.Expect(artifact.Revision).To(Equal(obj.Status.Artifact.Revision))
+				t.Expect(obj.Status.LastFetchTime).ToNot(BeNil())
+				t.Expect(obj.Status.LastFetchTime.Time).To(BeTemporally("~", time.Now(), time.Minute))
+			},
+			want: sreconcile.ResultSuccess,
+		},
+		{
+			name:     "Stored index with same revision and chart name validation is loaded",
+			protocol: "http",
+			beforeFunc: func(t *WithT, obj *helmv1.HelmRepository, rev digest.Digest) {
+				obj.Spec.ChartNameValidation = &helmv1.ChartNameValidation{MaxLength: 5}
+				obj.Status.Artifact = &sourcev1.Artifact{
+					Revision: rev.String(),
+				}
+			},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactOutdatedCondition, "NewRevision", "new index revision"),
+				*conditions.TrueCondition(meta.ReconcilingCondition, meta.ProgressingReason, "building artifact: new index revision"),
+				*conditions.UnknownCondition(meta.ReadyCondition, meta.ProgressingReason, "building artifact: new index revision"),
+			},
+			afterFunc: func(t *WithT, obj *helmv1.HelmRepository, artifact sourcev1.Artifact, chartRepo *repository.ChartRepository) {
+				t.Expect(chartRepo.Index).ToNot(BeNil())
+				// The chart with a name longer than the maximum length is
+				// removed, although the upstream index did not change.
+				t.Expect(chartRepo.Index.Entries).ToNot(HaveKey("helmchart"))
+				t.Expect(artifact.Revision).ToNot(Equal(obj.Status.Artifact.Revision))
+			},
+			want: sreconcile.ResultSuccess,
+		},
+		{
+			name:     "Stored index with same revision in another format",
+			protocol: "http",
+			beforeFunc: func(t *WithT, obj *helmv1.HelmRepository, rev digest.Digest) {
+				obj.Spec.RevisionFormat = helmv1.HelmRepositoryRevisionFormatShort
+				obj.Status.Artifact = &sourcev1.Artifact{
+					Path:     fmt.Sprintf("helmrepository/default/foo/index-%s.yaml", rev.Encoded()),
+					Revision: rev.String(),
+				}
+			},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactOutdatedCondition, "NewRevision", "new index revision"),
+				*conditions.TrueCondition(meta.ReconcilingCondition, meta.ProgressingReason, "building artifact: new index revision"),
+				*conditions.UnknownCondition(meta.ReadyCondition, meta.ProgressingReason, "building artifact: new index revision"),
+			},
+			afterFunc: func(t *WithT, obj *helmv1.HelmRepository, artifact sourcev1.Artifact, chartRepo *repository.ChartRepository) {
+				t.Expect(chartRepo.Index).ToNot(BeNil())
+
+				t.Expect(artifact.Revision).To(HaveLen(12))
+				t.Expect(artifactRevision(artifact)).To(Equal(artifactRevision(*obj.Status.Artifact)))
 			},
 			want: sreconcile.ResultSuccess,
 		},
@@ -788,55 +1026,250 @@ func TestHelmRepositoryReconciler_reconcileSource(t *testing.T) {
 
 				t.Expect(artifact.Path).To(Not(BeEmpty()))
 				t.Expect(artifact.Revision).ToNot(Equal(obj.Status.Artifact.Revision))
+
+				t.Expect(obj.Status.ObservedURL).To(Equal(chartRepo.URL))
+				t.Expect(obj.Status.ChartCount).To(Equal(pointer.Int64(1)))
+				t.Expect(obj.Status.ChartVersionCount).To(Equal(pointer.Int64(1)))
 			},
 			want: sreconcile.ResultSuccess,
 		},
 		{
-			name:     "Existing artifact makes ArtifactOutdated=True",
+			name:     "Blocked versions are removed from the index before calculating the revision",
 			protocol: "http",
 			beforeFunc: func(t *WithT, obj *helmv1.HelmRepository, rev digest.Digest) {
+				obj.Spec.BlockVersions = map[string]string{"helmchart": "0.1.0"}
 				obj.Status.Artifact = &sourcev1.Artifact{
-					Path:     "some-path",
-					Revision: "some-rev",
+					Revision: rev.String(),
 				}
 			},
-			want: sreconcile.ResultSuccess,
 			assertConditions: []metav1.Condition{
 				*conditions.TrueCondition(sourcev1.ArtifactOutdatedCondition, "NewRevision", "new index revision"),
 				*conditions.TrueCondition(meta.ReconcilingCondition, meta.ProgressingReason, "building artifact: new index revision"),
 				*conditions.UnknownCondition(meta.ReadyCondition, meta.ProgressingReason, "building artifact: new index revision"),
 			},
-		},
-	}
+			afterFunc: func(t *WithT, obj *helmv1.HelmRepository, artifact sourcev1.Artifact, chartRepo *repository.ChartRepository) {
+				t.Expect(chartRepo.Index).ToNot(BeNil())
+				t.Expect(chartRepo.Index.Entries).ToNot(HaveKey("helmchart"))
 
-	for _, tt := range tests {
-		obj := &helmv1.HelmRepository{
-			ObjectMeta: metav1.ObjectMeta{
-				GenerateName: "auth-strategy-",
-				Generation:   1,
+				t.Expect(artifact.Revision).ToNot(Equal(obj.Status.Artifact.Revision))
+				t.Expect(obj.Status.ChartCount).To(Equal(pointer.Int64(0)))
 			},
-			Spec: helmv1.HelmRepositorySpec{
-				Interval: metav1.Duration{Duration: interval},
-				Timeout:  &metav1.Duration{Duration: timeout},
+			want: sreconcile.ResultSuccess,
+		},
+		{
+			name:     "Empty index with failIfEmpty makes FetchFailed=True and keeps the artifact",
+			protocol: "http",
+			beforeFunc: func(t *WithT, obj *helmv1.HelmRepository, rev digest.Digest) {
+				obj.Spec.BlockVersions = map[string]string{"helmchart": "*"}
+				obj.Spec.FailIfEmpty = true
+				obj.Status.Artifact = &sourcev1.Artifact{
+					Revision: rev.String(),
+				}
+				conditions.MarkReconciling(obj, meta.ProgressingReason, "foo")
+				conditions.MarkUnknown(obj, meta.ReadyCondition, "foo", "bar")
 			},
-		}
-
-		t.Run(tt.name, func(t *testing.T) {
-			g := NewWithT(t)
-
-			server, err := helmtestserver.NewTempHelmServer()
-			g.Expect(err).NotTo(HaveOccurred())
-			defer os.RemoveAll(server.Root())
-
-			g.Expect(server.PackageChart("testdata/charts/helmchart")).To(Succeed())
-			g.Expect(server.GenerateIndex()).To(Succeed())
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.FetchFailedCondition, helmv1.EmptyIndexReason, "Helm repository index has no entries"),
+				*conditions.TrueCondition(meta.ReconcilingCondition, meta.ProgressingReason, "foo"),
+				*conditions.UnknownCondition(meta.ReadyCondition, "foo", "bar"),
+			},
+			afterFunc: func(t *WithT, obj *helmv1.HelmRepository, artifact sourcev1.Artifact, chartRepo *repository.ChartRepository) {
+				t.Expect(artifact.Revision).To(BeEmpty())
+				t.Expect(obj.Status.Artifact).ToNot(BeNil())
+			},
+			wantErr: true,
+			want:    sreconcile.ResultEmpty,
+		},
+		{
+			name:     "Versions per chart are truncated before calculating the revision",
+			protocol: "http",
+			beforeFunc: func(t *WithT, obj *helmv1.HelmRepository, rev digest.Digest) {
+				obj.Spec.MaxVersionsPerChart = 1
+				obj.Status.Artifact = &sourcev1.Artifact{
+					Revision: rev.String(),
+				}
+			},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactOutdatedCondition, "NewRevision", "new index revision"),
+				*conditions.TrueCondition(meta.ReconcilingCondition, meta.ProgressingReason, "building artifact: new index revision"),
+				*conditions.UnknownCondition(meta.ReadyCondition, meta.ProgressingReason, "building artifact: new index revision"),
+			},
+			afterFunc: func(t *WithT, obj *helmv1.HelmRepository, artifact sourcev1.Artifact, chartRepo *repository.ChartRepository) {
+				t.Expect(chartRepo.Index).ToNot(BeNil())
+				t.Expect(chartRepo.Index.Entries["helmchart"]).To(HaveLen(1))
 
-			if len(tt.server.username+tt.server.password) > 0 {
-				server.WithMiddleware(func(handler http.Handler) http.Handler {
-					return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-						u, p, ok := r.BasicAuth()
-						if !ok || u != tt.server.username || p != tt.server.password {
-							w.WriteHeader(401)
+				t.Expect(obj.Status.ChartCount).To(Equal(pointer.Int64(1)))
+				t.Expect(obj.Status.ChartVersionCount).To(Equal(pointer.Int64(1)))
+			},
+			want: sreconcile.ResultSuccess,
+		},
+		{
+			name:     "Invalid blocked versions constraint makes FetchFailed=True",
+			protocol: "http",
+			beforeFunc: func(t *WithT, obj *helmv1.HelmRepository, rev digest.Digest) {
+				obj.Spec.BlockVersions = map[string]string{"helmchart": "invalid"}
+				conditions.MarkReconciling(obj, meta.ProgressingReason, "foo")
+				conditions.MarkUnknown(obj, meta.ReadyCondition, "foo", "bar")
+			},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.FetchFailedCondition, meta.FailedReason, "invalid blocked versions constraint 'invalid' for chart 'helmchart'"),
+				*conditions.TrueCondition(meta.ReconcilingCondition, meta.ProgressingReason, "foo"),
+				*conditions.UnknownCondition(meta.ReadyCondition, "foo", "bar"),
+			},
+			wantErr: true,
+			want:    sreconcile.ResultEmpty,
+		},
+		{
+			name:     "Additional indexes are merged before calculating the revision",
+			protocol: "http",
+			beforeFunc: func(t *WithT, obj *helmv1.HelmRepository, rev digest.Digest) {
+				obj.Spec.AdditionalURLs = []string{obj.Spec.URL + "/"}
+				obj.Status.Artifact = &sourcev1.Artifact{
+					Revision: rev.String(),
+				}
+			},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactOutdatedCondition, "NewRevision", "new index revision"),
+				*conditions.TrueCondition(meta.ReconcilingCondition, meta.ProgressingReason, "building artifact: new index revision"),
+				*conditions.UnknownCondition(meta.ReadyCondition, meta.ProgressingReason, "building artifact: new index revision"),
+			},
+			afterFunc: func(t *WithT, obj *helmv1.HelmRepository, artifact sourcev1.Artifact, chartRepo *repository.ChartRepository) {
+				t.Expect(chartRepo.Index).ToNot(BeNil())
+				t.Expect(chartRepo.Index.Entries).To(HaveKey("helmchart"))
+				t.Expect(obj.Status.ChartCount).To(Equal(pointer.Int64(1)))
+			},
+			want: sreconcile.ResultSuccess,
+		},
+		{
+			name:     "Invalid additional URL makes FetchFailed=True and returns stalling error",
+			protocol: "http",
+			beforeFunc: func(t *WithT, obj *helmv1.HelmRepository, rev digest.Digest) {
+				obj.Spec.AdditionalURLs = []string{" http://invalid"}
+				conditions.MarkReconciling(obj, meta.ProgressingReason, "foo")
+				conditions.MarkUnknown(obj, meta.ReadyCondition, "foo", "bar")
+			},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.FetchFailedCondition, sourcev1.URLInvalidReason, "invalid additional Helm repository URL"),
+				*conditions.TrueCondition(meta.ReconcilingCondition, meta.ProgressingReason, "foo"),
+				*conditions.UnknownCondition(meta.ReadyCondition, "foo", "bar"),
+			},
+			wantErr: true,
+			want:    sreconcile.ResultEmpty,
+		},
+		{
+			name:     "Digest spot check of index with matching digests succeeds",
+			protocol: "http",
+			beforeFunc: func(t *WithT, obj *helmv1.HelmRepository, rev digest.Digest) {
+				obj.Spec.DigestSpotCheck = &helmv1.DigestSpotCheck{SampleSize: 1}
+			},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(meta.ReconcilingCondition, meta.ProgressingReason, "building artifact: new index revision"),
+				*conditions.UnknownCondition(meta.ReadyCondition, meta.ProgressingReason, "building artifact: new index revision"),
+			},
+			afterFunc: func(t *WithT, obj *helmv1.HelmRepository, artifact sourcev1.Artifact, chartRepo *repository.ChartRepository) {
+				t.Expect(chartRepo.Index).ToNot(BeNil())
+				t.Expect(artifact.Revision).ToNot(BeEmpty())
+			},
+			want: sreconcile.ResultSuccess,
+		},
+		{
+			name:     "Chart URL check of index with reachable charts succeeds",
+			protocol: "http",
+			beforeFunc: func(t *WithT, obj *helmv1.HelmRepository, rev digest.Digest) {
+				obj.Spec.ChartURLCheck = &helmv1.ChartURLCheck{SampleSize: 1}
+				conditions.MarkTrue(obj, helmv1.ChartsUnreachableCondition, helmv1.ChartURLsUnreachableReason, "foo")
+			},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(meta.ReconcilingCondition, meta.ProgressingReason, "building artifact: new index revision"),
+				*conditions.UnknownCondition(meta.ReadyCondition, meta.ProgressingReason, "building artifact: new index revision"),
+			},
+			want: sreconcile.ResultSuccess,
+		},
+		{
+			name:     "Index not found makes FetchFailed=True and returns error",
+			protocol: "http",
+			beforeFunc: func(t *WithT, obj *helmv1.HelmRepository, rev digest.Digest) {
+				obj.Spec.URL = obj.Spec.URL + "/missing"
+				conditions.MarkReconciling(obj, meta.ProgressingReason, "foo")
+				conditions.MarkUnknown(obj, meta.ReadyCondition, "foo", "bar")
+			},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.FetchFailedCondition, meta.FailedReason, "failed to fetch Helm repository index: failed to cache index to temporary file: index not found"),
+				*conditions.TrueCondition(meta.ReconcilingCondition, meta.ProgressingReason, "foo"),
+				*conditions.UnknownCondition(meta.ReadyCondition, "foo", "bar"),
+			},
+			afterFunc: func(t *WithT, obj *helmv1.HelmRepository, artifact sourcev1.Artifact, chartRepo *repository.ChartRepository) {
+				t.Expect(obj.Status.LastFetchStatusCode).To(Equal(http.StatusNotFound))
+			},
+			wantErr: true,
+			want:    sreconcile.ResultEmpty,
+		},
+		{
+			name:     "Index not found with treat404AsStalling makes FetchFailed=True and returns stalling error",
+			protocol: "http",
+			beforeFunc: func(t *WithT, obj *helmv1.HelmRepository, rev digest.Digest) {
+				obj.Spec.URL = obj.Spec.URL + "/missing"
+				obj.Spec.Treat404AsStalling = true
+				conditions.MarkReconciling(obj, meta.ProgressingReason, "foo")
+				conditions.MarkUnknown(obj, meta.ReadyCondition, "foo", "bar")
+			},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.FetchFailedCondition, helmv1.IndexNotFoundReason, "failed to fetch Helm repository index: failed to cache index to temporary file: index not found"),
+				*conditions.TrueCondition(meta.ReconcilingCondition, meta.ProgressingReason, "foo"),
+				*conditions.UnknownCondition(meta.ReadyCondition, "foo", "bar"),
+			},
+			wantErr: true,
+			want:    sreconcile.ResultEmpty,
+		},
+		{
+			name:     "Existing artifact makes ArtifactOutdated=True",
+			protocol: "http",
+			beforeFunc: func(t *WithT, obj *helmv1.HelmRepository, rev digest.Digest) {
+				obj.Status.Artifact = &sourcev1.Artifact{
+					Path:     "some-path",
+					Revision: "some-rev",
+				}
+			},
+			want: sreconcile.ResultSuccess,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactOutdatedCondition, "NewRevision", "new index revision"),
+				*conditions.TrueCondition(meta.ReconcilingCondition, meta.ProgressingReason, "building artifact: new index revision"),
+				*conditions.UnknownCondition(meta.ReadyCondition, meta.ProgressingReason, "building artifact: new index revision"),
+			},
+			afterFunc: func(t *WithT, obj *helmv1.HelmRepository, artifact sourcev1.Artifact, chartRepo *repository.ChartRepository) {
+				t.Expect(obj.Status.LastFetchStatusCode).To(Equal(http.StatusOK))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		obj := &helmv1.HelmRepository{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "auth-strategy-",
+				Generation:   1,
+			},
+			Spec: helmv1.HelmRepositorySpec{
+				Interval: metav1.Duration{Duration: interval},
+				Timeout:  &metav1.Duration{Duration: timeout},
+			},
+		}
+
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			server, err := helmtestserver.NewTempHelmServer()
+			g.Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(server.Root())
+
+			g.Expect(server.PackageChart("testdata/charts/helmchart")).To(Succeed())
+			g.Expect(server.GenerateIndex()).To(Succeed())
+
+			if len(tt.server.username+tt.server.password) > 0 {
+				server.WithMiddleware(func(handler http.Handler) http.Handler {
+					return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						u, p, ok := r.BasicAuth()
+						if !ok || u != tt.server.username || p != tt.server.password {
+							w.WriteHeader(401)
 							return
 						}
 						handler.ServeHTTP(w, r)
@@ -917,11 +1350,12 @@ func TestHelmRepositoryReconciler_reconcileSource(t *testing.T) {
 			}
 
 			r := &HelmRepositoryReconciler{
-				EventRecorder: record.NewFakeRecorder(32),
-				Client:        clientBuilder.Build(),
-				Storage:       testStorage,
-				Getters:       testGetters,
-				patchOptions:  getPatchOptions(helmRepositoryReadyCondition.Owned, "sc"),
+				EventRecorder:       record.NewFakeRecorder(32),
+				Client:              clientBuilder.Build(),
+				Storage:             testStorage,
+				Getters:             testGetters,
+				DenyHTTPCredentials: tt.denyHTTPCredentials,
+				patchOptions:        getPatchOptions(helmRepositoryReadyCondition.Owned, "sc"),
 			}
 			if tt.beforeFunc != nil {
 				tt.beforeFunc(g, obj, rev)
@@ -954,6 +1388,153 @@ func TestHelmRepositoryReconciler_reconcileSource(t *testing.T) {
 	}
 }
 
+func TestHelmRepositoryReconciler_withinMinRefreshWindow(t *testing.T) {
+	tests := []struct {
+		name       string
+		minRefresh time.Duration
+		triggered  bool
+		beforeFunc func(obj *helmv1.HelmRepository)
+		want       bool
+	}{
+		{
+			name:       "disabled window",
+			minRefresh: 0,
+			want:       false,
+		},
+		{
+			name:       "recently ready",
+			minRefresh: time.Minute,
+			want:       true,
+		},
+		{
+			name:       "fetched before window",
+			minRefresh: time.Minute,
+			beforeFunc: func(obj *helmv1.HelmRepository) {
+				obj.Status.LastSuccessfulFetchTime = &metav1.Time{Time: time.Now().Add(-2 * time.Minute)}
+			},
+			want: false,
+		},
+		{
+			name:       "long ready and recently fetched",
+			minRefresh: time.Minute,
+			beforeFunc: func(obj *helmv1.HelmRepository) {
+				for i := range obj.Status.Conditions {
+					obj.Status.Conditions[i].LastTransitionTime = metav1.NewTime(time.Now().Add(-24 * time.Hour))
+				}
+				obj.Status.Artifact.LastUpdateTime = metav1.NewTime(time.Now().Add(-24 * time.Hour))
+				obj.Status.LastFetchTime = &metav1.Time{Time: time.Now().Add(-24 * time.Hour)}
+				obj.Status.LastSuccessfulFetchTime = &metav1.Time{Time: time.Now().Add(-10 * time.Second)}
+			},
+			want: true,
+		},
+		{
+			name:       "falls back to last fetch time",
+			minRefresh: time.Minute,
+			beforeFunc: func(obj *helmv1.HelmRepository) {
+				obj.Status.Artifact.LastUpdateTime = metav1.NewTime(time.Now().Add(-24 * time.Hour))
+				obj.Status.LastFetchTime = &metav1.Time{Time: time.Now().Add(-10 * time.Second)}
+			},
+			want: true,
+		},
+		{
+			name:       "falls back to artifact update time",
+			minRefresh: time.Minute,
+			beforeFunc: func(obj *helmv1.HelmRepository) {
+				obj.Status.Artifact.LastUpdateTime = metav1.NewTime(time.Now().Add(-2 * time.Minute))
+			},
+			want: false,
+		},
+		{
+			name:       "not ready",
+			minRefresh: time.Minute,
+			beforeFunc: func(obj *helmv1.HelmRepository) {
+				conditions.MarkFalse(obj, meta.ReadyCondition, meta.FailedReason, "failed")
+			},
+			want: false,
+		},
+		{
+			name:       "no artifact",
+			minRefresh: time.Minute,
+			beforeFunc: func(obj *helmv1.HelmRepository) {
+				obj.Status.Artifact = nil
+			},
+			want: false,
+		},
+		{
+			name:       "new generation",
+			minRefresh: time.Minute,
+			beforeFunc: func(obj *helmv1.HelmRepository) {
+				obj.Generation = 2
+			},
+			want: false,
+		},
+		{
+			name:       "reconcile requested",
+			minRefresh: time.Minute,
+			beforeFunc: func(obj *helmv1.HelmRepository) {
+				obj.SetAnnotations(map[string]string{meta.ReconcileRequestAnnotation: "now"})
+			},
+			want: false,
+		},
+		{
+			name:       "artifact older than maxArtifactAge",
+			minRefresh: time.Minute,
+			beforeFunc: func(obj *helmv1.HelmRepository) {
+				obj.Spec.MaxArtifactAge = &metav1.Duration{Duration: time.Hour}
+				obj.Status.LastFetchTime = &metav1.Time{Time: time.Now().Add(-2 * time.Hour)}
+			},
+			want: false,
+		},
+		{
+			name:       "reconcile triggered",
+			minRefresh: time.Minute,
+			triggered:  true,
+			want:       false,
+		},
+		{
+			name:       "reconcile request handled",
+			minRefresh: time.Minute,
+			beforeFunc: func(obj *helmv1.HelmRepository) {
+				obj.SetAnnotations(map[string]string{meta.ReconcileRequestAnnotation: "now"})
+				obj.Status.SetLastHandledReconcileRequest("now")
+			},
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			obj := &helmv1.HelmRepository{
+				ObjectMeta: metav1.ObjectMeta{
+					Generation: 1,
+				},
+				Status: helmv1.HelmRepositoryStatus{
+					ObservedGeneration: 1,
+					Artifact: &sourcev1.Artifact{
+						Revision:       "sha256:6a33e4c2a3c5f2ff1a0e44e13b0f2c1a5d1d4aa1b2b2e4d2a0c3f6e9b1d5e7a9",
+						LastUpdateTime: metav1.Now(),
+					},
+				},
+			}
+			conditions.MarkTrue(obj, meta.ReadyCondition, meta.SucceededReason, "ready")
+			if tt.beforeFunc != nil {
+				tt.beforeFunc(obj)
+			}
+
+			r := &HelmRepositoryReconciler{
+				MinRefreshInterval: tt.minRefresh,
+			}
+			if tt.triggered {
+				r.trigger = &ReconcileTriggerHandler{}
+				g.Expect(r.trigger.enqueue("helmrepositories", obj)).To(BeTrue())
+			}
+			g.Expect(r.withinMinRefreshWindow(obj)).To(Equal(tt.want))
+		})
+	}
+}
+
 func TestHelmRepositoryReconciler_reconcileArtifact(t *testing.T) {
 	tests := []struct {
 		name             string
@@ -1000,6 +1581,44 @@ func TestHelmRepositoryReconciler_reconcileArtifact(t *testing.T) {
 				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, meta.SucceededReason, "stored artifact: revision 'existing'"),
 			},
 		},
+		{
+			name:  "Archiving artifact to storage with cacheIndex disabled does not add to cache",
+			cache: cache.New(10, time.Minute),
+			beforeFunc: func(t *WithT, obj *helmv1.HelmRepository, artifact sourcev1.Artifact, index *repository.ChartRepository) {
+				index.Index = &repo.IndexFile{
+					APIVersion: "v1",
+					Generated:  time.Now(),
+				}
+				obj.Spec.Interval = metav1.Duration{Duration: interval}
+				obj.Spec.CacheIndex = pointer.Bool(false)
+			},
+			want: sreconcile.ResultSuccess,
+			afterFunc: func(t *WithT, obj *helmv1.HelmRepository, cache *cache.Cache) {
+				_, ok := cache.Get(obj.GetArtifact().Path)
+				t.Expect(ok).To(BeFalse())
+				t.Expect(obj.GetArtifact().Path).ToNot(BeEmpty())
+			},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, meta.SucceededReason, "stored artifact: revision 'existing'"),
+			},
+		},
+		{
+			name: "Weak revision match does not archive artifact",
+			beforeFunc: func(t *WithT, obj *helmv1.HelmRepository, artifact sourcev1.Artifact, index *repository.ChartRepository) {
+				obj.Spec.Interval = metav1.Duration{Duration: interval}
+				obj.Spec.RevisionComparison = helmv1.HelmRepositoryRevisionComparisonWeak
+				obj.Status.Artifact = artifact.DeepCopy()
+				obj.Status.Artifact.Digest = "sha256:stored"
+			},
+			afterFunc: func(t *WithT, obj *helmv1.HelmRepository, _ *cache.Cache) {
+				t.Expect(obj.Status.Artifact.Digest).To(Equal("sha256:stored"))
+				t.Expect(obj.Status.URL).To(BeEmpty())
+			},
+			want: sreconcile.ResultSuccess,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, meta.SucceededReason, "stored artifact: revision 'existing'"),
+			},
+		},
 		{
 			name: "Up-to-date artifact should not update status",
 			beforeFunc: func(t *WithT, obj *helmv1.HelmRepository, artifact sourcev1.Artifact, index *repository.ChartRepository) {
@@ -1042,22 +1661,62 @@ func TestHelmRepositoryReconciler_reconcileArtifact(t *testing.T) {
 				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, meta.SucceededReason, "stored artifact: revision 'existing'"),
 			},
 		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			g := NewWithT(t)
+		{
+			name: "Creates JSON symlink to the created artifact with JSON index format",
+			beforeFunc: func(t *WithT, obj *helmv1.HelmRepository, artifact sourcev1.Artifact, index *repository.ChartRepository) {
+				obj.Spec.Interval = metav1.Duration{Duration: interval}
+				obj.Spec.IndexFormat = helmv1.HelmRepositoryIndexFormatJSON
+			},
+			afterFunc: func(t *WithT, obj *helmv1.HelmRepository, _ *cache.Cache) {
+				localPath := testStorage.LocalPath(*obj.GetArtifact())
+				symlinkPath := filepath.Join(filepath.Dir(localPath), "index.json")
+				targetFile, err := os.Readlink(symlinkPath)
+				t.Expect(err).NotTo(HaveOccurred())
+				t.Expect(localPath).To(Equal(targetFile))
+				t.Expect(obj.Status.URL).To(HaveSuffix("/index.json"))
+			},
+			want: sreconcile.ResultSuccess,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, meta.SucceededReason, "stored artifact: revision 'existing'"),
+			},
+		},
+		{
+			name: "Embeds provenance in the stored index with embedProvenance",
+			beforeFunc: func(t *WithT, obj *helmv1.HelmRepository, artifact sourcev1.Artifact, index *repository.ChartRepository) {
+				obj.Spec.Interval = metav1.Duration{Duration: interval}
+				obj.Spec.EmbedProvenance = true
+				obj.Status.LastFetchTime = &metav1.Time{Time: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)}
+			},
+			afterFunc: func(t *WithT, obj *helmv1.HelmRepository, _ *cache.Cache) {
+				b, err := os.ReadFile(testStorage.LocalPath(*obj.GetArtifact()))
+				t.Expect(err).NotTo(HaveOccurred())
+				index := &repo.IndexFile{}
+				t.Expect(json.Unmarshal(b, index)).To(Succeed())
+				t.Expect(index.Annotations).To(HaveKeyWithValue(helmv1.HelmRepositoryProvenanceAnnotation,
+					`{"url":"https://example.com/index.yaml","fetchTime":"2023-01-01T00:00:00Z","controller":"source-controller"}`))
+			},
+			want: sreconcile.ResultSuccess,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.ArtifactInStorageCondition, meta.SucceededReason, "stored artifact: revision 'existing'"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
 
 			r := &HelmRepositoryReconciler{
 				Client: fakeclient.NewClientBuilder().
 					WithScheme(testEnv.GetScheme()).
 					WithStatusSubresource(&helmv1.HelmRepository{}).
 					Build(),
-				EventRecorder: record.NewFakeRecorder(32),
-				Storage:       testStorage,
-				Cache:         tt.cache,
-				TTL:           1 * time.Minute,
-				patchOptions:  getPatchOptions(helmRepositoryReadyCondition.Owned, "sc"),
+				EventRecorder:  record.NewFakeRecorder(32),
+				Storage:        testStorage,
+				Cache:          tt.cache,
+				TTL:            1 * time.Minute,
+				ControllerName: "source-controller",
+				patchOptions:   getPatchOptions(helmRepositoryReadyCondition.Owned, "sc"),
 			}
 
 			obj := &helmv1.HelmRepository{
@@ -1106,6 +1765,66 @@ func TestHelmRepositoryReconciler_reconcileArtifact(t *testing.T) {
 	}
 }
 
+func TestHelmRepositoryReconciler_reconcileArtifactLockBackoff(t *testing.T) {
+	g := NewWithT(t)
+
+	r := &HelmRepositoryReconciler{
+		Client: fakeclient.NewClientBuilder().
+			WithScheme(testEnv.GetScheme()).
+			WithStatusSubresource(&helmv1.HelmRepository{}).
+			Build(),
+		EventRecorder:  record.NewFakeRecorder(32),
+		Storage:        testStorage,
+		LockBackoff:    time.Second,
+		LockBackoffMax: 3 * time.Second,
+		patchOptions:   getPatchOptions(helmRepositoryReadyCondition.Owned, "sc"),
+	}
+
+	obj := &helmv1.HelmRepository{
+		TypeMeta: metav1.TypeMeta{
+			Kind: helmv1.HelmRepositoryKind,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "lock-backoff",
+			Generation: 1,
+			Namespace:  "default",
+		},
+		Spec: helmv1.HelmRepositorySpec{
+			Timeout: &metav1.Duration{Duration: timeout},
+			URL:     "https://example.com/index.yaml",
+		},
+	}
+
+	chartRepo, err := repository.NewChartRepository(obj.Spec.URL, "", testGetters, nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	chartRepo.Index = &repo.IndexFile{}
+
+	artifact := testStorage.NewArtifactFor(obj.Kind, obj, "existing", "foo.tar.gz")
+	g.Expect(testStorage.MkdirAll(artifact)).To(Succeed())
+	defer testStorage.RemoveAll(artifact)
+
+	// A directory at the path of the lock file makes acquiring the lock fail.
+	lockPath := testStorage.LocalPath(artifact) + ".lock"
+	g.Expect(os.MkdirAll(lockPath, 0o700)).To(Succeed())
+
+	sp := patch.NewSerialPatcher(obj, r.Client)
+	for _, want := range []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second} {
+		got, err := r.reconcileArtifact(context.TODO(), sp, obj, artifact.DeepCopy(), chartRepo)
+		g.Expect(got).To(Equal(sreconcile.ResultEmpty))
+		var waitErr *serror.Waiting
+		g.Expect(errors.As(err, &waitErr)).To(BeTrue())
+		g.Expect(waitErr.Reason).To(Equal(sourcev1.AcquireLockFailedReason))
+		g.Expect(waitErr.RequeueAfter).To(Equal(want))
+	}
+
+	// Once the lock is acquired, the backoff is reset.
+	g.Expect(os.Remove(lockPath)).To(Succeed())
+	_, err = r.reconcileArtifact(context.TODO(), sp, obj, artifact.DeepCopy(), chartRepo)
+	var waitErr *serror.Waiting
+	g.Expect(errors.As(err, &waitErr)).To(BeFalse())
+	g.Expect(r.lockFailures.failures).To(BeEmpty())
+}
+
 func TestHelmRepositoryReconciler_reconcileSubRecs(t *testing.T) {
 	// Helper to build simple helmRepositoryReconcileFunc with result and error.
 	buildReconcileFuncs := func(r sreconcile.Result, e error) helmRepositoryReconcileFunc {
@@ -1459,159 +2178,768 @@ func TestHelmRepositoryReconciler_notify(t *testing.T) {
 	}
 }
 
-func TestHelmRepositoryReconciler_ReconcileTypeUpdatePredicateFilter(t *testing.T) {
+func TestHelmRepositoryReconciler_ReconcilePaused(t *testing.T) {
 	g := NewWithT(t)
 
-	testServer, err := helmtestserver.NewTempHelmServer()
-	g.Expect(err).NotTo(HaveOccurred())
-	defer os.RemoveAll(testServer.Root())
+	pauseUntil := metav1.NewTime(time.Now().Add(time.Hour))
+	obj := &helmv1.HelmRepository{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "paused",
+			Namespace:  "default",
+			Finalizers: []string{sourcev1.SourceFinalizer},
+		},
+		Spec: helmv1.HelmRepositorySpec{
+			Interval:   metav1.Duration{Duration: interval},
+			URL:        "https://example.com",
+			PauseUntil: &pauseUntil,
+		},
+	}
 
-	g.Expect(testServer.PackageChart("testdata/charts/helmchart")).To(Succeed())
-	g.Expect(testServer.GenerateIndex()).To(Succeed())
+	r := &HelmRepositoryReconciler{
+		Client: fakeclient.NewClientBuilder().
+			WithScheme(testEnv.GetScheme()).
+			WithObjects(obj).
+			WithStatusSubresource(&helmv1.HelmRepository{}).
+			Build(),
+		EventRecorder: record.NewFakeRecorder(32),
+		Storage:       testStorage,
+		patchOptions:  getPatchOptions(helmRepositoryReadyCondition.Owned, "sc"),
+	}
 
-	testServer.Start()
-	defer testServer.Stop()
+	key := client.ObjectKeyFromObject(obj)
+	res, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: key})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(res.RequeueAfter).To(BeNumerically(">", 59*time.Minute))
+	g.Expect(res.RequeueAfter).To(BeNumerically("<=", time.Hour))
+
+	updatedObj := &helmv1.HelmRepository{}
+	g.Expect(r.Get(context.TODO(), key, updatedObj)).To(Succeed())
+	g.Expect(updatedObj.GetConditions()).To(conditions.MatchConditions([]metav1.Condition{
+		*conditions.TrueCondition(helmv1.PausedCondition, helmv1.PauseUntilReason,
+			"reconciliation is paused until %s", pauseUntil.UTC().Format(time.RFC3339)),
+	}))
+	g.Expect(updatedObj.GetArtifact()).To(BeNil())
+}
+
+func TestHelmRepositoryReconciler_ReconcileScheduled(t *testing.T) {
+	g := NewWithT(t)
 
+	next := metav1.NewTime(time.Now().Add(time.Hour))
 	obj := &helmv1.HelmRepository{
 		ObjectMeta: metav1.ObjectMeta{
-			GenerateName: "helmrepository-reconcile-",
-			Namespace:    "default",
+			Name:       "scheduled",
+			Namespace:  "default",
+			Generation: 1,
+			Finalizers: []string{sourcev1.SourceFinalizer},
 		},
 		Spec: helmv1.HelmRepositorySpec{
 			Interval: metav1.Duration{Duration: interval},
-			URL:      testServer.URL(),
+			URL:      "https://example.com",
+			Schedule: "0 2 * * *",
+		},
+		Status: helmv1.HelmRepositoryStatus{
+			ObservedGeneration: 1,
+			NextScheduledTime:  &next,
+			Conditions: []metav1.Condition{
+				*conditions.TrueCondition(meta.ReadyCondition, meta.SucceededReason, "stored artifact"),
+			},
 		},
 	}
-	g.Expect(testEnv.CreateAndWait(ctx, obj)).To(Succeed())
-
-	key := client.ObjectKey{Name: obj.Name, Namespace: obj.Namespace}
 
-	// Wait for finalizer to be set
-	g.Eventually(func() bool {
-		if err := testEnv.Get(ctx, key, obj); err != nil {
-			return false
-		}
-		return len(obj.Finalizers) > 0
-	}, timeout).Should(BeTrue())
+	r := &HelmRepositoryReconciler{
+		Client: fakeclient.NewClientBuilder().
+			WithScheme(testEnv.GetScheme()).
+			WithObjects(obj).
+			WithStatusSubresource(&helmv1.HelmRepository{}).
+			Build(),
+		EventRecorder: record.NewFakeRecorder(32),
+		Storage:       testStorage,
+		patchOptions:  getPatchOptions(helmRepositoryReadyCondition.Owned, "sc"),
+	}
 
-	// Wait for HelmRepository to be Ready
-	g.Eventually(func() bool {
-		if err := testEnv.Get(ctx, key, obj); err != nil {
-			return false
-		}
-		if !conditions.IsReady(obj) && obj.Status.Artifact == nil {
-			return false
-		}
-		readyCondition := conditions.Get(obj, meta.ReadyCondition)
-		return readyCondition.Status == metav1.ConditionTrue &&
-			obj.Generation == readyCondition.ObservedGeneration &&
-			obj.Generation == obj.Status.ObservedGeneration
-	}, timeout).Should(BeTrue())
+	key := client.ObjectKeyFromObject(obj)
+	res, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: key})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(res.RequeueAfter).To(BeNumerically(">", 59*time.Minute))
+	g.Expect(res.RequeueAfter).To(BeNumerically("<=", time.Hour))
 
-	// Check if the object status is valid.
-	condns := &conditionscheck.Conditions{NegativePolarity: helmRepositoryReadyCondition.NegativePolarity}
-	checker := conditionscheck.NewChecker(testEnv.Client, condns)
-	checker.WithT(g).CheckErr(ctx, obj)
+	updatedObj := &helmv1.HelmRepository{}
+	g.Expect(r.Get(context.TODO(), key, updatedObj)).To(Succeed())
+	g.Expect(updatedObj.GetArtifact()).To(BeNil())
 
-	// kstatus client conformance check.
-	u, err := patch.ToUnstructured(obj)
-	g.Expect(err).ToNot(HaveOccurred())
-	res, err := kstatus.Compute(u)
+	// An invalid schedule stalls the object.
+	updatedObj.Spec.Schedule = "0 25 * * *"
+	g.Expect(r.Update(context.TODO(), updatedObj)).To(Succeed())
+	_, err = r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: key})
 	g.Expect(err).ToNot(HaveOccurred())
-	g.Expect(res.Status).To(Equal(kstatus.CurrentStatus))
+	g.Expect(r.Get(context.TODO(), key, updatedObj)).To(Succeed())
+	g.Expect(conditions.IsStalled(updatedObj)).To(BeTrue())
+	g.Expect(conditions.GetReason(updatedObj, meta.StalledCondition)).To(Equal(helmv1.InvalidScheduleReason))
+}
 
-	// Switch to a OCI helm repository type
-	secret := &corev1.Secret{
-		ObjectMeta: metav1.ObjectMeta{
-			GenerateName: "helmrepository-reconcile-",
-			Namespace:    "default",
+func TestScheduledRunDueIn(t *testing.T) {
+	tests := []struct {
+		name       string
+		beforeFunc func(obj *helmv1.HelmRepository)
+		wantDue    bool
+	}{
+		{
+			name:    "next run not due",
+			wantDue: true,
 		},
-		Data: map[string][]byte{
-			"username": []byte(testRegistryUsername),
-			"password": []byte(testRegistryPassword),
+		{
+			name: "next run passed",
+			beforeFunc: func(obj *helmv1.HelmRepository) {
+				obj.Status.NextScheduledTime = &metav1.Time{Time: time.Now().Add(-time.Minute)}
+			},
+		},
+		{
+			name: "no next run",
+			beforeFunc: func(obj *helmv1.HelmRepository) {
+				obj.Status.NextScheduledTime = nil
+			},
+		},
+		{
+			name: "not ready",
+			beforeFunc: func(obj *helmv1.HelmRepository) {
+				conditions.MarkFalse(obj, meta.ReadyCondition, meta.FailedReason, "failed")
+			},
+		},
+		{
+			name: "new generation",
+			beforeFunc: func(obj *helmv1.HelmRepository) {
+				obj.Generation = 2
+			},
+		},
+		{
+			name: "reconcile requested",
+			beforeFunc: func(obj *helmv1.HelmRepository) {
+				obj.SetAnnotations(map[string]string{meta.ReconcileRequestAnnotation: "now"})
+			},
+		},
+		{
+			name: "reconcile request handled",
+			beforeFunc: func(obj *helmv1.HelmRepository) {
+				obj.SetAnnotations(map[string]string{meta.ReconcileRequestAnnotation: "now"})
+				obj.Status.SetLastHandledReconcileRequest("now")
+			},
+			wantDue: true,
 		},
 	}
-	g.Expect(testEnv.CreateAndWait(ctx, secret)).To(Succeed())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
 
-	obj.Spec.Type = helmv1.HelmRepositoryTypeOCI
-	obj.Spec.URL = fmt.Sprintf("oci://%s", testRegistryServer.registryHost)
-	obj.Spec.SecretRef = &meta.LocalObjectReference{
-		Name: secret.Name,
-	}
+			obj := &helmv1.HelmRepository{
+				ObjectMeta: metav1.ObjectMeta{Generation: 1},
+				Status: helmv1.HelmRepositoryStatus{
+					ObservedGeneration: 1,
+					NextScheduledTime:  &metav1.Time{Time: time.Now().Add(time.Hour)},
+				},
+			}
+			conditions.MarkTrue(obj, meta.ReadyCondition, meta.SucceededReason, "ready")
+			if tt.beforeFunc != nil {
+				tt.beforeFunc(obj)
+			}
 
-	oldGen := obj.GetGeneration()
-	g.Expect(testEnv.Update(ctx, obj)).To(Succeed())
-	newGen := oldGen + 1
+			d := scheduledRunDueIn(obj)
+			if tt.wantDue {
+				g.Expect(d).To(BeNumerically(">", 59*time.Minute))
+				return
+			}
+			g.Expect(d).To(BeZero())
+		})
+	}
+}
 
-	// Wait for HelmRepository to be Ready with new generation.
-	g.Eventually(func() bool {
-		if err := testEnv.Get(ctx, key, obj); err != nil {
-			return false
-		}
-		if !conditions.IsReady(obj) && obj.Status.Artifact != nil {
-			return false
-		}
-		readyCondition := conditions.Get(obj, meta.ReadyCondition)
-		if readyCondition == nil {
-			return false
-		}
-		return readyCondition.Status == metav1.ConditionTrue &&
-			newGen == readyCondition.ObservedGeneration &&
-			newGen == obj.Status.ObservedGeneration
-	}, timeout).Should(BeTrue())
+func TestHelmRepositoryReconciler_recordFetchFailure(t *testing.T) {
+	g := NewWithT(t)
 
-	// Check if the object status is valid.
-	condns = &conditionscheck.Conditions{NegativePolarity: helmRepositoryOCINegativeConditions}
-	checker = conditionscheck.NewChecker(testEnv.Client, condns)
-	checker.WithT(g).CheckErr(ctx, obj)
+	r := &HelmRepositoryReconciler{HistoryLimit: 2}
+	obj := &helmv1.HelmRepository{}
 
-	g.Expect(testEnv.Delete(ctx, obj)).To(Succeed())
+	// Successful reconciliations are not recorded.
+	r.recordFetchFailure(obj, nil)
+	g.Expect(obj.Status.History).To(BeEmpty())
 
-	// Wait for HelmRepository to be deleted
-	g.Eventually(func() bool {
-		if err := testEnv.Get(ctx, key, obj); err != nil {
-			return apierrors.IsNotFound(err)
-		}
-		return false
-	}, timeout).Should(BeTrue())
+	for _, reason := range []string{"First", "Second", "Third"} {
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, reason, strings.Repeat("x", 300))
+		r.recordFetchFailure(obj, errors.New("failure"))
+	}
+	g.Expect(obj.Status.History).To(HaveLen(2))
+	g.Expect(obj.Status.History[0].Reason).To(Equal("Second"))
+	g.Expect(obj.Status.History[1].Reason).To(Equal("Third"))
+	g.Expect(obj.Status.History[1].Message).To(HaveLen(maxFetchFailureMessageLength))
+	g.Expect(obj.Status.History[1].Time.IsZero()).To(BeFalse())
+
+	// Failures without a FetchFailed condition are not recorded.
+	conditions.Delete(obj, sourcev1.FetchFailedCondition)
+	r.recordFetchFailure(obj, errors.New("failure"))
+	g.Expect(obj.Status.History).To(HaveLen(2))
+
+	// Disabling the history removes it.
+	r.HistoryLimit = 0
+	r.recordFetchFailure(obj, nil)
+	g.Expect(obj.Status.History).To(BeNil())
 }
 
-func TestHelmRepositoryReconciler_ReconcileSpecUpdatePredicateFilter(t *testing.T) {
+func TestHelmRepositoryReconciler_recordConsecutiveFailures(t *testing.T) {
 	g := NewWithT(t)
 
-	testServer, err := helmtestserver.NewTempHelmServer()
-	g.Expect(err).NotTo(HaveOccurred())
-	defer os.RemoveAll(testServer.Root())
+	r := &HelmRepositoryReconciler{}
+	obj := &helmv1.HelmRepository{}
 
-	g.Expect(testServer.PackageChart("testdata/charts/helmchart")).To(Succeed())
-	g.Expect(testServer.GenerateIndex()).To(Succeed())
+	r.recordConsecutiveFailures(obj, errors.New("failure"))
+	r.recordConsecutiveFailures(obj, errors.New("failure"))
+	g.Expect(obj.Status.ConsecutiveFailures).To(BeEquivalentTo(2))
 
-	testServer.Start()
-	defer testServer.Stop()
+	// Serving a stale artifact counts as a failure.
+	conditions.MarkTrue(obj, helmv1.StaleArtifactCondition, helmv1.ServingStaleArtifactReason, "stale")
+	r.recordConsecutiveFailures(obj, nil)
+	g.Expect(obj.Status.ConsecutiveFailures).To(BeEquivalentTo(3))
+
+	// The first success resets the count.
+	conditions.Delete(obj, helmv1.StaleArtifactCondition)
+	r.recordConsecutiveFailures(obj, nil)
+	g.Expect(obj.Status.ConsecutiveFailures).To(BeZero())
+}
+
+func TestHelmRepositoryReconciler_limitConditions(t *testing.T) {
+	g := NewWithT(t)
 
+	now := time.Now()
 	obj := &helmv1.HelmRepository{
-		ObjectMeta: metav1.ObjectMeta{
-			GenerateName: "helmrepository-reconcile-",
-			Namespace:    "default",
-		},
-		Spec: helmv1.HelmRepositorySpec{
-			Interval: metav1.Duration{Duration: interval},
-			URL:      testServer.URL(),
+		Status: helmv1.HelmRepositoryStatus{
+			Conditions: []metav1.Condition{
+				{Type: meta.ReadyCondition, Status: metav1.ConditionFalse, LastTransitionTime: metav1.NewTime(now.Add(-4 * time.Hour))},
+				{Type: sourcev1.FetchFailedCondition, Status: metav1.ConditionTrue, LastTransitionTime: metav1.NewTime(now.Add(-4 * time.Hour))},
+				{Type: helmv1.InsecureTLSCondition, Status: metav1.ConditionTrue, LastTransitionTime: metav1.NewTime(now.Add(-3 * time.Hour))},
+				{Type: helmv1.ChartsUnreachableCondition, Status: metav1.ConditionTrue, LastTransitionTime: metav1.NewTime(now.Add(-1 * time.Hour))},
+				{Type: helmv1.SuspiciousIndexChangeCondition, Status: metav1.ConditionTrue, LastTransitionTime: metav1.NewTime(now.Add(-2 * time.Hour))},
+				{Type: "Foreign", Status: metav1.ConditionTrue, LastTransitionTime: metav1.NewTime(now.Add(-5 * time.Hour))},
+			},
 		},
 	}
-	g.Expect(testEnv.CreateAndWait(ctx, obj)).To(Succeed())
 
-	key := client.ObjectKey{Name: obj.Name, Namespace: obj.Namespace}
+	// No limit retains all Conditions.
+	r := &HelmRepositoryReconciler{}
+	r.limitConditions(obj)
+	g.Expect(obj.Status.Conditions).To(HaveLen(6))
+
+	// The informational Conditions which transitioned the longest ago are
+	// removed first, while Conditions of other controllers are not touched.
+	r.ConditionsLimit = 3
+	r.limitConditions(obj)
+	g.Expect(conditions.Has(obj, helmv1.InsecureTLSCondition)).To(BeFalse())
+	g.Expect(conditions.Has(obj, helmv1.SuspiciousIndexChangeCondition)).To(BeFalse())
+	g.Expect(conditions.Has(obj, helmv1.ChartsUnreachableCondition)).To(BeTrue())
+	g.Expect(conditions.Has(obj, "Foreign")).To(BeTrue())
+
+	// The Ready Condition and the Conditions it is summarized from are
+	// always retained.
+	r.ConditionsLimit = 1
+	r.limitConditions(obj)
+	g.Expect(conditions.Has(obj, helmv1.ChartsUnreachableCondition)).To(BeFalse())
+	g.Expect(conditions.Has(obj, meta.ReadyCondition)).To(BeTrue())
+	g.Expect(conditions.Has(obj, sourcev1.FetchFailedCondition)).To(BeTrue())
+}
 
-	// Wait for finalizer to be set
-	g.Eventually(func() bool {
-		if err := testEnv.Get(ctx, key, obj); err != nil {
-			return false
+func TestHelmRepositoryReconciler_failureBackoff(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold int64
+		max       time.Duration
+		failures  int64
+		interval  time.Duration
+		want      time.Duration
+	}{
+		{name: "disabled", failures: 10, interval: time.Minute, want: time.Minute},
+		{name: "below threshold", threshold: 3, max: time.Hour, failures: 2, interval: time.Minute, want: time.Minute},
+		{name: "at threshold", threshold: 3, max: time.Hour, failures: 3, interval: time.Minute, want: 2 * time.Minute},
+		{name: "above threshold", threshold: 3, max: time.Hour, failures: 5, interval: time.Minute, want: 8 * time.Minute},
+		{name: "capped", threshold: 3, max: time.Hour, failures: 20, interval: time.Minute, want: time.Hour},
+		{name: "unbounded", threshold: 1, failures: 10, interval: time.Minute, want: 1024 * time.Minute},
+		{name: "interval above cap", threshold: 1, max: time.Hour, failures: 10, interval: 2 * time.Hour, want: 2 * time.Hour},
+		{name: "overflow", threshold: 1, failures: 1000, interval: time.Minute, want: time.Minute << 27},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			r := &HelmRepositoryReconciler{
+				FailureBackoffThreshold: tt.threshold,
+				FailureBackoffMax:       tt.max,
+			}
+			obj := &helmv1.HelmRepository{
+				Status: helmv1.HelmRepositoryStatus{ConsecutiveFailures: tt.failures},
+			}
+			g.Expect(r.failureBackoff(obj, tt.interval)).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestHelmRepositoryReconciler_serveStaleArtifact(t *testing.T) {
+	newObj := func() *helmv1.HelmRepository {
+		obj := &helmv1.HelmRepository{
+			Spec: helmv1.HelmRepositorySpec{
+				ServeStaleOnError: &helmv1.ServeStaleOnError{
+					MaxStaleness: metav1.Duration{Duration: time.Hour},
+				},
+			},
+			Status: helmv1.HelmRepositoryStatus{
+				Artifact: &sourcev1.Artifact{Revision: "sha256:foo"},
+			},
 		}
-		return len(obj.Finalizers) > 0
-	}, timeout).Should(BeTrue())
+		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, helmv1.IndexationFailedReason, "fetch error")
+		return obj
+	}
+	fetchErr := serror.NewGeneric(errors.New("fetch error"), helmv1.IndexationFailedReason)
 
-	// Wait for HelmRepository to be Ready
+	tests := []struct {
+		name             string
+		beforeFunc       func(obj *helmv1.HelmRepository)
+		err              error
+		want             bool
+		wantStaleSince   bool
+		assertConditions []metav1.Condition
+	}{
+		{
+			name:           "serves stale artifact",
+			err:            fetchErr,
+			want:           true,
+			wantStaleSince: true,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(helmv1.StaleArtifactCondition, helmv1.ServingStaleArtifactReason, "serving stale artifact for revision 'sha256:foo': fetch error"),
+			},
+		},
+		{
+			name: "max staleness exceeded",
+			beforeFunc: func(obj *helmv1.HelmRepository) {
+				obj.Status.StaleSince = &metav1.Time{Time: time.Now().Add(-2 * time.Hour)}
+			},
+			err:            fetchErr,
+			want:           false,
+			wantStaleSince: true,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.FetchFailedCondition, helmv1.IndexationFailedReason, "fetch error"),
+				*conditions.TrueCondition(helmv1.StaleArtifactCondition, helmv1.MaxStalenessExceededReason, "artifact has been stale for more than 1h0m0s: fetch error"),
+			},
+		},
+		{
+			name: "no artifact",
+			beforeFunc: func(obj *helmv1.HelmRepository) {
+				obj.Status.Artifact = nil
+			},
+			err:  fetchErr,
+			want: false,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.FetchFailedCondition, helmv1.IndexationFailedReason, "fetch error"),
+			},
+		},
+		{
+			name: "stalling error",
+			err:  serror.NewStalling(errors.New("fetch error"), helmv1.IndexationFailedReason),
+			want: false,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.FetchFailedCondition, helmv1.IndexationFailedReason, "fetch error"),
+			},
+		},
+		{
+			name: "disabled",
+			beforeFunc: func(obj *helmv1.HelmRepository) {
+				obj.Spec.ServeStaleOnError = nil
+				obj.Status.StaleSince = &metav1.Time{Time: time.Now()}
+				conditions.MarkTrue(obj, helmv1.StaleArtifactCondition, helmv1.ServingStaleArtifactReason, "stale")
+			},
+			err:  fetchErr,
+			want: false,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.FetchFailedCondition, helmv1.IndexationFailedReason, "fetch error"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			r := &HelmRepositoryReconciler{
+				EventRecorder: record.NewFakeRecorder(32),
+			}
+			obj := newObj()
+			if tt.beforeFunc != nil {
+				tt.beforeFunc(obj)
+			}
+
+			g.Expect(r.serveStaleArtifact(ctx, obj, tt.err)).To(Equal(tt.want))
+			g.Expect(obj.Status.StaleSince != nil).To(Equal(tt.wantStaleSince))
+			g.Expect(obj.Status.Conditions).To(conditions.MatchConditions(tt.assertConditions))
+		})
+	}
+}
+
+func TestHelmRepositoryReconciler_isSuspiciousIndexChange(t *testing.T) {
+	newChartRepo := func(charts int) *repository.ChartRepository {
+		i := repo.NewIndexFile()
+		for n := 0; n < charts; n++ {
+			name := fmt.Sprintf("chart-%d", n)
+			i.Entries[name] = repo.ChartVersions{
+				{Metadata: &chart.Metadata{Name: name, Version: "1.0.0"}},
+			}
+		}
+		chartRepo, err := repository.NewChartRepository("https://example.com", "", testGetters, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		chartRepo.Index = i
+		return chartRepo
+	}
+
+	tests := []struct {
+		name             string
+		annotation       string
+		curCount         *int64
+		newCount         int
+		want             bool
+		wantErr          string
+		assertConditions []metav1.Condition
+	}{
+		{
+			name:     "no annotation",
+			curCount: pointer.Int64(10),
+			newCount: 0,
+		},
+		{
+			name:       "no current chart count",
+			annotation: "0.5",
+			newCount:   0,
+		},
+		{
+			name:       "above ratio",
+			annotation: "0.5",
+			curCount:   pointer.Int64(10),
+			newCount:   5,
+		},
+		{
+			name:       "below ratio",
+			annotation: "0.5",
+			curCount:   pointer.Int64(10),
+			newCount:   4,
+			want:       true,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(helmv1.SuspiciousIndexChangeCondition, helmv1.ChartCountDecreasedReason,
+					"index with 4 charts not applied, as the current artifact has 10 charts (minimum ratio 0.5)"),
+			},
+		},
+		{
+			name:       "invalid annotation",
+			annotation: "2",
+			curCount:   pointer.Int64(10),
+			newCount:   10,
+			wantErr:    "invalid 'source.toolkit.fluxcd.io/min-chart-count-ratio' annotation value '2'",
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.FetchFailedCondition, helmv1.IndexationFailedReason,
+					"invalid 'source.toolkit.fluxcd.io/min-chart-count-ratio' annotation value '2': must be a number greater than 0 and at most 1"),
+				*conditions.TrueCondition(helmv1.SuspiciousIndexChangeCondition, helmv1.ChartCountDecreasedReason, "previous"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			r := &HelmRepositoryReconciler{
+				EventRecorder: record.NewFakeRecorder(32),
+			}
+			obj := &helmv1.HelmRepository{
+				Status: helmv1.HelmRepositoryStatus{
+					Artifact:   &sourcev1.Artifact{Revision: "sha256:foo"},
+					ChartCount: tt.curCount,
+				},
+			}
+			if tt.annotation != "" {
+				obj.SetAnnotations(map[string]string{helmv1.MinChartCountRatioAnnotation: tt.annotation})
+			}
+			conditions.MarkTrue(obj, helmv1.SuspiciousIndexChangeCondition, helmv1.ChartCountDecreasedReason, "previous")
+
+			got, err := r.isSuspiciousIndexChange(ctx, obj, newChartRepo(tt.newCount))
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+			g.Expect(got).To(Equal(tt.want))
+			g.Expect(obj.Status.Conditions).To(conditions.MatchConditions(tt.assertConditions))
+		})
+	}
+}
+
+func TestHelmRepositoryReconciler_canaryFetch(t *testing.T) {
+	tests := []struct {
+		name             string
+		flap             bool
+		artifact         *sourcev1.Artifact
+		want             bool
+		wantErr          bool
+		assertConditions []metav1.Condition
+	}{
+		{
+			name:     "consistent upstream",
+			artifact: &sourcev1.Artifact{Revision: "sha256:foo"},
+			want:     true,
+		},
+		{
+			name:     "inconsistent upstream keeps artifact",
+			flap:     true,
+			artifact: &sourcev1.Artifact{Revision: "sha256:foo"},
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(helmv1.UpstreamInconsistentCondition, helmv1.UpstreamInconsistentReason, "index not applied"),
+			},
+		},
+		{
+			name:    "inconsistent upstream without artifact",
+			flap:    true,
+			wantErr: true,
+			assertConditions: []metav1.Condition{
+				*conditions.TrueCondition(sourcev1.FetchFailedCondition, helmv1.UpstreamInconsistentReason, "index not applied"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			var requests int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				requests++
+				version := "1.0.0"
+				if tt.flap && requests > 1 {
+					version = "2.0.0"
+				}
+				_, _ = fmt.Fprintf(w, "apiVersion: v1\nentries:\n  foo:\n  - name: foo\n    version: %s\n", version)
+			}))
+			defer server.Close()
+
+			r := &HelmRepositoryReconciler{
+				EventRecorder: record.NewFakeRecorder(32),
+				Getters:       testGetters,
+			}
+			obj := &helmv1.HelmRepository{
+				Spec: helmv1.HelmRepositorySpec{
+					URL:         server.URL,
+					Timeout:     &metav1.Duration{Duration: timeout},
+					CanaryFetch: &helmv1.CanaryFetch{Delay: &metav1.Duration{Duration: time.Millisecond}},
+				},
+				Status: helmv1.HelmRepositoryStatus{
+					Artifact: tt.artifact,
+				},
+			}
+			if !tt.flap {
+				conditions.MarkTrue(obj, helmv1.UpstreamInconsistentCondition, helmv1.UpstreamInconsistentReason, "previous")
+			}
+
+			chartRepo, err := repository.NewChartRepository(server.URL, "", testGetters, nil)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(chartRepo.CacheIndex()).To(Succeed())
+			defer chartRepo.Clear()
+
+			got, err := r.canaryFetch(ctx, obj, &getter.ClientOpts{}, chartRepo)
+			g.Expect(err != nil).To(Equal(tt.wantErr))
+			g.Expect(got).To(Equal(tt.want))
+			g.Expect(requests).To(Equal(2))
+			g.Expect(obj.Status.Conditions).To(conditions.MatchConditions(tt.assertConditions))
+		})
+	}
+}
+
+func TestHelmRepositoryReconciler_reconcileSourceCanaryFetchSuspiciousIndexChange(t *testing.T) {
+	g := NewWithT(t)
+
+	var mu sync.Mutex
+	var requests int
+	flap := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+		version := "1.0.0"
+		if flap && requests%2 == 0 {
+			version = "2.0.0"
+		}
+		_, _ = fmt.Fprintf(w, "apiVersion: v1\nentries:\n  foo:\n  - name: foo\n    version: %s\n    urls:\n    - foo-%s.tgz\n", version, version)
+	}))
+	defer server.Close()
+
+	chartCount := int64(10)
+	obj := &helmv1.HelmRepository{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "canary-suspicious-",
+			Generation:   1,
+			Namespace:    "default",
+			Annotations: map[string]string{
+				helmv1.MinChartCountRatioAnnotation: "0.5",
+			},
+		},
+		Spec: helmv1.HelmRepositorySpec{
+			URL:         server.URL,
+			Interval:    metav1.Duration{Duration: interval},
+			Timeout:     &metav1.Duration{Duration: timeout},
+			CanaryFetch: &helmv1.CanaryFetch{Delay: &metav1.Duration{Duration: time.Millisecond}},
+		},
+		Status: helmv1.HelmRepositoryStatus{
+			Artifact:   &sourcev1.Artifact{Revision: "sha256:foo"},
+			ChartCount: &chartCount,
+		},
+	}
+	conditions.MarkTrue(obj, helmv1.SuspiciousIndexChangeCondition, helmv1.ChartCountDecreasedReason, "previous")
+
+	r := &HelmRepositoryReconciler{
+		EventRecorder: record.NewFakeRecorder(32),
+		Client: fakeclient.NewClientBuilder().
+			WithScheme(testEnv.GetScheme()).
+			WithStatusSubresource(&helmv1.HelmRepository{}).
+			WithObjects(obj).
+			Build(),
+		Storage:      testStorage,
+		Getters:      testGetters,
+		patchOptions: getPatchOptions(helmRepositoryReadyCondition.Owned, "sc"),
+	}
+	sp := patch.NewSerialPatcher(obj, r.Client)
+
+	reconcileSource := func() sourcev1.Artifact {
+		var chartRepo repository.ChartRepository
+		var artifact sourcev1.Artifact
+		got, err := r.reconcileSource(context.TODO(), sp, obj, &artifact, &chartRepo)
+		defer os.Remove(chartRepo.Path)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(got).To(Equal(sreconcile.ResultSuccess))
+		return artifact
+	}
+
+	// An inconsistent upstream is reported on its own Condition, without
+	// clearing the suspicious index change.
+	artifact := reconcileSource()
+	g.Expect(&artifact).To(BeEquivalentTo(obj.Status.Artifact))
+	g.Expect(obj.Status.Conditions).To(conditions.MatchConditions([]metav1.Condition{
+		*conditions.TrueCondition(helmv1.SuspiciousIndexChangeCondition, helmv1.ChartCountDecreasedReason, "previous"),
+		*conditions.TrueCondition(helmv1.UpstreamInconsistentCondition, helmv1.UpstreamInconsistentReason, "index not applied"),
+	}))
+	// The index of an inconsistent upstream is not observed.
+	g.Expect(obj.Status.ObservedURL).To(BeEmpty())
+	g.Expect(obj.Status.LastSuccessfulFetchTime).To(BeNil())
+
+	// Once the upstream is consistent, the index is still not applied as the
+	// number of charts dropped suspiciously.
+	mu.Lock()
+	flap = false
+	mu.Unlock()
+	artifact = reconcileSource()
+	g.Expect(&artifact).To(BeEquivalentTo(obj.Status.Artifact))
+	g.Expect(obj.Status.Conditions).To(conditions.MatchConditions([]metav1.Condition{
+		*conditions.TrueCondition(helmv1.SuspiciousIndexChangeCondition, helmv1.ChartCountDecreasedReason, "index with 1 charts not applied"),
+	}))
+	g.Expect(obj.Status.ObservedURL).ToNot(BeEmpty())
+	g.Expect(obj.Status.LastSuccessfulFetchTime).ToNot(BeNil())
+}
+
+func TestHelmRepositoryReconciler_requestsForSecretChange(t *testing.T) {
+	g := NewWithT(t)
+
+	newRepo := func(name, repoType string, secretRef, certSecretRef string) *helmv1.HelmRepository {
+		obj := &helmv1.HelmRepository{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+			},
+			Spec: helmv1.HelmRepositorySpec{
+				URL:  "https://example.com",
+				Type: repoType,
+			},
+		}
+		if secretRef != "" {
+			obj.Spec.SecretRef = &meta.LocalObjectReference{Name: secretRef}
+		}
+		if certSecretRef != "" {
+			obj.Spec.CertSecretRef = &meta.LocalObjectReference{Name: certSecretRef}
+		}
+		return obj
+	}
+	proxy := newRepo("proxy", "", "", "")
+	proxy.Spec.ProxySecretRef = &meta.LocalObjectReference{Name: "creds"}
+	headers := newRepo("headers", "", "", "")
+	headers.Spec.HeadersSecretRef = &meta.LocalObjectReference{Name: "creds"}
+
+	r := &HelmRepositoryReconciler{}
+	r.Client = fakeclient.NewClientBuilder().
+		WithScheme(testEnv.GetScheme()).
+		WithObjects(
+			proxy,
+			headers,
+			newRepo("auth", "", "creds", ""),
+			newRepo("tls", helmv1.HelmRepositoryTypeDefault, "", "creds"),
+			newRepo("other", "", "other-creds", ""),
+			newRepo("none", "", "", ""),
+			newRepo("oci", helmv1.HelmRepositoryTypeOCI, "creds", ""),
+		).
+		WithIndex(&helmv1.HelmRepository{}, helmv1.HelmRepositorySecretRefIndexKey, r.indexHelmRepositoryBySecretRef).
+		Build()
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "creds",
+			Namespace: "default",
+		},
+	}
+	reqs := r.requestsForSecretChange(context.TODO(), secret)
+	var names []string
+	for _, req := range reqs {
+		names = append(names, req.Name)
+	}
+	g.Expect(names).To(ConsistOf("auth", "tls", "proxy", "headers"))
+
+	secret.Namespace = "other"
+	g.Expect(r.requestsForSecretChange(context.TODO(), secret)).To(BeEmpty())
+}
+
+func TestHelmRepositoryReconciler_ReconcileTypeUpdatePredicateFilter(t *testing.T) {
+	g := NewWithT(t)
+
+	testServer, err := helmtestserver.NewTempHelmServer()
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(testServer.Root())
+
+	g.Expect(testServer.PackageChart("testdata/charts/helmchart")).To(Succeed())
+	g.Expect(testServer.GenerateIndex()).To(Succeed())
+
+	testServer.Start()
+	defer testServer.Stop()
+
+	obj := &helmv1.HelmRepository{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "helmrepository-reconcile-",
+			Namespace:    "default",
+		},
+		Spec: helmv1.HelmRepositorySpec{
+			Interval: metav1.Duration{Duration: interval},
+			URL:      testServer.URL(),
+		},
+	}
+	g.Expect(testEnv.CreateAndWait(ctx, obj)).To(Succeed())
+
+	key := client.ObjectKey{Name: obj.Name, Namespace: obj.Namespace}
+
+	// Wait for finalizer to be set
+	g.Eventually(func() bool {
+		if err := testEnv.Get(ctx, key, obj); err != nil {
+			return false
+		}
+		return len(obj.Finalizers) > 0
+	}, timeout).Should(BeTrue())
+
+	// Wait for HelmRepository to be Ready
 	g.Eventually(func() bool {
 		if err := testEnv.Get(ctx, key, obj); err != nil {
 			return false
@@ -1637,28 +2965,48 @@ func TestHelmRepositoryReconciler_ReconcileSpecUpdatePredicateFilter(t *testing.
 	g.Expect(err).ToNot(HaveOccurred())
 	g.Expect(res.Status).To(Equal(kstatus.CurrentStatus))
 
-	// Change spec Interval to validate spec update
-	obj.Spec.Interval = metav1.Duration{Duration: interval + time.Second}
+	// Switch to a OCI helm repository type
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "helmrepository-reconcile-",
+			Namespace:    "default",
+		},
+		Data: map[string][]byte{
+			"username": []byte(testRegistryUsername),
+			"password": []byte(testRegistryPassword),
+		},
+	}
+	g.Expect(testEnv.CreateAndWait(ctx, secret)).To(Succeed())
+
+	obj.Spec.Type = helmv1.HelmRepositoryTypeOCI
+	obj.Spec.URL = fmt.Sprintf("oci://%s", testRegistryServer.registryHost)
+	obj.Spec.SecretRef = &meta.LocalObjectReference{
+		Name: secret.Name,
+	}
+
 	oldGen := obj.GetGeneration()
 	g.Expect(testEnv.Update(ctx, obj)).To(Succeed())
 	newGen := oldGen + 1
 
-	// Wait for HelmRepository to be Ready
+	// Wait for HelmRepository to be Ready with new generation.
 	g.Eventually(func() bool {
 		if err := testEnv.Get(ctx, key, obj); err != nil {
 			return false
 		}
-		if !conditions.IsReady(obj) && obj.Status.Artifact == nil {
+		if !conditions.IsReady(obj) && obj.Status.Artifact != nil {
 			return false
 		}
 		readyCondition := conditions.Get(obj, meta.ReadyCondition)
+		if readyCondition == nil {
+			return false
+		}
 		return readyCondition.Status == metav1.ConditionTrue &&
 			newGen == readyCondition.ObservedGeneration &&
 			newGen == obj.Status.ObservedGeneration
 	}, timeout).Should(BeTrue())
 
 	// Check if the object status is valid.
-	condns = &conditionscheck.Conditions{NegativePolarity: helmRepositoryReadyCondition.NegativePolarity}
+	condns = &conditionscheck.Conditions{NegativePolarity: helmRepositoryOCINegativeConditions}
 	checker = conditionscheck.NewChecker(testEnv.Client, condns)
 	checker.WithT(g).CheckErr(ctx, obj)
 
@@ -1673,24 +3021,121 @@ func TestHelmRepositoryReconciler_ReconcileSpecUpdatePredicateFilter(t *testing.
 	}, timeout).Should(BeTrue())
 }
 
-func TestHelmRepositoryReconciler_InMemoryCaching(t *testing.T) {
+func TestHelmRepositoryReconciler_ReconcileSpecUpdatePredicateFilter(t *testing.T) {
 	g := NewWithT(t)
-	testCache.Clear()
 
 	testServer, err := helmtestserver.NewTempHelmServer()
 	g.Expect(err).NotTo(HaveOccurred())
 	defer os.RemoveAll(testServer.Root())
 
-	g.Expect(testServer.PackageChartWithVersion("testdata/charts/helmchart", "0.1.0")).To(Succeed())
+	g.Expect(testServer.PackageChart("testdata/charts/helmchart")).To(Succeed())
 	g.Expect(testServer.GenerateIndex()).To(Succeed())
 
 	testServer.Start()
 	defer testServer.Stop()
 
-	ns, err := testEnv.CreateNamespace(ctx, "helmrepository")
-	g.Expect(err).ToNot(HaveOccurred())
-	defer func() { g.Expect(testEnv.Delete(ctx, ns)).To(Succeed()) }()
-
+	obj := &helmv1.HelmRepository{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "helmrepository-reconcile-",
+			Namespace:    "default",
+		},
+		Spec: helmv1.HelmRepositorySpec{
+			Interval: metav1.Duration{Duration: interval},
+			URL:      testServer.URL(),
+		},
+	}
+	g.Expect(testEnv.CreateAndWait(ctx, obj)).To(Succeed())
+
+	key := client.ObjectKey{Name: obj.Name, Namespace: obj.Namespace}
+
+	// Wait for finalizer to be set
+	g.Eventually(func() bool {
+		if err := testEnv.Get(ctx, key, obj); err != nil {
+			return false
+		}
+		return len(obj.Finalizers) > 0
+	}, timeout).Should(BeTrue())
+
+	// Wait for HelmRepository to be Ready
+	g.Eventually(func() bool {
+		if err := testEnv.Get(ctx, key, obj); err != nil {
+			return false
+		}
+		if !conditions.IsReady(obj) && obj.Status.Artifact == nil {
+			return false
+		}
+		readyCondition := conditions.Get(obj, meta.ReadyCondition)
+		return readyCondition.Status == metav1.ConditionTrue &&
+			obj.Generation == readyCondition.ObservedGeneration &&
+			obj.Generation == obj.Status.ObservedGeneration
+	}, timeout).Should(BeTrue())
+
+	// Check if the object status is valid.
+	condns := &conditionscheck.Conditions{NegativePolarity: helmRepositoryReadyCondition.NegativePolarity}
+	checker := conditionscheck.NewChecker(testEnv.Client, condns)
+	checker.WithT(g).CheckErr(ctx, obj)
+
+	// kstatus client conformance check.
+	u, err := patch.ToUnstructured(obj)
+	g.Expect(err).ToNot(HaveOccurred())
+	res, err := kstatus.Compute(u)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(res.Status).To(Equal(kstatus.CurrentStatus))
+
+	// Change spec Interval to validate spec update
+	obj.Spec.Interval = metav1.Duration{Duration: interval + time.Second}
+	oldGen := obj.GetGeneration()
+	g.Expect(testEnv.Update(ctx, obj)).To(Succeed())
+	newGen := oldGen + 1
+
+	// Wait for HelmRepository to be Ready
+	g.Eventually(func() bool {
+		if err := testEnv.Get(ctx, key, obj); err != nil {
+			return false
+		}
+		if !conditions.IsReady(obj) && obj.Status.Artifact == nil {
+			return false
+		}
+		readyCondition := conditions.Get(obj, meta.ReadyCondition)
+		return readyCondition.Status == metav1.ConditionTrue &&
+			newGen == readyCondition.ObservedGeneration &&
+			newGen == obj.Status.ObservedGeneration
+	}, timeout).Should(BeTrue())
+
+	// Check if the object status is valid.
+	condns = &conditionscheck.Conditions{NegativePolarity: helmRepositoryReadyCondition.NegativePolarity}
+	checker = conditionscheck.NewChecker(testEnv.Client, condns)
+	checker.WithT(g).CheckErr(ctx, obj)
+
+	g.Expect(testEnv.Delete(ctx, obj)).To(Succeed())
+
+	// Wait for HelmRepository to be deleted
+	g.Eventually(func() bool {
+		if err := testEnv.Get(ctx, key, obj); err != nil {
+			return apierrors.IsNotFound(err)
+		}
+		return false
+	}, timeout).Should(BeTrue())
+}
+
+func TestHelmRepositoryReconciler_InMemoryCaching(t *testing.T) {
+	g := NewWithT(t)
+	testCache.Clear()
+
+	testServer, err := helmtestserver.NewTempHelmServer()
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(testServer.Root())
+
+	g.Expect(testServer.PackageChartWithVersion("testdata/charts/helmchart", "0.1.0")).To(Succeed())
+	g.Expect(testServer.GenerateIndex()).To(Succeed())
+
+	testServer.Start()
+	defer testServer.Stop()
+
+	ns, err := testEnv.CreateNamespace(ctx, "helmrepository")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer func() { g.Expect(testEnv.Delete(ctx, ns)).To(Succeed()) }()
+
 	helmRepo := &helmv1.HelmRepository{
 		ObjectMeta: metav1.ObjectMeta{
 			GenerateName: "helmrepository-",
@@ -1729,3 +3174,1043 @@ func TestHelmRepositoryReconciler_InMemoryCaching(t *testing.T) {
 	_, cacheHit := testCache.Get(helmRepo.GetArtifact().Path)
 	g.Expect(cacheHit).To(BeTrue())
 }
+
+func TestHelmRepositoryReconciler_requeueAfterRetryInterval(t *testing.T) {
+	tests := []struct {
+		name          string
+		retryInterval *metav1.Duration
+		err           error
+		stale         bool
+		want          time.Duration
+	}{
+		{name: "success", retryInterval: &metav1.Duration{Duration: time.Minute}, want: 5 * time.Minute},
+		{name: "failure", retryInterval: &metav1.Duration{Duration: time.Minute}, err: errors.New("failed"), want: time.Minute},
+		{name: "stale artifact", retryInterval: &metav1.Duration{Duration: time.Minute}, stale: true, want: time.Minute},
+		{name: "failure without retry interval", err: errors.New("failed"), want: 5 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			r := &HelmRepositoryReconciler{}
+			obj := &helmv1.HelmRepository{
+				ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+				Spec: helmv1.HelmRepositorySpec{
+					Interval:      metav1.Duration{Duration: 5 * time.Minute},
+					RetryInterval: tt.retryInterval,
+				},
+			}
+			if tt.stale {
+				conditions.MarkTrue(obj, helmv1.StaleArtifactCondition, helmv1.ServingStaleArtifactReason, "stale")
+			}
+			g.Expect(r.requeueAfter(obj, tt.err)).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestHelmRepositoryReconciler_retryWaiting(t *testing.T) {
+	tests := []struct {
+		name                string
+		retryInterval       *metav1.Duration
+		err                 error
+		consecutiveFailures int64
+		wantRetry           bool
+	}{
+		{
+			name:          "generic error with retry interval",
+			retryInterval: &metav1.Duration{Duration: time.Minute},
+			err:           serror.NewGeneric(errors.New("failed"), helmv1.IndexationFailedReason),
+			wantRetry:     true,
+		},
+		{
+			name: "generic error without retry interval",
+			err:  serror.NewGeneric(errors.New("failed"), helmv1.IndexationFailedReason),
+		},
+		{
+			name:                "generic error with failure backoff",
+			err:                 serror.NewGeneric(errors.New("failed"), helmv1.IndexationFailedReason),
+			consecutiveFailures: 3,
+			wantRetry:           true,
+		},
+		{
+			name:          "stalling error",
+			retryInterval: &metav1.Duration{Duration: time.Minute},
+			err:           serror.NewStalling(errors.New("invalid"), helmv1.URLInvalidReason),
+		},
+		{
+			name:          "ignored error",
+			retryInterval: &metav1.Duration{Duration: time.Minute},
+			err:           &serror.Generic{Err: errors.New("no-op"), Config: serror.Config{Ignore: true}},
+		},
+		{
+			name:          "no error",
+			retryInterval: &metav1.Duration{Duration: time.Minute},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			obj := &helmv1.HelmRepository{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       helmv1.HelmRepositoryKind,
+					APIVersion: helmv1.GroupVersion.String(),
+				},
+				ObjectMeta: metav1.ObjectMeta{Name: "helmrepo", Namespace: "foo"},
+				Spec: helmv1.HelmRepositorySpec{
+					Interval:      metav1.Duration{Duration: 5 * time.Minute},
+					RetryInterval: tt.retryInterval,
+				},
+				Status: helmv1.HelmRepositoryStatus{
+					ConsecutiveFailures: tt.consecutiveFailures,
+				},
+			}
+
+			r := &HelmRepositoryReconciler{FailureBackoffThreshold: 3}
+			w := r.retryWaiting(obj, tt.err, time.Minute)
+			if !tt.wantRetry {
+				g.Expect(w).To(BeNil())
+				return
+			}
+			g.Expect(w).ToNot(BeNil())
+			g.Expect(w.RequeueAfter).To(Equal(time.Minute))
+			g.Expect(w.Reason).To(Equal(helmv1.IndexationFailedReason))
+			g.Expect(w.Event).To(Equal(corev1.EventTypeWarning))
+			g.Expect(w.Log).To(BeTrue())
+
+			// The failure is recorded in the conditions of the object, but
+			// not returned to the runtime to requeue at the retry interval.
+			c := fakeclient.NewClientBuilder().
+				WithObjects(obj).
+				WithStatusSubresource(&helmv1.HelmRepository{}).
+				Build()
+			conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, helmv1.IndexationFailedReason, "failed")
+
+			summarizeHelper := summarize.NewHelper(record.NewFakeRecorder(32), patch.NewSerialPatcher(obj, c))
+			result, err := summarizeHelper.SummarizeAndPatch(context.TODO(), obj,
+				summarize.WithConditions(helmRepositoryReadyCondition),
+				summarize.WithReconcileResult(sreconcile.ResultEmpty),
+				summarize.WithReconcileError(w),
+				summarize.WithResultBuilder(retryResultBuilder{
+					AlwaysRequeueResultBuilder: sreconcile.AlwaysRequeueResultBuilder{RequeueAfter: time.Minute},
+					retrying:                   true,
+				}),
+				summarize.WithPatchFieldOwner("source-controller"),
+			)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(result.RequeueAfter).To(Equal(time.Minute))
+			g.Expect(conditions.IsFalse(obj, meta.ReadyCondition)).To(BeTrue())
+		})
+	}
+}
+
+func TestHelmRepositoryReconciler_recordUpstreamInterval(t *testing.T) {
+	tests := []struct {
+		name         string
+		fromUpstream bool
+		cacheControl string
+		want         time.Duration
+	}{
+		{name: "not deferred to upstream", cacheControl: "max-age=600", want: 5 * time.Minute},
+		{name: "max-age", fromUpstream: true, cacheControl: "max-age=600", want: 10 * time.Minute},
+		{name: "clamped to min", fromUpstream: true, cacheControl: "max-age=1", want: time.Minute},
+		{name: "clamped to max", fromUpstream: true, cacheControl: "max-age=86400", want: time.Hour},
+		{name: "no max-age", fromUpstream: true, cacheControl: "no-cache", want: 5 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Cache-Control", tt.cacheControl)
+				_, _ = w.Write([]byte("apiVersion: v1\nentries: {}\n"))
+			}))
+			defer server.Close()
+
+			chartRepo, err := repository.NewChartRepository(server.URL, "", testGetters, nil)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(chartRepo.CacheIndex()).To(Succeed())
+			defer os.Remove(chartRepo.Path)
+
+			r := &HelmRepositoryReconciler{
+				EventRecorder:           record.NewFakeRecorder(32),
+				CacheControlMinInterval: time.Minute,
+				CacheControlMaxInterval: time.Hour,
+			}
+			obj := &helmv1.HelmRepository{
+				ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+				Spec: helmv1.HelmRepositorySpec{
+					Interval:                 metav1.Duration{Duration: 5 * time.Minute},
+					IntervalFromCacheControl: tt.fromUpstream,
+				},
+			}
+
+			r.recordUpstreamInterval(context.TODO(), obj, chartRepo)
+			g.Expect(r.requeueAfter(obj, nil)).To(Equal(tt.want))
+			// The recorded interval only applies to a single reconciliation.
+			g.Expect(r.requeueAfter(obj, nil)).To(Equal(5 * time.Minute))
+		})
+	}
+}
+
+func Test_withDefaultSecretRef(t *testing.T) {
+	tests := []struct {
+		name          string
+		secretName    string
+		repoType      string
+		secretRef     string
+		wantSecretRef string
+	}{
+		{
+			name:          "falls back to default secret",
+			secretName:    "default-creds",
+			wantSecretRef: "default-creds",
+		},
+		{
+			name:          "explicit secretRef wins",
+			secretName:    "default-creds",
+			secretRef:     "creds",
+			wantSecretRef: "creds",
+		},
+		{
+			name:       "missing default secret",
+			secretName: "missing",
+		},
+		{
+			name: "fallback disabled",
+		},
+		{
+			name:       "OCI repository",
+			secretName: "default-creds",
+			repoType:   helmv1.HelmRepositoryTypeOCI,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			c := fakeclient.NewClientBuilder().
+				WithScheme(testEnv.GetScheme()).
+				WithObjects(&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "default-creds",
+						Namespace: "default",
+					},
+				}).
+				Build()
+
+			obj := &helmv1.HelmRepository{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "repo",
+					Namespace: "default",
+				},
+				Spec: helmv1.HelmRepositorySpec{
+					URL:  "https://example.com",
+					Type: tt.repoType,
+				},
+			}
+			if tt.secretRef != "" {
+				obj.Spec.SecretRef = &meta.LocalObjectReference{Name: tt.secretRef}
+			}
+			orig := obj.DeepCopy()
+
+			got, err := withDefaultSecretRef(context.TODO(), c, obj, tt.secretName)
+			g.Expect(err).ToNot(HaveOccurred())
+			if tt.wantSecretRef == "" {
+				g.Expect(got.Spec.SecretRef).To(BeNil())
+			} else {
+				g.Expect(got.Spec.SecretRef).To(Equal(&meta.LocalObjectReference{Name: tt.wantSecretRef}))
+			}
+			// The fallback is never persisted on the object.
+			g.Expect(obj).To(Equal(orig))
+		})
+	}
+}
+
+func Test_indexFormatChanged(t *testing.T) {
+	tests := []struct {
+		path   string
+		format string
+		want   bool
+	}{
+		{path: "helmrepository/default/foo/index-abc.yaml", format: helmv1.HelmRepositoryIndexFormatYAML, want: false},
+		{path: "helmrepository/default/foo/index-abc.yaml.zst", format: helmv1.HelmRepositoryIndexFormatYAML, want: false},
+		{path: "helmrepository/default/foo/index-abc.json", format: helmv1.HelmRepositoryIndexFormatYAML, want: true},
+		{path: "helmrepository/default/foo/index-abc.yaml", format: helmv1.HelmRepositoryIndexFormatJSON, want: true},
+		{path: "helmrepository/default/foo/index-abc.json.zst", format: helmv1.HelmRepositoryIndexFormatJSON, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path+" "+tt.format, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(indexFormatChanged(sourcev1.Artifact{Path: tt.path}, tt.format)).To(Equal(tt.want))
+		})
+	}
+}
+
+func Test_formatRevision(t *testing.T) {
+	rev := digest.Digest("sha256:6a33e4c2a3c5f2ff1a0e44e13b0f2c1a5d1d4aa1b2b2e4d2a0c3f6e9b1d5e7a9")
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{format: "", want: rev.String()},
+		{format: helmv1.HelmRepositoryRevisionFormatDigest, want: rev.String()},
+		{format: helmv1.HelmRepositoryRevisionFormatHex, want: rev.Encoded()},
+		{format: helmv1.HelmRepositoryRevisionFormatShort, want: "6a33e4c2a3c5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			g := NewWithT(t)
+
+			obj := &helmv1.HelmRepository{Spec: helmv1.HelmRepositorySpec{RevisionFormat: tt.format}}
+			artifact := sourcev1.Artifact{
+				Path:     fmt.Sprintf("helmrepository/default/foo/index-%s.yaml.zst", rev.Encoded()),
+				Revision: formatRevision(obj, rev),
+			}
+			g.Expect(artifact.Revision).To(Equal(tt.want))
+			g.Expect(artifactRevision(artifact)).To(Equal(rev))
+		})
+	}
+}
+
+func Test_sameRevision(t *testing.T) {
+	g := NewWithT(t)
+
+	obj := &helmv1.HelmRepository{Spec: helmv1.HelmRepositorySpec{
+		RevisionFormat: helmv1.HelmRepositoryRevisionFormatShort,
+	}}
+	cur := &sourcev1.Artifact{
+		Path:     "helmrepository/default/foo/index-6a33e4c2a3c5f2ff1a0e44e13b0f2c1a5d1d4aa1b2b2e4d2a0c3f6e9b1d5e7a9.yaml",
+		Revision: "6a33e4c2a3c5",
+	}
+	g.Expect(sameRevision(obj, cur, *cur)).To(BeTrue())
+	g.Expect(sameRevision(obj, nil, *cur)).To(BeFalse())
+
+	// Short revisions which are equal, while the full revisions differ.
+	collision := sourcev1.Artifact{
+		Path:     "helmrepository/default/foo/index-6a33e4c2a3c5000000000000000000000000000000000000000000000000000.yaml",
+		Revision: "6a33e4c2a3c5",
+	}
+	g.Expect(sameRevision(obj, cur, collision)).To(BeFalse())
+
+	// A revision in another format differs.
+	obj.Spec.RevisionFormat = ""
+	full := *cur
+	full.Revision = "sha256:6a33e4c2a3c5f2ff1a0e44e13b0f2c1a5d1d4aa1b2b2e4d2a0c3f6e9b1d5e7a9"
+	g.Expect(sameRevision(obj, cur, full)).To(BeFalse())
+}
+
+func TestHelmRepositoryReconciler_reconcileSourceTimeout(t *testing.T) {
+	g := NewWithT(t)
+
+	// The listener accepts connections but never responds, causing the TLS
+	// handshake to hang.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer l.Close()
+	go func() {
+		var conns []net.Conn
+		defer func() {
+			for _, conn := range conns {
+				conn.Close()
+			}
+		}()
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conns = append(conns, conn)
+		}
+	}()
+
+	obj := &helmv1.HelmRepository{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "timeout-",
+			Generation:   1,
+			Namespace:    "default",
+		},
+		Spec: helmv1.HelmRepositorySpec{
+			URL:     "https://" + l.Addr().String(),
+			Timeout: &metav1.Duration{Duration: 200 * time.Millisecond},
+		},
+	}
+
+	r := &HelmRepositoryReconciler{
+		EventRecorder: record.NewFakeRecorder(32),
+		Client: fakeclient.NewClientBuilder().
+			WithScheme(testEnv.GetScheme()).
+			WithStatusSubresource(&helmv1.HelmRepository{}).
+			WithObjects(obj).
+			Build(),
+		Storage:      testStorage,
+		Getters:      testGetters,
+		patchOptions: getPatchOptions(helmRepositoryReadyCondition.Owned, "sc"),
+	}
+
+	var chartRepo repository.ChartRepository
+	var artifact sourcev1.Artifact
+	sp := patch.NewSerialPatcher(obj, r.Client)
+
+	start := time.Now()
+	got, err := r.reconcileSource(context.TODO(), sp, obj, &artifact, &chartRepo)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(got).To(Equal(sreconcile.ResultEmpty))
+	g.Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+	g.Expect(conditions.GetReason(obj, sourcev1.FetchFailedCondition)).To(Equal(helmv1.NetworkErrorReason))
+}
+
+func TestHelmRepositoryReconciler_reconcileSourceWeakRevision(t *testing.T) {
+	g := NewWithT(t)
+
+	const indexFmt = `apiVersion: v1
+entries:
+  foo:
+  - apiVersion: v2
+    name: foo
+    version: 1.0.0
+    urls:
+    - https://example.com/foo-1.0.0.tgz
+generated: "%s"
+`
+	// The upstream only regenerated the timestamp of the index.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, indexFmt, "2023-02-01T00:00:00Z")
+	}))
+	defer server.Close()
+
+	// Calculate the weak revision of the index the current Artifact was
+	// produced from.
+	curIndex := filepath.Join(t.TempDir(), "index.yaml")
+	g.Expect(os.WriteFile(curIndex, []byte(fmt.Sprintf(indexFmt, "2023-01-01T00:00:00Z")), 0o640)).To(Succeed())
+	curRepo, err := repository.NewChartRepository(server.URL, curIndex, testGetters, nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(curRepo.LoadFromPath()).To(Succeed())
+	curRev := curRepo.WeakDigest(intdigest.Canonical)
+	g.Expect(curRev.Validate()).To(Succeed())
+	g.Expect(curRepo.Digest(intdigest.Canonical)).ToNot(Equal(curRev))
+
+	obj := &helmv1.HelmRepository{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "weak-revision-",
+			Generation:   1,
+			Namespace:    "default",
+		},
+		Spec: helmv1.HelmRepositorySpec{
+			URL:                server.URL,
+			Interval:           metav1.Duration{Duration: interval},
+			Timeout:            &metav1.Duration{Duration: timeout},
+			RevisionComparison: helmv1.HelmRepositoryRevisionComparisonWeak,
+		},
+		Status: helmv1.HelmRepositoryStatus{
+			Artifact: &sourcev1.Artifact{
+				Revision: curRev.String(),
+			},
+		},
+	}
+
+	r := &HelmRepositoryReconciler{
+		EventRecorder: record.NewFakeRecorder(32),
+		Client: fakeclient.NewClientBuilder().
+			WithScheme(testEnv.GetScheme()).
+			WithStatusSubresource(&helmv1.HelmRepository{}).
+			WithObjects(obj).
+			Build(),
+		Storage:      testStorage,
+		Getters:      testGetters,
+		patchOptions: getPatchOptions(helmRepositoryReadyCondition.Owned, "sc"),
+	}
+
+	var chartRepo repository.ChartRepository
+	var artifact sourcev1.Artifact
+	sp := patch.NewSerialPatcher(obj, r.Client)
+
+	got, err := r.reconcileSource(context.TODO(), sp, obj, &artifact, &chartRepo)
+	defer os.Remove(chartRepo.Path)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(got).To(Equal(sreconcile.ResultSuccess))
+
+	// The fetched index is loaded for the weak comparison, and the current
+	// Artifact is kept without the index being marked as a new revision.
+	g.Expect(chartRepo.Index).ToNot(BeNil())
+	g.Expect(obj.Status.LastFetchTime).ToNot(BeNil())
+	g.Expect(&artifact).To(BeEquivalentTo(obj.Status.Artifact))
+	g.Expect(conditions.Has(obj, sourcev1.ArtifactOutdatedCondition)).To(BeFalse())
+}
+
+func TestHelmRepositoryReconciler_reconcileSourceInsecureTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("apiVersion: v1\nentries: {}\n"))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name             string
+		insecure         bool
+		allowInsecureTLS bool
+		wantErr          bool
+		wantFetchReason  string
+		wantInsecureTLS  bool
+	}{
+		{
+			name:            "verifies the certificate by default",
+			wantErr:         true,
+			wantFetchReason: meta.FailedReason,
+		},
+		{
+			name:            "refuses to skip verification when not allowed",
+			insecure:        true,
+			wantErr:         true,
+			wantFetchReason: helmv1.InsecureSkipTLSVerifyReason,
+		},
+		{
+			name:             "skips verification when allowed",
+			insecure:         true,
+			allowInsecureTLS: true,
+			wantInsecureTLS:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			obj := &helmv1.HelmRepository{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       "insecure-tls",
+					Generation: 1,
+					Namespace:  "default",
+				},
+				Spec: helmv1.HelmRepositorySpec{
+					URL:                   server.URL,
+					Timeout:               &metav1.Duration{Duration: timeout},
+					InsecureSkipTLSVerify: tt.insecure,
+				},
+			}
+
+			r := &HelmRepositoryReconciler{
+				EventRecorder: record.NewFakeRecorder(32),
+				Client: fakeclient.NewClientBuilder().
+					WithScheme(testEnv.GetScheme()).
+					WithStatusSubresource(&helmv1.HelmRepository{}).
+					WithObjects(obj).
+					Build(),
+				Storage:          testStorage,
+				Getters:          testGetters,
+				AllowInsecureTLS: tt.allowInsecureTLS,
+				patchOptions:     getPatchOptions(helmRepositoryReadyCondition.Owned, "sc"),
+			}
+
+			var chartRepo repository.ChartRepository
+			var artifact sourcev1.Artifact
+			sp := patch.NewSerialPatcher(obj, r.Client)
+
+			_, err := r.reconcileSource(context.TODO(), sp, obj, &artifact, &chartRepo)
+			defer os.Remove(chartRepo.Path)
+			g.Expect(err != nil).To(Equal(tt.wantErr))
+			if tt.wantFetchReason != "" {
+				g.Expect(conditions.GetReason(obj, sourcev1.FetchFailedCondition)).To(Equal(tt.wantFetchReason))
+			}
+			g.Expect(conditions.IsTrue(obj, helmv1.InsecureTLSCondition)).To(Equal(tt.wantInsecureTLS))
+		})
+	}
+}
+
+func TestHelmRepositoryReconciler_validateChartNames(t *testing.T) {
+	tests := []struct {
+		name       string
+		strict     bool
+		wantErr    bool
+		wantCharts int
+	}{
+		{
+			name:       "lenient removes invalid charts",
+			wantCharts: 1,
+		},
+		{
+			name:       "strict fails the fetch",
+			strict:     true,
+			wantErr:    true,
+			wantCharts: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			obj := &helmv1.HelmRepository{
+				Spec: helmv1.HelmRepositorySpec{
+					ChartNameValidation: &helmv1.ChartNameValidation{
+						MaxLength: 10,
+						Strict:    tt.strict,
+					},
+				},
+			}
+			chartRepo, err := repository.NewChartRepository("https://example.com", "", testGetters, nil)
+			g.Expect(err).ToNot(HaveOccurred())
+			chartRepo.Index = repo.NewIndexFile()
+			chartRepo.Index.Entries["foo"] = repo.ChartVersions{{Metadata: &chart.Metadata{Name: "foo", Version: "1.0.0"}}}
+			chartRepo.Index.Entries["a-too-long-name"] = repo.ChartVersions{{Metadata: &chart.Metadata{Name: "a-too-long-name", Version: "1.0.0"}}}
+
+			r := &HelmRepositoryReconciler{
+				EventRecorder: record.NewFakeRecorder(32),
+			}
+			err = r.validateChartNames(context.TODO(), obj, chartRepo)
+			g.Expect(err != nil).To(Equal(tt.wantErr))
+			g.Expect(chartRepo.Index.Entries).To(HaveLen(tt.wantCharts))
+			if tt.wantErr {
+				g.Expect(conditions.GetReason(obj, sourcev1.FetchFailedCondition)).To(Equal(helmv1.InvalidChartNamesReason))
+				g.Expect(conditions.GetMessage(obj, sourcev1.FetchFailedCondition)).To(ContainSubstring(`"a-too-long-name"`))
+			}
+		})
+	}
+}
+
+func TestHelmRepositoryReconciler_loadIndex(t *testing.T) {
+	tests := []struct {
+		name        string
+		timeout     time.Duration
+		wantTimeout bool
+	}{
+		{name: "without parse timeout"},
+		{name: "within parse timeout", timeout: time.Minute},
+		{name: "exceeding parse timeout", timeout: time.Nanosecond, wantTimeout: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			indexPath := filepath.Join(t.TempDir(), "index.yaml")
+			g.Expect(os.WriteFile(indexPath, []byte("apiVersion: v1"), 0o600)).To(Succeed())
+			chartRepo, err := repository.NewChartRepository("https://example.com", indexPath, testGetters, nil)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			r := &HelmRepositoryReconciler{IndexParseTimeout: tt.timeout}
+			err = r.loadIndex(ctx, chartRepo)
+			if tt.wantTimeout {
+				g.Expect(errors.Is(err, repository.ErrParseTimeout)).To(BeTrue())
+				g.Expect(err.Error()).To(Equal("ParseTimeout: index could not be parsed within 1ns"))
+				g.Expect(chartRepo.Index).To(BeNil())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(chartRepo.Index).ToNot(BeNil())
+		})
+	}
+}
+
+func TestHelmRepositoryReconciler_mergeAdditionalIndexes(t *testing.T) {
+	tests := []struct {
+		name            string
+		sameOrigin      bool
+		passCredentials bool
+		wantAuth        bool
+	}{
+		{
+			name:       "credentials are sent to the same origin",
+			sameOrigin: true,
+			wantAuth:   true,
+		},
+		{
+			name:     "credentials are not sent to a different origin",
+			wantAuth: false,
+		},
+		{
+			name:            "credentials are sent to a different origin with passCredentials",
+			passCredentials: true,
+			wantAuth:        true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			var (
+				mu        sync.Mutex
+				requested bool
+				gotAuth   string
+				gotAPIKey string
+			)
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				requested = true
+				gotAuth, gotAPIKey = r.Header.Get("Authorization"), r.Header.Get("X-Api-Key")
+				mu.Unlock()
+				_, _ = w.Write([]byte(`apiVersion: v1
+entries:
+  other:
+  - name: other
+    version: 1.0.0
+    urls:
+    - other-1.0.0.tgz
+`))
+			})
+			primary := httptest.NewServer(handler)
+			defer primary.Close()
+			foreign := httptest.NewServer(handler)
+			defer foreign.Close()
+
+			additionalURL := foreign.URL
+			if tt.sameOrigin {
+				additionalURL = primary.URL + "/mirror"
+			}
+			obj := &helmv1.HelmRepository{
+				Spec: helmv1.HelmRepositorySpec{
+					URL:             primary.URL,
+					AdditionalURLs:  []string{additionalURL},
+					PassCredentials: tt.passCredentials,
+					Timeout:         &metav1.Duration{Duration: time.Minute},
+				},
+			}
+
+			signer, err := transport.NewSigV4Signer(credentials.NewStaticV4("AKID", "secret", ""), "us-east-1")
+			g.Expect(err).ToNot(HaveOccurred())
+			clientOpts := &getter.ClientOpts{
+				GetterOpts: []helmgetter.Option{
+					helmgetter.WithURL(primary.URL),
+					helmgetter.WithPassCredentialsAll(tt.passCredentials),
+					helmgetter.WithBasicAuth("user", "password"),
+				},
+				SigV4Signer: signer,
+			}
+			chartRepo, err := repository.NewChartRepository(primary.URL, "", testGetters, nil, clientOpts.GetterOpts...)
+			g.Expect(err).ToNot(HaveOccurred())
+			chartRepo.SigV4Signer = signer
+			chartRepo.Headers = http.Header{"X-Api-Key": {"token"}}
+			chartRepo.Index = repo.NewIndexFile()
+
+			r := &HelmRepositoryReconciler{
+				EventRecorder: record.NewFakeRecorder(32),
+				Getters:       testGetters,
+			}
+			g.Expect(r.mergeAdditionalIndexes(ctx, obj, clientOpts, chartRepo)).To(Succeed())
+			g.Expect(chartRepo.Index.Entries).To(HaveKey("other"))
+
+			mu.Lock()
+			defer mu.Unlock()
+			g.Expect(requested).To(BeTrue())
+			if tt.wantAuth {
+				g.Expect(gotAuth).ToNot(BeEmpty())
+				g.Expect(gotAPIKey).To(Equal("token"))
+			} else {
+				g.Expect(gotAuth).To(BeEmpty())
+				g.Expect(gotAPIKey).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestHelmRepositoryReconciler_evaluatePolicy(t *testing.T) {
+	tests := []struct {
+		name       string
+		key        string
+		policy     string
+		wantErr    bool
+		wantReason string
+		wantMsg    string
+	}{
+		{
+			name: "allows index",
+			policy: `package helmrepository
+deny["disallowed chart"] { input.entries["bar"] }`,
+		},
+		{
+			name: "denies index",
+			key:  "index.rego",
+			policy: `package helmrepository
+deny[msg] {
+	input.entries[name][_].maintainers[_].name == "untrusted"
+	msg := sprintf("chart %s has a disallowed maintainer", [name])
+}`,
+			wantErr:    true,
+			wantReason: helmv1.PolicyViolationReason,
+			wantMsg:    "chart foo has a disallowed maintainer",
+		},
+		{
+			name:       "invalid policy",
+			policy:     "package other",
+			wantErr:    true,
+			wantReason: meta.FailedReason,
+			wantMsg:    "must be declared in package 'helmrepository'",
+		},
+		{
+			name:       "missing key",
+			key:        "missing.rego",
+			wantErr:    true,
+			wantReason: meta.FailedReason,
+			wantMsg:    "has no key 'missing.rego'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			data := map[string]string{}
+			if tt.policy != "" {
+				key := tt.key
+				if key == "" {
+					key = helmv1.DefaultIndexPolicyKey
+				}
+				data[key] = tt.policy
+			}
+			r := &HelmRepositoryReconciler{
+				EventRecorder: record.NewFakeRecorder(32),
+				Client: fakeclient.NewClientBuilder().
+					WithScheme(testEnv.GetScheme()).
+					WithObjects(&corev1.ConfigMap{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      "policy",
+							Namespace: "default",
+						},
+						Data: data,
+					}).
+					Build(),
+			}
+
+			obj := &helmv1.HelmRepository{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "repo",
+					Namespace: "default",
+				},
+				Spec: helmv1.HelmRepositorySpec{
+					Policy: &helmv1.IndexPolicy{
+						ConfigMapRef: meta.LocalObjectReference{Name: "policy"},
+						Key:          tt.key,
+					},
+				},
+			}
+			chartRepo, err := repository.NewChartRepository("https://example.com", "", testGetters, nil)
+			g.Expect(err).ToNot(HaveOccurred())
+			chartRepo.Index = repo.NewIndexFile()
+			chartRepo.Index.Entries["foo"] = repo.ChartVersions{{Metadata: &chart.Metadata{
+				Name:        "foo",
+				Version:     "1.0.0",
+				Maintainers: []*chart.Maintainer{{Name: "untrusted"}},
+			}}}
+
+			err = r.evaluatePolicy(context.TODO(), obj, chartRepo)
+			g.Expect(err != nil).To(Equal(tt.wantErr))
+			if tt.wantErr {
+				g.Expect(conditions.GetReason(obj, sourcev1.FetchFailedCondition)).To(Equal(tt.wantReason))
+				g.Expect(conditions.GetMessage(obj, sourcev1.FetchFailedCondition)).To(ContainSubstring(tt.wantMsg))
+			}
+		})
+	}
+}
+
+func TestHelmRepositoryReconciler_logIndexDelta(t *testing.T) {
+	g := NewWithT(t)
+
+	obj := &helmv1.HelmRepository{
+		TypeMeta: metav1.TypeMeta{
+			Kind: helmv1.HelmRepositoryKind,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "index-delta",
+			Namespace: "default",
+		},
+	}
+
+	artifact := testStorage.NewArtifactFor(obj.Kind, obj, "existing", "index.yaml")
+	g.Expect(testStorage.MkdirAll(artifact)).To(Succeed())
+	defer testStorage.RemoveAll(artifact)
+	previous := `apiVersion: v1
+entries:
+  foo:
+  - name: foo
+    version: 1.0.0
+  - name: foo
+    version: 1.1.0
+  bar:
+  - name: bar
+    version: 0.1.0
+`
+	g.Expect(testStorage.AtomicWriteFile(&artifact, strings.NewReader(previous), 0o600)).To(Succeed())
+	obj.Status.Artifact = &artifact
+
+	chartRepo, err := repository.NewChartRepository("https://example.com", "", testGetters, nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	chartRepo.Index = repo.NewIndexFile()
+	for _, v := range []string{"1.1.0", "2.0.0", "2.1.0"} {
+		chartRepo.Index.Entries["foo"] = append(chartRepo.Index.Entries["foo"],
+			&repo.ChartVersion{Metadata: &chart.Metadata{Name: "foo", Version: v}})
+	}
+
+	recorder := record.NewFakeRecorder(32)
+	r := &HelmRepositoryReconciler{
+		EventRecorder:   recorder,
+		Storage:         testStorage,
+		IndexDeltaLimit: 1,
+	}
+	r.logIndexDelta(context.TODO(), obj, chartRepo)
+	g.Expect(recorder.Events).To(Receive(Equal(
+		"Trace IndexChanged index changed: added foo@2.0.0 (and 1 more); removed bar@0.1.0 (and 1 more)",
+	)))
+
+	// An unchanged index does not emit an event.
+	chartRepo.Index, err = repository.IndexFromBytes([]byte(previous))
+	g.Expect(err).ToNot(HaveOccurred())
+	r.logIndexDelta(context.TODO(), obj, chartRepo)
+	g.Expect(recorder.Events).ToNot(Receive())
+}
+
+func TestHelmRepositoryReconciler_checkCredentialTransport(t *testing.T) {
+	tests := []struct {
+		name                string
+		url                 string
+		denyHTTPCredentials bool
+		beforeFunc          func(obj *helmv1.HelmRepository)
+		wantErr             bool
+		wantEvent           bool
+	}{
+		{
+			name: "HTTPS",
+			url:  "https://example.com",
+		},
+		{
+			name:      "HTTP",
+			url:       "http://example.com",
+			wantEvent: true,
+		},
+		{
+			name:                "HTTP denied by the controller",
+			url:                 "http://example.com",
+			denyHTTPCredentials: true,
+			wantErr:             true,
+		},
+		{
+			name:                "HTTP denied by the controller with passCredentials",
+			url:                 "http://example.com",
+			denyHTTPCredentials: true,
+			beforeFunc: func(obj *helmv1.HelmRepository) {
+				obj.Spec.PassCredentials = true
+			},
+			wantEvent: true,
+		},
+		{
+			name:                "HTTP denied by the controller with insecureSkipTLSVerify",
+			url:                 "http://example.com",
+			denyHTTPCredentials: true,
+			beforeFunc: func(obj *helmv1.HelmRepository) {
+				obj.Spec.InsecureSkipTLSVerify = true
+			},
+			wantEvent: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			obj := &helmv1.HelmRepository{
+				Spec: helmv1.HelmRepositorySpec{
+					URL: tt.url,
+				},
+			}
+			if tt.beforeFunc != nil {
+				tt.beforeFunc(obj)
+			}
+
+			recorder := record.NewFakeRecorder(32)
+			r := &HelmRepositoryReconciler{
+				EventRecorder:       recorder,
+				DenyHTTPCredentials: tt.denyHTTPCredentials,
+			}
+			clientOpts := &getter.ClientOpts{
+				Headers: http.Header{"X-Api-Key": []string{"token"}},
+			}
+
+			err := r.checkCredentialTransport(context.TODO(), obj, clientOpts, tt.url)
+			if tt.wantErr {
+				var stallingErr *serror.Stalling
+				g.Expect(errors.As(err, &stallingErr)).To(BeTrue())
+				g.Expect(conditions.GetReason(obj, sourcev1.FetchFailedCondition)).To(Equal(helmv1.InsecureCredentialTransportReason))
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(conditions.Has(obj, sourcev1.FetchFailedCondition)).To(BeFalse())
+			}
+			if tt.wantEvent {
+				g.Expect(recorder.Events).To(Receive(ContainSubstring("Warning InsecureCredentialTransport")))
+			} else {
+				g.Expect(recorder.Events).ToNot(Receive())
+			}
+		})
+	}
+}
+
+func TestHelmRepositoryReconciler_checkChartURLs(t *testing.T) {
+	g := NewWithT(t)
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	obj := &helmv1.HelmRepository{
+		Spec: helmv1.HelmRepositorySpec{
+			Timeout:       &metav1.Duration{Duration: time.Minute},
+			ChartURLCheck: &helmv1.ChartURLCheck{SampleSize: 2, MaxUnreachable: 1},
+		},
+	}
+	chartRepo, err := repository.NewChartRepository(server.URL+"/", "", testGetters, nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	chartRepo.Index = repo.NewIndexFile()
+	for _, v := range []string{"1.0.0", "2.0.0"} {
+		chartRepo.Index.Entries["foo"] = append(chartRepo.Index.Entries["foo"], &repo.ChartVersion{
+			Metadata: &chart.Metadata{Name: "foo", Version: v},
+			URLs:     []string{"foo-" + v + ".tgz"},
+		})
+	}
+
+	recorder := record.NewFakeRecorder(32)
+	r := &HelmRepositoryReconciler{EventRecorder: recorder}
+	g.Expect(r.checkChartURLs(context.TODO(), obj, chartRepo)).To(Succeed())
+	g.Expect(obj.Status.Conditions).To(conditions.MatchConditions([]metav1.Condition{
+		*conditions.TrueCondition(helmv1.ChartsUnreachableCondition, helmv1.ChartURLsUnreachableReason,
+			"2 of 2 sampled chart versions have an unreachable URL: foo@1.0.0, foo@2.0.0"),
+	}))
+	g.Expect(recorder.Events).To(Receive(ContainSubstring("Warning ChartURLsUnreachable")))
+
+	// Tolerated unreachable URLs remove the Condition.
+	obj.Spec.ChartURLCheck.SampleSize = 1
+	g.Expect(r.checkChartURLs(context.TODO(), obj, chartRepo)).To(Succeed())
+	g.Expect(conditions.Has(obj, helmv1.ChartsUnreachableCondition)).To(BeFalse())
+}
+
+func TestHelmRepositoryReconciler_reconcileDelete(t *testing.T) {
+	for _, disableFinalizers := range []bool{false, true} {
+		t.Run(fmt.Sprintf("disableFinalizers=%t", disableFinalizers), func(t *testing.T) {
+			g := NewWithT(t)
+
+			now := metav1.Now()
+			obj := &helmv1.HelmRepository{
+				TypeMeta: metav1.TypeMeta{Kind: helmv1.HelmRepositoryKind},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "reconcile-delete",
+					Namespace:         "default",
+					DeletionTimestamp: &now,
+					Finalizers:        []string{sourcev1.SourceFinalizer, "example.com/external"},
+				},
+			}
+			artifact := testStorage.NewArtifactFor(obj.Kind, obj.GetObjectMeta(), "rev", "index.yaml")
+			g.Expect(testStorage.MkdirAll(artifact)).To(Succeed())
+			g.Expect(testStorage.AtomicWriteFile(&artifact, strings.NewReader("index"), 0o600)).To(Succeed())
+			obj.Status.Artifact = &artifact
+
+			r := &HelmRepositoryReconciler{
+				EventRecorder:     record.NewFakeRecorder(32),
+				Storage:           testStorage,
+				DisableFinalizers: disableFinalizers,
+			}
+			got, err := r.reconcileDelete(context.TODO(), obj)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got).To(Equal(sreconcile.ResultEmpty))
+
+			// Artifacts are garbage collected regardless of the finalizers.
+			g.Expect(testStorage.ArtifactExist(artifact)).To(BeFalse())
+			g.Expect(obj.Status.Artifact).To(BeNil())
+			if disableFinalizers {
+				g.Expect(obj.Finalizers).To(Equal([]string{sourcev1.SourceFinalizer, "example.com/external"}))
+			} else {
+				g.Expect(obj.Finalizers).To(Equal([]string{"example.com/external"}))
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/repo"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	helmv1 "github.com/fluxcd/source-controller/api/v1beta2"
+	"github.com/fluxcd/source-controller/internal/cache"
+)
+
+// HelmRepositoryIndexHandlerPath is the path prefix at which the
+// HelmRepositoryIndexHandler is served. The namespace and name of the
+// HelmRepository are appended to it, e.g.
+// /debug/helmrepository/index/<namespace>/<name>.
+const HelmRepositoryIndexHandlerPath = "/debug/helmrepository/index/"
+
+// HelmRepositoryIndexHandler is a http.Handler which serves the cached index
+// of a HelmRepository as JSON, for debugging purposes.
+//
+// The cache key is derived from the Artifact in the status of the requested
+// HelmRepository, in the same way as by the reconcilers. This ensures only
+// the index produced for that exact object can be served, and not the index
+// of an object in another namespace.
+type HelmRepositoryIndexHandler struct {
+	// Client is used to get the requested HelmRepository.
+	Client client.Reader
+	// Cache is the cache of Helm repository indexes.
+	Cache *cache.Cache
+	// Token is the bearer token requests must be authenticated with.
+	// Requests are always rejected if empty.
+	Token string
+}
+
+// ServeHTTP implements http.Handler.
+func (h *HelmRepositoryIndexHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.authenticated(req) {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(req.URL.Path, HelmRepositoryIndexHandlerPath), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		http.Error(w, "expected path in the format of <namespace>/<name>", http.StatusBadRequest)
+		return
+	}
+	if h.Cache == nil {
+		http.Error(w, "caching of Helm index files is disabled", http.StatusNotFound)
+		return
+	}
+
+	obj := &helmv1.HelmRepository{}
+	if err := h.Client.Get(req.Context(), types.NamespacedName{Namespace: parts[0], Name: parts[1]}, obj); err != nil {
+		if apierrors.IsNotFound(err) {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+		ctrl.LoggerFrom(req.Context()).Error(err, "failed to get HelmRepository to serve cached index")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	artifact := obj.GetArtifact()
+	if artifact == nil {
+		http.Error(w, "no artifact for HelmRepository", http.StatusNotFound)
+		return
+	}
+	cached, ok := h.Cache.Get(artifact.Path)
+	if !ok {
+		http.Error(w, "no cached index for HelmRepository", http.StatusNotFound)
+		return
+	}
+	index, ok := cached.(*repo.IndexFile)
+	if !ok {
+		http.Error(w, "unexpected cached index type", http.StatusInternalServerError)
+		return
+	}
+
+	b, err := json.Marshal(index)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
+
+// authenticated returns true if the request carries the configured bearer
+// token.
+func (h *HelmRepositoryIndexHandler) authenticated(req *http.Request) bool {
+	if h.Token == "" {
+		return false
+	}
+	token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(h.Token)) == 1
+}
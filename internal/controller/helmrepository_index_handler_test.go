@@ -0,0 +1,169 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"helm.sh/helm/v3/pkg/repo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	helmv1 "github.com/fluxcd/source-controller/api/v1beta2"
+	"github.com/fluxcd/source-controller/internal/cache"
+)
+
+func TestHelmRepositoryIndexHandler_ServeHTTP(t *testing.T) {
+	withArtifact := &helmv1.HelmRepository{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cached",
+			Namespace: "tenant-a",
+		},
+		Status: helmv1.HelmRepositoryStatus{
+			Artifact: &sourcev1.Artifact{
+				Path: "helmrepository/tenant-a/cached/index-abc.yaml",
+			},
+		},
+	}
+	withoutArtifact := &helmv1.HelmRepository{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "new",
+			Namespace: "tenant-b",
+		},
+	}
+	uncached := &helmv1.HelmRepository{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "uncached",
+			Namespace: "tenant-b",
+		},
+		Status: helmv1.HelmRepositoryStatus{
+			Artifact: &sourcev1.Artifact{
+				Path: "helmrepository/tenant-b/uncached/index-def.yaml",
+			},
+		},
+	}
+
+	indexCache := cache.New(5, time.Minute)
+	index := repo.NewIndexFile()
+	index.APIVersion = "v1"
+	g := NewWithT(t)
+	g.Expect(indexCache.Set(withArtifact.Status.Artifact.Path, index, time.Minute)).To(Succeed())
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		token      string
+		nilCache   bool
+		wantStatus int
+	}{
+		{
+			name:       "serves cached index",
+			path:       "tenant-a/cached",
+			token:      "secret",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "rejects missing token",
+			path:       "tenant-a/cached",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "rejects invalid token",
+			path:       "tenant-a/cached",
+			token:      "invalid",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "rejects non-GET method",
+			method:     http.MethodPost,
+			path:       "tenant-a/cached",
+			token:      "secret",
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:       "rejects invalid path",
+			path:       "tenant-a",
+			token:      "secret",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "object not found",
+			path:       "tenant-b/cached",
+			token:      "secret",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "object without artifact",
+			path:       "tenant-b/new",
+			token:      "secret",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "index not cached",
+			path:       "tenant-b/uncached",
+			token:      "secret",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "cache disabled",
+			path:       "tenant-a/cached",
+			token:      "secret",
+			nilCache:   true,
+			wantStatus: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			h := &HelmRepositoryIndexHandler{
+				Client: fakeclient.NewClientBuilder().
+					WithScheme(testEnv.GetScheme()).
+					WithObjects(withArtifact, withoutArtifact, uncached).
+					Build(),
+				Cache: indexCache,
+				Token: "secret",
+			}
+			if tt.nilCache {
+				h.Cache = nil
+			}
+
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, HelmRepositoryIndexHandlerPath+tt.path, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			g.Expect(rec.Code).To(Equal(tt.wantStatus))
+			if tt.wantStatus == http.StatusOK {
+				g.Expect(rec.Header().Get("Content-Type")).To(Equal("application/json"))
+				g.Expect(rec.Body.String()).To(ContainSubstring(`"apiVersion":"v1"`))
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+
+	"helm.sh/helm/v3/pkg/repo"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	helmv1 "github.com/fluxcd/source-controller/api/v1beta2"
+	"github.com/fluxcd/source-controller/internal/helm/repository"
+)
+
+// HelmRepositoryVirtualIndexHandlerPath is the path at which the
+// HelmRepositoryVirtualIndexHandler is served.
+const HelmRepositoryVirtualIndexHandlerPath = "/helmrepositories/index.yaml"
+
+// HelmRepositoryVirtualIndexHandler is a http.Handler which serves a single
+// index.yaml, merged from the stored indexes of the HelmRepositories the
+// caller is allowed to list.
+//
+// Requests are authenticated with a Kubernetes bearer token using a
+// TokenReview. The HelmRepositories of a namespace are only included if the
+// authenticated user is allowed to list HelmRepositories in that namespace,
+// as verified using a SubjectAccessReview. The namespaces can be restricted
+// with one or more "namespace" query parameters, and the objects with a
+// "labelSelector" query parameter.
+//
+// The indexes are merged in the order of the namespace and name of the
+// objects. Chart URLs are made absolute, and charts listed by multiple
+// repositories are resolved using repository.MergePolicyHighestVersionWins.
+type HelmRepositoryVirtualIndexHandler struct {
+	// Client is used to review the tokens and access of requests, and to
+	// list the HelmRepositories.
+	Client client.Client
+	// Storage is used to load the stored indexes.
+	Storage *Storage
+}
+
+// ServeHTTP implements http.Handler.
+func (h *HelmRepositoryVirtualIndexHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	log := ctrl.LoggerFrom(req.Context())
+	user, ok, err := reviewToken(req, h.Client)
+	if err != nil {
+		log.Error(err, "failed to review token to serve virtual index")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	selector := labels.Everything()
+	if s := req.URL.Query().Get("labelSelector"); s != "" {
+		if selector, err = labels.Parse(s); err != nil {
+			http.Error(w, "invalid label selector: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	var objs []helmv1.HelmRepository
+	namespaces := req.URL.Query()["namespace"]
+	if len(namespaces) == 0 {
+		// Consider the objects of all namespaces.
+		namespaces = []string{""}
+	}
+	for _, ns := range namespaces {
+		var list helmv1.HelmRepositoryList
+		if err := h.Client.List(req.Context(), &list, client.InNamespace(ns), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+			log.Error(err, "failed to list HelmRepositories to serve virtual index")
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		objs = append(objs, list.Items...)
+	}
+
+	sort.Slice(objs, func(i, j int) bool {
+		if objs[i].Namespace != objs[j].Namespace {
+			return objs[i].Namespace < objs[j].Namespace
+		}
+		return objs[i].Name < objs[j].Name
+	})
+
+	// Review the access per namespace, rather than per object.
+	allowed := make(map[string]bool)
+	var others []*repository.ChartRepository
+	for i, obj := range objs {
+		if i > 0 && obj.Namespace == objs[i-1].Namespace && obj.Name == objs[i-1].Name {
+			continue
+		}
+		if obj.Spec.Type == helmv1.HelmRepositoryTypeOCI || obj.GetArtifact() == nil {
+			continue
+		}
+		ok, reviewed := allowed[obj.Namespace]
+		if !reviewed {
+			ok, err = reviewAccess(req.Context(), h.Client, user, &authorizationv1.ResourceAttributes{
+				Namespace: obj.Namespace,
+				Verb:      "list",
+				Group:     sourcev1.GroupVersion.Group,
+				Resource:  "helmrepositories",
+			})
+			if err != nil {
+				log.Error(err, "failed to review access to serve virtual index")
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+			allowed[obj.Namespace] = ok
+		}
+		if !ok {
+			continue
+		}
+
+		// The index is loaded from storage rather than the cache, as merging
+		// modifies the chart URLs.
+		idx, err := repository.IndexFromFile(h.Storage.LocalPath(*obj.GetArtifact()))
+		if err != nil {
+			// The artifact may have been garbage collected since the object
+			// was listed, which should not fail the other indexes.
+			log.Error(err, "failed to load index to serve virtual index", "helmrepository", client.ObjectKeyFromObject(&obj))
+			continue
+		}
+		others = append(others, &repository.ChartRepository{URL: obj.Spec.URL, Index: idx})
+	}
+	index := &repository.ChartRepository{Index: repo.NewIndexFile(), RWMutex: &sync.RWMutex{}}
+	if err := index.MergeIndexes(repository.MergePolicyHighestVersionWins, others...); err != nil {
+		log.Error(err, "failed to merge indexes to serve virtual index")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	b, err := yaml.Marshal(index.Index)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-yaml")
+	_, _ = w.Write(b)
+}
@@ -0,0 +1,211 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"helm.sh/helm/v3/pkg/repo"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/yaml"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	helmv1 "github.com/fluxcd/source-controller/api/v1beta2"
+)
+
+func TestHelmRepositoryVirtualIndexHandler_ServeHTTP(t *testing.T) {
+	g := NewWithT(t)
+
+	newObj := func(namespace, name, index string) *helmv1.HelmRepository {
+		obj := &helmv1.HelmRepository{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+				Labels:    map[string]string{"team": namespace},
+			},
+			Spec: helmv1.HelmRepositorySpec{
+				URL: "https://" + name + ".example.com/charts",
+			},
+		}
+		if index != "" {
+			artifact := sourcev1.Artifact{
+				Path: "helmrepository/" + namespace + "/" + name + "/index.yaml",
+			}
+			g.Expect(testStorage.MkdirAll(artifact)).To(Succeed())
+			g.Expect(testStorage.AtomicWriteFile(&artifact, strings.NewReader(index), 0o600)).To(Succeed())
+			obj.Status.Artifact = &artifact
+		}
+		return obj
+	}
+	objs := []client.Object{
+		newObj("tenant-a", "foo", `apiVersion: v1
+entries:
+  foo:
+  - name: foo
+    version: 1.0.0
+    urls:
+    - foo-1.0.0.tgz
+`),
+		newObj("tenant-a", "new", ""),
+		newObj("tenant-b", "bar", `apiVersion: v1
+entries:
+  bar:
+  - name: bar
+    version: 2.0.0
+    urls:
+    - bar-2.0.0.tgz
+`),
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		query      string
+		token      string
+		wantStatus int
+		wantCharts map[string]string
+	}{
+		{
+			name:       "merges indexes of allowed namespaces",
+			token:      "alice",
+			wantStatus: http.StatusOK,
+			wantCharts: map[string]string{"foo": "https://foo.example.com/charts/foo-1.0.0.tgz"},
+		},
+		{
+			name:       "merges indexes of all namespaces",
+			token:      "admin",
+			wantStatus: http.StatusOK,
+			wantCharts: map[string]string{
+				"foo": "https://foo.example.com/charts/foo-1.0.0.tgz",
+				"bar": "https://bar.example.com/charts/bar-2.0.0.tgz",
+			},
+		},
+		{
+			name:       "restricts to namespace",
+			query:      "namespace=tenant-b",
+			token:      "admin",
+			wantStatus: http.StatusOK,
+			wantCharts: map[string]string{"bar": "https://bar.example.com/charts/bar-2.0.0.tgz"},
+		},
+		{
+			name:       "restricts to label selector",
+			query:      "labelSelector=team%3Dtenant-a",
+			token:      "admin",
+			wantStatus: http.StatusOK,
+			wantCharts: map[string]string{"foo": "https://foo.example.com/charts/foo-1.0.0.tgz"},
+		},
+		{
+			name:       "omits namespace not allowed",
+			query:      "namespace=tenant-b",
+			token:      "alice",
+			wantStatus: http.StatusOK,
+			wantCharts: map[string]string{},
+		},
+		{
+			name:       "rejects invalid label selector",
+			query:      "labelSelector=%21%21",
+			token:      "alice",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "rejects missing token",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "rejects unauthenticated token",
+			token:      "invalid",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "rejects non-GET method",
+			method:     http.MethodPost,
+			token:      "alice",
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			h := &HelmRepositoryVirtualIndexHandler{
+				Client: fakeclient.NewClientBuilder().
+					WithScheme(testEnv.GetScheme()).
+					WithObjects(objs...).
+					WithInterceptorFuncs(interceptor.Funcs{
+						Create: func(ctx context.Context, c client.WithWatch, o client.Object, opts ...client.CreateOption) error {
+							switch r := o.(type) {
+							case *authenticationv1.TokenReview:
+								// The token is the name of the user, if known.
+								if r.Spec.Token == "alice" || r.Spec.Token == "admin" {
+									r.Status.Authenticated = true
+									r.Status.User.Username = r.Spec.Token
+								}
+							case *authorizationv1.SubjectAccessReview:
+								a := r.Spec.ResourceAttributes
+								r.Status.Allowed = (r.Spec.User == "admin" || a.Namespace == "tenant-a") &&
+									a.Verb == "list" && a.Resource == "helmrepositories"
+							default:
+								return c.Create(ctx, o, opts...)
+							}
+							return nil
+						},
+					}).
+					Build(),
+				Storage: testStorage,
+			}
+
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			target := HelmRepositoryVirtualIndexHandlerPath
+			if tt.query != "" {
+				target += "?" + tt.query
+			}
+			req := httptest.NewRequest(method, target, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			g.Expect(rec.Code).To(Equal(tt.wantStatus))
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			g.Expect(rec.Header().Get("Content-Type")).To(Equal("application/x-yaml"))
+
+			index := &repo.IndexFile{}
+			g.Expect(yaml.Unmarshal(rec.Body.Bytes(), index)).To(Succeed())
+			g.Expect(index.Entries).To(HaveLen(len(tt.wantCharts)))
+			for name, url := range tt.wantCharts {
+				g.Expect(index.Entries).To(HaveKey(name))
+				g.Expect(index.Entries[name][0].URLs).To(Equal([]string{url}))
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// HostAllowlistKey is the key of the ConfigMap data holding the allowed
+// host patterns, one per line.
+const HostAllowlistKey = "hosts"
+
+// HostAllowlist restricts the hosts objects may fetch from to the patterns
+// configured in a ConfigMap. The ConfigMap is read on every check, so that
+// changes take effect without a restart of the controller, while the patterns
+// are only parsed again when its resource version changes.
+//
+// Patterns are matched against the lowercase hostname of a URL (without
+// port) using path.Match, e.g. "charts.example.com" or "*.example.com".
+// Empty lines and lines starting with "#" are ignored.
+type HostAllowlist struct {
+	// Reader is used to get the ConfigMap.
+	Reader client.Reader
+	// ConfigMap is the namespace and name of the ConfigMap.
+	ConfigMap types.NamespacedName
+
+	resourceVersion string
+	patterns        []string
+	mu              sync.Mutex
+}
+
+// Allowed returns true if the given hostname matches a pattern of the
+// allowlist. A nil HostAllowlist allows any host.
+func (a *HostAllowlist) Allowed(ctx context.Context, hostname string) (bool, error) {
+	if a == nil {
+		return true, nil
+	}
+	patterns, err := a.load(ctx)
+	if err != nil {
+		return false, err
+	}
+	hostname = strings.ToLower(hostname)
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, hostname); ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// load returns the patterns of the ConfigMap, parsing them again if the
+// ConfigMap changed since the last call.
+func (a *HostAllowlist) load(ctx context.Context) ([]string, error) {
+	var cm corev1.ConfigMap
+	if err := a.Reader.Get(ctx, a.ConfigMap, &cm); err != nil {
+		return nil, fmt.Errorf("failed to get allowed hosts ConfigMap '%s': %w", a.ConfigMap, err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if cm.ResourceVersion != "" && cm.ResourceVersion == a.resourceVersion {
+		return a.patterns, nil
+	}
+	patterns, err := parseHostPatterns(cm.Data[HostAllowlistKey])
+	if err != nil {
+		return nil, fmt.Errorf("invalid allowed hosts ConfigMap '%s': %w", a.ConfigMap, err)
+	}
+	a.resourceVersion, a.patterns = cm.ResourceVersion, patterns
+	return patterns, nil
+}
+
+// parseHostPatterns returns the lowercase host patterns of the given lines.
+func parseHostPatterns(s string) ([]string, error) {
+	var patterns []string
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p := strings.ToLower(line)
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("invalid host pattern '%s': %w", line, err)
+		}
+		patterns = append(patterns, p)
+	}
+	return patterns, nil
+}
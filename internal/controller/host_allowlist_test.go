@@ -0,0 +1,125 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/fluxcd/pkg/runtime/conditions"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	helmv1 "github.com/fluxcd/source-controller/api/v1beta2"
+	serror "github.com/fluxcd/source-controller/internal/error"
+)
+
+func TestHostAllowlist_Allowed(t *testing.T) {
+	g := NewWithT(t)
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "flux-system", Name: "allowed-hosts"},
+		Data: map[string]string{
+			HostAllowlistKey: "# Public charts\ncharts.example.com\n\n*.Internal.example.com\n",
+		},
+	}
+	c := fakeclient.NewClientBuilder().WithObjects(cm).Build()
+	a := &HostAllowlist{
+		Reader:    c,
+		ConfigMap: types.NamespacedName{Namespace: "flux-system", Name: "allowed-hosts"},
+	}
+
+	for host, want := range map[string]bool{
+		"charts.example.com":       true,
+		"CHARTS.example.com":       true,
+		"a.internal.example.com":   true,
+		"internal.example.com":     false,
+		"charts.example.com.evil":  false,
+		"other.example.com":        false,
+		"a.internal.example.com.x": false,
+	} {
+		got, err := a.Allowed(context.TODO(), host)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(got).To(Equal(want), host)
+	}
+
+	// Changes to the ConfigMap take effect on the next check.
+	cm.Data[HostAllowlistKey] = "other.example.com"
+	g.Expect(c.Update(context.TODO(), cm)).To(Succeed())
+	got, err := a.Allowed(context.TODO(), "other.example.com")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(got).To(BeTrue())
+	got, err = a.Allowed(context.TODO(), "charts.example.com")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(got).To(BeFalse())
+
+	// Invalid patterns and a missing ConfigMap return an error.
+	cm.Data[HostAllowlistKey] = "[invalid"
+	g.Expect(c.Update(context.TODO(), cm)).To(Succeed())
+	_, err = a.Allowed(context.TODO(), "other.example.com")
+	g.Expect(err).To(HaveOccurred())
+
+	g.Expect(c.Delete(context.TODO(), cm)).To(Succeed())
+	_, err = a.Allowed(context.TODO(), "other.example.com")
+	g.Expect(err).To(HaveOccurred())
+
+	// A nil allowlist allows any host.
+	var nilAllowlist *HostAllowlist
+	got, err = nilAllowlist.Allowed(context.TODO(), "other.example.com")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(got).To(BeTrue())
+}
+
+func TestHelmRepositoryReconciler_checkAllowedHosts(t *testing.T) {
+	g := NewWithT(t)
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "flux-system", Name: "allowed-hosts"},
+		Data:       map[string]string{HostAllowlistKey: "charts.example.com"},
+	}
+	r := &HelmRepositoryReconciler{
+		EventRecorder: record.NewFakeRecorder(32),
+		HostAllowlist: &HostAllowlist{
+			Reader:    fakeclient.NewClientBuilder().WithObjects(cm).Build(),
+			ConfigMap: types.NamespacedName{Namespace: "flux-system", Name: "allowed-hosts"},
+		},
+	}
+
+	obj := &helmv1.HelmRepository{
+		Spec: helmv1.HelmRepositorySpec{
+			URL: "https://charts.example.com:8443/stable",
+		},
+	}
+	g.Expect(r.checkAllowedHosts(context.TODO(), obj)).To(Succeed())
+	g.Expect(conditions.Has(obj, sourcev1.FetchFailedCondition)).To(BeFalse())
+
+	obj.Spec.AdditionalURLs = []string{"https://mirror.example.com"}
+	err := r.checkAllowedHosts(context.TODO(), obj)
+	var stallingErr *serror.Stalling
+	g.Expect(errors.As(err, &stallingErr)).To(BeTrue())
+	g.Expect(stallingErr.Reason).To(Equal(helmv1.HostNotAllowedReason))
+	g.Expect(obj.Status.Conditions).To(conditions.MatchConditions([]metav1.Condition{
+		*conditions.TrueCondition(sourcev1.FetchFailedCondition, helmv1.HostNotAllowedReason, "host 'mirror.example.com'"),
+	}))
+}
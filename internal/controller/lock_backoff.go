@@ -0,0 +1,64 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// lockBackoff tracks the consecutive failures to acquire the storage lock
+// per object, to calculate a bounded exponential backoff after which the
+// object is requeued. The zero value is ready to use.
+type lockBackoff struct {
+	failures map[types.NamespacedName]int
+	mu       sync.Mutex
+}
+
+// next records a failure to acquire the lock for the given object, and
+// returns the backoff duration and the number of consecutive failures.
+// The duration doubles with every failure starting from base, and is capped
+// at max if max is set.
+func (b *lockBackoff) next(key types.NamespacedName, base, max time.Duration) (time.Duration, int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures == nil {
+		b.failures = make(map[types.NamespacedName]int)
+	}
+	b.failures[key]++
+	n := b.failures[key]
+
+	d := base
+	for i := 1; i < n && (max <= 0 || d < max) && d <= math.MaxInt64/2; i++ {
+		d *= 2
+	}
+	if max > 0 && d > max {
+		d = max
+	}
+	return d, n
+}
+
+// reset forgets the failures to acquire the lock for the given object.
+func (b *lockBackoff) reset(key types.NamespacedName) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.failures, key)
+}
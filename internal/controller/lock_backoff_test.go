@@ -0,0 +1,60 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func Test_lockBackoff(t *testing.T) {
+	g := NewWithT(t)
+
+	var b lockBackoff
+	key := types.NamespacedName{Namespace: "default", Name: "foo"}
+	other := types.NamespacedName{Namespace: "default", Name: "bar"}
+
+	var got []time.Duration
+	for i := 0; i < 5; i++ {
+		d, n := b.next(key, time.Second, 5*time.Second)
+		g.Expect(n).To(Equal(i + 1))
+		got = append(got, d)
+	}
+	g.Expect(got).To(Equal([]time.Duration{
+		time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second,
+	}))
+
+	// Failures are tracked per object.
+	d, n := b.next(other, time.Second, 5*time.Second)
+	g.Expect(d).To(Equal(time.Second))
+	g.Expect(n).To(Equal(1))
+
+	// A reset starts from the base again.
+	b.reset(key)
+	d, n = b.next(key, time.Second, 5*time.Second)
+	g.Expect(d).To(Equal(time.Second))
+	g.Expect(n).To(Equal(1))
+
+	// Without max, the backoff is not capped.
+	for i := 0; i < 10; i++ {
+		d, _ = b.next(other, time.Second, 0)
+	}
+	g.Expect(d).To(Equal(1024 * time.Second))
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/reference"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/fluxcd/pkg/apis/meta"
+	"github.com/fluxcd/pkg/runtime/conditions"
+	helper "github.com/fluxcd/pkg/runtime/controller"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	serror "github.com/fluxcd/source-controller/internal/error"
+	smetrics "github.com/fluxcd/source-controller/internal/metrics"
+)
+
+// artifactSource is a source object with conditions and an Artifact.
+type artifactSource interface {
+	conditions.Getter
+	GetArtifact() *sourcev1.Artifact
+}
+
+// recordLastSuccess records the time of the last successful reconciliation
+// of the object with the given smetrics.Recorder. The current time is
+// recorded if the object is Ready. If the object is not Ready and no success
+// has been recorded since the controller started, the last update time of the
+// Artifact is recorded instead. The metric is deleted if the object is being
+// deleted, along with the artifact write metrics of the object.
+func recordLastSuccess(ctx context.Context, m helper.Metrics, rec *smetrics.Recorder, obj artifactSource) {
+	if rec == nil {
+		return
+	}
+
+	ref, err := reference.GetReference(m.Scheme, obj)
+	if err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "unable to get object reference to record last success")
+		return
+	}
+
+	if m.IsDelete(obj) {
+		rec.DeleteLastSuccess(*ref)
+		rec.DeleteArtifactWrite(*ref)
+		return
+	}
+
+	if conditions.IsReady(obj) {
+		rec.RecordLastSuccess(*ref, time.Now())
+		return
+	}
+	if artifact := obj.GetArtifact(); artifact != nil && !rec.HasLastSuccess(*ref) {
+		rec.RecordLastSuccess(*ref, artifact.LastUpdateTime.Time)
+	}
+}
+
+// recordArtifactWrite records the duration of writing the given Artifact of
+// the object to storage since the given start time, and the size of the
+// Artifact, with the given smetrics.Recorder.
+func recordArtifactWrite(ctx context.Context, m helper.Metrics, rec *smetrics.Recorder, obj runtime.Object, artifact sourcev1.Artifact, start time.Time) {
+	if rec == nil {
+		return
+	}
+
+	ref, err := reference.GetReference(m.Scheme, obj)
+	if err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "unable to get object reference to record artifact write")
+		return
+	}
+	var size int64
+	if artifact.Size != nil {
+		size = *artifact.Size
+	}
+	rec.RecordArtifactWrite(*ref, time.Since(start), size)
+}
+
+// recordFailure records the failure of a sub-reconciler of an object of the
+// given kind with the given smetrics.Recorder, by the reason of the error.
+func recordFailure(rec *smetrics.Recorder, kind string, err error) {
+	if rec == nil || err == nil {
+		return
+	}
+	rec.RecordFailure(kind, failureReason(err))
+}
+
+// failureReason returns the reason of the given serror.Stalling,
+// serror.Waiting or serror.Generic error, or meta.FailedReason for any other
+// error.
+func failureReason(err error) string {
+	var stalling *serror.Stalling
+	if errors.As(err, &stalling) {
+		return stalling.Reason
+	}
+	var waiting *serror.Waiting
+	if errors.As(err, &waiting) {
+		return waiting.Reason
+	}
+	var generic *serror.Generic
+	if errors.As(err, &generic) {
+		return generic.Reason
+	}
+	return meta.FailedReason
+}
@@ -0,0 +1,41 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/fluxcd/pkg/apis/meta"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	serror "github.com/fluxcd/source-controller/internal/error"
+)
+
+func Test_failureReason(t *testing.T) {
+	g := NewWithT(t)
+
+	err := errors.New("failure")
+	g.Expect(failureReason(serror.NewGeneric(err, sourcev1.AuthenticationFailedReason))).To(Equal(sourcev1.AuthenticationFailedReason))
+	g.Expect(failureReason(serror.NewStalling(err, sourcev1.URLInvalidReason))).To(Equal(sourcev1.URLInvalidReason))
+	g.Expect(failureReason(serror.NewWaiting(err, sourcev1.AcquireLockFailedReason))).To(Equal(sourcev1.AcquireLockFailedReason))
+	g.Expect(failureReason(fmt.Errorf("wrapped: %w", serror.NewGeneric(err, "Custom")))).To(Equal("Custom"))
+	g.Expect(failureReason(err)).To(Equal(meta.FailedReason))
+}
@@ -67,6 +67,7 @@ import (
 	sourcev1 "github.com/fluxcd/source-controller/api/v1"
 	ociv1 "github.com/fluxcd/source-controller/api/v1beta2"
 	serror "github.com/fluxcd/source-controller/internal/error"
+	smetrics "github.com/fluxcd/source-controller/internal/metrics"
 	soci "github.com/fluxcd/source-controller/internal/oci"
 	sreconcile "github.com/fluxcd/source-controller/internal/reconcile"
 	"github.com/fluxcd/source-controller/internal/reconcile/summarize"
@@ -130,17 +131,25 @@ type OCIRepositoryReconciler struct {
 	client.Client
 	helper.Metrics
 	kuberecorder.EventRecorder
+	SourceMetrics *smetrics.Recorder
 
 	Storage           *Storage
 	ControllerName    string
 	requeueDependency time.Duration
 
+	// DisableFinalizers skips adding and removing the source finalizer, for
+	// the finalizers of objects to be managed externally.
+	DisableFinalizers bool
+
 	patchOptions []patch.Option
 }
 
 type OCIRepositoryReconcilerOptions struct {
 	DependencyRequeueInterval time.Duration
 	RateLimiter               ratelimiter.RateLimiter
+	// ReconcileTrigger enqueues the reconciles triggered through the
+	// ReconcileTriggerHandler, if not nil.
+	ReconcileTrigger *ReconcileTriggerHandler
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -152,15 +161,19 @@ func (r *OCIRepositoryReconciler) SetupWithManagerAndOptions(mgr ctrl.Manager, o
 	r.patchOptions = getPatchOptions(ociRepositoryReadyCondition.Owned, r.ControllerName)
 
 	r.requeueDependency = opts.DependencyRequeueInterval
+	queue := r.SourceMetrics.QueueTracker(ociv1.OCIRepositoryKind)
 
-	return ctrl.NewControllerManagedBy(mgr).
+	b := ctrl.NewControllerManagedBy(mgr).
 		For(&ociv1.OCIRepository{}, builder.WithPredicates(
 			predicate.Or(predicate.GenerationChangedPredicate{}, predicates.ReconcileRequestedPredicate{}),
-		)).
+			queue.Predicate(),
+		))
+
+	return watchReconcileTrigger(b, opts.ReconcileTrigger, "ocirepositories", queue.Predicate()).
 		WithOptions(controller.Options{
-			RateLimiter: opts.RateLimiter,
+			RateLimiter: queue.RateLimiter(opts.RateLimiter),
 		}).
-		Complete(r)
+		Complete(queue.Reconciler(r))
 }
 
 // +kubebuilder:rbac:groups=source.toolkit.fluxcd.io,resources=ocirepositories,verbs=get;list;watch;create;update;patch;delete
@@ -170,6 +183,7 @@ func (r *OCIRepositoryReconciler) SetupWithManagerAndOptions(mgr ctrl.Manager, o
 
 func (r *OCIRepositoryReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, retErr error) {
 	start := time.Now()
+	defer r.SourceMetrics.RecordInFlight(ociv1.OCIRepositoryKind)()
 	log := ctrl.LoggerFrom(ctx)
 
 	// Fetch the OCIRepository
@@ -209,6 +223,7 @@ func (r *OCIRepositoryReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		r.Metrics.RecordSuspend(ctx, obj, obj.Spec.Suspend)
 		r.Metrics.RecordReadiness(ctx, obj)
 		r.Metrics.RecordDuration(ctx, obj, start)
+		recordLastSuccess(ctx, r.Metrics, r.SourceMetrics, obj)
 	}()
 
 	// Examine if the object is under deletion.
@@ -221,7 +236,7 @@ func (r *OCIRepositoryReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 	// and delete.
 	// Note: Finalizers in general can only be added when the deletionTimestamp
 	// is not set.
-	if !controllerutil.ContainsFinalizer(obj, sourcev1.SourceFinalizer) {
+	if !r.DisableFinalizers && !controllerutil.ContainsFinalizer(obj, sourcev1.SourceFinalizer) {
 		controllerutil.AddFinalizer(obj, sourcev1.SourceFinalizer)
 		recResult = sreconcile.ResultRequeue
 		return
@@ -305,6 +320,7 @@ func (r *OCIRepositoryReconciler) reconcile(ctx context.Context, sp *patch.Seria
 		// If an error is received, prioritize the returned results because an
 		// error also means immediate requeue.
 		if err != nil {
+			recordFailure(r.SourceMetrics, ociv1.OCIRepositoryKind, err)
 			resErr = err
 			res = recResult
 			break
@@ -480,7 +496,11 @@ func (r *OCIRepositoryReconciler) reconcileSource(ctx context.Context, sp *patch
 	// Extract the compressed content from the selected layer
 	blob, err := r.selectLayer(obj, img)
 	if err != nil {
-		e := serror.NewGeneric(err, ociv1.OCILayerOperationFailedReason)
+		reason := ociv1.OCILayerOperationFailedReason
+		if errors.Is(err, errAmbiguousLayerSelection) {
+			reason = ociv1.OCILayerSelectionAmbiguousReason
+		}
+		e := serror.NewGeneric(err, reason)
 		conditions.MarkTrue(obj, sourcev1.FetchFailedCondition, e.Reason, e.Err.Error())
 		return sreconcile.ResultEmpty, e
 	}
@@ -531,8 +551,14 @@ func (r *OCIRepositoryReconciler) reconcileSource(ctx context.Context, sp *patch
 	return sreconcile.ResultSuccess, nil
 }
 
+// errAmbiguousLayerSelection is returned by selectLayer when more than one
+// layer of the OCI artifact matches the layer selector.
+var errAmbiguousLayerSelection = errors.New("multiple layers match the layer selector")
+
 // selectLayer finds the matching layer and returns its compressed contents.
 // If no layer selector was provided, we pick the first layer from the OCI artifact.
+// If more than one layer matches the media type of the selector, an error
+// wrapping errAmbiguousLayerSelection is returned.
 func (r *OCIRepositoryReconciler) selectLayer(obj *ociv1.OCIRepository, image gcrv1.Image) (io.ReadCloser, error) {
 	layers, err := image.Layers()
 	if err != nil {
@@ -546,20 +572,24 @@ func (r *OCIRepositoryReconciler) selectLayer(obj *ociv1.OCIRepository, image gc
 	var layer gcrv1.Layer
 	switch {
 	case obj.GetLayerMediaType() != "":
-		var found bool
+		var matches []int
 		for i, l := range layers {
 			md, err := l.MediaType()
 			if err != nil {
 				return nil, fmt.Errorf("failed to determine the media type of layer[%v] from artifact: %w", i, err)
 			}
 			if string(md) == obj.GetLayerMediaType() {
-				layer = layers[i]
-				found = true
-				break
+				matches = append(matches, i)
 			}
 		}
-		if !found {
+		switch len(matches) {
+		case 0:
 			return nil, fmt.Errorf("failed to find layer with media type '%s' in artifact", obj.GetLayerMediaType())
+		case 1:
+			layer = layers[matches[0]]
+		default:
+			return nil, fmt.Errorf("%w: found layers%v with media type '%s' in artifact",
+				errAmbiguousLayerSelection, matches, obj.GetLayerMediaType())
 		}
 	default:
 		layer = layers[0]
@@ -985,6 +1015,7 @@ func (r *OCIRepositoryReconciler) reconcileArtifact(ctx context.Context, sp *pat
 	}
 	defer unlock()
 
+	writeStart := time.Now()
 	switch obj.GetLayerOperation() {
 	case ociv1.OCILayerCopy:
 		if err = r.Storage.CopyFromPath(&artifact, filepath.Join(dir, metadata.Path)); err != nil {
@@ -1018,6 +1049,13 @@ func (r *OCIRepositoryReconciler) reconcileArtifact(ctx context.Context, sp *pat
 			return sreconcile.ResultEmpty, e
 		}
 	}
+	recordArtifactWrite(ctx, r.Metrics, r.SourceMetrics, obj, artifact, writeStart)
+
+	// Mirror the artifact to the backup storage on a "best effort" basis
+	if err := r.Storage.BackupArtifact(artifact); err != nil {
+		r.eventLogf(ctx, obj, corev1.EventTypeWarning, sourcev1.BackupOperationFailedReason,
+			"failed to mirror artifact to backup storage: %s", err)
+	}
 
 	// Record the observations on the object.
 	obj.Status.Artifact = artifact.DeepCopy()
@@ -1050,7 +1088,9 @@ func (r *OCIRepositoryReconciler) reconcileDelete(ctx context.Context, obj *ociv
 	}
 
 	// Remove our finalizer from the list
-	controllerutil.RemoveFinalizer(obj, sourcev1.SourceFinalizer)
+	if !r.DisableFinalizers {
+		controllerutil.RemoveFinalizer(obj, sourcev1.SourceFinalizer)
+	}
 
 	// Stop reconciliation as the object is being deleted
 	return sreconcile.ResultEmpty, nil
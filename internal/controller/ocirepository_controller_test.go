@@ -25,6 +25,7 @@ import (
 	"encoding/pem"
 	"errors"
 	"fmt"
+	"io"
 	"math/big"
 	"net/http"
 	"net/url"
@@ -38,8 +39,11 @@ import (
 	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/crane"
 	gcrv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
 	"github.com/google/go-containerregistry/pkg/v1/mutate"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
 	. "github.com/onsi/gomega"
 	coptions "github.com/sigstore/cosign/v2/cmd/cosign/cli/options"
 	"github.com/sigstore/cosign/v2/cmd/cosign/cli/sign"
@@ -397,6 +401,68 @@ func TestOCIRepository_Reconcile_MediaType(t *testing.T) {
 	}
 }
 
+func TestOCIRepository_selectLayer(t *testing.T) {
+	img, err := mutate.AppendLayers(empty.Image,
+		static.NewLayer([]byte("first"), types.MediaType("application/vnd.foo")),
+		static.NewLayer([]byte("second"), types.MediaType("application/vnd.bar")),
+		static.NewLayer([]byte("third"), types.MediaType("application/vnd.bar")),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		mediaType string
+		want      string
+		wantErr   string
+	}{
+		{
+			name: "first layer without selector",
+			want: "first",
+		},
+		{
+			name:      "single matching layer",
+			mediaType: "application/vnd.foo",
+			want:      "first",
+		},
+		{
+			name:      "no matching layer",
+			mediaType: "application/vnd.baz",
+			wantErr:   "failed to find layer with media type 'application/vnd.baz' in artifact",
+		},
+		{
+			name:      "multiple matching layers",
+			mediaType: "application/vnd.bar",
+			wantErr:   "multiple layers match the layer selector: found layers[1 2] with media type 'application/vnd.bar' in artifact",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			obj := &ociv1.OCIRepository{}
+			if tt.mediaType != "" {
+				obj.Spec.LayerSelector = &ociv1.OCILayerSelector{MediaType: tt.mediaType}
+			}
+
+			r := &OCIRepositoryReconciler{}
+			blob, err := r.selectLayer(obj, img)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(Equal(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			defer blob.Close()
+			b, err := io.ReadAll(blob)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(string(b)).To(Equal(tt.want))
+		})
+	}
+}
+
 func TestOCIRepository_reconcileSource_authStrategy(t *testing.T) {
 	type secretOptions struct {
 		username      string
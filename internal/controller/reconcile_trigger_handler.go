@@ -0,0 +1,263 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	helmv1 "github.com/fluxcd/source-controller/api/v1beta2"
+)
+
+// +kubebuilder:rbac:groups=authentication.k8s.io,resources=tokenreviews,verbs=create
+// +kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
+
+// ReconcileTriggerHandlerPath is the path prefix at which the
+// ReconcileTriggerHandler is served. The resource, namespace and name of the
+// object are appended to it, e.g.
+// /reconcile/helmrepositories/<namespace>/<name>.
+const ReconcileTriggerHandlerPath = "/reconcile/"
+
+// reconcileTriggerBufferSize is the number of reconcile requests buffered per
+// controller, before requests are rejected.
+const reconcileTriggerBufferSize = 128
+
+// reconcileTriggerResources maps the resources of which a reconcile can be
+// triggered to a constructor of their object.
+var reconcileTriggerResources = map[string]func() client.Object{
+	"buckets":          func() client.Object { return &helmv1.Bucket{} },
+	"gitrepositories":  func() client.Object { return &sourcev1.GitRepository{} },
+	"helmcharts":       func() client.Object { return &helmv1.HelmChart{} },
+	"helmrepositories": func() client.Object { return &helmv1.HelmRepository{} },
+	"ocirepositories":  func() client.Object { return &helmv1.OCIRepository{} },
+}
+
+// ReconcileTriggerHandler is a http.Handler which enqueues a reconcile of the
+// requested object, equivalent to setting the reconcile request annotation,
+// without updating the object through the API server. Requests are made
+// using the POST method.
+//
+// Requests are authenticated with a Kubernetes bearer token using a
+// TokenReview, and the authenticated user must be allowed to patch the
+// requested object, as verified using a SubjectAccessReview.
+//
+// The triggered reconciles are recorded as pending until the controller
+// marks them done, so that the controller can tell them apart from a
+// periodic requeue. A nil ReconcileTriggerHandler has no pending reconciles.
+type ReconcileTriggerHandler struct {
+	// Client is used to review the tokens and access of requests, and to
+	// get the requested object.
+	Client client.Client
+
+	mu      sync.Mutex
+	sources map[string][]chan event.GenericEvent
+	pending map[string]map[types.NamespacedName]struct{}
+}
+
+// Source returns a source.Source of the triggered reconciles of objects of
+// the given resource, for a single controller.
+func (h *ReconcileTriggerHandler) Source(resource string) source.Source {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.sources == nil {
+		h.sources = make(map[string][]chan event.GenericEvent)
+	}
+	ch := make(chan event.GenericEvent, reconcileTriggerBufferSize)
+	h.sources[resource] = append(h.sources[resource], ch)
+	return &source.Channel{Source: ch}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *ReconcileTriggerHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, ok, err := reviewToken(req, h.Client)
+	if err != nil {
+		ctrl.LoggerFrom(req.Context()).Error(err, "failed to review token to trigger reconcile")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(req.URL.Path, ReconcileTriggerHandlerPath), "/")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		http.Error(w, "expected path in the format of <resource>/<namespace>/<name>", http.StatusBadRequest)
+		return
+	}
+	resource, namespace, name := parts[0], parts[1], parts[2]
+	newObj, ok := reconcileTriggerResources[resource]
+	if !ok {
+		http.Error(w, "unsupported resource", http.StatusNotFound)
+		return
+	}
+
+	allowed, err := reviewAccess(req.Context(), h.Client, user, &authorizationv1.ResourceAttributes{
+		Namespace: namespace,
+		Verb:      "patch",
+		Group:     sourcev1.GroupVersion.Group,
+		Resource:  resource,
+		Name:      name,
+	})
+	if err != nil {
+		ctrl.LoggerFrom(req.Context()).Error(err, "failed to review access to trigger reconcile")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	if !allowed {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	obj := newObj()
+	if err := h.Client.Get(req.Context(), types.NamespacedName{Namespace: namespace, Name: name}, obj); err != nil {
+		if apierrors.IsNotFound(err) {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+		ctrl.LoggerFrom(req.Context()).Error(err, "failed to get object to trigger reconcile")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	if !h.enqueue(resource, obj) {
+		http.Error(w, "too many pending reconcile requests", http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// enqueue records a pending reconcile of the object, and sends a generic event
+// for it to the sources of the given resource. It returns false if any of the
+// sources is full.
+func (h *ReconcileTriggerHandler) enqueue(resource string, obj client.Object) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.pending == nil {
+		h.pending = make(map[string]map[types.NamespacedName]struct{})
+	}
+	if h.pending[resource] == nil {
+		h.pending[resource] = make(map[types.NamespacedName]struct{})
+	}
+	h.pending[resource][client.ObjectKeyFromObject(obj)] = struct{}{}
+
+	ok := true
+	for _, ch := range h.sources[resource] {
+		select {
+		case ch <- event.GenericEvent{Object: obj}:
+		default:
+			ok = false
+		}
+	}
+	return ok
+}
+
+// isPending returns true if a reconcile of the given object of the given
+// resource has been triggered, and not been marked done yet.
+func (h *ReconcileTriggerHandler) isPending(resource string, key types.NamespacedName) bool {
+	if h == nil {
+		return false
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	_, ok := h.pending[resource][key]
+	return ok
+}
+
+// markDone records the triggered reconcile of the given object of the given
+// resource as performed.
+func (h *ReconcileTriggerHandler) markDone(resource string, key types.NamespacedName) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.pending[resource], key)
+}
+
+// watchReconcileTrigger configures the builder to enqueue the reconciles of
+// objects of the given resource triggered through the ReconcileTriggerHandler,
+// if not nil.
+func watchReconcileTrigger(b *builder.Builder, trigger *ReconcileTriggerHandler, resource string, prct ...predicate.Predicate) *builder.Builder {
+	if trigger == nil {
+		return b
+	}
+	return b.WatchesRawSource(trigger.Source(resource), &handler.EnqueueRequestForObject{}, builder.WithPredicates(prct...))
+}
+
+// reviewToken authenticates the Kubernetes bearer token of the request using
+// a TokenReview. It returns the authenticated user, and false if the request
+// does not carry a token or the token is not authenticated.
+func reviewToken(req *http.Request, c client.Client) (authenticationv1.UserInfo, bool, error) {
+	token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return authenticationv1.UserInfo{}, false, nil
+	}
+	tr := &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token},
+	}
+	if err := c.Create(req.Context(), tr); err != nil {
+		return authenticationv1.UserInfo{}, false, err
+	}
+	return tr.Status.User, tr.Status.Authenticated, nil
+}
+
+// reviewAccess returns true if the given user is allowed to perform the
+// action described by the given attributes, using a SubjectAccessReview.
+func reviewAccess(ctx context.Context, c client.Client, user authenticationv1.UserInfo, attrs *authorizationv1.ResourceAttributes) (bool, error) {
+	extra := make(map[string]authorizationv1.ExtraValue, len(user.Extra))
+	for k, v := range user.Extra {
+		extra[k] = authorizationv1.ExtraValue(v)
+	}
+	sar := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:               user.Username,
+			UID:                user.UID,
+			Groups:             user.Groups,
+			Extra:              extra,
+			ResourceAttributes: attrs,
+		},
+	}
+	if err := c.Create(ctx, sar); err != nil {
+		return false, err
+	}
+	return sar.Status.Allowed, nil
+}
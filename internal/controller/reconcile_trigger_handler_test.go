@@ -0,0 +1,176 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	helmv1 "github.com/fluxcd/source-controller/api/v1beta2"
+)
+
+func TestReconcileTriggerHandler_ServeHTTP(t *testing.T) {
+	obj := &helmv1.HelmRepository{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "podinfo",
+			Namespace: "tenant-a",
+		},
+	}
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		token       string
+		wantStatus  int
+		wantEnqueue bool
+	}{
+		{
+			name:        "enqueues allowed object",
+			path:        "helmrepositories/tenant-a/podinfo",
+			token:       "alice",
+			wantStatus:  http.StatusAccepted,
+			wantEnqueue: true,
+		},
+		{
+			name:       "rejects missing token",
+			path:       "helmrepositories/tenant-a/podinfo",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "rejects unauthenticated token",
+			path:       "helmrepositories/tenant-a/podinfo",
+			token:      "invalid",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "rejects unauthorized user",
+			path:       "helmrepositories/tenant-a/podinfo",
+			token:      "bob",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "rejects non-POST method",
+			method:     http.MethodGet,
+			path:       "helmrepositories/tenant-a/podinfo",
+			token:      "alice",
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:       "rejects invalid path",
+			path:       "helmrepositories/tenant-a",
+			token:      "alice",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "rejects unsupported resource",
+			path:       "secrets/tenant-a/podinfo",
+			token:      "alice",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "object not found",
+			path:       "helmrepositories/tenant-a/missing",
+			token:      "alice",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			h := &ReconcileTriggerHandler{
+				Client: fakeclient.NewClientBuilder().
+					WithScheme(testEnv.GetScheme()).
+					WithObjects(obj).
+					WithInterceptorFuncs(interceptor.Funcs{
+						Create: func(ctx context.Context, c client.WithWatch, o client.Object, opts ...client.CreateOption) error {
+							switch r := o.(type) {
+							case *authenticationv1.TokenReview:
+								// The token is the name of the user, if known.
+								if r.Spec.Token == "alice" || r.Spec.Token == "bob" {
+									r.Status.Authenticated = true
+									r.Status.User.Username = r.Spec.Token
+								}
+							case *authorizationv1.SubjectAccessReview:
+								a := r.Spec.ResourceAttributes
+								r.Status.Allowed = r.Spec.User == "alice" && a.Namespace == "tenant-a" &&
+									a.Verb == "patch" && a.Group == "source.toolkit.fluxcd.io"
+							default:
+								return c.Create(ctx, o, opts...)
+							}
+							return nil
+						},
+					}).
+					Build(),
+			}
+			src := h.Source("helmrepositories").(*source.Channel)
+
+			method := tt.method
+			if method == "" {
+				method = http.MethodPost
+			}
+			req := httptest.NewRequest(method, ReconcileTriggerHandlerPath+tt.path, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			g.Expect(rec.Code).To(Equal(tt.wantStatus))
+			key := client.ObjectKeyFromObject(obj)
+			if !tt.wantEnqueue {
+				g.Expect(src.Source).To(BeEmpty())
+				g.Expect(h.isPending("helmrepositories", key)).To(BeFalse())
+				return
+			}
+			var e event.GenericEvent
+			g.Expect(src.Source).To(Receive(&e))
+			g.Expect(e.Object.GetNamespace()).To(Equal(obj.Namespace))
+			g.Expect(e.Object.GetName()).To(Equal(obj.Name))
+
+			g.Expect(h.isPending("helmrepositories", key)).To(BeTrue())
+			g.Expect(h.isPending("gitrepositories", key)).To(BeFalse())
+			h.markDone("helmrepositories", key)
+			g.Expect(h.isPending("helmrepositories", key)).To(BeFalse())
+		})
+	}
+}
+
+func TestReconcileTriggerHandler_enqueueFull(t *testing.T) {
+	g := NewWithT(t)
+
+	h := &ReconcileTriggerHandler{}
+	_ = h.Source("helmrepositories")
+	obj := &helmv1.HelmRepository{}
+	for i := 0; i < reconcileTriggerBufferSize; i++ {
+		g.Expect(h.enqueue("helmrepositories", obj)).To(BeTrue())
+	}
+	g.Expect(h.enqueue("helmrepositories", obj)).To(BeFalse())
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// startupReconciles tracks the objects of which the first reconciliation
+// since the controller started is pending, and paces these
+// reconciliations. A nil startupReconciles has no pending reconciliations.
+type startupReconciles struct {
+	limiter *rate.Limiter
+	// due holds the time at which the startup reconciliation of an object
+	// may be performed, once it has been paced.
+	due  map[types.NamespacedName]time.Time
+	done map[types.NamespacedName]struct{}
+	mu   sync.Mutex
+}
+
+// newStartupReconciles returns a startupReconciles which allows the given
+// number of startup reconciliations per second. A rate of zero or less
+// does not limit the reconciliations.
+func newStartupReconciles(perSecond float64) *startupReconciles {
+	limit := rate.Inf
+	if perSecond > 0 {
+		limit = rate.Limit(perSecond)
+	}
+	return &startupReconciles{
+		limiter: rate.NewLimiter(limit, 1),
+		due:     make(map[types.NamespacedName]time.Time),
+		done:    make(map[types.NamespacedName]struct{}),
+	}
+}
+
+// dueIn returns true if the startup reconciliation of the given object is
+// pending, and the duration after which it may be performed. The first call
+// for an object reserves its slot with the rate limiter.
+func (s *startupReconciles) dueIn(key types.NamespacedName) (bool, time.Duration) {
+	if s == nil {
+		return false, 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.done[key]; ok {
+		return false, 0
+	}
+	at, ok := s.due[key]
+	if !ok {
+		at = time.Now().Add(s.limiter.Reserve().Delay())
+		s.due[key] = at
+	}
+	if d := time.Until(at); d > 0 {
+		return true, d
+	}
+	return true, 0
+}
+
+// pending returns true if the startup reconciliation of the given object
+// has not been performed yet.
+func (s *startupReconciles) pending(key types.NamespacedName) bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.done[key]
+	return !ok
+}
+
+// markDone records the startup reconciliation of the given object as
+// performed.
+func (s *startupReconciles) markDone(key types.NamespacedName) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.due, key)
+	s.done[key] = struct{}{}
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func Test_startupReconciles(t *testing.T) {
+	g := NewWithT(t)
+
+	s := newStartupReconciles(1)
+	key := types.NamespacedName{Namespace: "default", Name: "foo"}
+	other := types.NamespacedName{Namespace: "default", Name: "bar"}
+
+	// The first object is due right away.
+	pending, d := s.dueIn(key)
+	g.Expect(pending).To(BeTrue())
+	g.Expect(d).To(BeZero())
+	g.Expect(s.pending(key)).To(BeTrue())
+
+	// The next object is paced by the rate limit.
+	pending, d = s.dueIn(other)
+	g.Expect(pending).To(BeTrue())
+	g.Expect(d).To(BeNumerically("~", time.Second, 100*time.Millisecond))
+
+	// The slot of an object is reserved once.
+	_, d2 := s.dueIn(other)
+	g.Expect(d2).To(BeNumerically("<=", d))
+
+	// Once performed, the startup reconciliation is no longer pending.
+	s.markDone(key)
+	pending, d = s.dueIn(key)
+	g.Expect(pending).To(BeFalse())
+	g.Expect(d).To(BeZero())
+	g.Expect(s.pending(key)).To(BeFalse())
+
+	// A nil startupReconciles has no pending reconciliations.
+	var nilStartup *startupReconciles
+	pending, _ = nilStartup.dueIn(key)
+	g.Expect(pending).To(BeFalse())
+	g.Expect(nilStartup.pending(key)).To(BeFalse())
+	nilStartup.markDone(key)
+}
@@ -20,6 +20,7 @@ import (
 	"archive/tar"
 	"compress/gzip"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
@@ -28,10 +29,12 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"syscall"
 	"time"
 
 	securejoin "github.com/cyphar/filepath-securejoin"
 	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+	"github.com/klauspost/compress/zstd"
 	"github.com/opencontainers/go-digest"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
@@ -56,6 +59,35 @@ const (
 	defaultExeFileMode int64 = 0o744
 )
 
+const (
+	// DefaultArtifactFileMode is the permission mode of artifact files in
+	// the storage, unless configured otherwise.
+	DefaultArtifactFileMode os.FileMode = 0o600
+	// DefaultArtifactDirMode is the permission mode of artifact directories
+	// created in the storage, unless configured otherwise.
+	DefaultArtifactDirMode os.FileMode = 0o700
+)
+
+const (
+	// ArtifactCompressionZstd is the artifact compression algorithm which
+	// stores artifacts compressed with zstd.
+	ArtifactCompressionZstd = "zstd"
+	// ZstdExtension is the file extension of artifacts stored compressed
+	// with zstd.
+	ZstdExtension = ".zst"
+)
+
+// SignatureExtension is the file extension of the detached signature of an
+// artifact, stored next to the artifact file.
+const SignatureExtension = ".sig"
+
+// isSidecarFile returns true if the file at the given path accompanies an
+// artifact file, rather than being an artifact file itself.
+func isSidecarFile(path string) bool {
+	ext := filepath.Ext(path)
+	return ext == ".lock" || ext == SignatureExtension
+}
+
 // Storage manages artifacts
 type Storage struct {
 	// BasePath is the local directory path where the source artifacts are stored.
@@ -71,6 +103,27 @@ type Storage struct {
 	// ArtifactRetentionRecords is the maximum number of artifacts to be kept in
 	// storage after a garbage collection.
 	ArtifactRetentionRecords int `json:"artifactRetentionRecords"`
+
+	// ArtifactReplacementGracePeriod is the duration of time that the artifact
+	// replaced by the current artifact is kept in storage, regardless of the
+	// retention options, to allow in-progress downloads to complete.
+	ArtifactReplacementGracePeriod time.Duration `json:"artifactReplacementGracePeriod,omitempty"`
+
+	// FileMode is the permission mode of the artifact files written to the
+	// storage. Defaults to DefaultArtifactFileMode when zero.
+	FileMode os.FileMode `json:"fileMode,omitempty"`
+
+	// DirMode is the permission mode of the artifact directories created in
+	// the storage. Defaults to DefaultArtifactDirMode when zero.
+	DirMode os.FileMode `json:"dirMode,omitempty"`
+
+	// Backup is an optional secondary Storage the artifacts are mirrored to
+	// using BackupArtifact. The artifacts removed from the Storage are
+	// removed from the Backup as well.
+	Backup *Storage `json:"backup,omitempty"`
+
+	// locks counts the artifact locks held through the Storage, for Drain.
+	locks *heldLocks
 }
 
 // NewStorage creates the storage helper for a given path and hostname.
@@ -83,9 +136,26 @@ func NewStorage(basePath string, hostname string, artifactRetentionTTL time.Dura
 		Hostname:                 hostname,
 		ArtifactRetentionTTL:     artifactRetentionTTL,
 		ArtifactRetentionRecords: artifactRetentionRecords,
+		locks:                    &heldLocks{},
 	}, nil
 }
 
+// fileMode returns the permission mode of artifact files.
+func (s Storage) fileMode() os.FileMode {
+	if s.FileMode == 0 {
+		return DefaultArtifactFileMode
+	}
+	return s.FileMode
+}
+
+// dirMode returns the permission mode of artifact directories.
+func (s Storage) dirMode() os.FileMode {
+	if s.DirMode == 0 {
+		return DefaultArtifactDirMode
+	}
+	return s.DirMode
+}
+
 // NewArtifactFor returns a new v1.Artifact.
 func (s Storage) NewArtifactFor(kind string, metadata metav1.Object, revision, fileName string) v1.Artifact {
 	path := v1.ArtifactPath(kind, metadata.GetNamespace(), metadata.GetName(), fileName)
@@ -109,6 +179,17 @@ func (s Storage) SetArtifactURL(artifact *v1.Artifact) {
 	artifact.URL = fmt.Sprintf(format, s.Hostname, strings.TrimLeft(artifact.Path, "/"))
 }
 
+// WithHostname returns a copy of the Storage which composes the artifacts URIs
+// with the given hostname, or the Storage itself if hostname is empty.
+func (s *Storage) WithHostname(hostname string) *Storage {
+	if hostname == "" {
+		return s
+	}
+	c := *s
+	c.Hostname = hostname
+	return &c
+}
+
 // SetHostname sets the hostname of the given URL string to the current Storage.Hostname and returns the result.
 func (s Storage) SetHostname(URL string) string {
 	u, err := url.Parse(URL)
@@ -119,10 +200,11 @@ func (s Storage) SetHostname(URL string) string {
 	return u.String()
 }
 
-// MkdirAll calls os.MkdirAll for the given v1.Artifact base dir.
+// MkdirAll calls os.MkdirAll for the given v1.Artifact base dir, creating
+// the missing directories with the DirMode of the Storage.
 func (s Storage) MkdirAll(artifact v1.Artifact) error {
 	dir := filepath.Dir(s.LocalPath(artifact))
-	return os.MkdirAll(dir, 0o700)
+	return os.MkdirAll(dir, s.dirMode())
 }
 
 // Remove calls os.Remove for the given v1.Artifact path.
@@ -130,7 +212,8 @@ func (s Storage) Remove(artifact v1.Artifact) error {
 	return os.Remove(s.LocalPath(artifact))
 }
 
-// RemoveAll calls os.RemoveAll for the given v1.Artifact base dir.
+// RemoveAll calls os.RemoveAll for the given v1.Artifact base dir, in both
+// the Storage and the Backup.
 func (s Storage) RemoveAll(artifact v1.Artifact) (string, error) {
 	var deletedDir string
 	dir := filepath.Dir(s.LocalPath(artifact))
@@ -139,11 +222,37 @@ func (s Storage) RemoveAll(artifact v1.Artifact) (string, error) {
 	if err == nil {
 		deletedDir = dir
 	}
-	return deletedDir, os.RemoveAll(dir)
+	if err := os.RemoveAll(dir); err != nil {
+		return deletedDir, err
+	}
+	if s.Backup != nil {
+		if _, err := s.Backup.RemoveAll(artifact); err != nil {
+			return deletedDir, fmt.Errorf("failed to remove backup: %w", err)
+		}
+	}
+	return deletedDir, nil
 }
 
-// RemoveAllButCurrent removes all files for the given v1.Artifact base dir, excluding the current one.
+// RemoveAllButCurrent removes all files for the given v1.Artifact base dir, excluding the current one,
+// in both the Storage and the Backup.
 func (s Storage) RemoveAllButCurrent(artifact v1.Artifact) ([]string, error) {
+	deletedFiles, err := s.removeAllButCurrent(artifact)
+	if err != nil || s.Backup == nil || !s.Backup.dirExists(artifact) {
+		return deletedFiles, err
+	}
+	deletedBackupFiles, err := s.Backup.RemoveAllButCurrent(artifact)
+	return append(deletedFiles, deletedBackupFiles...), err
+}
+
+// dirExists returns true if the base dir of the given v1.Artifact exists.
+func (s Storage) dirExists(artifact v1.Artifact) bool {
+	_, err := os.Stat(filepath.Dir(s.LocalPath(artifact)))
+	return err == nil
+}
+
+// removeAllButCurrent removes all files for the given v1.Artifact base dir of
+// the Storage, excluding the current one.
+func (s Storage) removeAllButCurrent(artifact v1.Artifact) ([]string, error) {
 	deletedFiles := []string{}
 	localPath := s.LocalPath(artifact)
 	dir := filepath.Dir(localPath)
@@ -154,7 +263,7 @@ func (s Storage) RemoveAllButCurrent(artifact v1.Artifact) ([]string, error) {
 			return nil
 		}
 
-		if path != localPath && !info.IsDir() && info.Mode()&os.ModeSymlink != os.ModeSymlink {
+		if path != localPath && path != localPath+SignatureExtension && !info.IsDir() && info.Mode()&os.ModeSymlink != os.ModeSymlink {
 			if err := os.Remove(path); err != nil {
 				errors = append(errors, info.Name())
 			} else {
@@ -204,9 +313,9 @@ func (s Storage) getGarbageFiles(artifact v1.Artifact, totalCountLimit, maxItems
 		// Compare the time difference between now and the time at which the file was created
 		// with the provided TTL. Delete if the difference is greater than the TTL. Since the
 		// below logic just deals with determining if an artifact needs to be garbage collected,
-		// we avoid all lock files, adding them at the end to the list of garbage files.
+		// we avoid all lock and signature files, which are removed along with their artifact.
 		expired := diff > ttl
-		if !info.IsDir() && info.Mode()&os.ModeSymlink != os.ModeSymlink && filepath.Ext(path) != ".lock" {
+		if !info.IsDir() && info.Mode()&os.ModeSymlink != os.ModeSymlink && !isSidecarFile(path) {
 			if path != localPath && expired {
 				garbageFiles = append(garbageFiles, path)
 			}
@@ -240,7 +349,7 @@ func (s Storage) getGarbageFiles(artifact v1.Artifact, totalCountLimit, maxItems
 	var collected int
 	noOfGarbageFiles := len(garbageFiles)
 	for _, path := range sortedPaths {
-		if path != localPath && filepath.Ext(path) != ".lock" && !stringInSlice(path, garbageFiles) {
+		if path != localPath && !isSidecarFile(path) && !stringInSlice(path, garbageFiles) {
 			// If we previously collected some garbage files with an expired ttl, then take that into account
 			// when checking whether we need to remove more files to satisfy the max no. of items allowed
 			// in the filesystem, along with the no. of files already removed in this loop.
@@ -262,8 +371,22 @@ func (s Storage) getGarbageFiles(artifact v1.Artifact, totalCountLimit, maxItems
 }
 
 // GarbageCollect removes all garbage files in the artifact dir according to the provided
-// retention options.
+// retention options, in both the Storage and the Backup.
 func (s Storage) GarbageCollect(ctx context.Context, artifact v1.Artifact, timeout time.Duration) ([]string, error) {
+	deleted, err := s.garbageCollect(ctx, artifact, timeout)
+	if err != nil || s.Backup == nil || !s.Backup.dirExists(artifact) {
+		return deleted, err
+	}
+	deletedBackup, err := s.Backup.GarbageCollect(ctx, artifact, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to garbage collect backup: %w", err)
+	}
+	return append(deleted, deletedBackup...), nil
+}
+
+// garbageCollect removes all garbage files in the artifact dir of the Storage
+// according to the provided retention options.
+func (s Storage) garbageCollect(ctx context.Context, artifact v1.Artifact, timeout time.Duration) ([]string, error) {
 	delFilesChan := make(chan []string)
 	errChan := make(chan error)
 	// Abort if it takes more than the provided timeout duration.
@@ -276,6 +399,12 @@ func (s Storage) GarbageCollect(ctx context.Context, artifact v1.Artifact, timeo
 			errChan <- err
 			return
 		}
+		if s.ArtifactReplacementGracePeriod > 0 {
+			if garbageFiles, err = s.retainReplacedFile(artifact, garbageFiles); err != nil {
+				errChan <- err
+				return
+			}
+		}
 		var errors []error
 		var deleted []string
 		if len(garbageFiles) > 0 {
@@ -286,12 +415,14 @@ func (s Storage) GarbageCollect(ctx context.Context, artifact v1.Artifact, timeo
 				} else {
 					deleted = append(deleted, file)
 				}
-				// If a lock file exists for this garbage artifact, remove that too.
-				lockFile := file + ".lock"
-				if _, err = os.Lstat(lockFile); err == nil {
-					err = os.Remove(lockFile)
-					if err != nil {
-						errors = append(errors, err)
+				// If a lock or signature file exists for this garbage artifact,
+				// remove that too.
+				for _, sidecar := range []string{file + ".lock", file + SignatureExtension} {
+					if _, err = os.Lstat(sidecar); err == nil {
+						err = os.Remove(sidecar)
+						if err != nil {
+							errors = append(errors, err)
+						}
 					}
 				}
 			}
@@ -315,6 +446,67 @@ func (s Storage) GarbageCollect(ctx context.Context, artifact v1.Artifact, timeo
 	}
 }
 
+// retainReplacedFile removes the file of the artifact replaced by the given
+// artifact from the garbage files, if the given artifact was written less
+// than the ArtifactReplacementGracePeriod ago. The replaced artifact is the
+// most recently modified artifact file other than the given artifact.
+func (s Storage) retainReplacedFile(artifact v1.Artifact, garbageFiles []string) ([]string, error) {
+	localPath := s.LocalPath(artifact)
+	info, err := os.Stat(localPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return garbageFiles, nil
+		}
+		return nil, err
+	}
+	if time.Since(info.ModTime()) >= s.ArtifactReplacementGracePeriod {
+		return garbageFiles, nil
+	}
+
+	var replaced string
+	var replacedAt time.Time
+	for _, path := range garbageFiles {
+		if isSidecarFile(path) {
+			continue
+		}
+		fi, err := os.Lstat(path)
+		if err != nil {
+			continue
+		}
+		if fi.ModTime().After(replacedAt) {
+			replaced, replacedAt = path, fi.ModTime()
+		}
+	}
+	if replaced == "" {
+		return garbageFiles, nil
+	}
+
+	// Only retain the file if no artifact file more recent than it is
+	// retained already, in which case it was not the replaced artifact.
+	entries, err := os.ReadDir(filepath.Dir(localPath))
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		path := filepath.Join(filepath.Dir(localPath), e.Name())
+		if path == localPath || e.IsDir() || e.Type()&os.ModeSymlink != 0 ||
+			isSidecarFile(path) || stringInSlice(path, garbageFiles) {
+			continue
+		}
+		if fi, err := e.Info(); err == nil && fi.ModTime().After(replacedAt) {
+			return garbageFiles, nil
+		}
+	}
+
+	retained := make([]string, 0, len(garbageFiles)-1)
+	for _, path := range garbageFiles {
+		if path != replaced {
+			retained = append(retained, path)
+		}
+	}
+	return retained, nil
+}
+
 func stringInSlice(a string, list []string) bool {
 	for _, b := range list {
 		if b == a {
@@ -335,7 +527,8 @@ func (s Storage) ArtifactExist(artifact v1.Artifact) bool {
 
 // VerifyArtifact verifies if the Digest of the v1.Artifact matches the digest
 // of the file in Storage. It returns an error if the digests don't match, or
-// if it can't be verified.
+// if it can't be verified. For compressed artifacts, the digest of the
+// uncompressed contents is verified.
 func (s Storage) VerifyArtifact(artifact v1.Artifact) error {
 	if artifact.Digest == "" {
 		return fmt.Errorf("artifact has no digest")
@@ -352,8 +545,18 @@ func (s Storage) VerifyArtifact(artifact v1.Artifact) error {
 	}
 	defer f.Close()
 
+	var r io.Reader = f
+	if IsCompressedArtifact(artifact) {
+		zr, err := zstd.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		r = zr
+	}
+
 	verifier := d.Verifier()
-	if _, err = io.Copy(verifier, f); err != nil {
+	if _, err = io.Copy(verifier, r); err != nil {
 		return err
 	}
 	if !verifier.Verified() {
@@ -472,11 +675,15 @@ func (s Storage) Archive(artifact *v1.Artifact, dir string, filter ArchiveFileFi
 		tf.Close()
 		return err
 	}
+	if err := tf.Sync(); err != nil {
+		tf.Close()
+		return err
+	}
 	if err := tf.Close(); err != nil {
 		return err
 	}
 
-	if err := os.Chmod(tmpName, 0o600); err != nil {
+	if err := os.Chmod(tmpName, s.fileMode()); err != nil {
 		return err
 	}
 
@@ -492,6 +699,8 @@ func (s Storage) Archive(artifact *v1.Artifact, dir string, filter ArchiveFileFi
 }
 
 // AtomicWriteFile atomically writes the io.Reader contents to the v1.Artifact path.
+// The contents are written to a temporary file which is synced to disk, and
+// only renamed to the path on success.
 // If successful, it sets the digest and last update time on the artifact.
 func (s Storage) AtomicWriteFile(artifact *v1.Artifact, reader io.Reader, mode os.FileMode) (err error) {
 	localPath := s.LocalPath(*artifact)
@@ -514,6 +723,10 @@ func (s Storage) AtomicWriteFile(artifact *v1.Artifact, reader io.Reader, mode o
 		tf.Close()
 		return err
 	}
+	if err := tf.Sync(); err != nil {
+		tf.Close()
+		return err
+	}
 	if err := tf.Close(); err != nil {
 		return err
 	}
@@ -534,6 +747,9 @@ func (s Storage) AtomicWriteFile(artifact *v1.Artifact, reader io.Reader, mode o
 }
 
 // Copy atomically copies the io.Reader contents to the v1.Artifact path.
+// The contents are written to a temporary file which is synced to disk, and
+// only renamed to the path on success. If the v1.Artifact path has the
+// ZstdExtension, the contents are compressed with zstd.
 // If successful, it sets the digest and last update time on the artifact.
 func (s Storage) Copy(artifact *v1.Artifact, reader io.Reader) (err error) {
 	localPath := s.LocalPath(*artifact)
@@ -548,18 +764,44 @@ func (s Storage) Copy(artifact *v1.Artifact, reader io.Reader) (err error) {
 		}
 	}()
 
+	// The digest and size are calculated over the uncompressed content, so
+	// they match the content served to consumers.
+	var w io.Writer = tf
+	var zw *zstd.Encoder
+	if IsCompressedArtifact(*artifact) {
+		if zw, err = zstd.NewWriter(tf); err != nil {
+			tf.Close()
+			return err
+		}
+		w = zw
+	}
+
 	d := intdigest.Canonical.Digester()
 	sz := &writeCounter{}
-	mw := io.MultiWriter(tf, d.Hash(), sz)
+	mw := io.MultiWriter(w, d.Hash(), sz)
 
 	if _, err := io.Copy(mw, reader); err != nil {
 		tf.Close()
 		return err
 	}
+	if zw != nil {
+		if err := zw.Close(); err != nil {
+			tf.Close()
+			return err
+		}
+	}
+	if err := tf.Sync(); err != nil {
+		tf.Close()
+		return err
+	}
 	if err := tf.Close(); err != nil {
 		return err
 	}
 
+	if err := os.Chmod(tfName, s.fileMode()); err != nil {
+		return err
+	}
+
 	if err := sourcefs.RenameWithFallback(tfName, localPath); err != nil {
 		return err
 	}
@@ -571,6 +813,18 @@ func (s Storage) Copy(artifact *v1.Artifact, reader io.Reader) (err error) {
 	return nil
 }
 
+// IsCompressedArtifact returns true if the given v1.Artifact is stored
+// compressed with zstd.
+func IsCompressedArtifact(artifact v1.Artifact) bool {
+	return strings.HasSuffix(artifact.Path, ZstdExtension)
+}
+
+// IsDiskFull returns true if the given error was caused by the storage
+// device running out of space.
+func IsDiskFull(err error) bool {
+	return errors.Is(err, syscall.ENOSPC)
+}
+
 // CopyFromPath atomically copies the contents of the given path to the path of the v1.Artifact.
 // If successful, the digest and last update time on the artifact is set.
 func (s Storage) CopyFromPath(artifact *v1.Artifact, path string) (err error) {
@@ -587,6 +841,53 @@ func (s Storage) CopyFromPath(artifact *v1.Artifact, path string) (err error) {
 	return err
 }
 
+// BackupArtifact mirrors the file of the given v1.Artifact to the Backup, if
+// configured. The file is copied as stored, including any compression, to a
+// temporary file which is synced to disk, and only renamed to the path in the
+// Backup on success.
+func (s Storage) BackupArtifact(artifact v1.Artifact) (err error) {
+	if s.Backup == nil {
+		return nil
+	}
+
+	f, err := os.Open(s.LocalPath(artifact))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := s.Backup.MkdirAll(artifact); err != nil {
+		return err
+	}
+	localPath := s.Backup.LocalPath(artifact)
+	tf, err := os.CreateTemp(filepath.Split(localPath))
+	if err != nil {
+		return err
+	}
+	tfName := tf.Name()
+	defer func() {
+		if err != nil {
+			os.Remove(tfName)
+		}
+	}()
+
+	if _, err := io.Copy(tf, f); err != nil {
+		tf.Close()
+		return err
+	}
+	if err := tf.Sync(); err != nil {
+		tf.Close()
+		return err
+	}
+	if err := tf.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tfName, s.Backup.fileMode()); err != nil {
+		return err
+	}
+	return sourcefs.RenameWithFallback(tfName, localPath)
+}
+
 // CopyToPath copies the contents in the (sub)path of the given artifact to the given path.
 func (s Storage) CopyToPath(artifact *v1.Artifact, subPath, toPath string) error {
 	// create a tmp directory to store artifact
@@ -627,10 +928,19 @@ func (s Storage) CopyToPath(artifact *v1.Artifact, subPath, toPath string) error
 }
 
 // Symlink creates or updates a symbolic link for the given v1.Artifact and returns the URL for the symlink.
+// The linkName must be a plain file name, which differs from the file name of the artifact.
 func (s Storage) Symlink(artifact v1.Artifact, linkName string) (string, error) {
+	if linkName == "" || linkName == "." || linkName == ".." ||
+		strings.ContainsAny(linkName, `/\`) {
+		return "", fmt.Errorf("invalid symlink name '%s'", linkName)
+	}
+
 	localPath := s.LocalPath(artifact)
 	dir := filepath.Dir(localPath)
 	link := filepath.Join(dir, linkName)
+	if link == localPath {
+		return "", fmt.Errorf("invalid symlink name '%s': equals the artifact file name", linkName)
+	}
 	tmpLink := link + ".tmp"
 
 	if err := os.Remove(tmpLink); err != nil && !os.IsNotExist(err) {
@@ -648,11 +958,20 @@ func (s Storage) Symlink(artifact v1.Artifact, linkName string) (string, error)
 	return fmt.Sprintf("http://%s/%s", s.Hostname, filepath.Join(filepath.Dir(artifact.Path), linkName)), nil
 }
 
-// Lock creates a file lock for the given v1.Artifact.
+// Lock creates a file lock for the given v1.Artifact. The lock is counted as
+// held until it is unlocked, see Drain.
 func (s Storage) Lock(artifact v1.Artifact) (unlock func(), err error) {
 	lockFile := s.LocalPath(artifact) + ".lock"
 	mutex := lockedfile.MutexAt(lockFile)
-	return mutex.Lock()
+	fileUnlock, err := mutex.Lock()
+	if err != nil || s.locks == nil {
+		return fileUnlock, err
+	}
+	s.locks.acquire()
+	return func() {
+		fileUnlock()
+		s.locks.release()
+	}, nil
 }
 
 // LocalPath returns the secure local path of the given artifact (that is: relative to the Storage.BasePath).
@@ -0,0 +1,85 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// heldLocks counts the artifact locks held through a Storage, so that a
+// shutdown of the controller can wait for them to be released.
+type heldLocks struct {
+	count int
+	// idle is closed when the count drops to zero, if anyone is waiting.
+	idle chan struct{}
+	mu   sync.Mutex
+}
+
+func (h *heldLocks) acquire() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.count++
+}
+
+func (h *heldLocks) release() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.count--
+	if h.count == 0 && h.idle != nil {
+		close(h.idle)
+		h.idle = nil
+	}
+}
+
+// Held returns the number of artifact locks held through the Storage.
+func (s Storage) Held() int {
+	if s.locks == nil {
+		return 0
+	}
+	s.locks.mu.Lock()
+	defer s.locks.mu.Unlock()
+	return s.locks.count
+}
+
+// Drain waits for the artifact locks held through the Storage to be
+// released, e.g. by reconciliations which are writing an artifact while the
+// controller shuts down. It returns an error if the context is done before
+// all locks are released.
+func (s Storage) Drain(ctx context.Context) error {
+	if s.locks == nil {
+		return nil
+	}
+	s.locks.mu.Lock()
+	if s.locks.count == 0 {
+		s.locks.mu.Unlock()
+		return nil
+	}
+	if s.locks.idle == nil {
+		s.locks.idle = make(chan struct{})
+	}
+	idle := s.locks.idle
+	s.locks.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%d artifact lock(s) still held: %w", s.Held(), ctx.Err())
+	}
+}
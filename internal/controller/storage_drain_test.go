@@ -0,0 +1,58 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+)
+
+func TestStorage_Drain(t *testing.T) {
+	g := NewWithT(t)
+
+	s, err := NewStorage(t.TempDir(), "hostname", time.Minute, 2)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	// Nothing to drain without held locks.
+	g.Expect(s.Drain(context.TODO())).To(Succeed())
+
+	artifact := sourcev1.Artifact{Path: "helmrepository/default/foo/index.yaml"}
+	g.Expect(s.MkdirAll(artifact)).To(Succeed())
+	unlock, err := s.Lock(artifact)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(s.Held()).To(Equal(1))
+
+	// The drain times out while the lock is held.
+	ctx, cancel := context.WithTimeout(context.TODO(), 50*time.Millisecond)
+	defer cancel()
+	g.Expect(s.Drain(ctx)).To(MatchError(ContainSubstring("1 artifact lock(s) still held")))
+
+	// The drain completes once the lock is released.
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		unlock()
+	}()
+	ctx, cancel = context.WithTimeout(context.TODO(), 5*time.Second)
+	defer cancel()
+	g.Expect(s.Drain(ctx)).To(Succeed())
+	g.Expect(s.Held()).To(BeZero())
+}
@@ -0,0 +1,272 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/opencontainers/go-digest"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	helmv1 "github.com/fluxcd/source-controller/api/v1beta2"
+	intdigest "github.com/fluxcd/source-controller/internal/digest"
+)
+
+// NewFileServer returns an http.Handler serving the artifacts in the given
+// base path. Artifacts stored compressed with zstd are served decompressed,
+// including when they are requested through a symlink.
+//
+// Artifact files are served with their digest as ETag header, and a
+// Cache-Control header which requires clients to revalidate their cached
+// copy. Requests with a matching If-None-Match header are answered with
+// http.StatusNotModified.
+func NewFileServer(basePath string) http.Handler {
+	fs := http.FileServer(http.Dir(basePath))
+	digests := &fileDigestCache{entries: make(map[string]fileDigest)}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Resolve any symlinks, as the index symlink of a HelmRepository
+		// points to the (compressed) artifact.
+		p := filepath.Join(basePath, filepath.FromSlash(path.Clean("/"+r.URL.Path)))
+		resolved, err := filepath.EvalSymlinks(p)
+		if err != nil || !withinDir(basePath, resolved) {
+			fs.ServeHTTP(w, r)
+			return
+		}
+		if fi, err := os.Stat(resolved); err == nil && fi.Mode().IsRegular() {
+			if d, err := digests.get(resolved, fi); err == nil {
+				w.Header().Set("ETag", `"`+d.String()+`"`)
+				w.Header().Set("Cache-Control", "no-cache")
+			}
+		}
+		if !strings.HasSuffix(resolved, ZstdExtension) {
+			// The http.FileServer sets the Last-Modified header, and honors
+			// the conditional request headers using the ETag header.
+			fs.ServeHTTP(w, r)
+			return
+		}
+		serveCompressed(w, r, resolved)
+	})
+}
+
+// ArtifactTokens mints the per-namespace bearer tokens with which private
+// artifacts are downloaded from the file server.
+type ArtifactTokens struct {
+	// Key is the key the tokens are derived from. Changing the key
+	// invalidates all tokens.
+	Key []byte
+}
+
+// Token returns the bearer token for the artifacts of the given namespace.
+func (t *ArtifactTokens) Token(namespace string) string {
+	mac := hmac.New(sha256.New, t.Key)
+	mac.Write([]byte(namespace))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// WithPrivateArtifacts returns an http.Handler which requires the bearer
+// token of the namespace for requests to the artifacts of HelmRepositories
+// with .spec.private set to true, before passing them on to the given
+// http.Handler. Requests for other artifacts are passed on as is.
+//
+// As the privacy of an artifact can only be determined from its object, the
+// directories of HelmRepository artifacts are not listed, and the artifacts
+// of HelmRepositories which can not be found are not served.
+func WithPrivateArtifacts(next http.Handler, c client.Reader, tokens *ArtifactTokens) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Artifact paths are in the format of <kind>/<namespace>/<name>/<file>.
+		parts := strings.Split(strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/"), "/")
+		if parts[0] != strings.ToLower(helmv1.HelmRepositoryKind) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(parts) < 4 {
+			http.NotFound(w, r)
+			return
+		}
+
+		obj := &helmv1.HelmRepository{}
+		if err := c.Get(r.Context(), types.NamespacedName{Namespace: parts[1], Name: parts[2]}, obj); err != nil {
+			if apierrors.IsNotFound(err) {
+				http.NotFound(w, r)
+				return
+			}
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			return
+		}
+		if obj.Spec.Private {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(tokens.Token(obj.Namespace))) != 1 {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// fileDigestCacheMaxEntries is the maximum number of entries in a
+// fileDigestCache, after which it is reset.
+const fileDigestCacheMaxEntries = 10000
+
+// fileDigestCache caches the digests of the files served by the file server,
+// which are invalidated when the modification time or size of a file
+// changes.
+type fileDigestCache struct {
+	entries map[string]fileDigest
+	mu      sync.Mutex
+}
+
+type fileDigest struct {
+	modTime time.Time
+	size    int64
+	digest  digest.Digest
+}
+
+// get returns the digest of the file at the given path, calculated using
+// the intdigest.Canonical algorithm. For files with the ZstdExtension, the
+// digest is calculated over the decompressed contents, matching the digest
+// of the artifact.
+func (c *fileDigestCache) get(p string, fi os.FileInfo) (digest.Digest, error) {
+	c.mu.Lock()
+	e, ok := c.entries[p]
+	c.mu.Unlock()
+	if ok && e.modTime.Equal(fi.ModTime()) && e.size == fi.Size() {
+		return e.digest, nil
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	var rd io.Reader = f
+	if strings.HasSuffix(p, ZstdExtension) {
+		zr, err := zstd.NewReader(f)
+		if err != nil {
+			return "", err
+		}
+		defer zr.Close()
+		rd = zr
+	}
+	d, err := intdigest.Canonical.FromReader(rd)
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= fileDigestCacheMaxEntries {
+		c.entries = make(map[string]fileDigest)
+	}
+	c.entries[p] = fileDigest{modTime: fi.ModTime(), size: fi.Size(), digest: d}
+	return d, nil
+}
+
+// notModified returns true if the If-None-Match header of the request
+// matches the given ETag, or if the request has no If-None-Match header and
+// the If-Modified-Since header is not before the given modification time.
+func notModified(r *http.Request, etag string, modTime time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		if etag == "" {
+			return false
+		}
+		for _, v := range strings.Split(inm, ",") {
+			v = strings.TrimPrefix(strings.TrimSpace(v), "W/")
+			if v == "*" || v == etag {
+				return true
+			}
+		}
+		return false
+	}
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" {
+		t, err := http.ParseTime(ims)
+		return err == nil && !modTime.Truncate(time.Second).After(t)
+	}
+	return false
+}
+
+// withinDir returns true if the given path is located within the given
+// directory.
+func withinDir(dir, p string) bool {
+	if d, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = d
+	}
+	rel, err := filepath.Rel(dir, p)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// serveCompressed writes the decompressed contents of the zstd compressed
+// file at the given path to the http.ResponseWriter.
+func serveCompressed(w http.ResponseWriter, r *http.Request, path string) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			http.NotFound(w, r)
+			return
+		}
+		http.Error(w, "500 Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil || !fi.Mode().IsRegular() {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Last-Modified", fi.ModTime().UTC().Format(http.TimeFormat))
+	if (r.Method == http.MethodGet || r.Method == http.MethodHead) && notModified(r, w.Header().Get("ETag"), fi.ModTime()) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	zr, err := zstd.NewReader(f)
+	if err != nil {
+		http.Error(w, "500 Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	defer zr.Close()
+
+	ctype := mime.TypeByExtension(filepath.Ext(strings.TrimSuffix(path, ZstdExtension)))
+	if ctype == "" {
+		ctype = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", ctype)
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodHead {
+		return
+	}
+	_, _ = io.Copy(w, zr)
+}
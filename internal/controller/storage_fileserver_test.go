@@ -0,0 +1,192 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1"
+	helmv1 "github.com/fluxcd/source-controller/api/v1beta2"
+)
+
+func TestNewFileServer(t *testing.T) {
+	g := NewWithT(t)
+
+	dir := t.TempDir()
+	storage, err := NewStorage(dir, "hostname", time.Minute, 2)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	plain := sourcev1.Artifact{Path: filepath.Join("plain", "index.yaml")}
+	g.Expect(storage.MkdirAll(plain)).To(Succeed())
+	g.Expect(storage.Copy(&plain, strings.NewReader("plain"))).To(Succeed())
+
+	compressed := sourcev1.Artifact{Path: filepath.Join("compressed", "index-abc.yaml"+ZstdExtension)}
+	g.Expect(storage.MkdirAll(compressed)).To(Succeed())
+	g.Expect(storage.Copy(&compressed, strings.NewReader("compressed"))).To(Succeed())
+	_, err = storage.Symlink(compressed, "index.yaml")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "uncompressed artifact",
+			path:       "/plain/index.yaml",
+			wantStatus: http.StatusOK,
+			wantBody:   "plain",
+		},
+		{
+			name:       "compressed artifact",
+			path:       "/compressed/index-abc.yaml.zst",
+			wantStatus: http.StatusOK,
+			wantBody:   "compressed",
+		},
+		{
+			name:       "symlink to compressed artifact",
+			path:       "/compressed/index.yaml",
+			wantStatus: http.StatusOK,
+			wantBody:   "compressed",
+		},
+		{
+			name:       "missing compressed artifact",
+			path:       "/compressed/missing.yaml.zst",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	handler := NewFileServer(dir)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			g.Expect(rec.Code).To(Equal(tt.wantStatus))
+			if tt.wantBody != "" {
+				g.Expect(rec.Body.String()).To(Equal(tt.wantBody))
+			}
+		})
+	}
+}
+
+func TestNewFileServer_CachingHeaders(t *testing.T) {
+	g := NewWithT(t)
+
+	dir := t.TempDir()
+	storage, err := NewStorage(dir, "hostname", time.Minute, 2)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	plain := sourcev1.Artifact{Path: filepath.Join("plain", "index.yaml")}
+	compressed := sourcev1.Artifact{Path: filepath.Join("compressed", "index-abc.yaml"+ZstdExtension)}
+	for _, a := range []*sourcev1.Artifact{&plain, &compressed} {
+		g.Expect(storage.MkdirAll(*a)).To(Succeed())
+		g.Expect(storage.Copy(a, strings.NewReader("content"))).To(Succeed())
+	}
+
+	handler := NewFileServer(dir)
+	for _, a := range []sourcev1.Artifact{plain, compressed} {
+		t.Run(a.Path, func(t *testing.T) {
+			g := NewWithT(t)
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/"+a.Path, nil))
+			g.Expect(rec.Code).To(Equal(http.StatusOK))
+			g.Expect(rec.Header().Get("ETag")).To(Equal(`"` + a.Digest + `"`))
+			g.Expect(rec.Header().Get("Cache-Control")).To(Equal("no-cache"))
+			g.Expect(rec.Header().Get("Last-Modified")).ToNot(BeEmpty())
+
+			req := httptest.NewRequest(http.MethodGet, "/"+a.Path, nil)
+			req.Header.Set("If-None-Match", `"`+a.Digest+`"`)
+			rec = httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			g.Expect(rec.Code).To(Equal(http.StatusNotModified))
+			g.Expect(rec.Body.Len()).To(BeZero())
+
+			req = httptest.NewRequest(http.MethodGet, "/"+a.Path, nil)
+			req.Header.Set("If-None-Match", `"sha256:other"`)
+			rec = httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			g.Expect(rec.Code).To(Equal(http.StatusOK))
+			g.Expect(rec.Body.String()).To(Equal("content"))
+		})
+	}
+}
+
+func TestWithPrivateArtifacts(t *testing.T) {
+	tokens := &ArtifactTokens{Key: []byte("key")}
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	c := fakeclient.NewClientBuilder().
+		WithScheme(testEnv.GetScheme()).
+		WithObjects(
+			&helmv1.HelmRepository{
+				ObjectMeta: metav1.ObjectMeta{Name: "private", Namespace: "default"},
+				Spec:       helmv1.HelmRepositorySpec{Private: true},
+			},
+			&helmv1.HelmRepository{
+				ObjectMeta: metav1.ObjectMeta{Name: "public", Namespace: "default"},
+			},
+		).Build()
+	handler := WithPrivateArtifacts(next, c, tokens)
+
+	tests := []struct {
+		name  string
+		path  string
+		token string
+		want  int
+	}{
+		{name: "public artifact", path: "/helmrepository/default/public/index.yaml", want: http.StatusOK},
+		{name: "other kind", path: "/gitrepository/default/private/artifact.tar.gz", want: http.StatusOK},
+		{name: "private artifact without token", path: "/helmrepository/default/private/index.yaml", want: http.StatusUnauthorized},
+		{name: "private artifact with token of other namespace", path: "/helmrepository/default/private/index.yaml", token: tokens.Token("other"), want: http.StatusUnauthorized},
+		{name: "private artifact with token", path: "/helmrepository/default/private/index.yaml", token: tokens.Token("default"), want: http.StatusOK},
+		{name: "private artifact with unclean path", path: "/helmrepository/../helmrepository/default/private/index.yaml", want: http.StatusUnauthorized},
+		{name: "artifact of missing object", path: "/helmrepository/default/missing/index.yaml", want: http.StatusNotFound},
+		{name: "artifact directory listing", path: "/helmrepository/default/private/", want: http.StatusNotFound},
+		{name: "namespace directory listing", path: "/helmrepository/default", want: http.StatusNotFound},
+		{name: "kind directory listing", path: "/helmrepository/", want: http.StatusNotFound},
+		{name: "other kind directory listing", path: "/gitrepository/default/", want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+			req.URL.Path = tt.path
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			g.Expect(rec.Code).To(Equal(tt.want))
+		})
+	}
+}
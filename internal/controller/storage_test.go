@@ -21,16 +21,19 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"syscall"
 	"testing"
 	"time"
 
 	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+	"github.com/klauspost/compress/zstd"
 	. "github.com/onsi/gomega"
 
 	sourcev1 "github.com/fluxcd/source-controller/api/v1"
@@ -340,6 +343,49 @@ func TestStorage_Remove(t *testing.T) {
 	})
 }
 
+func TestStorage_Symlink(t *testing.T) {
+	tests := []struct {
+		name     string
+		linkName string
+		wantURL  string
+		wantErr  string
+	}{
+		{name: "valid name", linkName: "index.yaml", wantURL: "http://hostname/foo/bar/index.yaml"},
+		{name: "empty name", linkName: "", wantErr: "invalid symlink name"},
+		{name: "parent directory", linkName: "..", wantErr: "invalid symlink name"},
+		{name: "path traversal", linkName: "../../index.yaml", wantErr: "invalid symlink name"},
+		{name: "nested path", linkName: "sub/index.yaml", wantErr: "invalid symlink name"},
+		{name: "artifact file name", linkName: "index-abc.yaml", wantErr: "equals the artifact file name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			s, err := NewStorage(t.TempDir(), "hostname", time.Minute, 2)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			artifact := sourcev1.Artifact{
+				Path: filepath.Join("foo", "bar", "index-abc.yaml"),
+			}
+			g.Expect(s.MkdirAll(artifact)).To(Succeed())
+			g.Expect(s.AtomicWriteFile(&artifact, bytes.NewReader([]byte("test")), 0o600)).To(Succeed())
+
+			url, err := s.Symlink(artifact, tt.linkName)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				g.Expect(s.ArtifactExist(artifact)).To(BeTrue())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(url).To(Equal(tt.wantURL))
+			target, err := os.Readlink(filepath.Join(s.BasePath, "foo", "bar", tt.linkName))
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(target).To(Equal(s.LocalPath(artifact)))
+		})
+	}
+}
+
 func TestStorageRemoveAllButCurrent(t *testing.T) {
 	t.Run("bad directory in archive", func(t *testing.T) {
 		dir := t.TempDir()
@@ -529,6 +575,175 @@ func TestStorageCopyFromPath(t *testing.T) {
 	}
 }
 
+type failingReader struct {
+	err error
+}
+
+func (r failingReader) Read(p []byte) (int, error) {
+	return 0, r.err
+}
+
+func TestStorage_CopyDiskFull(t *testing.T) {
+	g := NewWithT(t)
+
+	dir := t.TempDir()
+	storage, err := NewStorage(dir, "hostname", time.Minute, 2)
+	g.Expect(err).ToNot(HaveOccurred(), "failed to create storage")
+
+	artifact := sourcev1.Artifact{
+		Path: filepath.Join("foo", "bar", "index.yaml"),
+	}
+	g.Expect(storage.MkdirAll(artifact)).To(Succeed())
+
+	err = storage.Copy(&artifact, failingReader{err: syscall.ENOSPC})
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(IsDiskFull(err)).To(BeTrue())
+	g.Expect(artifact.Digest).To(BeEmpty())
+
+	// Ensure no partial or temporary file is left behind.
+	entries, err := os.ReadDir(filepath.Dir(storage.LocalPath(artifact)))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(entries).To(BeEmpty())
+
+	// A subsequent copy recovers cleanly.
+	g.Expect(storage.Copy(&artifact, strings.NewReader("foo"))).To(Succeed())
+	g.Expect(artifact.Digest).ToNot(BeEmpty())
+	b, err := os.ReadFile(storage.LocalPath(artifact))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(b)).To(Equal("foo"))
+}
+
+func TestStorage_CopyCompressed(t *testing.T) {
+	g := NewWithT(t)
+
+	dir := t.TempDir()
+	storage, err := NewStorage(dir, "hostname", time.Minute, 2)
+	g.Expect(err).ToNot(HaveOccurred(), "failed to create storage")
+
+	artifact := sourcev1.Artifact{
+		Path: filepath.Join("foo", "bar", "index.yaml"+ZstdExtension),
+	}
+	g.Expect(IsCompressedArtifact(artifact)).To(BeTrue())
+	g.Expect(storage.MkdirAll(artifact)).To(Succeed())
+
+	content := strings.Repeat("foo", 100)
+	g.Expect(storage.Copy(&artifact, strings.NewReader(content))).To(Succeed())
+
+	// The digest and size are calculated over the uncompressed content.
+	g.Expect(artifact.Digest).To(Equal("sha256:" + fmt.Sprintf("%x", sha256.Sum256([]byte(content)))))
+	g.Expect(*artifact.Size).To(Equal(int64(len(content))))
+	g.Expect(storage.VerifyArtifact(artifact)).To(Succeed())
+
+	f, err := os.Open(storage.LocalPath(artifact))
+	g.Expect(err).ToNot(HaveOccurred())
+	defer f.Close()
+	zr, err := zstd.NewReader(f)
+	g.Expect(err).ToNot(HaveOccurred())
+	defer zr.Close()
+	b, err := io.ReadAll(zr)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(string(b)).To(Equal(content))
+}
+
+func TestStorage_FileAndDirMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileMode os.FileMode
+		dirMode  os.FileMode
+		wantFile os.FileMode
+		wantDir  os.FileMode
+	}{
+		{
+			name:     "defaults",
+			wantFile: DefaultArtifactFileMode,
+			wantDir:  DefaultArtifactDirMode,
+		},
+		{
+			name:     "configured",
+			fileMode: 0o640,
+			dirMode:  0o750,
+			wantFile: 0o640,
+			wantDir:  0o750,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			dir := t.TempDir()
+			storage, err := NewStorage(dir, "hostname", time.Minute, 2)
+			g.Expect(err).ToNot(HaveOccurred(), "failed to create storage")
+			storage.FileMode = tt.fileMode
+			storage.DirMode = tt.dirMode
+
+			artifact := sourcev1.Artifact{
+				Path: filepath.Join("foo", "bar", "file"),
+			}
+			g.Expect(storage.MkdirAll(artifact)).To(Succeed())
+			g.Expect(storage.Copy(&artifact, strings.NewReader("foo"))).To(Succeed())
+
+			fi, err := os.Stat(storage.LocalPath(artifact))
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(fi.Mode().Perm()).To(Equal(tt.wantFile))
+
+			di, err := os.Stat(filepath.Dir(storage.LocalPath(artifact)))
+			g.Expect(err).ToNot(HaveOccurred())
+			// The umask may clear bits of the directory mode.
+			g.Expect(di.Mode().Perm() &^ tt.wantDir).To(BeZero())
+
+			tarArtifact := sourcev1.Artifact{
+				Path: filepath.Join("foo", "bar", "archive.tar.gz"),
+			}
+			g.Expect(storage.Archive(&tarArtifact, t.TempDir(), nil)).To(Succeed())
+			fi, err = os.Stat(storage.LocalPath(tarArtifact))
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(fi.Mode().Perm()).To(Equal(tt.wantFile))
+		})
+	}
+}
+
+func TestStorage_BackupArtifact(t *testing.T) {
+	g := NewWithT(t)
+
+	storage, err := NewStorage(t.TempDir(), "hostname", time.Minute, 1)
+	g.Expect(err).ToNot(HaveOccurred(), "failed to create storage")
+	storage.Backup, err = NewStorage(t.TempDir(), "hostname", time.Minute, 1)
+	g.Expect(err).ToNot(HaveOccurred(), "failed to create backup storage")
+
+	artifact := sourcev1.Artifact{
+		Path: filepath.Join("foo", "bar", "first.zst"),
+	}
+	g.Expect(storage.MkdirAll(artifact)).To(Succeed())
+	g.Expect(storage.Copy(&artifact, strings.NewReader("first"))).To(Succeed())
+	g.Expect(storage.BackupArtifact(artifact)).To(Succeed())
+
+	// The file is mirrored as stored.
+	want, err := os.ReadFile(storage.LocalPath(artifact))
+	g.Expect(err).ToNot(HaveOccurred())
+	got, err := os.ReadFile(storage.Backup.LocalPath(artifact))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(got).To(Equal(want))
+
+	// Garbage collection applies to the backup.
+	current := sourcev1.Artifact{
+		Path: filepath.Join("foo", "bar", "second"),
+	}
+	g.Expect(storage.Copy(&current, strings.NewReader("second"))).To(Succeed())
+	g.Expect(storage.BackupArtifact(current)).To(Succeed())
+	deleted, err := storage.RemoveAllButCurrent(current)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(deleted).To(ConsistOf(storage.LocalPath(artifact), storage.Backup.LocalPath(artifact)))
+	g.Expect(storage.Backup.LocalPath(current)).To(BeAnExistingFile())
+
+	_, err = storage.RemoveAll(current)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(storage.Backup.LocalPath(current)).ToNot(BeAnExistingFile())
+
+	// Without a backup, nothing is mirrored.
+	storage.Backup = nil
+	g.Expect(storage.BackupArtifact(current)).To(Succeed())
+}
+
 func TestStorage_getGarbageFiles(t *testing.T) {
 	artifactFolder := filepath.Join("foo", "bar")
 	tests := []struct {
@@ -794,6 +1009,75 @@ func TestStorage_GarbageCollect(t *testing.T) {
 	}
 }
 
+func TestStorage_GarbageCollectReplacementGracePeriod(t *testing.T) {
+	artifactFolder := filepath.Join("foo", "bar")
+	now := time.Now()
+
+	tests := []struct {
+		name             string
+		gracePeriod      time.Duration
+		currentAge       time.Duration
+		wantCollected    []string
+		wantNotCollected []string
+	}{
+		{
+			name:          "no grace period",
+			currentAge:    time.Second,
+			wantCollected: []string{"artifact1.tar.gz", "artifact2.tar.gz"},
+		},
+		{
+			name:             "within grace period",
+			gracePeriod:      time.Minute,
+			currentAge:       time.Second,
+			wantCollected:    []string{"artifact1.tar.gz"},
+			wantNotCollected: []string{"artifact2.tar.gz"},
+		},
+		{
+			name:          "after grace period",
+			gracePeriod:   time.Minute,
+			currentAge:    2 * time.Minute,
+			wantCollected: []string{"artifact1.tar.gz", "artifact2.tar.gz"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			dir := t.TempDir()
+
+			s, err := NewStorage(dir, "hostname", time.Second, 1)
+			g.Expect(err).ToNot(HaveOccurred(), "failed to create new storage")
+			s.ArtifactReplacementGracePeriod = tt.gracePeriod
+
+			g.Expect(os.MkdirAll(filepath.Join(dir, artifactFolder), 0o750)).To(Succeed())
+			modTimes := map[string]time.Time{
+				"artifact1.tar.gz": now.Add(-1 * time.Hour),
+				"artifact2.tar.gz": now.Add(-tt.currentAge - time.Minute),
+				"artifact3.tar.gz": now.Add(-tt.currentAge),
+			}
+			for name, modTime := range modTimes {
+				p := filepath.Join(dir, artifactFolder, name)
+				g.Expect(os.WriteFile(p, []byte(name), 0o600)).To(Succeed())
+				g.Expect(os.Chtimes(p, modTime, modTime)).To(Succeed())
+			}
+
+			artifact := sourcev1.Artifact{
+				Path: filepath.Join(artifactFolder, "artifact3.tar.gz"),
+			}
+			collected, err := s.GarbageCollect(context.TODO(), artifact, time.Second)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(collected).To(HaveLen(len(tt.wantCollected)))
+			for _, name := range tt.wantCollected {
+				g.Expect(filepath.Join(dir, artifactFolder, name)).ToNot(BeAnExistingFile())
+			}
+			for _, name := range tt.wantNotCollected {
+				g.Expect(filepath.Join(dir, artifactFolder, name)).To(BeAnExistingFile())
+			}
+			g.Expect(s.LocalPath(artifact)).To(BeAnExistingFile())
+		})
+	}
+}
+
 func TestStorage_VerifyArtifact(t *testing.T) {
 	g := NewWithT(t)
 
@@ -0,0 +1,65 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/fluxcd/source-controller/internal/helm/repository"
+)
+
+func TestHelmRepositoryReconciler_loadIndexSpan(t *testing.T) {
+	g := NewWithT(t)
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	tracer := provider.Tracer(TracerName)
+
+	indexPath := filepath.Join(t.TempDir(), "index.yaml")
+	g.Expect(os.WriteFile(indexPath, []byte("invalid"), 0o600)).To(Succeed())
+	chartRepo, err := repository.NewChartRepository("https://example.com", indexPath, testGetters, nil)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	ctx, parent := tracer.Start(context.TODO(), "HelmRepository.Reconcile")
+	r := &HelmRepositoryReconciler{}
+	loadErr := r.loadIndex(ctx, chartRepo)
+	g.Expect(loadErr).To(HaveOccurred())
+	parent.End()
+
+	// The global TracerProvider is not configured, which makes the spans of
+	// the reconciler no-ops.
+	spans := recorder.Ended()
+	g.Expect(spans).To(HaveLen(1))
+	g.Expect(spans[0].Name()).To(Equal("HelmRepository.Reconcile"))
+
+	// Spans ended with an error record the error.
+	_, span := tracer.Start(ctx, "load index")
+	endSpan(span, loadErr)
+	spans = recorder.Ended()
+	g.Expect(spans).To(HaveLen(2))
+	g.Expect(spans[1].Parent().SpanID()).To(Equal(spans[0].SpanContext().SpanID()))
+	g.Expect(spans[1].Status().Code).To(Equal(codes.Error))
+	g.Expect(spans[1].Events()).To(HaveLen(1))
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cron
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr string
+	}{
+		{expr: "0 2 * * *"},
+		{expr: "*/15 0-6,22-23 1,15 jan-jun mon-fri"},
+		{expr: "@daily"},
+		{expr: "0 0 * * 7"},
+		{expr: "0 2 * *", wantErr: "expected 5 fields, got 4"},
+		{expr: "60 * * * *", wantErr: "invalid value '60' in minute field"},
+		{expr: "* * 0 * *", wantErr: "invalid value '0' in day of month field"},
+		{expr: "*/0 * * * *", wantErr: "invalid step '0' in minute field"},
+		{expr: "* 5-2 * * *", wantErr: "invalid range '5-2' in hour field"},
+		{expr: "* * * foo *", wantErr: "invalid value 'foo' in month field"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			g := NewWithT(t)
+
+			_, err := Parse(tt.expr)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+		})
+	}
+}
+
+func TestSchedule_Next(t *testing.T) {
+	// A Tuesday.
+	from := time.Date(2023, time.August, 15, 10, 30, 15, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{expr: "0 2 * * *", want: time.Date(2023, time.August, 16, 2, 0, 0, 0, time.UTC)},
+		{expr: "*/20 * * * *", want: time.Date(2023, time.August, 15, 10, 40, 0, 0, time.UTC)},
+		{expr: "30 10 * * *", want: time.Date(2023, time.August, 16, 10, 30, 0, 0, time.UTC)},
+		{expr: "0 0 * * sun", want: time.Date(2023, time.August, 20, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 0 * * 7", want: time.Date(2023, time.August, 20, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 0 1 * *", want: time.Date(2023, time.September, 1, 0, 0, 0, 0, time.UTC)},
+		{expr: "@yearly", want: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 0 29 2 *", want: time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		// Either the day of the month or the day of the week matches.
+		{expr: "0 0 1 * fri", want: time.Date(2023, time.August, 18, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 0 31 2 *", want: time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			g := NewWithT(t)
+
+			s, err := Parse(tt.expr)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(s.Next(from)).To(Equal(tt.want))
+		})
+	}
+}
@@ -21,6 +21,8 @@ import (
 	"crypto/tls"
 	"errors"
 	"fmt"
+	"net/http"
+	neturl "net/url"
 	"os"
 	"path"
 
@@ -34,8 +36,10 @@ import (
 
 	helmv1 "github.com/fluxcd/source-controller/api/v1beta2"
 	"github.com/fluxcd/source-controller/internal/helm/registry"
+	"github.com/fluxcd/source-controller/internal/helm/repository"
 	soci "github.com/fluxcd/source-controller/internal/oci"
 	stls "github.com/fluxcd/source-controller/internal/tls"
+	"github.com/fluxcd/source-controller/internal/transport"
 )
 
 const (
@@ -54,6 +58,22 @@ type ClientOpts struct {
 	RegLoginOpts  []helmreg.LoginOption
 	TlsConfig     *tls.Config
 	GetterOpts    []helmgetter.Option
+	AuthMethods   []repository.AuthMethod
+	// RequestSigner signs the index requests, if not nil.
+	RequestSigner *transport.HTTPSigner
+	// SigV4Signer signs the index requests with an AWS Signature Version 4,
+	// if not nil.
+	SigV4Signer *transport.SigV4Signer
+	// SPNEGO authenticates the index requests using SPNEGO, if not nil. It
+	// must be closed by the caller.
+	SPNEGO *transport.SPNEGONegotiator
+	// SecretKeys describes the keys of the authentication Secret, if any.
+	SecretKeys *SecretKeys
+	// ProxyURL is the URL of the proxy requests are sent through, including
+	// the credentials of the proxy, if not nil.
+	ProxyURL *neturl.URL
+	// Headers are the headers set on the index requests, if not nil.
+	Headers http.Header
 }
 
 // MustLoginToRegistry returns true if the client options contain at least
@@ -62,6 +82,15 @@ func (o ClientOpts) MustLoginToRegistry() bool {
 	return len(o.RegLoginOpts) > 0 && o.RegLoginOpts[0] != nil
 }
 
+// HasCredentials returns true if the client options contain credentials
+// which are sent along with the index requests, i.e. the data of an
+// authentication Secret, the headers, the authentication methods, or a
+// request signer or SPNEGO negotiator.
+func (o ClientOpts) HasCredentials() bool {
+	return o.SecretKeys != nil || len(o.Headers) > 0 || len(o.AuthMethods) > 0 ||
+		o.RequestSigner != nil || o.SigV4Signer != nil || o.SPNEGO != nil
+}
+
 // GetClientOpts uses the provided HelmRepository object and a normalized
 // URL to construct a HelmClientOpts object. If obj is an OCI HelmRepository,
 // then the returned options object will also contain the required registry
@@ -108,13 +137,37 @@ func GetClientOpts(ctx context.Context, c client.Client, obj *helmv1.HelmReposit
 			return nil, "", fmt.Errorf("failed to get authentication secret '%s/%s': %w", obj.GetNamespace(), obj.Spec.SecretRef.Name, err)
 		}
 
+		secretKeys := SecretKeysFromSecret(*authSecret, ociRepo)
+		hrOpts.SecretKeys = &secretKeys
+
 		// Construct actual Helm client options.
 		opts, err := GetterOptionsFromSecret(*authSecret)
 		if err != nil {
-			return nil, "", fmt.Errorf("failed to configure Helm client: %w", err)
+			return nil, "", fmt.Errorf("failed to configure Helm client: %w (%s)", err, secretKeys)
 		}
 		hrOpts.GetterOpts = append(hrOpts.GetterOpts, opts...)
 
+		if !ociRepo {
+			var host string
+			if !obj.Spec.PassCredentials {
+				if u, err := neturl.Parse(url); err == nil {
+					host = u.Host
+				}
+			}
+			hrOpts.AuthMethods, err = AuthMethodsFromSecret(*authSecret, host)
+			if err != nil {
+				return nil, "", fmt.Errorf("failed to configure Helm client: %w (%s)", err, secretKeys)
+			}
+			hrOpts.RequestSigner, err = RequestSignerFromSecret(*authSecret)
+			if err != nil {
+				return nil, "", fmt.Errorf("failed to configure Helm client: %w (%s)", err, secretKeys)
+			}
+			hrOpts.SPNEGO, err = SPNEGONegotiatorFromSecret(*authSecret)
+			if err != nil {
+				return nil, "", fmt.Errorf("failed to configure Helm client: %w (%s)", err, secretKeys)
+			}
+		}
+
 		// If the TLS config is nil, i.e. one couldn't be constructed using
 		// `.spec.certSecretRef`, then try to use `.spec.secretRef`.
 		if hrOpts.TlsConfig == nil && !ociRepo {
@@ -131,7 +184,7 @@ func GetClientOpts(ctx context.Context, c client.Client, obj *helmv1.HelmReposit
 		if ociRepo {
 			hrOpts.Keychain, err = registry.LoginOptionFromSecret(url, *authSecret)
 			if err != nil {
-				return nil, "", fmt.Errorf("failed to configure login options: %w", err)
+				return nil, "", fmt.Errorf("failed to configure login options: %w (%s)", err, secretKeys)
 			}
 		}
 	} else if obj.Spec.Provider != helmv1.GenericOCIProvider && obj.Spec.Type == helmv1.HelmRepositoryTypeOCI && ociRepo {
@@ -144,6 +197,37 @@ func GetClientOpts(ctx context.Context, c client.Client, obj *helmv1.HelmReposit
 		}
 	}
 
+	// Sign the index requests of a default HelmRepository with SigV4 for
+	// the 'aws' provider.
+	if obj.Spec.Provider == helmv1.AmazonOCIProvider && !ociRepo {
+		hrOpts.SigV4Signer, err = SigV4SignerFromSecret(authSecret)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to configure SigV4 signing: %w", err)
+		}
+	}
+
+	if obj.Spec.ProxySecretRef != nil && !ociRepo {
+		proxySecret, err := fetchSecret(ctx, c, obj.Spec.ProxySecretRef.Name, obj.GetNamespace())
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to get proxy secret '%s/%s': %w", obj.GetNamespace(), obj.Spec.ProxySecretRef.Name, err)
+		}
+		hrOpts.ProxyURL, err = ProxyURLFromSecret(*proxySecret)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to configure proxy: %w", err)
+		}
+	}
+
+	if obj.Spec.HeadersSecretRef != nil && !ociRepo {
+		headersSecret, err := fetchSecret(ctx, c, obj.Spec.HeadersSecretRef.Name, obj.GetNamespace())
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to get headers secret '%s/%s': %w", obj.GetNamespace(), obj.Spec.HeadersSecretRef.Name, err)
+		}
+		hrOpts.Headers, err = HeadersFromSecret(*headersSecret)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to configure request headers: %w", err)
+		}
+	}
+
 	if ociRepo {
 		// Persist the certs files to the path if needed.
 		if tlsBytes != nil {
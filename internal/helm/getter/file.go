@@ -0,0 +1,87 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package getter
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+
+	helmgetter "helm.sh/helm/v3/pkg/getter"
+
+	"github.com/fluxcd/source-controller/internal/helm"
+)
+
+// FileScheme is the URL scheme of Helm repositories on the local file
+// system of the controller.
+const FileScheme = "file"
+
+// FileProvider is the helmgetter.Provider for the FileScheme.
+var FileProvider = helmgetter.Provider{
+	Schemes: []string{FileScheme},
+	New:     NewFileGetter,
+}
+
+// NewFileGetter constructs a helmgetter.Getter which reads the file at the
+// path of a file:// URL from the local file system. The options are ignored,
+// as they only apply to remote repositories.
+//
+// As it allows reading any file the controller has access to, it must only
+// be registered when explicitly enabled.
+func NewFileGetter(_ ...helmgetter.Option) (helmgetter.Getter, error) {
+	return &fileGetter{}, nil
+}
+
+type fileGetter struct{}
+
+// Get implements helmgetter.Getter.
+func (g *fileGetter) Get(u string, _ ...helmgetter.Option) (*bytes.Buffer, error) {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return nil, err
+	}
+	if parsed.Scheme != FileScheme {
+		return nil, fmt.Errorf("unsupported scheme '%s' for file getter", parsed.Scheme)
+	}
+	if parsed.Host != "" && parsed.Host != "localhost" {
+		return nil, fmt.Errorf("unsupported host '%s' in file URL: only local files are supported", parsed.Host)
+	}
+
+	f, err := os.Open(parsed.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open '%s': %w", parsed.Path, err)
+	}
+	defer f.Close()
+
+	// Bound the read to the largest of the Helm file limits, as the getter
+	// is used for both the index and the charts.
+	limit := helm.MaxIndexSize
+	if helm.MaxChartSize > limit {
+		limit = helm.MaxChartSize
+	}
+	buf := &bytes.Buffer{}
+	n, err := io.Copy(buf, io.LimitReader(f, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read '%s': %w", parsed.Path, err)
+	}
+	if n > limit {
+		return nil, fmt.Errorf("size of '%s' exceeds the limit of %d bytes", parsed.Path, limit)
+	}
+	return buf, nil
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package getter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestFileGetter_Get(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "index.yaml")
+	if err := os.WriteFile(p, []byte("apiVersion: v1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		url     string
+		want    string
+		wantErr string
+	}{
+		{name: "file", url: "file://" + p, want: "apiVersion: v1\n"},
+		{name: "localhost", url: "file://localhost" + p, want: "apiVersion: v1\n"},
+		{name: "remote host", url: "file://example.com" + p, wantErr: "only local files are supported"},
+		{name: "not found", url: "file://" + filepath.Join(dir, "missing.yaml"), wantErr: "failed to open"},
+		{name: "other scheme", url: "https://example.com/index.yaml", wantErr: "unsupported scheme"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			getter, err := NewFileGetter()
+			g.Expect(err).ToNot(HaveOccurred())
+
+			got, err := getter.Get(tt.url)
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(got.String()).To(Equal(tt.want))
+		})
+	}
+}
@@ -18,15 +18,82 @@ package getter
 
 import (
 	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
 
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"golang.org/x/net/http/httpguts"
 	"helm.sh/helm/v3/pkg/getter"
 	corev1 "k8s.io/api/core/v1"
+
+	"github.com/fluxcd/source-controller/internal/helm/repository"
+	"github.com/fluxcd/source-controller/internal/transport"
+)
+
+const (
+	// AuthMethodBasic is the name of the basic authentication method, using
+	// the "username" and "password" of the secret.
+	AuthMethodBasic = "basic"
+	// AuthMethodBearer is the name of the bearer token authentication method,
+	// using the "bearerToken" of the secret.
+	AuthMethodBearer = "bearer"
 )
 
+// SecretKeys describes which keys of an authentication Secret are recognized
+// and used to configure the client, without exposing their values.
+type SecretKeys struct {
+	// Used are the recognized keys found in the Secret.
+	Used []string
+	// Ignored are the keys found in the Secret which are not recognized.
+	Ignored []string
+}
+
+// String returns a description of the used and ignored keys.
+func (k SecretKeys) String() string {
+	return fmt.Sprintf("used keys: [%s], ignored keys: [%s]", strings.Join(k.Used, ", "), strings.Join(k.Ignored, ", "))
+}
+
+// SecretKeysFromSecret returns the keys of the given authentication Secret
+// which are recognized and ignored, for an OCI or HTTP/S Helm repository.
+func SecretKeysFromSecret(secret corev1.Secret, ociRepo bool) SecretKeys {
+	recognized := []string{"username", "password", "authMethods", "bearerToken", "signatureKeyId", "signatureKey", "kerberosPrincipal", "kerberosKeytab", "kerberosConfig", "kerberosSPN", "accesskey", "secretkey", "region", "certFile", "keyFile", "caFile"}
+	if ociRepo {
+		recognized = []string{"username", "password", corev1.DockerConfigJsonKey}
+	}
+
+	var keys SecretKeys
+	for k := range secret.Data {
+		used := false
+		for _, r := range recognized {
+			if k == r {
+				used = true
+				break
+			}
+		}
+		if used {
+			keys.Used = append(keys.Used, k)
+		} else {
+			keys.Ignored = append(keys.Ignored, k)
+		}
+	}
+	sort.Strings(keys.Used)
+	sort.Strings(keys.Ignored)
+	return keys
+}
+
 // GetterOptionsFromSecret constructs a getter.Option slice for the given secret.
 // It returns the slice, or an error.
+//
+// When the secret defines "authMethods", the authentication options are
+// omitted, as they are configured using AuthMethodsFromSecret instead.
 func GetterOptionsFromSecret(secret corev1.Secret) ([]getter.Option, error) {
 	var opts []getter.Option
+	if _, ok := secret.Data["authMethods"]; ok {
+		return opts, nil
+	}
 	basicAuth, err := basicAuthFromSecret(secret)
 	if err != nil {
 		return opts, err
@@ -52,3 +119,175 @@ func basicAuthFromSecret(secret corev1.Secret) (getter.Option, error) {
 	}
 	return getter.WithBasicAuth(username, password), nil
 }
+
+// AuthMethodsFromSecret constructs the repository.AuthMethod slice for the
+// comma-separated list of method names in the "authMethods" of the given
+// secret, in the order they are listed. It returns nil if the secret does not
+// define "authMethods", or an error if a method is unknown or its secret data
+// is missing.
+//
+// The bearer token is restricted to the given host, or set for all hosts if
+// empty.
+func AuthMethodsFromSecret(secret corev1.Secret, host string) ([]repository.AuthMethod, error) {
+	v, ok := secret.Data["authMethods"]
+	if !ok {
+		return nil, nil
+	}
+
+	var methods []repository.AuthMethod
+	for _, name := range strings.Split(string(v), ",") {
+		name = strings.TrimSpace(name)
+		switch name {
+		case AuthMethodBasic:
+			basicAuth, err := basicAuthFromSecret(secret)
+			if err != nil {
+				return nil, err
+			}
+			if basicAuth == nil {
+				return nil, fmt.Errorf("invalid '%s' secret data: required fields 'username' and 'password' for '%s' auth method", secret.Name, name)
+			}
+			methods = append(methods, repository.AuthMethod{Name: name, Options: []getter.Option{basicAuth}})
+		case AuthMethodBearer:
+			token := string(secret.Data["bearerToken"])
+			if token == "" {
+				return nil, fmt.Errorf("invalid '%s' secret data: required field 'bearerToken' for '%s' auth method", secret.Name, name)
+			}
+			methods = append(methods, repository.AuthMethod{Name: name, BearerToken: token, BearerTokenHost: host})
+		default:
+			return nil, fmt.Errorf("invalid '%s' secret data: unsupported auth method '%s' in 'authMethods'", secret.Name, name)
+		}
+	}
+	return methods, nil
+}
+
+// RequestSignerFromSecret constructs a transport.HTTPSigner for the
+// "signatureKeyId" and "signatureKey" of the given secret, with which the
+// index requests are signed. It returns nil if the secret defines neither, or
+// an error if only one is defined or the key is invalid.
+func RequestSignerFromSecret(secret corev1.Secret) (*transport.HTTPSigner, error) {
+	keyID, key := string(secret.Data["signatureKeyId"]), secret.Data["signatureKey"]
+	switch {
+	case keyID == "" && len(key) == 0:
+		return nil, nil
+	case keyID == "" || len(key) == 0:
+		return nil, fmt.Errorf("invalid '%s' secret data: required fields 'signatureKeyId' and 'signatureKey'", secret.Name)
+	}
+	signer, err := transport.NewHTTPSigner(keyID, key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid '%s' secret data: %w", secret.Name, err)
+	}
+	return signer, nil
+}
+
+// SigV4SignerFromSecret constructs a transport.SigV4Signer with which the
+// index requests are signed for the 'aws' provider. The credentials are the
+// "accesskey" and "secretkey" of the given secret if it defines them, or are
+// retrieved from the workload identity of the controller otherwise. The
+// region is the "region" of the secret, or is taken from the AWS_REGION or
+// AWS_DEFAULT_REGION environment variables, defaulting to 'us-east-1'. The
+// secret may be nil. It returns an error if only one of "accesskey" and
+// "secretkey" is defined.
+func SigV4SignerFromSecret(secret *corev1.Secret) (*transport.SigV4Signer, error) {
+	var creds *credentials.Credentials
+	var region string
+	if secret != nil {
+		accessKey, secretKey := string(secret.Data["accesskey"]), string(secret.Data["secretkey"])
+		switch {
+		case accessKey != "" && secretKey != "":
+			creds = credentials.NewStaticV4(accessKey, secretKey, "")
+		case accessKey != "" || secretKey != "":
+			return nil, fmt.Errorf("invalid '%s' secret data: required fields 'accesskey' and 'secretkey'", secret.Name)
+		}
+		region = string(secret.Data["region"])
+	}
+	if creds == nil {
+		creds = credentials.NewIAM("")
+	}
+	for _, env := range []string{"AWS_REGION", "AWS_DEFAULT_REGION"} {
+		if region != "" {
+			break
+		}
+		region = os.Getenv(env)
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+	return transport.NewSigV4Signer(creds, region)
+}
+
+// SPNEGONegotiatorFromSecret constructs a transport.SPNEGONegotiator for the
+// "kerberosPrincipal", "kerberosKeytab", "kerberosConfig" and optional
+// "kerberosSPN" of the given secret, with which the index requests are
+// authenticated. It returns nil if the secret defines none of the required
+// fields, or an error if only some are defined or are invalid.
+func SPNEGONegotiatorFromSecret(secret corev1.Secret) (*transport.SPNEGONegotiator, error) {
+	principal, kt, conf := string(secret.Data["kerberosPrincipal"]), secret.Data["kerberosKeytab"], string(secret.Data["kerberosConfig"])
+	switch {
+	case principal == "" && len(kt) == 0 && conf == "":
+		return nil, nil
+	case principal == "" || len(kt) == 0 || conf == "":
+		return nil, fmt.Errorf("invalid '%s' secret data: required fields 'kerberosPrincipal', 'kerberosKeytab' and 'kerberosConfig'", secret.Name)
+	}
+	n, err := transport.NewSPNEGONegotiator(principal, kt, conf, string(secret.Data["kerberosSPN"]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid '%s' secret data: %w", secret.Name, err)
+	}
+	return n, nil
+}
+
+// ProxyURLFromSecret constructs the URL of the proxy for the "address" and
+// optional "username" and "password" of the given proxy secret. The address
+// must be an absolute http or https URL. Errors do not contain the
+// credentials.
+func ProxyURLFromSecret(secret corev1.Secret) (*url.URL, error) {
+	address := strings.TrimSpace(string(secret.Data["address"]))
+	if address == "" {
+		return nil, fmt.Errorf("invalid '%s' secret data: required field 'address'", secret.Name)
+	}
+	u, err := url.Parse(address)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid '%s' secret data: 'address' must be an absolute http or https URL", secret.Name)
+	}
+	username, password := string(secret.Data["username"]), string(secret.Data["password"])
+	switch {
+	case username != "" && password != "":
+		u.User = url.UserPassword(username, password)
+	case username != "" || password != "":
+		return nil, fmt.Errorf("invalid '%s' secret data: required fields 'username' and 'password'", secret.Name)
+	}
+	return u, nil
+}
+
+// reservedHeaders are the request headers which can not be set with a
+// headers secret, as they are managed by the HTTP client.
+var reservedHeaders = map[string]struct{}{
+	"Host":              {},
+	"Content-Length":    {},
+	"Transfer-Encoding": {},
+	"Connection":        {},
+}
+
+// HeadersFromSecret returns the request headers of the given headers secret,
+// of which every key is the name of a header and its value the value of the
+// header. Errors do not contain the header values.
+func HeadersFromSecret(secret corev1.Secret) (http.Header, error) {
+	if len(secret.Data) == 0 {
+		return nil, fmt.Errorf("invalid '%s' secret data: no headers", secret.Name)
+	}
+	headers := make(http.Header, len(secret.Data))
+	for name, value := range secret.Data {
+		if !httpguts.ValidHeaderFieldName(name) {
+			return nil, fmt.Errorf("invalid '%s' secret data: '%s' is not a valid header name", secret.Name, name)
+		}
+		name = http.CanonicalHeaderKey(name)
+		if _, ok := reservedHeaders[name]; ok {
+			return nil, fmt.Errorf("invalid '%s' secret data: header '%s' can not be set", secret.Name, name)
+		}
+		v := strings.TrimSpace(string(value))
+		if v == "" || !httpguts.ValidHeaderFieldValue(v) {
+			return nil, fmt.Errorf("invalid '%s' secret data: invalid value for header '%s'", secret.Name, name)
+		}
+		headers.Set(name, v)
+	}
+	return headers, nil
+}
@@ -18,20 +18,30 @@ package repository
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"math/rand"
+	"net/http"
 	"net/url"
 	"os"
 	"path"
+	"runtime"
 	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/Masterminds/semver/v3"
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
 	"github.com/opencontainers/go-digest"
+	"github.com/ulikunitz/xz"
 	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/getter"
 	"helm.sh/helm/v3/pkg/repo"
@@ -39,18 +49,64 @@ import (
 
 	"github.com/fluxcd/pkg/version"
 
+	intdigest "github.com/fluxcd/source-controller/internal/digest"
 	"github.com/fluxcd/source-controller/internal/helm"
 	"github.com/fluxcd/source-controller/internal/transport"
 )
 
 var (
 	ErrNoChartIndex = errors.New("no chart index")
+	// ErrIndexNotFound is returned when the index could not be found at the
+	// repository URL.
+	ErrIndexNotFound = errors.New("index not found")
+	// ErrUnsupportedEncoding is returned when the index response has a
+	// Content-Encoding which can not be decoded.
+	ErrUnsupportedEncoding = errors.New("unsupported content encoding")
+	// ErrUnexpectedContentType is returned when the Content-Type of the index
+	// response does not match the AcceptHeader.
+	ErrUnexpectedContentType = errors.New("unexpected content type")
+	// ErrTruncatedResponse is returned when the index response ends before
+	// its full body has been received.
+	ErrTruncatedResponse = errors.New("truncated response")
+	// ErrDigestMismatch is returned when the index does not match the digest
+	// advertised in the DigestHeader of the response.
+	ErrDigestMismatch = errors.New("digest mismatch")
+	// ErrParseTimeout is returned when the index could not be loaded before
+	// the deadline of the context passed to LoadFromPathContext.
+	ErrParseTimeout = errors.New("ParseTimeout")
 )
 
+// indexLoadSlots bounds the number of indexes loaded concurrently by
+// LoadFromPathContext. A load which is abandoned because its context is done
+// holds its slot until it returns, so the loads of a pathological index can
+// not pile up across retries.
+var indexLoadSlots = make(chan struct{}, runtime.GOMAXPROCS(0))
+
+// zstdExtension is the file extension of index files stored compressed
+// with zstd.
+const zstdExtension = ".zst"
+
+// xzExtension is the file extension of xz compressed index files. When the
+// repository URL points at a file with this extension, that file is
+// downloaded and decompressed instead of the "index.yaml" of the repository.
+const xzExtension = ".xz"
+
+// maxIndexResumes is the maximum number of times the download of an index is
+// resumed after the connection dropped, if the server supports it.
+const maxIndexResumes = 3
+
 // IndexFromFile loads a repo.IndexFile from the given path. It returns an
 // error if the file does not exist, is not a regular file, exceeds the
-// maximum index file size, or if the file cannot be parsed.
+// maximum index file size, or if the file cannot be parsed. Files with a
+// ".zst" extension are decompressed with zstd before they are parsed.
 func IndexFromFile(path string) (*repo.IndexFile, error) {
+	return indexFromFileContext(context.Background(), path)
+}
+
+// indexFromFileContext loads a repo.IndexFile from the given path, like
+// IndexFromFile. It returns the context error as soon as the context is done
+// between the stages of the load.
+func indexFromFileContext(ctx context.Context, path string) (*repo.IndexFile, error) {
 	st, err := os.Lstat(path)
 	if err != nil {
 		return nil, err
@@ -61,20 +117,60 @@ func IndexFromFile(path string) (*repo.IndexFile, error) {
 	if st.Size() > helm.MaxIndexSize {
 		return nil, fmt.Errorf("%s exceeds the maximum index file size of %d bytes", path, helm.MaxIndexSize)
 	}
+	if strings.HasSuffix(path, zstdExtension) {
+		return indexFromCompressedFile(ctx, path)
+	}
 	b, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
-	return IndexFromBytes(b)
+	return indexFromBytesContext(ctx, b)
+}
+
+// indexFromCompressedFile loads a repo.IndexFile from the zstd compressed
+// file at the given path. It returns an error if the decompressed contents
+// exceed the maximum index file size.
+func indexFromCompressedFile(ctx context.Context, path string) (*repo.IndexFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	zr, err := zstd.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	b, err := io.ReadAll(io.LimitReader(zr, helm.MaxIndexSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(b)) > helm.MaxIndexSize {
+		return nil, fmt.Errorf("%s exceeds the maximum index file size of %d bytes", path, helm.MaxIndexSize)
+	}
+	return indexFromBytesContext(ctx, b)
 }
 
 // IndexFromBytes loads a repo.IndexFile from the given bytes. It returns an
 // error if the bytes cannot be parsed, or if the API version is not set.
 // The entries are sorted before the index is returned.
 func IndexFromBytes(b []byte) (*repo.IndexFile, error) {
+	return indexFromBytesContext(context.Background(), b)
+}
+
+// indexFromBytesContext loads a repo.IndexFile from the given bytes, like
+// IndexFromBytes. It returns the context error as soon as the context is
+// done between the stages of the load, and between the validation of the
+// entries.
+func indexFromBytesContext(ctx context.Context, b []byte) (*repo.IndexFile, error) {
 	if len(b) == 0 {
 		return nil, repo.ErrEmptyIndexYaml
 	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	i := &repo.IndexFile{}
 	if err := jsonOrYamlUnmarshal(b, i); err != nil {
@@ -86,6 +182,9 @@ func IndexFromBytes(b []byte) (*repo.IndexFile, error) {
 	}
 
 	for _, cvs := range i.Entries {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		for idx := len(cvs) - 1; idx >= 0; idx-- {
 			if cvs[idx] == nil {
 				continue
@@ -120,15 +219,92 @@ type ChartRepository struct {
 	// Options to configure the Client with while downloading the Index
 	// or a chart from the URL.
 	Options []getter.Option
+	// RedirectPolicy is applied to the redirects while downloading the
+	// Index, if set.
+	RedirectPolicy *transport.RedirectPolicy
+	// ResolverAddress is the address of the DNS server used to resolve host
+	// names while downloading the Index or a chart. The system resolver is
+	// used when empty.
+	ResolverAddress string
+	// ProxyURL is the URL of the proxy requests are sent through while
+	// downloading the Index or a chart, including the credentials of the
+	// proxy. The proxy configured in the environment is used when nil.
+	ProxyURL *url.URL
+	// ForceHTTP1 disables HTTP/2 while downloading the Index or a chart.
+	ForceHTTP1 bool
+	// ShardManifestURL is the URL of a manifest listing the URLs of index
+	// shards. When set, the Index is assembled from the shards instead of
+	// being downloaded from the URL.
+	ShardManifestURL string
+	// Headers are set on the requests of the Index and charts. Unless the
+	// RedirectPolicy allows credentials to be passed on, they are only sent to
+	// the host of the requested URL, or of the repository for charts.
+	Headers http.Header
+	// ResponseHeaders are the names of the response headers included in the
+	// error returned when downloading the Index fails. Credentials and
+	// cookies are redacted.
+	ResponseHeaders []string
+	// AuthMethods are the authentication methods attempted in order while
+	// downloading the Index or a chart, moving on to the next method when the
+	// server responds with 401 Unauthorized. The Options are used as is when
+	// empty.
+	AuthMethods []AuthMethod
+
+	// RequestSigner signs the requests made to download the index or a
+	// chart with an HTTP Signature, if not nil.
+	RequestSigner *transport.HTTPSigner
+
+	// SigV4Signer signs the requests made to download the index or a chart
+	// with an AWS Signature Version 4, if not nil.
+	SigV4Signer *transport.SigV4Signer
+
+	// SPNEGO authenticates the requests made to download the index or a
+	// chart using SPNEGO, if not nil.
+	SPNEGO *transport.SPNEGONegotiator
+
+	// AcceptHeader is the Accept header of the requests made to download
+	// the index, if not empty. The Content-Type of the response must then
+	// match it.
+	AcceptHeader string
+
+	// DigestHeader is the name of a response header in which the server
+	// advertises the digest of the index, if not empty. When the response
+	// carries a digest of the canonical algorithm in it, the index must
+	// match it.
+	DigestHeader string
+
+	// Preflight is requested before the index, to obtain a token which is
+	// attached to the request made to download the index, if not nil.
+	Preflight *Preflight
 
 	tlsConfig *tls.Config
 
+	authMethodUsed  string
+	indexHeader     http.Header
+	indexStatusCode int
+	indexRedirects  []RedirectHop
+
 	cached  bool
 	digests map[digest.Algorithm]digest.Digest
 
 	*sync.RWMutex
 }
 
+// AuthMethod is an authentication method for a ChartRepository.
+type AuthMethod struct {
+	// Name of the method, e.g. "basic" or "bearer".
+	Name string
+	// Options to configure the Client with in addition to the Options of
+	// the ChartRepository.
+	Options []getter.Option
+	// BearerToken is set as the bearer token of the Authorization header of
+	// the requests, if not empty.
+	BearerToken string
+	// BearerTokenHost restricts the BearerToken to the requests made to this
+	// host. The BearerToken is set for all hosts when empty.
+	BearerTokenHost string
+}
+
 // NewChartRepository constructs and returns a new ChartRepository with
 // the ChartRepository.Client configured to the getter.Getter for the
 // repository URL scheme. It returns an error on URL parsing failures,
@@ -269,16 +445,56 @@ func (r *ChartRepository) DownloadChart(chart *repo.ChartVersion) (*bytes.Buffer
 	//  always the correct one to pick, check for updates once in awhile.
 	//  Ref: https://github.com/helm/helm/blob/v3.3.0/pkg/downloader/chart_downloader.go#L241
 	ref := chart.URLs[0]
-	resolvedUrl, err := repo.ResolveReferenceURL(r.URL, ref)
+	resolvedUrl, err := repo.ResolveReferenceURL(baseURL(r.URL), ref)
 	if err != nil {
 		return nil, err
 	}
 
 	t := transport.NewOrIdle(r.tlsConfig)
-	clientOpts := append(r.Options, getter.WithTransport(t))
 	defer transport.Release(t)
+	ct := r.withTransportOptions(t)
+	if ct != t {
+		defer ct.CloseIdleConnections()
+	}
+	// Charts may be hosted elsewhere than the repository, the credentials
+	// are only sent along to other hosts if credentials may be passed on.
+	if u, err := url.Parse(r.URL); err == nil {
+		if chartURL, err := url.Parse(resolvedUrl); err == nil && (chartURL.Host == u.Host || r.passCredentials()) {
+			ct = r.withCredentials(ct, u.Host)
+		}
+	}
+
+	res, _, err := r.get(resolvedUrl, ct)
+	return res, err
+}
 
-	return r.Client.Get(resolvedUrl, clientOpts...)
+// passCredentials returns true if the RedirectPolicy allows credentials to be
+// passed on to other hosts.
+func (r *ChartRepository) passCredentials() bool {
+	return r.RedirectPolicy != nil && r.RedirectPolicy.PassCredentials
+}
+
+// withCredentials returns the given http.Transport wrapped to sign the
+// requests with the RequestSigner and SigV4Signer, to authenticate them using
+// SPNEGO, and to set the Headers on the requests to the given host, or on all
+// requests if credentials may be passed on.
+func (r *ChartRepository) withCredentials(t *http.Transport, host string) *http.Transport {
+	if r.RequestSigner != nil {
+		t = transport.WithHTTPSigner(t, r.RequestSigner)
+	}
+	if r.SigV4Signer != nil {
+		t = transport.WithSigV4Signer(t, r.SigV4Signer)
+	}
+	if r.SPNEGO != nil {
+		t = transport.WithSPNEGO(t, r.SPNEGO)
+	}
+	if len(r.Headers) > 0 {
+		if r.passCredentials() {
+			host = ""
+		}
+		t = transport.WithHostRequestHeaders(t, r.Headers, host)
+	}
+	return t
 }
 
 // CacheIndex attempts to write the index from the remote into a new temporary file
@@ -286,12 +502,27 @@ func (r *ChartRepository) DownloadChart(chart *repo.ChartVersion) (*bytes.Buffer
 // The caller is expected to handle the garbage collection of Path, and to
 // load the Index separately using LoadFromPath if required.
 func (r *ChartRepository) CacheIndex() error {
+	return r.CacheIndexContext(context.Background())
+}
+
+// CacheIndexContext caches the index like CacheIndex, aborting the download
+// once the given context is done. The context is applied to the transport,
+// which ensures dialing and the TLS handshake are aborted as well.
+// When the connection drops while downloading the index, the download is
+// resumed using a range request if the server supports it, see
+// transport.WithRangeResume.
+func (r *ChartRepository) CacheIndexContext(ctx context.Context) error {
 	f, err := os.CreateTemp("", "chart-index-*.yaml")
 	if err != nil {
 		return fmt.Errorf("failed to create temp file to cache index to: %w", err)
 	}
 
-	if err = r.DownloadIndex(f); err != nil {
+	res, err := r.downloadIndex(ctx, f)
+	r.Lock()
+	r.indexStatusCode = res.statusCode
+	r.indexRedirects = res.redirects
+	r.Unlock()
+	if err != nil {
 		f.Close()
 		os.Remove(f.Name())
 		return fmt.Errorf("failed to cache index to temporary file: %w", err)
@@ -305,12 +536,63 @@ func (r *ChartRepository) CacheIndex() error {
 	r.Path = f.Name()
 	r.Index = nil
 	r.cached = true
+	r.authMethodUsed = res.authMethod
+	r.indexHeader = res.header
 	r.invalidate()
 	r.Unlock()
 
 	return nil
 }
 
+// AuthMethodUsed returns the name of the AuthMethod with which the Index was
+// last cached using CacheIndex, or an empty string if no AuthMethods are
+// configured.
+func (r *ChartRepository) AuthMethodUsed() string {
+	r.RLock()
+	defer r.RUnlock()
+	return r.authMethodUsed
+}
+
+// StatusCode returns the HTTP status code of the response to the last
+// attempt to cache the Index using CacheIndex, whether it succeeded or not.
+// It returns 0 if no response was received.
+func (r *ChartRepository) StatusCode() int {
+	r.RLock()
+	defer r.RUnlock()
+	return r.indexStatusCode
+}
+
+// RedirectChain returns the redirects followed by the last attempt to cache
+// the Index using CacheIndex, whether it succeeded or not, including the
+// final response. It returns nil if no redirect was followed.
+func (r *ChartRepository) RedirectChain() []RedirectHop {
+	r.RLock()
+	defer r.RUnlock()
+	return r.indexRedirects
+}
+
+// CacheMaxAge returns the max-age directive of the Cache-Control header of
+// the response with which the Index was last cached using CacheIndex. It
+// returns false if the response has no valid max-age directive.
+func (r *ChartRepository) CacheMaxAge() (time.Duration, bool) {
+	r.RLock()
+	defer r.RUnlock()
+	for _, v := range r.indexHeader.Values("Cache-Control") {
+		for _, directive := range strings.Split(v, ",") {
+			name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+			if !strings.EqualFold(name, "max-age") {
+				continue
+			}
+			seconds, err := strconv.ParseInt(strings.Trim(value, `"`), 10, 64)
+			if err != nil || seconds < 0 {
+				return 0, false
+			}
+			return time.Duration(seconds) * time.Second, true
+		}
+	}
+	return 0, false
+}
+
 // StrategicallyLoadIndex lazy-loads the Index if required, first
 // attempting to load it from Path if the file exists, before falling
 // back to caching it.
@@ -352,35 +634,379 @@ func (r *ChartRepository) LoadFromPath() error {
 	return nil
 }
 
+// LoadFromPathContext attempts to load the Index from the configured Path,
+// like LoadFromPath. It returns early with the context error if the context
+// is done before the Index has been loaded, in which case the Index is left
+// unchanged. If the deadline of the context is exceeded, the error wraps
+// ErrParseTimeout.
+//
+// The number of loads running concurrently is bounded. A load which is
+// abandoned stops at the next stage at which it observes the context to be
+// done, and counts towards the bound until then.
+func (r *ChartRepository) LoadFromPathContext(ctx context.Context) error {
+	r.RLock()
+	p := r.Path
+	r.RUnlock()
+
+	if len(p) == 0 {
+		return fmt.Errorf("no cache path")
+	}
+	if err := ctx.Err(); err != nil {
+		return loadContextError(err)
+	}
+
+	select {
+	case indexLoadSlots <- struct{}{}:
+	case <-ctx.Done():
+		return loadContextError(ctx.Err())
+	}
+
+	type result struct {
+		index *repo.IndexFile
+		err   error
+	}
+	resCh := make(chan result, 1)
+	go func() {
+		defer func() { <-indexLoadSlots }()
+		i, err := indexFromFileContext(ctx, p)
+		resCh <- result{index: i, err: err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return loadContextError(ctx.Err())
+	case res := <-resCh:
+		if res.err != nil {
+			return fmt.Errorf("failed to load index: %w", res.err)
+		}
+		r.Lock()
+		r.Index = res.index
+		r.Unlock()
+		return nil
+	}
+}
+
+// loadContextError returns the error of a load of the Index which was
+// aborted with the given context error.
+func loadContextError(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("failed to load index: %w: %w", ErrParseTimeout, err)
+	}
+	return fmt.Errorf("failed to load index: %w", err)
+}
+
 // DownloadIndex attempts to download the chart repository index using
 // the Client and set Options, and writes the index to the given io.Writer.
 // It returns an url.Error if the URL failed to parse.
-func (r *ChartRepository) DownloadIndex(w io.Writer) (err error) {
+func (r *ChartRepository) DownloadIndex(w io.Writer) error {
+	_, err := r.downloadIndex(context.Background(), w)
+	return err
+}
+
+// indexResponse describes the response with which the index was downloaded.
+type indexResponse struct {
+	// authMethod is the name of the AuthMethod of the request.
+	authMethod string
+	// statusCode is the status code of the last response, or 0 if no
+	// response was received.
+	statusCode int
+	// header holds the headers of the last response.
+	header http.Header
+	// redirects is the redirect chain of the request.
+	redirects []RedirectHop
+}
+
+// downloadIndex downloads the index like DownloadIndex, and describes the
+// response with which it was downloaded. The status code and redirect chain
+// are also returned on failure.
+func (r *ChartRepository) downloadIndex(ctx context.Context, w io.Writer) (indexResponse, error) {
 	r.RLock()
 	defer r.RUnlock()
 
+	if r.ShardManifestURL != "" {
+		return r.downloadShards(ctx, w)
+	}
+
 	u, err := url.Parse(r.URL)
 	if err != nil {
-		return err
+		return indexResponse{}, err
 	}
-	u.RawPath = path.Join(u.RawPath, "index.yaml")
-	u.Path = path.Join(u.Path, "index.yaml")
+	if isXZIndexURL(u) {
+		u.RawPath = strings.TrimRight(u.RawPath, "/")
+		u.Path = strings.TrimRight(u.Path, "/")
+	} else {
+		u.RawPath = path.Join(u.RawPath, "index.yaml")
+		u.Path = path.Join(u.Path, "index.yaml")
+	}
+	return r.download(ctx, u, w)
+}
 
+// download downloads the index file at the given URL, decodes it and writes
+// it to the given io.Writer, and describes the response with which it was
+// downloaded. The caller is expected to hold a read lock.
+func (r *ChartRepository) download(ctx context.Context, u *url.URL, w io.Writer) (indexResponse, error) {
+	xzIndex := isXZIndexURL(u)
 	t := transport.NewOrIdle(r.tlsConfig)
-	clientOpts := append(r.Options, getter.WithTransport(t))
 	defer transport.Release(t)
+	ct := r.withTransportOptions(t)
+	if ct != t {
+		defer ct.CloseIdleConnections()
+	}
+	ct = transport.WithRangeResume(ct, maxIndexResumes)
+	// Enforce the size limit while the (possibly chunked) response is
+	// received, as it is buffered in full before it is decoded.
+	ct = transport.WithBodyLimit(ct, helm.MaxIndexSize)
+	if r.RedirectPolicy != nil {
+		ct = transport.WithRedirectPolicy(ct, *r.RedirectPolicy)
+	}
+	ct = r.withCredentials(ct, u.Host)
+	if r.Preflight != nil {
+		token, err := r.preflightToken(ctx, ct)
+		if err != nil {
+			return indexResponse{}, err
+		}
+		ct = transport.WithRequestHeaders(ct, http.Header{
+			http.CanonicalHeaderKey(r.Preflight.requestHeader()): {token},
+		})
+	}
+	if r.AcceptHeader != "" {
+		ct = transport.WithRequestHeaders(ct, http.Header{"Accept": {r.AcceptHeader}})
+	}
+	if ctx.Done() != nil {
+		ct = transport.WithContext(ct, ctx)
+	}
+	var statusCode int
+	var header http.Header
+	var hops []RedirectHop
+	ct = transport.WithResponseObserver(ct, func(resp *http.Response) {
+		statusCode = resp.StatusCode
+		header = resp.Header
+		if resp.Request != nil {
+			hops = append(hops, RedirectHop{URL: resp.Request.URL.Redacted(), StatusCode: resp.StatusCode})
+		}
+	})
 
-	var res *bytes.Buffer
-	res, err = r.Client.Get(u.String(), clientOpts...)
+	res, method, err := r.get(u.String(), ct)
+	failed := indexResponse{statusCode: statusCode, redirects: redirectChain(hops)}
+	if err != nil {
+		if len(r.ResponseHeaders) > 0 {
+			if h := transport.FormatHeaders(header, r.ResponseHeaders); h != "" {
+				err = fmt.Errorf("%w (response headers: %s)", err, h)
+			}
+		}
+		if statusCode == http.StatusNotFound {
+			return failed, fmt.Errorf("%w: %w", ErrIndexNotFound, err)
+		}
+		if statusCode == http.StatusUnauthorized && r.SPNEGO != nil {
+			return failed, fmt.Errorf("%w: %w", transport.ErrSPNEGO, err)
+		}
+		// The stream ended before the final chunk or the announced
+		// Content-Length was received.
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return failed, fmt.Errorf("%w: %w", ErrTruncatedResponse, err)
+		}
+		return failed, err
+	}
+	if r.AcceptHeader != "" && !transport.AcceptsContentType(r.AcceptHeader, header.Get("Content-Type")) {
+		return failed, fmt.Errorf("%w '%s', expected '%s'", ErrUnexpectedContentType, header.Get("Content-Type"), r.AcceptHeader)
+	}
+	body, err := decodeContent(res, header.Get("Content-Encoding"))
+	if err != nil {
+		return failed, err
+	}
+	advertised, err := r.advertisedDigest(header)
 	if err != nil {
-		return err
+		return failed, err
+	}
+	var digester digest.Digester
+	if advertised != "" {
+		digester = advertised.Algorithm().Digester()
+		body = io.TeeReader(body, digester.Hash())
 	}
-	if _, err = io.Copy(w, res); err != nil {
-		return err
+	raw := body
+	if xzIndex {
+		if body, err = xz.NewReader(body); err != nil {
+			return failed, fmt.Errorf("failed to decode index: %w", err)
+		}
+	}
+	// Limit the size of the decoded index, to guard against decompression
+	// bombs.
+	n, err := io.Copy(w, io.LimitReader(body, helm.MaxIndexSize+1))
+	if err != nil {
+		return failed, fmt.Errorf("failed to decode index: %w", err)
+	}
+	if n > helm.MaxIndexSize {
+		return failed, fmt.Errorf("decoded index exceeds the maximum index file size of %d bytes", helm.MaxIndexSize)
+	}
+	if digester != nil {
+		// Include any remainder the decompressor did not consume.
+		if _, err := io.Copy(io.Discard, raw); err != nil {
+			return failed, fmt.Errorf("failed to decode index: %w", err)
+		}
+		if d := digester.Digest(); d != advertised {
+			return failed, fmt.Errorf("%w: index has digest '%s', but '%s' was advertised in the %s header",
+				ErrDigestMismatch, d, advertised, r.DigestHeader)
+		}
+	}
+	return indexResponse{
+		authMethod: method,
+		statusCode: statusCode,
+		header:     header,
+		redirects:  failed.redirects,
+	}, nil
+}
+
+// RedirectHop is a response received while following the redirects of a
+// request.
+type RedirectHop struct {
+	// URL is the redacted URL of the request.
+	URL string
+	// StatusCode is the status code of the response.
+	StatusCode int
+}
+
+// String returns the status code and URL of the hop.
+func (h RedirectHop) String() string {
+	return fmt.Sprintf("%d %s", h.StatusCode, h.URL)
+}
+
+// redirectChain returns the given hops if any of them is a redirect, or nil
+// otherwise.
+func redirectChain(hops []RedirectHop) []RedirectHop {
+	for _, h := range hops {
+		if transport.IsRedirect(h.StatusCode) {
+			return hops
+		}
 	}
 	return nil
 }
 
+// decodeContent returns a reader of the decoded content of a response with
+// the given Content-Encoding. Responses compressed with gzip are normally
+// decoded transparently by the transport, but not if the server compresses
+// the response without it being requested.
+func decodeContent(r io.Reader, encoding string) (io.Reader, error) {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "", "identity":
+		return r, nil
+	case "gzip", "x-gzip":
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode index: %w", err)
+		}
+		return zr, nil
+	case "br":
+		return brotli.NewReader(r), nil
+	case "xz", "x-xz":
+		zr, err := xz.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode index: %w", err)
+		}
+		return zr, nil
+	default:
+		return nil, fmt.Errorf("%w '%s'", ErrUnsupportedEncoding, encoding)
+	}
+}
+
+// advertisedDigest returns the digest advertised in the DigestHeader of the
+// given response header. It returns an empty digest if the DigestHeader is
+// not configured or absent, or if the digest is not of the canonical
+// algorithm, and an error if the digest is malformed.
+func (r *ChartRepository) advertisedDigest(header http.Header) (digest.Digest, error) {
+	if r.DigestHeader == "" {
+		return "", nil
+	}
+	v := strings.TrimSpace(header.Get(r.DigestHeader))
+	if v == "" {
+		return "", nil
+	}
+	d, err := digest.Parse(v)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid digest '%s' in the %s header: %w", ErrDigestMismatch, v, r.DigestHeader, err)
+	}
+	if d.Algorithm() != intdigest.Canonical {
+		return "", nil
+	}
+	return d, nil
+}
+
+// isXZIndexURL returns true if the path of the given repository URL points
+// at an xz compressed index file.
+func isXZIndexURL(u *url.URL) bool {
+	return strings.HasSuffix(strings.TrimRight(u.Path, "/"), xzExtension)
+}
+
+// baseURL returns the URL relative chart URLs in the index of the repository
+// with the given URL are resolved against. This is the URL itself, unless it
+// points at an xz compressed index file, in which case it is the URL of the
+// directory of that file.
+func baseURL(repositoryURL string) string {
+	u, err := url.Parse(repositoryURL)
+	if err != nil || !isXZIndexURL(u) {
+		return repositoryURL
+	}
+	u.Path = path.Dir(strings.TrimRight(u.Path, "/")) + "/"
+	if u.RawPath != "" {
+		u.RawPath = path.Dir(strings.TrimRight(u.RawPath, "/")) + "/"
+	}
+	return u.String()
+}
+
+// get downloads the given URL using the Client, Options and given transport.
+// When AuthMethods are configured, they are attempted in order until the
+// server no longer responds with 401 Unauthorized, and the name of the
+// method used for the last attempt is returned.
+func (r *ChartRepository) get(u string, t *http.Transport) (*bytes.Buffer, string, error) {
+	if len(r.AuthMethods) == 0 {
+		res, err := r.Client.Get(u, append(r.Options, getter.WithTransport(t))...)
+		return res, "", err
+	}
+
+	var err error
+	for _, m := range r.AuthMethods {
+		mt := t
+		if m.BearerToken != "" {
+			mt = transport.WithBearerToken(mt, m.BearerToken, m.BearerTokenHost)
+		}
+		var statusCode int
+		mt = transport.WithResponseObserver(mt, func(resp *http.Response) {
+			statusCode = resp.StatusCode
+		})
+
+		opts := make([]getter.Option, 0, len(r.Options)+len(m.Options)+1)
+		opts = append(opts, r.Options...)
+		opts = append(opts, m.Options...)
+		opts = append(opts, getter.WithTransport(mt))
+
+		var res *bytes.Buffer
+		res, err = r.Client.Get(u, opts...)
+		if err == nil {
+			return res, m.Name, nil
+		}
+		if statusCode != http.StatusUnauthorized {
+			return nil, m.Name, err
+		}
+	}
+	return nil, r.AuthMethods[len(r.AuthMethods)-1].Name, err
+}
+
+// withTransportOptions returns a transport using the ResolverAddress to
+// resolve host names, sending requests through the ProxyURL and only using
+// HTTP/1.1 if ForceHTTP1 is set, or the given transport if none is set.
+func (r *ChartRepository) withTransportOptions(t *http.Transport) *http.Transport {
+	ct := t
+	if r.ResolverAddress != "" {
+		ct = transport.WithResolver(ct, r.ResolverAddress)
+	}
+	if r.ProxyURL != nil {
+		ct = transport.WithProxy(ct, r.ProxyURL)
+	}
+	if r.ForceHTTP1 {
+		ct = transport.WithHTTP1(ct)
+	}
+	return ct
+}
+
 // Digest returns the digest of the file at the ChartRepository's Path.
 func (r *ChartRepository) Digest(algorithm digest.Algorithm) digest.Digest {
 	if !r.HasFile() {
@@ -402,6 +1028,27 @@ func (r *ChartRepository) Digest(algorithm digest.Algorithm) digest.Digest {
 	return r.digests[algorithm]
 }
 
+// WeakDigest calculates the digest of the Index, excluding its volatile
+// fields: the generated timestamp, the server info and the annotations. It
+// returns an empty string if the Index is not loaded, or can not be encoded.
+func (r *ChartRepository) WeakDigest(algorithm digest.Algorithm) digest.Digest {
+	r.RLock()
+	defer r.RUnlock()
+
+	if r.Index == nil {
+		return ""
+	}
+	i := *r.Index
+	i.Generated = time.Time{}
+	i.ServerInfo = nil
+	i.Annotations = nil
+	b, err := yaml.Marshal(&i)
+	if err != nil {
+		return ""
+	}
+	return algorithm.FromBytes(b)
+}
+
 // ToJSON returns the index formatted as JSON.
 func (r *ChartRepository) ToJSON() ([]byte, error) {
 	if !r.HasIndex() {
@@ -411,6 +1058,585 @@ func (r *ChartRepository) ToJSON() ([]byte, error) {
 	return json.MarshalIndent(r.Index, "", "  ")
 }
 
+// ToJSONWithAnnotations returns the index like ToJSON, with the given
+// annotations added to the annotations of the index. The loaded Index is not
+// modified.
+func (r *ChartRepository) ToJSONWithAnnotations(annotations map[string]string) ([]byte, error) {
+	if !r.HasIndex() {
+		return nil, fmt.Errorf("index not loaded yet")
+	}
+
+	r.RLock()
+	defer r.RUnlock()
+	index := *r.Index
+	index.Annotations = make(map[string]string, len(r.Index.Annotations)+len(annotations))
+	for k, v := range r.Index.Annotations {
+		index.Annotations[k] = v
+	}
+	for k, v := range annotations {
+		index.Annotations[k] = v
+	}
+	return json.MarshalIndent(&index, "", "  ")
+}
+
+// RemoveVersions removes the chart versions matching the semver constraints
+// of their chart name from the Index. Charts without any remaining versions
+// are removed from the Index entirely. If any versions were removed, the
+// Index is written back to the Path and the cached digests are invalidated,
+// so that they reflect the remaining versions. It returns the removed
+// versions per chart name.
+func (r *ChartRepository) RemoveVersions(constraints map[string]*semver.Constraints) (map[string][]string, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.Index == nil {
+		return nil, ErrNoChartIndex
+	}
+
+	removed := make(map[string][]string)
+	for name, c := range constraints {
+		cvs, ok := r.Index.Entries[name]
+		if !ok {
+			continue
+		}
+		var keep repo.ChartVersions
+		for _, cv := range cvs {
+			if cv != nil && cv.Metadata != nil {
+				if v, err := version.ParseVersion(cv.Version); err == nil && c.Check(v) {
+					removed[name] = append(removed[name], cv.Version)
+					continue
+				}
+			}
+			keep = append(keep, cv)
+		}
+		if len(keep) == 0 {
+			delete(r.Index.Entries, name)
+			continue
+		}
+		r.Index.Entries[name] = keep
+	}
+	if len(removed) == 0 {
+		return nil, nil
+	}
+
+	if r.Path != "" {
+		if err := r.Index.WriteFile(r.Path, 0o600); err != nil {
+			return removed, fmt.Errorf("failed to write index: %w", err)
+		}
+	}
+	r.invalidate()
+	return removed, nil
+}
+
+// InvalidChartNames returns the sorted names of the charts in the Index which
+// are empty, or longer than maxLength.
+func (r *ChartRepository) InvalidChartNames(maxLength int) ([]string, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	if r.Index == nil {
+		return nil, ErrNoChartIndex
+	}
+
+	var invalid []string
+	for name := range r.Index.Entries {
+		if name == "" || len(name) > maxLength {
+			invalid = append(invalid, name)
+		}
+	}
+	sort.Strings(invalid)
+	return invalid, nil
+}
+
+// RemoveCharts removes the charts with the given names from the Index
+// entirely. If any charts were removed, the Index is written back to the Path
+// and the cached digests are invalidated.
+func (r *ChartRepository) RemoveCharts(names ...string) error {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.Index == nil {
+		return ErrNoChartIndex
+	}
+
+	var removed bool
+	for _, name := range names {
+		if _, ok := r.Index.Entries[name]; ok {
+			delete(r.Index.Entries, name)
+			removed = true
+		}
+	}
+	if !removed {
+		return nil
+	}
+
+	if r.Path != "" {
+		if err := r.Index.WriteFile(r.Path, 0o600); err != nil {
+			return fmt.Errorf("failed to write index: %w", err)
+		}
+	}
+	r.invalidate()
+	return nil
+}
+
+// TruncateVersions keeps at most max versions per chart in the Index,
+// retaining the highest semver versions. Versions which are not valid semver
+// are considered lower than any valid version, and ordered by their string
+// representation, so that the same Index always yields the same result.
+// If any versions were removed, the Index is written back to the Path and
+// the cached digests are invalidated. It returns the number of removed
+// versions per chart name.
+func (r *ChartRepository) TruncateVersions(max int) (map[string]int, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.Index == nil {
+		return nil, ErrNoChartIndex
+	}
+
+	removed := make(map[string]int)
+	for name, cvs := range r.Index.Entries {
+		if len(cvs) <= max {
+			continue
+		}
+		sorted := make(repo.ChartVersions, len(cvs))
+		copy(sorted, cvs)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return versionGreater(sorted[i], sorted[j])
+		})
+		r.Index.Entries[name] = sorted[:max]
+		removed[name] = len(sorted) - max
+	}
+	if len(removed) == 0 {
+		return nil, nil
+	}
+
+	if r.Path != "" {
+		if err := r.Index.WriteFile(r.Path, 0o600); err != nil {
+			return removed, fmt.Errorf("failed to write index: %w", err)
+		}
+	}
+	r.invalidate()
+	return removed, nil
+}
+
+// Canonicalize sorts the versions of every chart in the Index from the
+// highest to the lowest version, and resets the generated timestamp of the
+// Index, so that indexes with identical contents result in an identical
+// digest. The Index is written back to the Path and the cached digests are
+// invalidated.
+func (r *ChartRepository) Canonicalize() error {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.Index == nil {
+		return ErrNoChartIndex
+	}
+
+	for _, cvs := range r.Index.Entries {
+		sort.SliceStable(cvs, func(i, j int) bool {
+			return versionGreater(cvs[i], cvs[j])
+		})
+	}
+	r.Index.Generated = time.Time{}
+
+	if r.Path != "" {
+		if err := r.Index.WriteFile(r.Path, 0o600); err != nil {
+			return fmt.Errorf("failed to write index: %w", err)
+		}
+	}
+	r.invalidate()
+	return nil
+}
+
+// ResetGenerated resets the generated timestamp of the Index, so that
+// indexes which only differ in their generation time result in an identical
+// digest. The Index is written back to the Path and the cached digests are
+// invalidated.
+func (r *ChartRepository) ResetGenerated() error {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.Index == nil {
+		return ErrNoChartIndex
+	}
+
+	r.Index.Generated = time.Time{}
+
+	if r.Path != "" {
+		if err := r.Index.WriteFile(r.Path, 0o600); err != nil {
+			return fmt.Errorf("failed to write index: %w", err)
+		}
+	}
+	r.invalidate()
+	return nil
+}
+
+// versionGreater reports whether chart version a orders before b when
+// sorting from highest to lowest version.
+func versionGreater(a, b *repo.ChartVersion) bool {
+	var av, bv *semver.Version
+	var as, bs string
+	if a != nil && a.Metadata != nil {
+		as = a.Version
+		av, _ = version.ParseVersion(a.Version)
+	}
+	if b != nil && b.Metadata != nil {
+		bs = b.Version
+		bv, _ = version.ParseVersion(b.Version)
+	}
+	switch {
+	case av != nil && bv != nil:
+		if c := av.Compare(bv); c != 0 {
+			return c > 0
+		}
+		// Equal precedence (e.g. differing build metadata), fall back to
+		// the string representation.
+		return as > bs
+	case av != nil:
+		return true
+	case bv != nil:
+		return false
+	default:
+		return as > bs
+	}
+}
+
+// MergePolicy defines which versions of a chart are kept when the chart is
+// listed in multiple indexes merged by MergeIndexes.
+type MergePolicy string
+
+const (
+	// MergePolicyFirstWins keeps the chart versions of the first index which
+	// lists the chart.
+	MergePolicyFirstWins MergePolicy = "FirstWins"
+	// MergePolicyHighestVersionWins keeps the chart versions of the index
+	// which lists the highest version of the chart.
+	MergePolicyHighestVersionWins MergePolicy = "HighestVersionWins"
+)
+
+// MergeIndexes merges the Index of the given ChartRepository objects into
+// the Index, in order. The chart URLs of the merged charts are made absolute
+// based on the URL of the ChartRepository they originate from. Charts listed
+// in multiple indexes are resolved using the given MergePolicy.
+// The Index is written back to the Path and the cached digests are
+// invalidated, so that they reflect the merged result.
+func (r *ChartRepository) MergeIndexes(policy MergePolicy, others ...*ChartRepository) error {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.Index == nil {
+		return ErrNoChartIndex
+	}
+
+	for _, o := range others {
+		if o.Index == nil {
+			return fmt.Errorf("%w for '%s'", ErrNoChartIndex, o.URL)
+		}
+		for name, cvs := range o.Index.Entries {
+			for _, cv := range cvs {
+				if cv == nil {
+					continue
+				}
+				for i, u := range cv.URLs {
+					absURL, err := repo.ResolveReferenceURL(baseURL(o.URL), u)
+					if err != nil {
+						return fmt.Errorf("failed to resolve URL of chart '%s@%s': %w", name, cv.Version, err)
+					}
+					cv.URLs[i] = absURL
+				}
+			}
+
+			cur, ok := r.Index.Entries[name]
+			switch {
+			case !ok:
+				r.Index.Entries[name] = cvs
+			case policy == MergePolicyHighestVersionWins && highestVersion(cvs).GreaterThan(highestVersion(cur)):
+				r.Index.Entries[name] = cvs
+			}
+		}
+	}
+	r.Index.SortEntries()
+
+	if r.Path != "" {
+		if err := r.Index.WriteFile(r.Path, 0o600); err != nil {
+			return fmt.Errorf("failed to write index: %w", err)
+		}
+	}
+	r.invalidate()
+	return nil
+}
+
+// highestVersion returns the highest valid semver version of the given
+// chart versions, or version 0.0.0 if there is none.
+func highestVersion(cvs repo.ChartVersions) *semver.Version {
+	highest := semver.MustParse("0.0.0")
+	for _, cv := range cvs {
+		if cv == nil {
+			continue
+		}
+		if v, err := version.ParseVersion(cv.Version); err == nil && v.GreaterThan(highest) {
+			highest = v
+		}
+	}
+	return highest
+}
+
+// VerifyDigests downloads a random sample of at most sampleSize chart
+// versions from the Index, and compares the digest of the downloaded chart
+// to the digest advertised in the Index. Chart versions without an
+// advertised digest are not sampled. The charts are streamed through a
+// digester rather than buffered, and may not exceed helm.MaxChartSize. A
+// chart version which can not be downloaded fails the verification.
+// It returns the sampled chart versions which failed the verification in the
+// format of "<name>@<version>", followed by the download error of the chart
+// versions which could not be downloaded, and the number of sampled chart
+// versions.
+func (r *ChartRepository) VerifyDigests(sampleSize int) (failed []string, sampled int, err error) {
+	r.RLock()
+	if r.Index == nil {
+		r.RUnlock()
+		return nil, 0, ErrNoChartIndex
+	}
+	var candidates repo.ChartVersions
+	for _, cvs := range r.Index.Entries {
+		for _, cv := range cvs {
+			if cv != nil && cv.Digest != "" && len(cv.URLs) > 0 {
+				candidates = append(candidates, cv)
+			}
+		}
+	}
+	r.RUnlock()
+
+	rand.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+	if len(candidates) > sampleSize {
+		candidates = candidates[:sampleSize]
+	}
+
+	for _, cv := range candidates {
+		got, err := r.chartDigest(cv)
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s@%s (%s)", cv.Name, cv.Version, err))
+			continue
+		}
+		want := strings.TrimPrefix(cv.Digest, digest.SHA256.String()+":")
+		if got.Encoded() != want {
+			failed = append(failed, fmt.Sprintf("%s@%s", cv.Name, cv.Version))
+		}
+	}
+	sort.Strings(failed)
+	return failed, len(candidates), nil
+}
+
+// chartDigest downloads the given chart version like DownloadChart, and
+// returns the SHA256 digest of the chart. The chart is streamed through the
+// digester instead of being buffered, and the download fails once it exceeds
+// helm.MaxChartSize.
+func (r *ChartRepository) chartDigest(chart *repo.ChartVersion) (digest.Digest, error) {
+	resolvedUrl, err := repo.ResolveReferenceURL(baseURL(r.URL), chart.URLs[0])
+	if err != nil {
+		return "", err
+	}
+
+	t := transport.NewOrIdle(r.tlsConfig)
+	defer transport.Release(t)
+	ct := r.withTransportOptions(t)
+	if ct != t {
+		defer ct.CloseIdleConnections()
+	}
+	digester := digest.SHA256.Digester()
+	ct = transport.WithBodySink(transport.WithBodyLimit(ct, helm.MaxChartSize), digester.Hash())
+
+	if _, _, err := r.get(resolvedUrl, ct); err != nil {
+		return "", err
+	}
+	return digester.Digest(), nil
+}
+
+// CheckChartURLs samples up to the given number of random chart versions from
+// the Index, and sends a HEAD request to the first HTTP/S URL of each. A URL
+// is considered reachable if any response is received, regardless of its
+// status code, as the charts may require different credentials than the
+// index. It returns the sampled chart versions with an unreachable URL in the
+// format of "<name>@<version>", and the number of sampled chart versions.
+func (r *ChartRepository) CheckChartURLs(ctx context.Context, sampleSize int) (unreachable []string, sampled int, err error) {
+	r.RLock()
+	if r.Index == nil {
+		r.RUnlock()
+		return nil, 0, ErrNoChartIndex
+	}
+	type candidate struct {
+		cv  *repo.ChartVersion
+		url string
+	}
+	var candidates []candidate
+	for _, cvs := range r.Index.Entries {
+		for _, cv := range cvs {
+			if cv == nil || len(cv.URLs) == 0 {
+				continue
+			}
+			u, err := repo.ResolveReferenceURL(baseURL(r.URL), cv.URLs[0])
+			if err != nil || !(strings.HasPrefix(u, "http://") || strings.HasPrefix(u, "https://")) {
+				continue
+			}
+			candidates = append(candidates, candidate{cv: cv, url: u})
+		}
+	}
+	r.RUnlock()
+
+	rand.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+	if len(candidates) > sampleSize {
+		candidates = candidates[:sampleSize]
+	}
+
+	t := transport.NewOrIdle(r.tlsConfig)
+	defer transport.Release(t)
+	ct := r.withTransportOptions(t)
+	if ct != t {
+		defer ct.CloseIdleConnections()
+	}
+	c := &http.Client{
+		Transport: ct,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	for _, cand := range candidates {
+		req, err := http.NewRequestWithContext(ctx, http.MethodHead, cand.url, nil)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid URL of chart '%s@%s': %w", cand.cv.Name, cand.cv.Version, err)
+		}
+		res, err := c.Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, 0, ctx.Err()
+			}
+			unreachable = append(unreachable, fmt.Sprintf("%s@%s", cand.cv.Name, cand.cv.Version))
+			continue
+		}
+		res.Body.Close()
+	}
+	sort.Strings(unreachable)
+	return unreachable, len(candidates), nil
+}
+
+// EntriesCount returns the number of charts and the total number of chart
+// versions in the Index. It returns zero for both if the Index is not loaded.
+func (r *ChartRepository) EntriesCount() (charts int64, versions int64) {
+	r.RLock()
+	defer r.RUnlock()
+
+	if r.Index == nil {
+		return 0, 0
+	}
+	for _, cvs := range r.Index.Entries {
+		charts++
+		versions += int64(len(cvs))
+	}
+	return charts, versions
+}
+
+// DependencyCounts returns the total number of dependencies declared by the
+// latest version of each chart in the Index, the number of those dependencies
+// on a chart in the Index itself, and the number of charts declaring at least
+// one dependency. A dependency is on a chart in the Index if its repository is
+// one of the given repository URLs, and the Index has an entry for it. It
+// returns zero for all if the Index is not loaded.
+func (r *ChartRepository) DependencyCounts(repositoryURLs ...string) (dependencies, internal, dependents int64) {
+	r.RLock()
+	defer r.RUnlock()
+
+	if r.Index == nil {
+		return 0, 0, 0
+	}
+	urls := make(map[string]struct{}, len(repositoryURLs))
+	for _, u := range repositoryURLs {
+		urls[strings.TrimSuffix(u, "/")] = struct{}{}
+	}
+	for _, cvs := range r.Index.Entries {
+		latest := latestChartVersion(cvs)
+		if latest == nil || latest.Metadata == nil || len(latest.Dependencies) == 0 {
+			continue
+		}
+		dependents++
+		for _, dep := range latest.Dependencies {
+			if dep == nil {
+				continue
+			}
+			dependencies++
+			if _, ok := urls[strings.TrimSuffix(dep.Repository, "/")]; !ok {
+				continue
+			}
+			if _, ok := r.Index.Entries[dep.Name]; ok {
+				internal++
+			}
+		}
+	}
+	return dependencies, internal, dependents
+}
+
+// latestChartVersion returns the highest semver version of the given chart
+// versions, ignoring versions which are not valid semver.
+func latestChartVersion(cvs repo.ChartVersions) *repo.ChartVersion {
+	var latest *repo.ChartVersion
+	var latestVer *semver.Version
+	for _, cv := range cvs {
+		if cv == nil || cv.Metadata == nil {
+			continue
+		}
+		v, err := semver.NewVersion(cv.Version)
+		if err != nil {
+			continue
+		}
+		if latestVer == nil || v.GreaterThan(latestVer) {
+			latest, latestVer = cv, v
+		}
+	}
+	return latest
+}
+
+// IndexDelta returns the chart versions which were added to and removed from
+// the given current index compared to the previous index, as sorted
+// "<name>@<version>" strings. A nil index is treated as an empty index.
+func IndexDelta(previous, current *repo.IndexFile) (added, removed []string) {
+	prevVersions, curVersions := indexVersions(previous), indexVersions(current)
+	for v := range curVersions {
+		if _, ok := prevVersions[v]; !ok {
+			added = append(added, v)
+		}
+	}
+	for v := range prevVersions {
+		if _, ok := curVersions[v]; !ok {
+			removed = append(removed, v)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
+
+// indexVersions returns the set of "<name>@<version>" strings of the chart
+// versions in the given index.
+func indexVersions(index *repo.IndexFile) map[string]struct{} {
+	versions := make(map[string]struct{})
+	if index == nil {
+		return versions
+	}
+	for name, cvs := range index.Entries {
+		for _, cv := range cvs {
+			if cv == nil || cv.Metadata == nil {
+				continue
+			}
+			versions[name+"@"+cv.Version] = struct{}{}
+		}
+	}
+	return versions
+}
+
 // HasIndex returns true if the Index is not nil.
 func (r *ChartRepository) HasIndex() bool {
 	r.RLock()
@@ -18,22 +18,35 @@ package repository
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/Masterminds/semver/v3"
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
 	. "github.com/onsi/gomega"
 	"github.com/opencontainers/go-digest"
+	"github.com/ulikunitz/xz"
 	"helm.sh/helm/v3/pkg/chart"
 	helmgetter "helm.sh/helm/v3/pkg/getter"
 	"helm.sh/helm/v3/pkg/repo"
 
 	"github.com/fluxcd/source-controller/internal/helm"
+	"github.com/fluxcd/source-controller/internal/transport"
 )
 
 var now = time.Now()
@@ -58,6 +71,22 @@ func (g *mockGetter) Get(u string, _ ...helmgetter.Option) (*bytes.Buffer, error
 	return bytes.NewBuffer(r), nil
 }
 
+func zstdCompress(t *testing.T, b []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw, err := zstd.NewWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = zw.Write(b); err != nil {
+		t.Fatal(err)
+	}
+	if err = zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
 // Index load tests are derived from https://github.com/helm/helm/blob/v3.3.4/pkg/repo/index_test.go#L108
 // to ensure parity with Helm behaviour.
 func TestIndexFromFile(t *testing.T) {
@@ -69,6 +98,14 @@ func TestIndexFromFile(t *testing.T) {
 	data := make([]byte, helm.MaxIndexSize+10)
 	g.Expect(os.WriteFile(bigIndexFile, data, 0o640)).ToNot(HaveOccurred())
 
+	// Create zstd compressed index files.
+	b, err := os.ReadFile(testFile)
+	g.Expect(err).ToNot(HaveOccurred())
+	compressedIndexFile := filepath.Join(tmpDir, "index.yaml.zst")
+	g.Expect(os.WriteFile(compressedIndexFile, zstdCompress(t, b), 0o640)).ToNot(HaveOccurred())
+	bigCompressedIndexFile := filepath.Join(tmpDir, "big-index.yaml.zst")
+	g.Expect(os.WriteFile(bigCompressedIndexFile, zstdCompress(t, data), 0o640)).ToNot(HaveOccurred())
+
 	tests := []struct {
 		name     string
 		filename string
@@ -91,6 +128,15 @@ func TestIndexFromFile(t *testing.T) {
 			filename: bigIndexFile,
 			wantErr:  "exceeds the maximum index file size",
 		},
+		{
+			name:     "zstd compressed index file",
+			filename: compressedIndexFile,
+		},
+		{
+			name:     "error if decompressed index size exceeds max size",
+			filename: bigCompressedIndexFile,
+			wantErr:  "exceeds the maximum index file size",
+		},
 	}
 
 	for _, tt := range tests {
@@ -351,6 +397,15 @@ func TestChartRepository_DownloadChart(t *testing.T) {
 			},
 			wantURL: "https://example.com/charts/foo-1.0.0.tgz",
 		},
+		{
+			name: "relative URL to xz compressed index",
+			url:  "https://example.com/stable/index.yaml.xz/",
+			chartVersion: &repo.ChartVersion{
+				Metadata: &chart.Metadata{Name: "chart"},
+				URLs:     []string{"charts/foo-1.0.0.tgz"},
+			},
+			wantURL: "https://example.com/stable/charts/foo-1.0.0.tgz",
+		},
 		{
 			name:         "no chart URL",
 			chartVersion: &repo.ChartVersion{Metadata: &chart.Metadata{Name: "chart"}},
@@ -389,6 +444,221 @@ func TestChartRepository_DownloadChart(t *testing.T) {
 	}
 }
 
+func TestChartRepository_MergeIndexes(t *testing.T) {
+	newRepo := func(u string, versions map[string][]string) *ChartRepository {
+		r := newChartRepository()
+		r.URL = u
+		r.Index = repo.NewIndexFile()
+		for name, vs := range versions {
+			for _, v := range vs {
+				r.Index.Entries[name] = append(r.Index.Entries[name], &repo.ChartVersion{
+					Metadata: &chart.Metadata{Name: name, Version: v},
+					URLs:     []string{fmt.Sprintf("%s-%s.tgz", name, v)},
+				})
+			}
+		}
+		return r
+	}
+
+	tests := []struct {
+		name         string
+		policy       MergePolicy
+		wantVersions map[string][]string
+		wantURLs     map[string]string
+	}{
+		{
+			name:   "first wins",
+			policy: MergePolicyFirstWins,
+			wantVersions: map[string][]string{
+				"a": {"1.0.0"},
+				"b": {"1.0.0"},
+				"c": {"1.0.0"},
+			},
+			wantURLs: map[string]string{
+				"a": "a-1.0.0.tgz",
+				"b": "https://one.example.com/charts/b-1.0.0.tgz",
+				"c": "https://two.example.com/c-1.0.0.tgz",
+			},
+		},
+		{
+			name:   "highest version wins",
+			policy: MergePolicyHighestVersionWins,
+			wantVersions: map[string][]string{
+				"a": {"2.0.0", "0.1.0"},
+				"b": {"1.0.0"},
+				"c": {"1.0.0"},
+			},
+			wantURLs: map[string]string{
+				"a": "https://two.example.com/a-2.0.0.tgz",
+				"b": "https://one.example.com/charts/b-1.0.0.tgz",
+				"c": "https://two.example.com/c-1.0.0.tgz",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			r := newRepo("https://example.com", map[string][]string{"a": {"1.0.0"}})
+			r.Path = filepath.Join(t.TempDir(), "index.yaml")
+			one := newRepo("https://one.example.com/charts", map[string][]string{"b": {"1.0.0"}})
+			two := newRepo("https://two.example.com", map[string][]string{"a": {"0.1.0", "2.0.0"}, "b": {"0.1.0"}, "c": {"1.0.0"}})
+
+			g.Expect(r.MergeIndexes(tt.policy, one, two)).To(Succeed())
+
+			for name, versions := range tt.wantVersions {
+				g.Expect(r.Index.Entries).To(HaveKey(name))
+				var got []string
+				for _, cv := range r.Index.Entries[name] {
+					got = append(got, cv.Version)
+				}
+				g.Expect(got).To(Equal(versions))
+				g.Expect(r.Index.Entries[name][0].URLs[0]).To(Equal(tt.wantURLs[name]))
+			}
+
+			// The merged index is written to the path.
+			i, err := IndexFromFile(r.Path)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(i.Entries).To(HaveLen(3))
+		})
+	}
+
+	t.Run("no index", func(t *testing.T) {
+		g := NewWithT(t)
+
+		r := newChartRepository()
+		g.Expect(r.MergeIndexes(MergePolicyFirstWins)).To(Equal(ErrNoChartIndex))
+	})
+}
+
+func TestChartRepository_VerifyDigests(t *testing.T) {
+	g := NewWithT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/large-1.0.0.tgz":
+			_, _ = w.Write(bytes.Repeat([]byte("x"), int(helm.MaxChartSize)+1))
+		case "/missing-1.0.0.tgz":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			_, _ = w.Write([]byte("foo"))
+		}
+	}))
+	defer server.Close()
+
+	r, err := NewChartRepository(server.URL, "", helmgetter.Providers{
+		helmgetter.Provider{Schemes: []string{"http"}, New: helmgetter.NewHTTPGetter},
+	}, nil)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	_, _, err = r.VerifyDigests(1)
+	g.Expect(err).To(Equal(ErrNoChartIndex))
+
+	r.Index = repo.NewIndexFile()
+	r.Index.Entries = map[string]repo.ChartVersions{
+		"valid": {
+			{
+				Metadata: &chart.Metadata{Name: "valid", Version: "1.0.0"},
+				URLs:     []string{"valid-1.0.0.tgz"},
+				Digest:   digest.SHA256.FromString("foo").Encoded(),
+			},
+			{
+				Metadata: &chart.Metadata{Name: "valid", Version: "1.1.0"},
+				URLs:     []string{"valid-1.1.0.tgz"},
+				Digest:   digest.SHA256.FromString("foo").String(),
+			},
+		},
+		"invalid": {
+			{
+				Metadata: &chart.Metadata{Name: "invalid", Version: "1.0.0"},
+				URLs:     []string{"invalid-1.0.0.tgz"},
+				Digest:   digest.SHA256.FromString("bar").Encoded(),
+			},
+			{
+				Metadata: &chart.Metadata{Name: "invalid", Version: "2.0.0"},
+				URLs:     []string{"invalid-2.0.0.tgz"},
+			},
+		},
+		"missing": {
+			{
+				Metadata: &chart.Metadata{Name: "missing", Version: "1.0.0"},
+				URLs:     []string{"missing-1.0.0.tgz"},
+				Digest:   digest.SHA256.FromString("foo").Encoded(),
+			},
+		},
+		"large": {
+			{
+				Metadata: &chart.Metadata{Name: "large", Version: "1.0.0"},
+				URLs:     []string{"large-1.0.0.tgz"},
+				Digest:   digest.SHA256.FromString("foo").Encoded(),
+			},
+		},
+	}
+
+	failed, sampled, err := r.VerifyDigests(10)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(sampled).To(Equal(5))
+	g.Expect(failed).To(HaveLen(3))
+	g.Expect(failed[0]).To(Equal("invalid@1.0.0"))
+	g.Expect(failed[1]).To(HavePrefix("large@1.0.0 ("))
+	g.Expect(failed[1]).To(ContainSubstring(transport.ErrBodyTooLarge.Error()))
+	g.Expect(failed[2]).To(HavePrefix("missing@1.0.0 ("))
+
+	_, sampled, err = r.VerifyDigests(1)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(sampled).To(Equal(1))
+}
+
+func TestChartRepository_CheckChartURLs(t *testing.T) {
+	g := NewWithT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.Expect(r.Method).To(Equal(http.MethodHead))
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	r := newChartRepository()
+	r.URL = server.URL + "/"
+
+	_, _, err := r.CheckChartURLs(context.TODO(), 1)
+	g.Expect(err).To(Equal(ErrNoChartIndex))
+
+	r.Index = repo.NewIndexFile()
+	r.Index.Entries = map[string]repo.ChartVersions{
+		"reachable": {
+			{
+				Metadata: &chart.Metadata{Name: "reachable", Version: "1.0.0"},
+				URLs:     []string{"reachable-1.0.0.tgz"},
+			},
+		},
+		"unreachable": {
+			{
+				Metadata: &chart.Metadata{Name: "unreachable", Version: "1.0.0"},
+				URLs:     []string{closed.URL + "/unreachable-1.0.0.tgz"},
+			},
+			{
+				Metadata: &chart.Metadata{Name: "unreachable", Version: "2.0.0"},
+				URLs:     []string{"oci://example.com/charts/unreachable"},
+			},
+			{
+				Metadata: &chart.Metadata{Name: "unreachable", Version: "3.0.0"},
+			},
+		},
+	}
+
+	unreachable, sampled, err := r.CheckChartURLs(context.TODO(), 10)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(sampled).To(Equal(2))
+	g.Expect(unreachable).To(Equal([]string{"unreachable@1.0.0"}))
+
+	_, sampled, err = r.CheckChartURLs(context.TODO(), 1)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(sampled).To(Equal(1))
+}
+
 func TestChartRepository_CacheIndex(t *testing.T) {
 	g := NewWithT(t)
 
@@ -451,97 +721,1056 @@ func TestChartRepository_DownloadIndex(t *testing.T) {
 	g.Expect(err).To(BeNil())
 }
 
-func TestChartRepository_StrategicallyLoadIndex(t *testing.T) {
-	t.Run("loads from path", func(t *testing.T) {
-		g := NewWithT(t)
+func TestChartRepository_DownloadIndexRedirectPolicy(t *testing.T) {
+	b, err := os.ReadFile(chartmuseumTestFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(b)
+	}))
+	defer target.Close()
+	redirect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL+r.URL.Path, http.StatusFound)
+	}))
+	defer redirect.Close()
 
-		i := filepath.Join(t.TempDir(), "index.yaml")
-		g.Expect(os.WriteFile(i, []byte(`apiVersion: v1`), 0o644)).To(Succeed())
+	tests := []struct {
+		name    string
+		policy  *transport.RedirectPolicy
+		wantErr bool
+	}{
+		{
+			name:   "no policy follows redirects",
+			policy: nil,
+		},
+		{
+			name:   "policy allows redirects",
+			policy: &transport.RedirectPolicy{Follow: true},
+		},
+		{
+			name:    "policy rejects redirects",
+			policy:  &transport.RedirectPolicy{Follow: false},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
 
-		r := newChartRepository()
-		r.Path = i
+			r, err := NewChartRepository(redirect.URL, "", helmgetter.Providers{
+				helmgetter.Provider{Schemes: []string{"http"}, New: helmgetter.NewHTTPGetter},
+			}, nil, helmgetter.WithURL(redirect.URL))
+			g.Expect(err).ToNot(HaveOccurred())
+			r.RedirectPolicy = tt.policy
 
-		err := r.StrategicallyLoadIndex()
-		g.Expect(err).To(Succeed())
-		g.Expect(r.Index).ToNot(BeNil())
-	})
+			buf := bytes.NewBuffer([]byte{})
+			err = r.DownloadIndex(buf)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(errors.Is(err, transport.ErrRedirectNotAllowed)).To(BeTrue())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(buf.Bytes()).To(Equal(b))
+		})
+	}
+}
 
-	t.Run("loads from client", func(t *testing.T) {
-		g := NewWithT(t)
+func TestChartRepository_RedirectChain(t *testing.T) {
+	g := NewWithT(t)
 
-		r := newChartRepository()
-		r.Client = &mockGetter{
-			Response: []byte(`apiVersion: v1`),
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("apiVersion: v1\nentries: {}\n"))
+	}))
+	defer target.Close()
+	redirect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL+r.URL.Path, http.StatusFound)
+	}))
+	defer redirect.Close()
+
+	for _, u := range []string{target.URL, redirect.URL} {
+		r, err := NewChartRepository(u, "", helmgetter.Providers{
+			helmgetter.Provider{Schemes: []string{"http"}, New: helmgetter.NewHTTPGetter},
+		}, nil, helmgetter.WithURL(u))
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(r.CacheIndex()).To(Succeed())
+		defer os.Remove(r.Path)
+
+		if u == target.URL {
+			g.Expect(r.RedirectChain()).To(BeNil())
+			continue
 		}
-		t.Cleanup(func() {
-			_ = os.Remove(r.Path)
-		})
+		g.Expect(r.RedirectChain()).To(Equal([]RedirectHop{
+			{URL: redirect.URL + "/index.yaml", StatusCode: http.StatusFound},
+			{URL: target.URL + "/index.yaml", StatusCode: http.StatusOK},
+		}))
+		g.Expect(r.RedirectChain()[0].String()).To(Equal("302 " + redirect.URL + "/index.yaml"))
+	}
+}
 
-		err := r.StrategicallyLoadIndex()
-		g.Expect(err).To(Succeed())
-		g.Expect(r.Path).ToNot(BeEmpty())
-		g.Expect(r.Index).ToNot(BeNil())
-	})
+func TestChartRepository_DownloadIndexNotFound(t *testing.T) {
+	g := NewWithT(t)
 
-	t.Run("skips if index is already loaded", func(t *testing.T) {
-		g := NewWithT(t)
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
 
-		r := newChartRepository()
-		r.Index = repo.NewIndexFile()
+	r, err := NewChartRepository(server.URL, "", helmgetter.Providers{
+		helmgetter.Provider{Schemes: []string{"http"}, New: helmgetter.NewHTTPGetter},
+	}, nil, helmgetter.WithURL(server.URL))
+	g.Expect(err).ToNot(HaveOccurred())
 
-		g.Expect(r.StrategicallyLoadIndex()).To(Succeed())
-	})
+	err = r.DownloadIndex(bytes.NewBuffer([]byte{}))
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(errors.Is(err, ErrIndexNotFound)).To(BeTrue())
 }
 
-func TestChartRepository_LoadFromPath(t *testing.T) {
-	t.Run("loads index", func(t *testing.T) {
-		g := NewWithT(t)
+func TestChartRepository_DownloadIndexContentEncoding(t *testing.T) {
+	b, err := os.ReadFile(chartmuseumTestFile)
+	if err != nil {
+		t.Fatal(err)
+	}
 
-		i := filepath.Join(t.TempDir(), "index.yaml")
-		g.Expect(os.WriteFile(i, []byte(`apiVersion: v1`), 0o644)).To(Succeed())
+	tests := []struct {
+		name     string
+		encoding string
+		encode   func(w io.Writer) io.WriteCloser
+		wantErr  error
+	}{
+		{
+			name: "identity",
+		},
+		{
+			name:     "gzip",
+			encoding: "gzip",
+			encode:   func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) },
+		},
+		{
+			name:     "brotli",
+			encoding: "br",
+			encode:   func(w io.Writer) io.WriteCloser { return brotli.NewWriter(w) },
+		},
+		{
+			name:     "xz",
+			encoding: "xz",
+			encode: func(w io.Writer) io.WriteCloser {
+				xw, _ := xz.NewWriter(w)
+				return xw
+			},
+		},
+		{
+			name:     "unsupported encoding",
+			encoding: "compress",
+			wantErr:  ErrUnsupportedEncoding,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
 
-		r := newChartRepository()
-		r.Path = i
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.encoding != "" {
+					w.Header().Set("Content-Encoding", tt.encoding)
+				}
+				if tt.encode == nil {
+					_, _ = w.Write(b)
+					return
+				}
+				ew := tt.encode(w)
+				_, _ = ew.Write(b)
+				_ = ew.Close()
+			}))
+			defer server.Close()
+
+			r, err := NewChartRepository(server.URL, "", helmgetter.Providers{
+				helmgetter.Provider{Schemes: []string{"http"}, New: helmgetter.NewHTTPGetter},
+			}, nil, helmgetter.WithURL(server.URL))
+			g.Expect(err).ToNot(HaveOccurred())
 
-		g.Expect(r.LoadFromPath()).To(Succeed())
-		g.Expect(r.Index).ToNot(BeNil())
-	})
+			buf := bytes.NewBuffer([]byte{})
+			err = r.DownloadIndex(buf)
+			if tt.wantErr != nil {
+				g.Expect(errors.Is(err, tt.wantErr)).To(BeTrue())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(buf.Bytes()).To(Equal(b))
+		})
+	}
+}
 
-	t.Run("no cache path", func(t *testing.T) {
-		g := NewWithT(t)
+func TestChartRepository_DownloadIndexXZ(t *testing.T) {
+	g := NewWithT(t)
 
-		err := newChartRepository().LoadFromPath()
-		g.Expect(err).To(HaveOccurred())
-		g.Expect(err.Error()).To(ContainSubstring("no cache path"))
-	})
+	b, err := os.ReadFile(chartmuseumTestFile)
+	g.Expect(err).ToNot(HaveOccurred())
 
-	t.Run("index load error", func(t *testing.T) {
-		g := NewWithT(t)
+	var requested string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.Path
+		xw, _ := xz.NewWriter(w)
+		_, _ = xw.Write(b)
+		_ = xw.Close()
+	}))
+	defer server.Close()
 
-		r := newChartRepository()
-		r.Path = filepath.Join(t.TempDir(), "index.yaml")
+	u, err := NormalizeURL(server.URL + "/stable/index.yaml.xz")
+	g.Expect(err).ToNot(HaveOccurred())
+	r, err := NewChartRepository(u, "", helmgetter.Providers{
+		helmgetter.Provider{Schemes: []string{"http"}, New: helmgetter.NewHTTPGetter},
+	}, nil, helmgetter.WithURL(u))
+	g.Expect(err).ToNot(HaveOccurred())
 
-		err := r.LoadFromPath()
-		g.Expect(err).To(HaveOccurred())
-		g.Expect(errors.Is(err, os.ErrNotExist)).To(BeTrue())
-	})
+	buf := bytes.NewBuffer([]byte{})
+	g.Expect(r.DownloadIndex(buf)).To(Succeed())
+	g.Expect(requested).To(Equal("/stable/index.yaml.xz"))
+	g.Expect(buf.Bytes()).To(Equal(b))
 }
 
-func TestChartRepository_Digest(t *testing.T) {
-	t.Run("with algorithm", func(t *testing.T) {
-		g := NewWithT(t)
+func TestChartRepository_DownloadIndexMaxSize(t *testing.T) {
+	g := NewWithT(t)
 
-		p := filepath.Join(t.TempDir(), "index.yaml")
-		g.Expect(repo.NewIndexFile().WriteFile(p, 0o644)).To(Succeed())
+	defer func(size int64) { helm.MaxIndexSize = size }(helm.MaxIndexSize)
+	helm.MaxIndexSize = 1 << 10
+
+	// The compressed response is small, but decodes to more than the
+	// maximum index size.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		zw := gzip.NewWriter(w)
+		_, _ = zw.Write(make([]byte, helm.MaxIndexSize+1))
+		_ = zw.Close()
+	}))
+	defer server.Close()
+
+	r, err := NewChartRepository(server.URL, "", helmgetter.Providers{
+		helmgetter.Provider{Schemes: []string{"http"}, New: helmgetter.NewHTTPGetter},
+	}, nil, helmgetter.WithURL(server.URL))
+	g.Expect(err).ToNot(HaveOccurred())
 
-		r := newChartRepository()
-		r.Path = p
+	err = r.DownloadIndex(io.Discard)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("exceeds the maximum index file size"))
+}
 
-		for _, algo := range []digest.Algorithm{digest.SHA256, digest.SHA512} {
-			t.Run(algo.String(), func(t *testing.T) {
-				g := NewWithT(t)
+func TestChartRepository_DownloadIndexAcceptHeader(t *testing.T) {
+	tests := []struct {
+		name        string
+		accept      string
+		contentType string
+		wantAccept  string
+		wantErr     error
+	}{
+		{
+			name:        "no accept header",
+			contentType: "text/html",
+		},
+		{
+			name:        "matching content type",
+			accept:      "application/json, application/yaml;q=0.9",
+			contentType: "application/json; charset=utf-8",
+			wantAccept:  "application/json, application/yaml;q=0.9",
+		},
+		{
+			name:        "wildcard",
+			accept:      "application/*",
+			contentType: "application/x-yaml",
+			wantAccept:  "application/*",
+		},
+		{
+			name:        "unexpected content type",
+			accept:      "application/json",
+			contentType: "text/html",
+			wantAccept:  "application/json",
+			wantErr:     ErrUnexpectedContentType,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
 
-				d := r.Digest(algo)
+			var accept string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				accept = r.Header.Get("Accept")
+				w.Header().Set("Content-Type", tt.contentType)
+				_, _ = w.Write([]byte(`{"apiVersion": "v1", "entries": {}}`))
+			}))
+			defer server.Close()
+
+			r, err := NewChartRepository(server.URL, "", helmgetter.Providers{
+				helmgetter.Provider{Schemes: []string{"http"}, New: helmgetter.NewHTTPGetter},
+			}, nil, helmgetter.WithURL(server.URL))
+			g.Expect(err).ToNot(HaveOccurred())
+			r.AcceptHeader = tt.accept
+
+			err = r.DownloadIndex(bytes.NewBuffer([]byte{}))
+			g.Expect(accept).To(Equal(tt.wantAccept))
+			if tt.wantErr != nil {
+				g.Expect(errors.Is(err, tt.wantErr)).To(BeTrue())
+				g.Expect(err.Error()).To(ContainSubstring(tt.contentType))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+		})
+	}
+}
+
+func TestChartRepository_DownloadIndexTruncated(t *testing.T) {
+	index := []byte(`{"apiVersion": "v1", "entries": {}}`)
+	tests := []struct {
+		name          string
+		contentLength int
+		truncate      bool
+		wantErr       error
+	}{
+		{
+			name: "complete chunked response",
+		},
+		{
+			name:     "truncated chunked response",
+			truncate: true,
+			wantErr:  ErrTruncatedResponse,
+		},
+		{
+			name:          "short Content-Length response",
+			contentLength: len(index) * 2,
+			truncate:      true,
+			wantErr:       ErrTruncatedResponse,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.contentLength > 0 {
+					w.Header().Set("Content-Length", strconv.Itoa(tt.contentLength))
+				}
+				_, _ = w.Write(index[:len(index)/2])
+				w.(http.Flusher).Flush()
+				if tt.truncate {
+					// Close the connection without finishing the response.
+					panic(http.ErrAbortHandler)
+				}
+				_, _ = w.Write(index[len(index)/2:])
+			}))
+			defer server.Close()
+
+			r, err := NewChartRepository(server.URL, "", helmgetter.Providers{
+				helmgetter.Provider{Schemes: []string{"http"}, New: helmgetter.NewHTTPGetter},
+			}, nil, helmgetter.WithURL(server.URL))
+			g.Expect(err).ToNot(HaveOccurred())
+
+			b := bytes.NewBuffer([]byte{})
+			err = r.DownloadIndex(b)
+			if tt.wantErr != nil {
+				g.Expect(errors.Is(err, tt.wantErr)).To(BeTrue(), err.Error())
+				g.Expect(b.Len()).To(BeZero())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(b.Bytes()).To(Equal(index))
+		})
+	}
+}
+
+func TestChartRepository_DownloadIndexDigestHeader(t *testing.T) {
+	index := []byte(`{"apiVersion": "v1", "entries": {}}`)
+	tests := []struct {
+		name         string
+		digestHeader string
+		advertised   string
+		wantErr      error
+	}{
+		{
+			name:       "no digest header configured",
+			advertised: digest.SHA256.FromString("other").String(),
+		},
+		{
+			name:         "no digest advertised",
+			digestHeader: "Docker-Content-Digest",
+		},
+		{
+			name:         "matching digest",
+			digestHeader: "Docker-Content-Digest",
+			advertised:   digest.SHA256.FromBytes(index).String(),
+		},
+		{
+			name:         "digest of other algorithm",
+			digestHeader: "Docker-Content-Digest",
+			advertised:   digest.SHA512.FromString("other").String(),
+		},
+		{
+			name:         "mismatching digest",
+			digestHeader: "Docker-Content-Digest",
+			advertised:   digest.SHA256.FromString("other").String(),
+			wantErr:      ErrDigestMismatch,
+		},
+		{
+			name:         "malformed digest",
+			digestHeader: "Docker-Content-Digest",
+			advertised:   "sha256:invalid",
+			wantErr:      ErrDigestMismatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.advertised != "" {
+					w.Header().Set("Docker-Content-Digest", tt.advertised)
+				}
+				_, _ = w.Write(index)
+			}))
+			defer server.Close()
+
+			r, err := NewChartRepository(server.URL, "", helmgetter.Providers{
+				helmgetter.Provider{Schemes: []string{"http"}, New: helmgetter.NewHTTPGetter},
+			}, nil, helmgetter.WithURL(server.URL))
+			g.Expect(err).ToNot(HaveOccurred())
+			r.DigestHeader = tt.digestHeader
+
+			err = r.DownloadIndex(bytes.NewBuffer([]byte{}))
+			if tt.wantErr != nil {
+				g.Expect(errors.Is(err, tt.wantErr)).To(BeTrue(), err.Error())
+				g.Expect(err.Error()).To(ContainSubstring(tt.advertised))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+		})
+	}
+}
+
+func TestChartRepository_DownloadIndexResponseHeaders(t *testing.T) {
+	g := NewWithT(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="example"`)
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	r, err := NewChartRepository(server.URL, "", helmgetter.Providers{
+		helmgetter.Provider{Schemes: []string{"http"}, New: helmgetter.NewHTTPGetter},
+	}, nil, helmgetter.WithURL(server.URL))
+	g.Expect(err).ToNot(HaveOccurred())
+	r.ResponseHeaders = []string{"WWW-Authenticate", "Set-Cookie", "X-Request-Id"}
+
+	err = r.DownloadIndex(bytes.NewBuffer([]byte{}))
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring(`(response headers: Set-Cookie: [redacted], Www-Authenticate: Bearer realm="example")`))
+	g.Expect(err.Error()).ToNot(ContainSubstring("secret"))
+}
+
+func TestChartRepository_CacheIndexAuthMethods(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte("apiVersion: v1\nentries: {}\n"))
+	}))
+	defer server.Close()
+
+	basic := AuthMethod{Name: "basic", Options: []helmgetter.Option{helmgetter.WithBasicAuth("user", "pass")}}
+	bearer := AuthMethod{Name: "bearer", BearerToken: "token"}
+
+	tests := []struct {
+		name       string
+		methods    []AuthMethod
+		wantErr    string
+		wantMethod string
+	}{
+		{name: "no methods", wantErr: "401"},
+		{name: "first method succeeds", methods: []AuthMethod{bearer, basic}, wantMethod: "bearer"},
+		{name: "falls back on 401", methods: []AuthMethod{basic, bearer}, wantMethod: "bearer"},
+		{name: "all methods fail", methods: []AuthMethod{basic}, wantErr: "401"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			r, err := NewChartRepository(server.URL, "", helmgetter.Providers{
+				helmgetter.Provider{Schemes: []string{"http"}, New: helmgetter.NewHTTPGetter},
+			}, nil, helmgetter.WithURL(server.URL))
+			g.Expect(err).ToNot(HaveOccurred())
+			r.AuthMethods = tt.methods
+
+			err = r.CacheIndex()
+			if tt.wantErr != "" {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			defer os.Remove(r.Path)
+			g.Expect(r.AuthMethodUsed()).To(Equal(tt.wantMethod))
+		})
+	}
+}
+
+func TestChartRepository_CacheMaxAge(t *testing.T) {
+	tests := []struct {
+		name         string
+		cacheControl []string
+		want         time.Duration
+		wantOK       bool
+	}{
+		{name: "no header"},
+		{name: "max-age", cacheControl: []string{"public, max-age=300"}, want: 5 * time.Minute, wantOK: true},
+		{name: "quoted max-age", cacheControl: []string{`max-age="60"`}, want: time.Minute, wantOK: true},
+		{name: "multiple headers", cacheControl: []string{"public", "Max-Age=0"}, want: 0, wantOK: true},
+		{name: "invalid max-age", cacheControl: []string{"max-age=-1"}},
+		{name: "no max-age", cacheControl: []string{"no-cache"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for _, v := range tt.cacheControl {
+					w.Header().Add("Cache-Control", v)
+				}
+				_, _ = w.Write([]byte("apiVersion: v1\nentries: {}\n"))
+			}))
+			defer server.Close()
+
+			r, err := NewChartRepository(server.URL, "", helmgetter.Providers{
+				helmgetter.Provider{Schemes: []string{"http"}, New: helmgetter.NewHTTPGetter},
+			}, nil, helmgetter.WithURL(server.URL))
+			g.Expect(err).ToNot(HaveOccurred())
+
+			g.Expect(r.CacheIndex()).To(Succeed())
+			defer os.Remove(r.Path)
+
+			got, ok := r.CacheMaxAge()
+			g.Expect(ok).To(Equal(tt.wantOK))
+			g.Expect(got).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestChartRepository_StatusCode(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		wantErr    bool
+	}{
+		{name: "ok", statusCode: http.StatusOK},
+		{name: "not found", statusCode: http.StatusNotFound, wantErr: true},
+		{name: "server error", statusCode: http.StatusServiceUnavailable, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.statusCode)
+				_, _ = w.Write([]byte("apiVersion: v1\nentries: {}\n"))
+			}))
+			defer server.Close()
+
+			r, err := NewChartRepository(server.URL, "", helmgetter.Providers{
+				helmgetter.Provider{Schemes: []string{"http"}, New: helmgetter.NewHTTPGetter},
+			}, nil, helmgetter.WithURL(server.URL))
+			g.Expect(err).ToNot(HaveOccurred())
+
+			err = r.CacheIndex()
+			defer os.Remove(r.Path)
+			g.Expect(err != nil).To(Equal(tt.wantErr))
+			g.Expect(r.StatusCode()).To(Equal(tt.statusCode))
+		})
+	}
+
+	// No status code is reported without a response.
+	g := NewWithT(t)
+	server := httptest.NewServer(http.NotFoundHandler())
+	u := server.URL
+	server.Close()
+	r, err := NewChartRepository(u, "", helmgetter.Providers{
+		helmgetter.Provider{Schemes: []string{"http"}, New: helmgetter.NewHTTPGetter},
+	}, nil, helmgetter.WithURL(u))
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(r.CacheIndex()).ToNot(Succeed())
+	g.Expect(r.StatusCode()).To(BeZero())
+}
+
+func TestChartRepository_CacheIndexContext(t *testing.T) {
+	g := NewWithT(t)
+
+	// The listener accepts connections but never responds, causing the TLS
+	// handshake to hang.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer l.Close()
+	go func() {
+		var conns []net.Conn
+		defer func() {
+			for _, conn := range conns {
+				conn.Close()
+			}
+		}()
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conns = append(conns, conn)
+		}
+	}()
+
+	u := "https://" + l.Addr().String()
+	r, err := NewChartRepository(u, "", helmgetter.Providers{
+		helmgetter.Provider{Schemes: []string{"https"}, New: helmgetter.NewHTTPGetter},
+	}, nil, helmgetter.WithURL(u))
+	g.Expect(err).ToNot(HaveOccurred())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err = r.CacheIndexContext(ctx)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+	g.Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+}
+
+func TestChartRepository_DownloadIndexResolver(t *testing.T) {
+	g := NewWithT(t)
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	// Nothing listens on the resolver address, causing the lookup to fail.
+	u := strings.Replace(server.URL, "127.0.0.1", "example.invalid", 1)
+	r, err := NewChartRepository(u, "", helmgetter.Providers{
+		helmgetter.Provider{Schemes: []string{"http"}, New: helmgetter.NewHTTPGetter},
+	}, nil, helmgetter.WithURL(u))
+	g.Expect(err).ToNot(HaveOccurred())
+	r.ResolverAddress = "127.0.0.1:1"
+
+	err = r.DownloadIndex(bytes.NewBuffer([]byte{}))
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("127.0.0.1:1"))
+}
+
+func TestChartRepository_CacheIndexResume(t *testing.T) {
+	g := NewWithT(t)
+
+	index := []byte("apiVersion: v1\nentries:\n  chart:\n  - name: chart\n    version: 1.0.0\n    description: " +
+		strings.Repeat("x", 4096) + "\n")
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("Range") != "" {
+			http.ServeContent(w, r, "index.yaml", time.Time{}, bytes.NewReader(index))
+			return
+		}
+		w.Header().Set("Accept-Ranges", "bytes")
+		w.Header().Set("Content-Length", strconv.Itoa(len(index)))
+		_, _ = w.Write(index[:len(index)/2])
+		w.(http.Flusher).Flush()
+		// Drop the connection halfway the index.
+		panic(http.ErrAbortHandler)
+	}))
+	defer server.Close()
+
+	r, err := NewChartRepository(server.URL, "", helmgetter.Providers{
+		helmgetter.Provider{Schemes: []string{"http"}, New: helmgetter.NewHTTPGetter},
+	}, nil, helmgetter.WithURL(server.URL))
+	g.Expect(err).ToNot(HaveOccurred())
+
+	g.Expect(r.CacheIndex()).To(Succeed())
+	defer os.Remove(r.Path)
+	g.Expect(requests).To(Equal(2))
+	b, err := os.ReadFile(r.Path)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(b).To(Equal(index))
+}
+
+func TestChartRepository_DownloadIndexProxy(t *testing.T) {
+	g := NewWithT(t)
+
+	// The proxy serves the index itself, instead of forwarding the request.
+	var proxyAuth, requestURL string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxyAuth = r.Header.Get("Proxy-Authorization")
+		requestURL = r.URL.String()
+		_, _ = w.Write([]byte("apiVersion: v1\nentries: {}\n"))
+	}))
+	defer proxy.Close()
+
+	u := "http://charts.example.invalid"
+	r, err := NewChartRepository(u, "", helmgetter.Providers{
+		helmgetter.Provider{Schemes: []string{"http"}, New: helmgetter.NewHTTPGetter},
+	}, nil, helmgetter.WithURL(u))
+	g.Expect(err).ToNot(HaveOccurred())
+	r.ProxyURL, err = url.Parse(proxy.URL)
+	g.Expect(err).ToNot(HaveOccurred())
+	r.ProxyURL.User = url.UserPassword("user", "pass")
+
+	b := bytes.NewBuffer([]byte{})
+	g.Expect(r.DownloadIndex(b)).To(Succeed())
+	g.Expect(b.String()).To(ContainSubstring("apiVersion: v1"))
+	g.Expect(requestURL).To(Equal(u + "/index.yaml"))
+	g.Expect(proxyAuth).To(Equal("Basic dXNlcjpwYXNz"))
+}
+
+func TestChartRepository_DownloadIndexHeaders(t *testing.T) {
+	g := NewWithT(t)
+
+	var apiKey string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiKey = r.Header.Get("X-Api-Key")
+		_, _ = w.Write([]byte("apiVersion: v1\nentries: {}\n"))
+	}))
+	defer server.Close()
+
+	r, err := NewChartRepository(server.URL, "", helmgetter.Providers{
+		helmgetter.Provider{Schemes: []string{"http"}, New: helmgetter.NewHTTPGetter},
+	}, nil, helmgetter.WithURL(server.URL))
+	g.Expect(err).ToNot(HaveOccurred())
+	r.Headers = http.Header{"X-Api-Key": {"secret"}}
+
+	b := bytes.NewBuffer([]byte{})
+	g.Expect(r.DownloadIndex(b)).To(Succeed())
+	g.Expect(apiKey).To(Equal("secret"))
+}
+
+func TestChartRepository_DownloadChartHeaders(t *testing.T) {
+	tests := []struct {
+		name            string
+		otherHost       bool
+		passCredentials bool
+		want            string
+	}{
+		{name: "chart on repository host", want: "secret"},
+		{name: "chart on other host", otherHost: true},
+		{name: "chart on other host with passCredentials", otherHost: true, passCredentials: true, want: "secret"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			var apiKey string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				apiKey = r.Header.Get("X-Api-Key")
+				_, _ = w.Write([]byte("chart"))
+			}))
+			defer server.Close()
+
+			// The same server is reached through a different host.
+			repoURL := server.URL
+			chartURL := server.URL + "/chart-1.0.0.tgz"
+			if tt.otherHost {
+				repoURL = strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+			}
+
+			r, err := NewChartRepository(repoURL, "", helmgetter.Providers{
+				helmgetter.Provider{Schemes: []string{"http"}, New: helmgetter.NewHTTPGetter},
+			}, nil)
+			g.Expect(err).ToNot(HaveOccurred())
+			r.Headers = http.Header{"X-Api-Key": {"secret"}}
+			r.RedirectPolicy = &transport.RedirectPolicy{Follow: true, PassCredentials: tt.passCredentials}
+
+			_, err = r.DownloadChart(&repo.ChartVersion{
+				Metadata: &chart.Metadata{Name: "chart"},
+				URLs:     []string{chartURL},
+			})
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(apiKey).To(Equal(tt.want))
+		})
+	}
+}
+
+func TestChartRepository_DownloadIndexShards(t *testing.T) {
+	shards := map[string]string{
+		"/shards/a.yaml": `apiVersion: v1
+generated: "2023-01-01T00:00:00Z"
+entries:
+  foo:
+  - name: foo
+    version: 1.0.0
+    urls:
+    - foo-1.0.0.tgz
+`,
+		"/shards/b.yaml": `apiVersion: v1
+generated: "2023-02-01T00:00:00Z"
+entries:
+  foo:
+  - name: foo
+    version: 2.0.0
+    urls:
+    - foo-2.0.0.tgz
+  - name: foo
+    version: 1.0.0
+    urls:
+    - duplicate.tgz
+  bar:
+  - name: bar
+    version: 0.1.0
+    urls:
+    - bar-0.1.0.tgz
+`,
+	}
+
+	tests := []struct {
+		name     string
+		manifest string
+		wantErr  string
+	}{
+		{
+			name:     "merges shards",
+			manifest: "shards:\n- shards/a.yaml\n- /shards/b.yaml\n",
+		},
+		{
+			name:     "merges shards listed in JSON",
+			manifest: `{"shards": ["shards/a.yaml", "shards/b.yaml"]}`,
+		},
+		{
+			name:     "fails on missing shard",
+			manifest: "shards:\n- shards/a.yaml\n- shards/missing.yaml\n",
+			wantErr:  "failed to fetch shard",
+		},
+		{
+			name:     "fails on shard from other host",
+			manifest: "shards:\n- shards/a.yaml\n- http://charts.example.invalid/shards/b.yaml\n",
+			wantErr:  "is not served from",
+		},
+		{
+			name:     "fails on empty manifest",
+			manifest: "shards: []\n",
+			wantErr:  "manifest lists no shards",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/manifest.yaml" {
+					_, _ = w.Write([]byte(tt.manifest))
+					return
+				}
+				shard, ok := shards[r.URL.Path]
+				if !ok {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				_, _ = w.Write([]byte(shard))
+			}))
+			defer server.Close()
+
+			r, err := NewChartRepository(server.URL, "", helmgetter.Providers{
+				helmgetter.Provider{Schemes: []string{"http"}, New: helmgetter.NewHTTPGetter},
+			}, nil, helmgetter.WithURL(server.URL))
+			g.Expect(err).ToNot(HaveOccurred())
+			r.ShardManifestURL = server.URL + "/manifest.yaml"
+
+			b := bytes.NewBuffer([]byte{})
+			err = r.DownloadIndex(b)
+			if tt.wantErr != "" {
+				g.Expect(err).To(MatchError(ErrShards))
+				g.Expect(err.Error()).To(ContainSubstring(tt.wantErr))
+				g.Expect(b.Len()).To(BeZero())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+
+			index, err := IndexFromBytes(b.Bytes())
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(index.Generated.Format(time.RFC3339)).To(Equal("2023-02-01T00:00:00Z"))
+			g.Expect(index.Entries).To(HaveLen(2))
+			g.Expect(index.Entries["foo"]).To(HaveLen(2))
+			g.Expect(index.Entries["foo"][0].Version).To(Equal("2.0.0"))
+			g.Expect(index.Entries["foo"][1].URLs).To(Equal([]string{"foo-1.0.0.tgz"}))
+			g.Expect(index.Entries["bar"]).To(HaveLen(1))
+		})
+	}
+}
+
+func TestChartRepository_StrategicallyLoadIndex(t *testing.T) {
+	t.Run("loads from path", func(t *testing.T) {
+		g := NewWithT(t)
+
+		i := filepath.Join(t.TempDir(), "index.yaml")
+		g.Expect(os.WriteFile(i, []byte(`apiVersion: v1`), 0o644)).To(Succeed())
+
+		r := newChartRepository()
+		r.Path = i
+
+		err := r.StrategicallyLoadIndex()
+		g.Expect(err).To(Succeed())
+		g.Expect(r.Index).ToNot(BeNil())
+	})
+
+	t.Run("loads from client", func(t *testing.T) {
+		g := NewWithT(t)
+
+		r := newChartRepository()
+		r.Client = &mockGetter{
+			Response: []byte(`apiVersion: v1`),
+		}
+		t.Cleanup(func() {
+			_ = os.Remove(r.Path)
+		})
+
+		err := r.StrategicallyLoadIndex()
+		g.Expect(err).To(Succeed())
+		g.Expect(r.Path).ToNot(BeEmpty())
+		g.Expect(r.Index).ToNot(BeNil())
+	})
+
+	t.Run("skips if index is already loaded", func(t *testing.T) {
+		g := NewWithT(t)
+
+		r := newChartRepository()
+		r.Index = repo.NewIndexFile()
+
+		g.Expect(r.StrategicallyLoadIndex()).To(Succeed())
+	})
+}
+
+func TestChartRepository_LoadFromPath(t *testing.T) {
+	t.Run("loads index", func(t *testing.T) {
+		g := NewWithT(t)
+
+		i := filepath.Join(t.TempDir(), "index.yaml")
+		g.Expect(os.WriteFile(i, []byte(`apiVersion: v1`), 0o644)).To(Succeed())
+
+		r := newChartRepository()
+		r.Path = i
+
+		g.Expect(r.LoadFromPath()).To(Succeed())
+		g.Expect(r.Index).ToNot(BeNil())
+	})
+
+	t.Run("no cache path", func(t *testing.T) {
+		g := NewWithT(t)
+
+		err := newChartRepository().LoadFromPath()
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(err.Error()).To(ContainSubstring("no cache path"))
+	})
+
+	t.Run("index load error", func(t *testing.T) {
+		g := NewWithT(t)
+
+		r := newChartRepository()
+		r.Path = filepath.Join(t.TempDir(), "index.yaml")
+
+		err := r.LoadFromPath()
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(errors.Is(err, os.ErrNotExist)).To(BeTrue())
+	})
+}
+
+func TestChartRepository_LoadFromPathContext(t *testing.T) {
+	t.Run("loads index", func(t *testing.T) {
+		g := NewWithT(t)
+
+		i := filepath.Join(t.TempDir(), "index.yaml")
+		g.Expect(os.WriteFile(i, []byte(`apiVersion: v1`), 0o644)).To(Succeed())
+
+		r := newChartRepository()
+		r.Path = i
+
+		g.Expect(r.LoadFromPathContext(context.TODO())).To(Succeed())
+		g.Expect(r.Index).ToNot(BeNil())
+	})
+
+	t.Run("no cache path", func(t *testing.T) {
+		g := NewWithT(t)
+
+		err := newChartRepository().LoadFromPathContext(context.TODO())
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(err.Error()).To(ContainSubstring("no cache path"))
+	})
+
+	t.Run("context done", func(t *testing.T) {
+		g := NewWithT(t)
+
+		i := filepath.Join(t.TempDir(), "index.yaml")
+		g.Expect(os.WriteFile(i, []byte(`apiVersion: v1`), 0o644)).To(Succeed())
+
+		r := newChartRepository()
+		r.Path = i
+
+		ctx, cancel := context.WithCancel(context.TODO())
+		cancel()
+
+		err := r.LoadFromPathContext(ctx)
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+		g.Expect(errors.Is(err, ErrParseTimeout)).To(BeFalse())
+		g.Expect(r.Index).To(BeNil())
+	})
+
+	t.Run("deadline exceeded while waiting for a slot", func(t *testing.T) {
+		g := NewWithT(t)
+
+		i := filepath.Join(t.TempDir(), "index.yaml")
+		g.Expect(os.WriteFile(i, []byte(`apiVersion: v1`), 0o644)).To(Succeed())
+
+		r := newChartRepository()
+		r.Path = i
+
+		// Occupy the slots as if abandoned loads were still running.
+		for n := 0; n < cap(indexLoadSlots); n++ {
+			indexLoadSlots <- struct{}{}
+		}
+		defer func() {
+			for n := 0; n < cap(indexLoadSlots); n++ {
+				<-indexLoadSlots
+			}
+		}()
+
+		ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+		defer cancel()
+
+		err := r.LoadFromPathContext(ctx)
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(errors.Is(err, ErrParseTimeout)).To(BeTrue())
+		g.Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+		g.Expect(r.Index).To(BeNil())
+	})
+}
+
+func Test_indexFromBytesContext(t *testing.T) {
+	g := NewWithT(t)
+
+	b, err := os.ReadFile(testFile)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+
+	_, err = indexFromBytesContext(ctx, b)
+	g.Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+
+	i, err := indexFromBytesContext(context.TODO(), b)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(i.Entries).ToNot(BeEmpty())
+}
+
+func TestChartRepository_Digest(t *testing.T) {
+	t.Run("with algorithm", func(t *testing.T) {
+		g := NewWithT(t)
+
+		p := filepath.Join(t.TempDir(), "index.yaml")
+		g.Expect(repo.NewIndexFile().WriteFile(p, 0o644)).To(Succeed())
+
+		r := newChartRepository()
+		r.Path = p
+
+		for _, algo := range []digest.Algorithm{digest.SHA256, digest.SHA512} {
+			t.Run(algo.String(), func(t *testing.T) {
+				g := NewWithT(t)
+
+				d := r.Digest(algo)
 				g.Expect(d).ToNot(BeEmpty())
 				g.Expect(d.Algorithm()).To(Equal(algo))
 				g.Expect(r.digests[algo]).To(Equal(d))
@@ -573,6 +1802,416 @@ func TestChartRepository_Digest(t *testing.T) {
 	})
 }
 
+func TestChartRepository_EntriesCount(t *testing.T) {
+	g := NewWithT(t)
+
+	r := newChartRepository()
+	charts, versions := r.EntriesCount()
+	g.Expect(charts).To(BeZero())
+	g.Expect(versions).To(BeZero())
+
+	r.Index = repo.NewIndexFile()
+	r.Index.Entries["foo"] = repo.ChartVersions{
+		{Metadata: &chart.Metadata{Name: "foo", Version: "1.0.0"}},
+		{Metadata: &chart.Metadata{Name: "foo", Version: "1.1.0"}},
+	}
+	r.Index.Entries["bar"] = repo.ChartVersions{
+		{Metadata: &chart.Metadata{Name: "bar", Version: "0.1.0"}},
+	}
+	charts, versions = r.EntriesCount()
+	g.Expect(charts).To(Equal(int64(2)))
+	g.Expect(versions).To(Equal(int64(3)))
+}
+
+func TestChartRepository_DependencyCounts(t *testing.T) {
+	g := NewWithT(t)
+
+	r := newChartRepository()
+	dependencies, internal, dependents := r.DependencyCounts("https://example.com")
+	g.Expect(dependencies).To(BeZero())
+	g.Expect(internal).To(BeZero())
+	g.Expect(dependents).To(BeZero())
+
+	r.Index = repo.NewIndexFile()
+	r.Index.Entries["app"] = repo.ChartVersions{
+		{Metadata: &chart.Metadata{Name: "app", Version: "1.0.0", Dependencies: []*chart.Dependency{
+			{Name: "old", Repository: "https://example.com"},
+		}}},
+		{Metadata: &chart.Metadata{Name: "app", Version: "1.1.0", Dependencies: []*chart.Dependency{
+			{Name: "lib", Repository: "https://example.com/"},
+			{Name: "redis", Repository: "https://charts.bitnami.com/bitnami"},
+			{Name: "missing", Repository: "https://example.com"},
+		}}},
+	}
+	r.Index.Entries["lib"] = repo.ChartVersions{
+		{Metadata: &chart.Metadata{Name: "lib", Version: "0.1.0"}},
+	}
+	r.Index.Entries["other"] = repo.ChartVersions{
+		{Metadata: &chart.Metadata{Name: "other", Version: "0.1.0", Dependencies: []*chart.Dependency{
+			{Name: "lib", Repository: "https://mirror.example.com"},
+		}}},
+	}
+	dependencies, internal, dependents = r.DependencyCounts("https://example.com", "https://mirror.example.com/")
+	g.Expect(dependencies).To(Equal(int64(4)))
+	g.Expect(internal).To(Equal(int64(2)))
+	g.Expect(dependents).To(Equal(int64(2)))
+}
+
+func TestIndexDelta(t *testing.T) {
+	g := NewWithT(t)
+
+	added, removed := IndexDelta(nil, nil)
+	g.Expect(added).To(BeEmpty())
+	g.Expect(removed).To(BeEmpty())
+
+	previous := repo.NewIndexFile()
+	previous.Entries["foo"] = repo.ChartVersions{
+		{Metadata: &chart.Metadata{Name: "foo", Version: "1.0.0"}},
+		{Metadata: &chart.Metadata{Name: "foo", Version: "1.1.0"}},
+	}
+	previous.Entries["bar"] = repo.ChartVersions{
+		{Metadata: &chart.Metadata{Name: "bar", Version: "0.1.0"}},
+	}
+	current := repo.NewIndexFile()
+	current.Entries["foo"] = repo.ChartVersions{
+		{Metadata: &chart.Metadata{Name: "foo", Version: "1.1.0"}},
+		{Metadata: &chart.Metadata{Name: "foo", Version: "2.0.0"}},
+		nil,
+	}
+	current.Entries["baz"] = repo.ChartVersions{
+		{Metadata: &chart.Metadata{Name: "baz", Version: "0.2.0"}},
+	}
+
+	added, removed = IndexDelta(previous, current)
+	g.Expect(added).To(Equal([]string{"baz@0.2.0", "foo@2.0.0"}))
+	g.Expect(removed).To(Equal([]string{"bar@0.1.0", "foo@1.0.0"}))
+
+	added, removed = IndexDelta(nil, previous)
+	g.Expect(added).To(Equal([]string{"bar@0.1.0", "foo@1.0.0", "foo@1.1.0"}))
+	g.Expect(removed).To(BeEmpty())
+}
+
+func TestChartRepository_RemoveVersions(t *testing.T) {
+	t.Run("removes matching versions", func(t *testing.T) {
+		g := NewWithT(t)
+
+		i := filepath.Join(t.TempDir(), "index.yaml")
+		g.Expect(os.WriteFile(i, []byte(`apiVersion: v1`), 0o644)).To(Succeed())
+
+		r := newChartRepository()
+		r.Path = i
+		r.Index = repo.NewIndexFile()
+		r.Index.Entries["foo"] = repo.ChartVersions{
+			{Metadata: &chart.Metadata{Name: "foo", Version: "1.0.0"}},
+			{Metadata: &chart.Metadata{Name: "foo", Version: "1.1.0"}},
+			{Metadata: &chart.Metadata{Name: "foo", Version: "2.0.0"}},
+		}
+		r.Index.Entries["bar"] = repo.ChartVersions{
+			{Metadata: &chart.Metadata{Name: "bar", Version: "0.1.0"}},
+		}
+		before := r.Digest(digest.SHA256)
+
+		constraints := map[string]*semver.Constraints{}
+		for name, v := range map[string]string{"foo": ">=1.0.0 <2.0.0", "bar": "0.1.0", "baz": "*"} {
+			c, err := semver.NewConstraint(v)
+			g.Expect(err).ToNot(HaveOccurred())
+			constraints[name] = c
+		}
+
+		removed, err := r.RemoveVersions(constraints)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(removed).To(Equal(map[string][]string{
+			"foo": {"1.0.0", "1.1.0"},
+			"bar": {"0.1.0"},
+		}))
+		g.Expect(r.Index.Entries).To(HaveLen(1))
+		g.Expect(r.Index.Entries["foo"]).To(HaveLen(1))
+		g.Expect(r.Index.Entries["foo"][0].Version).To(Equal("2.0.0"))
+
+		// The index is written back to the path.
+		g.Expect(r.Digest(digest.SHA256)).ToNot(Equal(before))
+		i2, err := IndexFromFile(i)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(i2.Entries).To(HaveLen(1))
+	})
+
+	t.Run("no matching versions", func(t *testing.T) {
+		g := NewWithT(t)
+
+		r := newChartRepository()
+		r.Index = repo.NewIndexFile()
+		r.Index.Entries["foo"] = repo.ChartVersions{
+			{Metadata: &chart.Metadata{Name: "foo", Version: "1.0.0"}},
+		}
+
+		c, err := semver.NewConstraint(">1.0.0")
+		g.Expect(err).ToNot(HaveOccurred())
+
+		removed, err := r.RemoveVersions(map[string]*semver.Constraints{"foo": c})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(removed).To(BeNil())
+		g.Expect(r.Index.Entries["foo"]).To(HaveLen(1))
+	})
+
+	t.Run("no index", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := newChartRepository().RemoveVersions(nil)
+		g.Expect(err).To(Equal(ErrNoChartIndex))
+	})
+}
+
+func TestChartRepository_RemoveInvalidChartNames(t *testing.T) {
+	g := NewWithT(t)
+
+	long := strings.Repeat("a", 11)
+	i := filepath.Join(t.TempDir(), "index.yaml")
+	g.Expect(os.WriteFile(i, []byte(`apiVersion: v1`), 0o644)).To(Succeed())
+
+	r := newChartRepository()
+	r.Path = i
+	r.Index = repo.NewIndexFile()
+	r.Index.Entries["foo"] = repo.ChartVersions{{Metadata: &chart.Metadata{Name: "foo", Version: "1.0.0"}}}
+	r.Index.Entries[""] = repo.ChartVersions{{Metadata: &chart.Metadata{Name: "", Version: "1.0.0"}}}
+	r.Index.Entries[long] = repo.ChartVersions{{Metadata: &chart.Metadata{Name: long, Version: "1.0.0"}}}
+	before := r.Digest(digest.SHA256)
+
+	invalid, err := r.InvalidChartNames(10)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(invalid).To(Equal([]string{"", long}))
+
+	g.Expect(r.RemoveCharts(invalid...)).To(Succeed())
+	g.Expect(r.Index.Entries).To(HaveLen(1))
+	g.Expect(r.Index.Entries).To(HaveKey("foo"))
+
+	// The index is written back to the path.
+	g.Expect(r.Digest(digest.SHA256)).ToNot(Equal(before))
+	i2, err := IndexFromFile(i)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(i2.Entries).To(HaveLen(1))
+
+	invalid, err = r.InvalidChartNames(10)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(invalid).To(BeEmpty())
+
+	_, err = newChartRepository().InvalidChartNames(10)
+	g.Expect(err).To(Equal(ErrNoChartIndex))
+}
+
+func TestChartRepository_WeakDigest(t *testing.T) {
+	g := NewWithT(t)
+
+	newIndex := func() *repo.IndexFile {
+		i := repo.NewIndexFile()
+		i.Entries["foo"] = repo.ChartVersions{{Metadata: &chart.Metadata{Name: "foo", Version: "1.0.0"}}}
+		return i
+	}
+
+	r := newChartRepository()
+	g.Expect(r.WeakDigest(digest.SHA256)).To(BeEmpty())
+
+	r.Index = newIndex()
+	want := r.WeakDigest(digest.SHA256)
+	g.Expect(want.Validate()).To(Succeed())
+
+	// Volatile fields do not change the digest.
+	r.Index = newIndex()
+	r.Index.Generated = time.Now().Add(time.Hour)
+	r.Index.ServerInfo = map[string]interface{}{"contextPath": "/v1/helm"}
+	r.Index.Annotations = map[string]string{"server": "a"}
+	g.Expect(r.WeakDigest(digest.SHA256)).To(Equal(want))
+
+	// Entries do change the digest.
+	r.Index = newIndex()
+	r.Index.Entries["bar"] = repo.ChartVersions{{Metadata: &chart.Metadata{Name: "bar", Version: "1.0.0"}}}
+	g.Expect(r.WeakDigest(digest.SHA256)).ToNot(Equal(want))
+}
+
+func TestChartRepository_TruncateVersions(t *testing.T) {
+	newIndex := func() *repo.IndexFile {
+		i := repo.NewIndexFile()
+		i.Generated = time.Unix(0, 0)
+		i.Entries["foo"] = repo.ChartVersions{
+			{Metadata: &chart.Metadata{Name: "foo", Version: "1.10.0"}},
+			{Metadata: &chart.Metadata{Name: "foo", Version: "not-semver"}},
+			{Metadata: &chart.Metadata{Name: "foo", Version: "1.9.0"}},
+			{Metadata: &chart.Metadata{Name: "foo", Version: "2.0.0-rc.1"}},
+			{Metadata: &chart.Metadata{Name: "foo", Version: "2.0.0"}},
+		}
+		i.Entries["bar"] = repo.ChartVersions{
+			{Metadata: &chart.Metadata{Name: "bar", Version: "0.1.0"}},
+		}
+		return i
+	}
+
+	t.Run("keeps highest versions", func(t *testing.T) {
+		g := NewWithT(t)
+
+		i := filepath.Join(t.TempDir(), "index.yaml")
+		g.Expect(os.WriteFile(i, []byte(`apiVersion: v1`), 0o644)).To(Succeed())
+
+		r := newChartRepository()
+		r.Path = i
+		r.Index = newIndex()
+		before := r.Digest(digest.SHA256)
+
+		removed, err := r.TruncateVersions(3)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(removed).To(Equal(map[string]int{"foo": 2}))
+		var versions []string
+		for _, cv := range r.Index.Entries["foo"] {
+			versions = append(versions, cv.Version)
+		}
+		g.Expect(versions).To(Equal([]string{"2.0.0", "2.0.0-rc.1", "1.10.0"}))
+		g.Expect(r.Index.Entries["bar"]).To(HaveLen(1))
+
+		// The index is written back to the path.
+		g.Expect(r.Digest(digest.SHA256)).ToNot(Equal(before))
+		i2, err := IndexFromFile(i)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(i2.Entries["foo"]).To(HaveLen(3))
+	})
+
+	t.Run("result is stable", func(t *testing.T) {
+		g := NewWithT(t)
+
+		r1 := newChartRepository()
+		r1.Path = filepath.Join(t.TempDir(), "index.yaml")
+		r1.Index = newIndex()
+		_, err := r1.TruncateVersions(2)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		r2 := newChartRepository()
+		r2.Path = filepath.Join(t.TempDir(), "index.yaml")
+		r2.Index = newIndex()
+		cvs := r2.Index.Entries["foo"]
+		for i, j := 0, len(cvs)-1; i < j; i, j = i+1, j-1 {
+			cvs[i], cvs[j] = cvs[j], cvs[i]
+		}
+		_, err = r2.TruncateVersions(2)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		g.Expect(r1.Digest(digest.SHA256)).ToNot(BeEmpty())
+		g.Expect(r1.Digest(digest.SHA256)).To(Equal(r2.Digest(digest.SHA256)))
+	})
+
+	t.Run("within limit", func(t *testing.T) {
+		g := NewWithT(t)
+
+		r := newChartRepository()
+		r.Index = newIndex()
+
+		removed, err := r.TruncateVersions(5)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(removed).To(BeNil())
+		g.Expect(r.Index.Entries["foo"]).To(HaveLen(5))
+	})
+
+	t.Run("no index", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := newChartRepository().TruncateVersions(1)
+		g.Expect(err).To(Equal(ErrNoChartIndex))
+	})
+}
+
+func TestChartRepository_Canonicalize(t *testing.T) {
+	newIndex := func(generated time.Time, versions ...string) *repo.IndexFile {
+		i := repo.NewIndexFile()
+		i.Generated = generated
+		for _, v := range versions {
+			i.Entries["foo"] = append(i.Entries["foo"], &repo.ChartVersion{
+				Metadata: &chart.Metadata{Name: "foo", Version: v},
+			})
+		}
+		return i
+	}
+
+	t.Run("equal digest for reordered index", func(t *testing.T) {
+		g := NewWithT(t)
+
+		r1 := newChartRepository()
+		r1.Path = filepath.Join(t.TempDir(), "index.yaml")
+		r1.Index = newIndex(time.Unix(0, 0), "1.0.0", "2.0.0", "1.10.0")
+		g.Expect(r1.Canonicalize()).To(Succeed())
+
+		r2 := newChartRepository()
+		r2.Path = filepath.Join(t.TempDir(), "index.yaml")
+		r2.Index = newIndex(time.Now(), "2.0.0", "1.10.0", "1.0.0")
+		g.Expect(r2.Canonicalize()).To(Succeed())
+
+		var versions []string
+		for _, cv := range r1.Index.Entries["foo"] {
+			versions = append(versions, cv.Version)
+		}
+		g.Expect(versions).To(Equal([]string{"2.0.0", "1.10.0", "1.0.0"}))
+		g.Expect(r1.Index.Generated.IsZero()).To(BeTrue())
+
+		g.Expect(r1.Digest(digest.SHA256)).ToNot(BeEmpty())
+		g.Expect(r1.Digest(digest.SHA256)).To(Equal(r2.Digest(digest.SHA256)))
+	})
+
+	t.Run("no index", func(t *testing.T) {
+		g := NewWithT(t)
+
+		g.Expect(newChartRepository().Canonicalize()).To(Equal(ErrNoChartIndex))
+	})
+}
+
+func TestChartRepository_ResetGenerated(t *testing.T) {
+	newIndex := func(generated time.Time, versions ...string) *repo.IndexFile {
+		i := repo.NewIndexFile()
+		i.Generated = generated
+		for _, v := range versions {
+			i.Entries["foo"] = append(i.Entries["foo"], &repo.ChartVersion{
+				Metadata: &chart.Metadata{Name: "foo", Version: v},
+			})
+		}
+		return i
+	}
+
+	t.Run("equal digest for republished index", func(t *testing.T) {
+		g := NewWithT(t)
+
+		r1 := newChartRepository()
+		r1.Path = filepath.Join(t.TempDir(), "index.yaml")
+		r1.Index = newIndex(time.Unix(0, 0), "1.0.0", "2.0.0")
+		g.Expect(r1.ResetGenerated()).To(Succeed())
+
+		r2 := newChartRepository()
+		r2.Path = filepath.Join(t.TempDir(), "index.yaml")
+		r2.Index = newIndex(time.Now(), "1.0.0", "2.0.0")
+		g.Expect(r2.ResetGenerated()).To(Succeed())
+
+		g.Expect(r1.Index.Generated.IsZero()).To(BeTrue())
+		g.Expect(r1.Digest(digest.SHA256)).ToNot(BeEmpty())
+		g.Expect(r1.Digest(digest.SHA256)).To(Equal(r2.Digest(digest.SHA256)))
+	})
+
+	t.Run("keeps version order", func(t *testing.T) {
+		g := NewWithT(t)
+
+		r1 := newChartRepository()
+		r1.Path = filepath.Join(t.TempDir(), "index.yaml")
+		r1.Index = newIndex(time.Now(), "1.0.0", "2.0.0")
+		g.Expect(r1.ResetGenerated()).To(Succeed())
+
+		r2 := newChartRepository()
+		r2.Path = filepath.Join(t.TempDir(), "index.yaml")
+		r2.Index = newIndex(time.Now(), "2.0.0", "1.0.0")
+		g.Expect(r2.ResetGenerated()).To(Succeed())
+
+		g.Expect(r1.Index.Entries["foo"][0].Version).To(Equal("1.0.0"))
+		g.Expect(r1.Digest(digest.SHA256)).ToNot(Equal(r2.Digest(digest.SHA256)))
+	})
+
+	t.Run("no index", func(t *testing.T) {
+		g := NewWithT(t)
+
+		g.Expect(newChartRepository().ResetGenerated()).To(Equal(ErrNoChartIndex))
+	})
+}
+
 func TestChartRepository_HasIndex(t *testing.T) {
 	g := NewWithT(t)
 
@@ -729,3 +2368,204 @@ func verifyLocalIndex(t *testing.T, i *repo.IndexFile) {
 		g.Expect(tt.Keywords).To(ContainElements(expect.Keywords))
 	}
 }
+
+func TestChartRepository_DownloadIndexPreflight(t *testing.T) {
+	tests := []struct {
+		name       string
+		preflight  Preflight
+		handler    http.HandlerFunc
+		wantHeader string
+		wantToken  string
+		wantErr    bool
+	}{
+		{
+			name: "token from body",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte("nonce\n"))
+			},
+			wantHeader: DefaultPreflightRequestHeader,
+			wantToken:  "nonce",
+		},
+		{
+			name:      "token from header",
+			preflight: Preflight{TokenHeader: "X-Token", RequestHeader: "X-Vendor-Token"},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Token", "nonce")
+			},
+			wantHeader: "X-Vendor-Token",
+			wantToken:  "nonce",
+		},
+		{
+			name:      "token from JSON path",
+			preflight: Preflight{TokenJSONPath: "data.token"},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"data": {"token": "nonce"}}`))
+			},
+			wantHeader: DefaultPreflightRequestHeader,
+			wantToken:  "nonce",
+		},
+		{
+			name:      "missing JSON field",
+			preflight: Preflight{TokenJSONPath: "data.token"},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"data": {}}`))
+			},
+			wantErr: true,
+		},
+		{
+			name:      "missing header",
+			preflight: Preflight{TokenHeader: "X-Token"},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte("nonce"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "preflight failure",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusForbidden)
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			var indexRequested bool
+			var indexHeader http.Header
+			mux := http.NewServeMux()
+			mux.Handle("/token", tt.handler)
+			mux.HandleFunc("/index.yaml", func(w http.ResponseWriter, r *http.Request) {
+				indexRequested = true
+				indexHeader = r.Header
+				_, _ = w.Write([]byte(`{"apiVersion": "v1", "entries": {}}`))
+			})
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			r, err := NewChartRepository(server.URL, "", helmgetter.Providers{
+				helmgetter.Provider{Schemes: []string{"http"}, New: helmgetter.NewHTTPGetter},
+			}, nil, helmgetter.WithURL(server.URL))
+			g.Expect(err).ToNot(HaveOccurred())
+			r.Preflight = &tt.preflight
+			r.Preflight.URL = server.URL + "/token"
+
+			err = r.DownloadIndex(bytes.NewBuffer([]byte{}))
+			if tt.wantErr {
+				g.Expect(errors.Is(err, ErrPreflight)).To(BeTrue())
+				g.Expect(indexRequested).To(BeFalse())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(indexHeader.Get(tt.wantHeader)).To(Equal(tt.wantToken))
+		})
+	}
+}
+
+func TestChartRepository_EvaluatePolicy(t *testing.T) {
+	index := repo.NewIndexFile()
+	index.Entries["foo"] = repo.ChartVersions{
+		&repo.ChartVersion{Metadata: &chart.Metadata{
+			Name:        "foo",
+			Version:     "1.0.0",
+			Maintainers: []*chart.Maintainer{{Name: "trusted"}},
+		}},
+	}
+	index.Entries["bar"] = repo.ChartVersions{
+		&repo.ChartVersion{Metadata: &chart.Metadata{
+			Name:        "bar",
+			Version:     "2.0.0",
+			Maintainers: []*chart.Maintainer{{Name: "untrusted"}},
+		}},
+	}
+
+	tests := []struct {
+		name        string
+		policy      string
+		timeout     time.Duration
+		wantDenials []string
+		wantErr     bool
+	}{
+		{
+			name: "allows index",
+			policy: `package helmrepository
+deny[msg] {
+	input.entries[name][_].maintainers[_].name == "nobody"
+	msg := sprintf("chart %s has a disallowed maintainer", [name])
+}`,
+		},
+		{
+			name: "denies index",
+			policy: `package helmrepository
+deny[msg] {
+	version := input.entries[name][_]
+	version.maintainers[_].name != "trusted"
+	msg := sprintf("chart %s %s has a disallowed maintainer", [name, version.version])
+}
+deny["index is too large"] {
+	count(input.entries) > 1
+}`,
+			wantDenials: []string{"chart bar 2.0.0 has a disallowed maintainer", "index is too large"},
+		},
+		{
+			name:    "wrong package",
+			policy:  "package main\ndeny[\"always\"] { true }",
+			wantErr: true,
+		},
+		{
+			name:    "invalid policy",
+			policy:  "package helmrepository\ndeny[",
+			wantErr: true,
+		},
+		{
+			name: "network access",
+			policy: `package helmrepository
+deny[msg] {
+	resp := http.send({"method": "GET", "url": "http://example.com"})
+	msg := resp.body
+}`,
+			wantErr: true,
+		},
+		{
+			name: "environment access",
+			policy: `package helmrepository
+deny[msg] {
+	msg := opa.runtime().env.HOME
+}`,
+			wantErr: true,
+		},
+		{
+			name: "timeout",
+			policy: `package helmrepository
+deny["never"] {
+	numbers.range(1, 100000000)[_] == 0
+}`,
+			timeout: 10 * time.Millisecond,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			r := newChartRepository()
+			r.Index = index
+
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+			denials, err := r.EvaluatePolicy(ctx, tt.policy)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(errors.Is(err, ErrPolicy)).To(BeTrue())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(denials).To(Equal(tt.wantDenials))
+		})
+	}
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/rego"
+)
+
+// ErrPolicy is returned when a policy can not be evaluated.
+var ErrPolicy = errors.New("failed to evaluate policy")
+
+const (
+	// PolicyPackage is the package a policy must be declared in.
+	PolicyPackage = "helmrepository"
+	// policyQuery queries the set of denial messages of a policy.
+	policyQuery = "data." + PolicyPackage + ".deny"
+)
+
+// policyUnsafeBuiltins are the built-in functions a policy may not call, as
+// they reach outside of the evaluation.
+var policyUnsafeBuiltins = map[string]struct{}{
+	ast.HTTPSend.Name:        {},
+	ast.OPARuntime.Name:      {},
+	ast.NetLookupIPAddr.Name: {},
+}
+
+// EvaluatePolicy evaluates the given Rego policy against the Index, and
+// returns the sorted denial messages of its "deny" rule. The policy must be
+// declared in the PolicyPackage, and may not make network requests or read
+// the environment. The evaluation is aborted once the given context is done.
+// It returns an error wrapping ErrPolicy if the policy is invalid or can not
+// be evaluated.
+func (r *ChartRepository) EvaluatePolicy(ctx context.Context, policy string) ([]string, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	if r.Index == nil {
+		return nil, ErrNoChartIndex
+	}
+
+	module, err := ast.ParseModule("policy.rego", policy)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrPolicy, err)
+	}
+	if module == nil {
+		return nil, fmt.Errorf("%w: policy is empty", ErrPolicy)
+	}
+	if pkg := module.Package.Path.String(); pkg != "data."+PolicyPackage {
+		return nil, fmt.Errorf("%w: policy must be declared in package '%s', got '%s'",
+			ErrPolicy, PolicyPackage, pkg[len("data."):])
+	}
+
+	query, err := rego.New(
+		rego.Query(policyQuery),
+		rego.ParsedModule(module),
+		rego.UnsafeBuiltins(policyUnsafeBuiltins),
+		rego.StrictBuiltinErrors(true),
+	).PrepareForEval(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrPolicy, err)
+	}
+	rs, err := query.Eval(ctx, rego.EvalInput(r.Index))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrPolicy, err)
+	}
+
+	var denials []string
+	for _, result := range rs {
+		for _, expr := range result.Expressions {
+			values, ok := expr.Value.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%w: 'deny' must be a set of messages, got %T", ErrPolicy, expr.Value)
+			}
+			for _, v := range values {
+				if msg, ok := v.(string); ok {
+					denials = append(denials, msg)
+					continue
+				}
+				denials = append(denials, fmt.Sprint(v))
+			}
+		}
+	}
+	sort.Strings(denials)
+	return denials, nil
+}
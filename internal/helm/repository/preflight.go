@@ -0,0 +1,128 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/fluxcd/source-controller/internal/transport"
+)
+
+// DefaultPreflightRequestHeader is the header the preflight token is attached
+// to the index request with, when no Preflight.RequestHeader is set.
+const DefaultPreflightRequestHeader = "X-CSRF-Token"
+
+// ErrPreflight is returned when the token can not be obtained from the
+// preflight URL.
+var ErrPreflight = errors.New("failed to obtain preflight token")
+
+// Preflight describes a request which is made before the index is
+// downloaded, to obtain a token (e.g. a nonce or CSRF token) which is
+// attached to the index request.
+type Preflight struct {
+	// URL is requested with the same options as the index.
+	URL string
+	// TokenHeader is the response header holding the token.
+	TokenHeader string
+	// TokenJSONPath is the dot-separated path of the string field holding
+	// the token in the JSON response body, used when TokenHeader is empty.
+	// When both are empty, the complete response body is the token.
+	TokenJSONPath string
+	// RequestHeader is the header the token is attached to the index
+	// request with. Defaults to DefaultPreflightRequestHeader.
+	RequestHeader string
+}
+
+// preflightToken requests the URL of the Preflight with the given transport,
+// and returns the token of the response. Errors wrap ErrPreflight.
+func (r *ChartRepository) preflightToken(ctx context.Context, t *http.Transport) (string, error) {
+	if ctx.Done() != nil {
+		t = transport.WithContext(t, ctx)
+	}
+	var header http.Header
+	t = transport.WithResponseObserver(t, func(resp *http.Response) {
+		header = resp.Header
+	})
+	res, _, err := r.get(r.Preflight.URL, t)
+	if err != nil {
+		return "", fmt.Errorf("%w from '%s': %w", ErrPreflight, r.Preflight.URL, err)
+	}
+	token, err := r.Preflight.token(header, res.Bytes())
+	if err != nil {
+		return "", fmt.Errorf("%w from '%s': %w", ErrPreflight, r.Preflight.URL, err)
+	}
+	return token, nil
+}
+
+// token extracts the token from the given preflight response.
+func (p Preflight) token(header http.Header, body []byte) (string, error) {
+	var token string
+	switch {
+	case p.TokenHeader != "":
+		token = header.Get(p.TokenHeader)
+		if token == "" {
+			return "", fmt.Errorf("no '%s' header in response", p.TokenHeader)
+		}
+	case p.TokenJSONPath != "":
+		var err error
+		if token, err = jsonPathString(body, p.TokenJSONPath); err != nil {
+			return "", err
+		}
+	default:
+		token = strings.TrimSpace(string(body))
+	}
+	if token == "" {
+		return "", errors.New("empty token in response")
+	}
+	return token, nil
+}
+
+// requestHeader returns the header the token is attached with.
+func (p Preflight) requestHeader() string {
+	if p.RequestHeader == "" {
+		return DefaultPreflightRequestHeader
+	}
+	return p.RequestHeader
+}
+
+// jsonPathString returns the string at the given dot-separated path of
+// object keys in the given JSON document.
+func jsonPathString(b []byte, path string) (string, error) {
+	var v any
+	if err := json.Unmarshal(b, &v); err != nil {
+		return "", fmt.Errorf("failed to parse response as JSON: %w", err)
+	}
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return "", fmt.Errorf("no field '%s' in response", path)
+		}
+		if v, ok = obj[key]; !ok {
+			return "", fmt.Errorf("no field '%s' in response", path)
+		}
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("field '%s' in response is not a string", path)
+	}
+	return s, nil
+}
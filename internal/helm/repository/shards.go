@@ -0,0 +1,122 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package repository
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+
+	"helm.sh/helm/v3/pkg/repo"
+	"sigs.k8s.io/yaml"
+
+	"github.com/fluxcd/source-controller/internal/helm"
+)
+
+// ErrShards is returned when the index can not be assembled from the shards
+// listed in the shard manifest.
+var ErrShards = errors.New("failed to fetch index shards")
+
+// shardManifest is the document served at the ShardManifestURL.
+type shardManifest struct {
+	// Shards are the URLs of the index shards, absolute or relative to the
+	// URL of the manifest.
+	Shards []string `json:"shards"`
+}
+
+// downloadShards downloads the shard manifest at the ShardManifestURL and
+// every shard it lists, merges the shards into a single index and writes it
+// to the given io.Writer. It fails if any of the shards can not be
+// downloaded or loaded, in which case nothing is written. The returned
+// indexResponse describes the response to the manifest request.
+// The caller is expected to hold a read lock.
+func (r *ChartRepository) downloadShards(ctx context.Context, w io.Writer) (indexResponse, error) {
+	manifestURL, err := url.Parse(r.ShardManifestURL)
+	if err != nil {
+		return indexResponse{}, fmt.Errorf("%w: invalid manifest URL: %w", ErrShards, err)
+	}
+	var b bytes.Buffer
+	res, err := r.download(ctx, manifestURL, &b)
+	if err != nil {
+		return res, fmt.Errorf("%w: failed to fetch manifest: %w", ErrShards, err)
+	}
+	var manifest shardManifest
+	if err := yaml.Unmarshal(b.Bytes(), &manifest); err != nil {
+		return res, fmt.Errorf("%w: failed to parse manifest: %w", ErrShards, err)
+	}
+	if len(manifest.Shards) == 0 {
+		return res, fmt.Errorf("%w: manifest lists no shards", ErrShards)
+	}
+
+	index := repo.NewIndexFile()
+	// The generated time is taken from the shards, for the revision of the
+	// merged index to only change when the shards do.
+	index.Generated = time.Time{}
+	for _, s := range manifest.Shards {
+		u, err := manifestURL.Parse(s)
+		if err != nil {
+			return res, fmt.Errorf("%w: invalid shard URL '%s': %w", ErrShards, s, err)
+		}
+		// Credentials are only sent to the host of the manifest.
+		if u.Scheme != manifestURL.Scheme || u.Host != manifestURL.Host {
+			return res, fmt.Errorf("%w: shard '%s' is not served from '%s://%s'", ErrShards, u.Redacted(), manifestURL.Scheme, manifestURL.Host)
+		}
+		b.Reset()
+		if _, err := r.download(ctx, u, &b); err != nil {
+			return res, fmt.Errorf("%w: failed to fetch shard '%s': %w", ErrShards, u.Redacted(), err)
+		}
+		shard, err := IndexFromBytes(b.Bytes())
+		if err != nil {
+			return res, fmt.Errorf("%w: failed to load shard '%s': %w", ErrShards, u.Redacted(), err)
+		}
+		mergeShard(index, shard)
+	}
+	index.SortEntries()
+
+	out, err := yaml.Marshal(index)
+	if err != nil {
+		return res, fmt.Errorf("%w: failed to encode merged index: %w", ErrShards, err)
+	}
+	if int64(len(out)) > helm.MaxIndexSize {
+		return res, fmt.Errorf("%w: merged index exceeds the maximum index file size of %d bytes", ErrShards, helm.MaxIndexSize)
+	}
+	if _, err := w.Write(out); err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+// mergeShard adds the chart versions of the given shard to the given index.
+// Versions of a chart which are already listed by the index are skipped.
+// The generated time of the index is the latest of the shards.
+func mergeShard(index, shard *repo.IndexFile) {
+	for name, cvs := range shard.Entries {
+		for _, cv := range cvs {
+			if cv == nil || index.Has(name, cv.Version) {
+				continue
+			}
+			index.Entries[name] = append(index.Entries[name], cv)
+		}
+	}
+	if shard.Generated.After(index.Generated) {
+		index.Generated = shard.Generated
+	}
+}
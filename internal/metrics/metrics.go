@@ -0,0 +1,283 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// Recorder is a recorder for source-controller specific metrics.
+type Recorder struct {
+	// lastSuccess tracks the time of the last successful reconciliation.
+	lastSuccess *lastSuccessCollector
+	// queueLatency observes the time objects spend in the reconcile queue.
+	queueLatency *prometheus.HistogramVec
+	// inFlight tracks the number of reconciliations in progress.
+	inFlight *prometheus.GaugeVec
+	// failures counts the failed sub-reconciliations by reason.
+	failures *prometheus.CounterVec
+	// oldestPending reports the time the most overdue object of the
+	// QueueTrackers has been waiting for its reconciliation.
+	oldestPending *oldestPendingCollector
+	// artifactWriteDuration observes the time spent writing artifacts to
+	// storage.
+	artifactWriteDuration *prometheus.HistogramVec
+	// artifactSize tracks the size of the last written artifact.
+	artifactSize *prometheus.GaugeVec
+}
+
+// NewRecorder returns a new Recorder.
+// The configured metrics are:
+//   - source_controller_time_since_last_success_seconds, with the labels
+//     kind, name and namespace.
+//   - source_controller_reconcile_queue_latency_seconds, with the label kind.
+//   - source_controller_reconcile_in_flight, with the label kind.
+//   - source_controller_reconcile_failures_total, with the labels kind and
+//     reason.
+//   - source_controller_artifact_write_duration_seconds, with the labels
+//     kind, name and namespace.
+//   - source_controller_artifact_size_bytes, with the labels kind, name and
+//     namespace.
+func NewRecorder() *Recorder {
+	return &Recorder{
+		lastSuccess: &lastSuccessCollector{
+			desc: prometheus.NewDesc(
+				"source_controller_time_since_last_success_seconds",
+				"The time in seconds since the last successful reconciliation of a source.",
+				[]string{"kind", "name", "namespace"}, nil,
+			),
+			times: make(map[objectKey]time.Time),
+			now:   time.Now,
+		},
+		queueLatency: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "source_controller_reconcile_queue_latency_seconds",
+				Help:    "The time in seconds between a source being enqueued and its reconciliation starting.",
+				Buckets: prometheus.ExponentialBuckets(0.005, 2, 16),
+			},
+			[]string{"kind"},
+		),
+		inFlight: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "source_controller_reconcile_in_flight",
+				Help: "The number of reconciliations of sources in progress.",
+			},
+			[]string{"kind"},
+		),
+		failures: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "source_controller_reconcile_failures_total",
+				Help: "The number of failed reconciliations of sources, by the reason of the failure.",
+			},
+			[]string{"kind", "reason"},
+		),
+		oldestPending: &oldestPendingCollector{
+			desc: prometheus.NewDesc(
+				"source_controller_oldest_pending_reconcile_seconds",
+				"The time in seconds the most overdue source has been waiting for its reconciliation past the time it was due.",
+				[]string{"kind"}, nil,
+			),
+		},
+		artifactWriteDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "source_controller_artifact_write_duration_seconds",
+				Help:    "The time in seconds spent writing the artifact of a source to storage.",
+				Buckets: prometheus.ExponentialBuckets(0.001, 2, 16),
+			},
+			[]string{"kind", "name", "namespace"},
+		),
+		artifactSize: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "source_controller_artifact_size_bytes",
+				Help: "The size in bytes of the artifact of a source last written to storage.",
+			},
+			[]string{"kind", "name", "namespace"},
+		),
+	}
+}
+
+// Collectors returns the metrics.Collector objects for the Recorder.
+func (r *Recorder) Collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		r.lastSuccess,
+		r.queueLatency,
+		r.inFlight,
+		r.failures,
+		r.oldestPending,
+		r.artifactWriteDuration,
+		r.artifactSize,
+	}
+}
+
+// RecordLastSuccess records the given time as the last successful
+// reconciliation for the ref.
+func (r *Recorder) RecordLastSuccess(ref corev1.ObjectReference, t time.Time) {
+	r.lastSuccess.set(keyFromRef(ref), t)
+}
+
+// HasLastSuccess returns true if a last successful reconciliation has been
+// recorded for the ref.
+func (r *Recorder) HasLastSuccess(ref corev1.ObjectReference) bool {
+	return r.lastSuccess.has(keyFromRef(ref))
+}
+
+// DeleteLastSuccess deletes the last successful reconciliation metric for
+// the ref.
+func (r *Recorder) DeleteLastSuccess(ref corev1.ObjectReference) {
+	r.lastSuccess.delete(keyFromRef(ref))
+}
+
+// RecordArtifactWrite records the duration of writing the artifact of the
+// ref to storage, and the size of the written artifact. It is safe to call
+// on a nil Recorder.
+func (r *Recorder) RecordArtifactWrite(ref corev1.ObjectReference, d time.Duration, size int64) {
+	if r == nil {
+		return
+	}
+	r.artifactWriteDuration.WithLabelValues(ref.Kind, ref.Name, ref.Namespace).Observe(d.Seconds())
+	r.artifactSize.WithLabelValues(ref.Kind, ref.Name, ref.Namespace).Set(float64(size))
+}
+
+// DeleteArtifactWrite deletes the artifact write metrics for the ref.
+func (r *Recorder) DeleteArtifactWrite(ref corev1.ObjectReference) {
+	r.artifactWriteDuration.DeleteLabelValues(ref.Kind, ref.Name, ref.Namespace)
+	r.artifactSize.DeleteLabelValues(ref.Kind, ref.Name, ref.Namespace)
+}
+
+// RecordInFlight increments the number of reconciliations in progress for
+// the kind, and returns a function which decrements it once the
+// reconciliation has finished. It is safe to call on a nil Recorder.
+func (r *Recorder) RecordInFlight(kind string) func() {
+	if r == nil {
+		return func() {}
+	}
+	g := r.inFlight.WithLabelValues(kind)
+	g.Inc()
+	return g.Dec
+}
+
+// RecordFailure increments the number of failed reconciliations for the kind
+// and reason. It is safe to call on a nil Recorder.
+func (r *Recorder) RecordFailure(kind, reason string) {
+	if r == nil {
+		return
+	}
+	r.failures.WithLabelValues(kind, reason).Inc()
+}
+
+// MustMakeRecorder creates a new Recorder, and registers the metrics
+// collectors in the controller-runtime metrics registry.
+func MustMakeRecorder() *Recorder {
+	r := NewRecorder()
+	metrics.Registry.MustRegister(r.Collectors()...)
+
+	return r
+}
+
+// objectKey is the set of labels identifying an object.
+type objectKey struct {
+	kind, name, namespace string
+}
+
+func keyFromRef(ref corev1.ObjectReference) objectKey {
+	return objectKey{kind: ref.Kind, name: ref.Name, namespace: ref.Namespace}
+}
+
+// lastSuccessCollector is a prometheus.Collector which computes the time
+// since the last successful reconciliation of an object at collection time,
+// rather than at the time the success was recorded.
+type lastSuccessCollector struct {
+	desc  *prometheus.Desc
+	times map[objectKey]time.Time
+	now   func() time.Time
+	mu    sync.RWMutex
+}
+
+// Describe implements prometheus.Collector.
+func (c *lastSuccessCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+// Collect implements prometheus.Collector.
+func (c *lastSuccessCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := c.now()
+	for k, t := range c.times {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue,
+			now.Sub(t).Seconds(), k.kind, k.name, k.namespace)
+	}
+}
+
+func (c *lastSuccessCollector) set(k objectKey, t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.times[k] = t
+}
+
+func (c *lastSuccessCollector) has(k objectKey) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	_, ok := c.times[k]
+	return ok
+}
+
+func (c *lastSuccessCollector) delete(k objectKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.times, k)
+}
+
+// oldestPendingCollector reports the oldest pending reconciliation of the
+// QueueTrackers of each kind when collected.
+type oldestPendingCollector struct {
+	desc     *prometheus.Desc
+	trackers []*QueueTracker
+	mu       sync.RWMutex
+}
+
+func (c *oldestPendingCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *oldestPendingCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	// Multiple controllers may reconcile objects of the same kind.
+	oldest := make(map[string]time.Duration)
+	for _, t := range c.trackers {
+		if d := t.oldestPending(); d >= oldest[t.kind] {
+			oldest[t.kind] = d
+		}
+	}
+	for kind, d := range oldest {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, d.Seconds(), kind)
+	}
+}
+
+func (c *oldestPendingCollector) add(t *QueueTracker) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.trackers = append(c.trackers, t)
+}
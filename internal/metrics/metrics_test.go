@@ -0,0 +1,116 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestRecorder_LastSuccess(t *testing.T) {
+	g := NewWithT(t)
+
+	now := time.Now()
+	r := NewRecorder()
+	r.lastSuccess.now = func() time.Time { return now }
+
+	ref := corev1.ObjectReference{Kind: "HelmRepository", Name: "foo", Namespace: "bar"}
+	g.Expect(r.HasLastSuccess(ref)).To(BeFalse())
+
+	r.RecordLastSuccess(ref, now.Add(-90*time.Second))
+	g.Expect(r.HasLastSuccess(ref)).To(BeTrue())
+
+	expected := `
+# HELP source_controller_time_since_last_success_seconds The time in seconds since the last successful reconciliation of a source.
+# TYPE source_controller_time_since_last_success_seconds gauge
+source_controller_time_since_last_success_seconds{kind="HelmRepository",name="foo",namespace="bar"} 90
+`
+	g.Expect(testutil.CollectAndCompare(r.lastSuccess, strings.NewReader(expected))).To(Succeed())
+
+	r.DeleteLastSuccess(ref)
+	g.Expect(r.HasLastSuccess(ref)).To(BeFalse())
+	g.Expect(testutil.CollectAndCount(r.lastSuccess)).To(BeZero())
+}
+
+func TestRecorder_RecordInFlight(t *testing.T) {
+	g := NewWithT(t)
+
+	r := NewRecorder()
+	done1 := r.RecordInFlight("GitRepository")
+	done2 := r.RecordInFlight("GitRepository")
+	g.Expect(testutil.ToFloat64(r.inFlight.WithLabelValues("GitRepository"))).To(Equal(float64(2)))
+
+	done1()
+	g.Expect(testutil.ToFloat64(r.inFlight.WithLabelValues("GitRepository"))).To(Equal(float64(1)))
+	done2()
+	g.Expect(testutil.ToFloat64(r.inFlight.WithLabelValues("GitRepository"))).To(Equal(float64(0)))
+
+	// A nil Recorder records nothing.
+	var nilRecorder *Recorder
+	nilRecorder.RecordInFlight("GitRepository")()
+}
+
+func TestRecorder_RecordFailure(t *testing.T) {
+	g := NewWithT(t)
+
+	r := NewRecorder()
+	r.RecordFailure("HelmRepository", "AuthenticationFailed")
+	r.RecordFailure("HelmRepository", "AuthenticationFailed")
+	r.RecordFailure("HelmRepository", "IndexationFailed")
+
+	expected := `
+# HELP source_controller_reconcile_failures_total The number of failed reconciliations of sources, by the reason of the failure.
+# TYPE source_controller_reconcile_failures_total counter
+source_controller_reconcile_failures_total{kind="HelmRepository",reason="AuthenticationFailed"} 2
+source_controller_reconcile_failures_total{kind="HelmRepository",reason="IndexationFailed"} 1
+`
+	g.Expect(testutil.CollectAndCompare(r.failures, strings.NewReader(expected))).To(Succeed())
+
+	// A nil Recorder records nothing.
+	var nilRecorder *Recorder
+	nilRecorder.RecordFailure("HelmRepository", "IndexationFailed")
+}
+
+func TestRecorder_RecordArtifactWrite(t *testing.T) {
+	g := NewWithT(t)
+
+	r := NewRecorder()
+	ref := corev1.ObjectReference{Kind: "HelmRepository", Name: "foo", Namespace: "bar"}
+	r.RecordArtifactWrite(ref, 250*time.Millisecond, 1024)
+	r.RecordArtifactWrite(ref, 500*time.Millisecond, 2048)
+
+	expected := `
+# HELP source_controller_artifact_size_bytes The size in bytes of the artifact of a source last written to storage.
+# TYPE source_controller_artifact_size_bytes gauge
+source_controller_artifact_size_bytes{kind="HelmRepository",name="foo",namespace="bar"} 2048
+`
+	g.Expect(testutil.CollectAndCompare(r.artifactSize, strings.NewReader(expected))).To(Succeed())
+	g.Expect(testutil.CollectAndCount(r.artifactWriteDuration)).To(Equal(1))
+
+	r.DeleteArtifactWrite(ref)
+	g.Expect(testutil.CollectAndCount(r.artifactSize)).To(BeZero())
+	g.Expect(testutil.CollectAndCount(r.artifactWriteDuration)).To(BeZero())
+
+	// A nil Recorder does not panic.
+	var nilRecorder *Recorder
+	nilRecorder.RecordArtifactWrite(ref, time.Second, 1)
+}
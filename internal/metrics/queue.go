@@ -0,0 +1,188 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/ratelimiter"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// QueueTracker records the time objects of a kind are enqueued for
+// reconciliation, and observes the time until their reconciliation starts in
+// the source_controller_reconcile_queue_latency_seconds histogram. The time
+// the most overdue object has been waiting past its enqueue time is reported
+// in the source_controller_oldest_pending_reconcile_seconds gauge.
+//
+// Objects are enqueued by the events passing the Predicate, by requeues with
+// a backoff of the RateLimiter, and by requeues after a duration returned by
+// the Reconciler. Enqueues caused by other watches are not tracked.
+//
+// All methods are safe to call on a nil QueueTracker, in which case nothing
+// is tracked.
+type QueueTracker struct {
+	kind     string
+	recorder *Recorder
+	enqueued map[reconcile.Request]time.Time
+	now      func() time.Time
+	mu       sync.Mutex
+}
+
+// QueueTracker returns a new QueueTracker for the given kind, or nil if the
+// Recorder is nil. A QueueTracker must be used for a single controller.
+func (r *Recorder) QueueTracker(kind string) *QueueTracker {
+	if r == nil {
+		return nil
+	}
+	t := &QueueTracker{
+		kind:     kind,
+		recorder: r,
+		enqueued: make(map[reconcile.Request]time.Time),
+		now:      time.Now,
+	}
+	r.oldestPending.add(t)
+	return t
+}
+
+// Predicate returns a predicate.Predicate which records the enqueue time of
+// the object of every event. It must be the last predicate of a watch, so
+// that only the events which result in an enqueue are recorded.
+func (t *QueueTracker) Predicate() predicate.Predicate {
+	if t == nil {
+		return predicate.Funcs{}
+	}
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			t.enqueue(e.Object.GetNamespace(), e.Object.GetName(), 0, false)
+			return true
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			t.enqueue(e.ObjectNew.GetNamespace(), e.ObjectNew.GetName(), 0, false)
+			return true
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			t.enqueue(e.Object.GetNamespace(), e.Object.GetName(), 0, false)
+			return true
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			t.enqueue(e.Object.GetNamespace(), e.Object.GetName(), 0, false)
+			return true
+		},
+	}
+}
+
+// RateLimiter returns a ratelimiter.RateLimiter which records the time an
+// object is requeued with the backoff of the given ratelimiter.RateLimiter.
+// When rl is nil, the default controller rate limiter is used.
+func (t *QueueTracker) RateLimiter(rl ratelimiter.RateLimiter) ratelimiter.RateLimiter {
+	if t == nil {
+		return rl
+	}
+	if rl == nil {
+		rl = workqueue.DefaultControllerRateLimiter()
+	}
+	return &trackingRateLimiter{RateLimiter: rl, tracker: t}
+}
+
+// Reconciler returns a reconcile.Reconciler which observes the queue latency
+// of an object before calling the given reconcile.Reconciler, and records
+// the time an object is requeued after the duration it returns.
+func (t *QueueTracker) Reconciler(r reconcile.Reconciler) reconcile.Reconciler {
+	if t == nil {
+		return r
+	}
+	return reconcile.Func(func(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+		t.dequeue(req)
+		result, err := r.Reconcile(ctx, req)
+		if err == nil && result.RequeueAfter > 0 {
+			t.enqueue(req.Namespace, req.Name, result.RequeueAfter, true)
+		}
+		return result, err
+	})
+}
+
+// enqueue records the object as enqueued after the given delay. If the
+// object is already enqueued, the earliest time is retained, as the queue
+// deduplicates the object, unless replace is true, e.g. for the RequeueAfter
+// of a completed reconciliation which supersedes any previous one.
+func (t *QueueTracker) enqueue(namespace, name string, delay time.Duration, replace bool) {
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: namespace, Name: name}}
+	at := t.now().Add(delay)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if cur, ok := t.enqueued[req]; ok && !replace && cur.Before(at) {
+		return
+	}
+	t.enqueued[req] = at
+}
+
+// dequeue drops the recorded enqueue time of the object, as its
+// reconciliation starts, and observes its queue latency if the time has
+// passed.
+func (t *QueueTracker) dequeue(req reconcile.Request) {
+	now := t.now()
+
+	t.mu.Lock()
+	at, ok := t.enqueued[req]
+	delete(t.enqueued, req)
+	t.mu.Unlock()
+	if !ok || at.After(now) {
+		return
+	}
+
+	t.recorder.queueLatency.WithLabelValues(t.kind).Observe(now.Sub(at).Seconds())
+}
+
+// oldestPending returns the time the most overdue object has been waiting
+// since it was due for reconciliation, or zero if no object is overdue.
+func (t *QueueTracker) oldestPending() time.Duration {
+	now := t.now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var oldest time.Duration
+	for _, at := range t.enqueued {
+		if d := now.Sub(at); d > oldest {
+			oldest = d
+		}
+	}
+	return oldest
+}
+
+// trackingRateLimiter records the time an object is requeued with the
+// backoff of the embedded ratelimiter.RateLimiter.
+type trackingRateLimiter struct {
+	ratelimiter.RateLimiter
+	tracker *QueueTracker
+}
+
+// When implements ratelimiter.RateLimiter.
+func (rl *trackingRateLimiter) When(item interface{}) time.Duration {
+	d := rl.RateLimiter.When(item)
+	if req, ok := item.(reconcile.Request); ok {
+		rl.tracker.enqueue(req.Namespace, req.Name, d, false)
+	}
+	return d
+}
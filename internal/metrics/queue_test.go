@@ -0,0 +1,190 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	sourcev1 "github.com/fluxcd/source-controller/api/v1beta2"
+)
+
+func TestQueueTracker(t *testing.T) {
+	g := NewWithT(t)
+
+	r := NewRecorder()
+	tracker := r.QueueTracker("HelmRepository")
+	now := time.Now()
+	tracker.now = func() time.Time { return now }
+
+	obj := &sourcev1.HelmRepository{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "bar"}}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "foo", Namespace: "bar"}}
+
+	var calls int
+	rec := tracker.Reconciler(reconcile.Func(func(context.Context, reconcile.Request) (reconcile.Result, error) {
+		calls++
+		return reconcile.Result{RequeueAfter: time.Minute}, nil
+	}))
+
+	// An event enqueues the object, the reconciliation starts 2s later.
+	g.Expect(tracker.Predicate().Create(event.CreateEvent{Object: obj})).To(BeTrue())
+	now = now.Add(2 * time.Second)
+	_, err := rec.Reconcile(context.TODO(), req)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	// The requeue is not due yet when an event enqueues the object again,
+	// the earliest enqueue time is observed.
+	now = now.Add(10 * time.Second)
+	g.Expect(tracker.Predicate().Update(event.UpdateEvent{ObjectOld: obj, ObjectNew: obj})).To(BeTrue())
+	now = now.Add(3 * time.Second)
+	_, err = rec.Reconcile(context.TODO(), req)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	// Reconciling an object of which the enqueue time is unknown is not
+	// observed.
+	_, err = rec.Reconcile(context.TODO(), reconcile.Request{NamespacedName: types.NamespacedName{Name: "baz"}})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(calls).To(Equal(3))
+
+	expected := `
+# HELP source_controller_reconcile_queue_latency_seconds The time in seconds between a source being enqueued and its reconciliation starting.
+# TYPE source_controller_reconcile_queue_latency_seconds histogram
+source_controller_reconcile_queue_latency_seconds_sum{kind="HelmRepository"} 5
+source_controller_reconcile_queue_latency_seconds_count{kind="HelmRepository"} 2
+`
+	g.Expect(testutil.CollectAndCompare(r.queueLatency, strings.NewReader(expected),
+		"source_controller_reconcile_queue_latency_seconds_sum",
+		"source_controller_reconcile_queue_latency_seconds_count")).To(Succeed())
+}
+
+func TestQueueTracker_Requeue(t *testing.T) {
+	g := NewWithT(t)
+
+	r := NewRecorder()
+	tracker := r.QueueTracker("HelmRepository")
+	now := time.Now()
+	tracker.now = func() time.Time { return now }
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "foo", Namespace: "bar"}}
+	requeueAfter := time.Minute
+	rec := tracker.Reconciler(reconcile.Func(func(context.Context, reconcile.Request) (reconcile.Result, error) {
+		return reconcile.Result{RequeueAfter: requeueAfter}, nil
+	}))
+
+	// A reconciliation started before the recorded requeue is due drops
+	// the entry without observing it, and records the new requeue.
+	tracker.enqueue("bar", "foo", 10*time.Minute, false)
+	_, err := rec.Reconcile(context.TODO(), req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(tracker.enqueued).To(HaveKeyWithValue(req, now.Add(time.Minute)))
+
+	// A later RequeueAfter replaces an earlier pending entry, e.g. recorded
+	// by an event during the reconciliation.
+	requeueAfter = 5 * time.Minute
+	rec = tracker.Reconciler(reconcile.Func(func(context.Context, reconcile.Request) (reconcile.Result, error) {
+		tracker.enqueue("bar", "foo", time.Minute, false)
+		return reconcile.Result{RequeueAfter: requeueAfter}, nil
+	}))
+	_, err = rec.Reconcile(context.TODO(), req)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(tracker.enqueued).To(HaveKeyWithValue(req, now.Add(requeueAfter)))
+
+	g.Expect(testutil.CollectAndCount(r.queueLatency)).To(Equal(0))
+}
+
+func TestQueueTracker_RateLimiter(t *testing.T) {
+	g := NewWithT(t)
+
+	r := NewRecorder()
+	tracker := r.QueueTracker("HelmRepository")
+	now := time.Now()
+	tracker.now = func() time.Time { return now }
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "foo", Namespace: "bar"}}
+	d := tracker.RateLimiter(nil).When(req)
+	g.Expect(d).To(BeNumerically(">", 0))
+	g.Expect(tracker.enqueued).To(HaveKeyWithValue(req, now.Add(d)))
+}
+
+func TestQueueTracker_Nil(t *testing.T) {
+	g := NewWithT(t)
+
+	var r *Recorder
+	tracker := r.QueueTracker("HelmRepository")
+	g.Expect(tracker).To(BeNil())
+	g.Expect(tracker.RateLimiter(nil)).To(BeNil())
+	g.Expect(tracker.Predicate().Create(event.CreateEvent{})).To(BeTrue())
+	rec := reconcile.Func(func(context.Context, reconcile.Request) (reconcile.Result, error) {
+		return reconcile.Result{}, nil
+	})
+	g.Expect(tracker.Reconciler(rec)).ToNot(BeNil())
+}
+
+func TestQueueTracker_OldestPending(t *testing.T) {
+	g := NewWithT(t)
+
+	r := NewRecorder()
+	now := time.Now()
+	tracker := r.QueueTracker("HelmRepository")
+	tracker.now = func() time.Time { return now }
+	other := r.QueueTracker("HelmRepository")
+	other.now = tracker.now
+	git := r.QueueTracker("GitRepository")
+	git.now = tracker.now
+
+	// Objects which are not due yet are not pending.
+	tracker.enqueue("bar", "foo", time.Minute, false)
+	tracker.enqueue("bar", "baz", 2*time.Minute, false)
+	other.enqueue("bar", "qux", 3*time.Minute, false)
+
+	expected := `
+# HELP source_controller_oldest_pending_reconcile_seconds The time in seconds the most overdue source has been waiting for its reconciliation past the time it was due.
+# TYPE source_controller_oldest_pending_reconcile_seconds gauge
+source_controller_oldest_pending_reconcile_seconds{kind="GitRepository"} 0
+source_controller_oldest_pending_reconcile_seconds{kind="HelmRepository"} 0
+`
+	g.Expect(testutil.CollectAndCompare(r.oldestPending, strings.NewReader(expected))).To(Succeed())
+
+	// The most overdue object of all trackers of a kind is reported.
+	now = now.Add(2*time.Minute + 30*time.Second)
+	expected = `
+# HELP source_controller_oldest_pending_reconcile_seconds The time in seconds the most overdue source has been waiting for its reconciliation past the time it was due.
+# TYPE source_controller_oldest_pending_reconcile_seconds gauge
+source_controller_oldest_pending_reconcile_seconds{kind="GitRepository"} 0
+source_controller_oldest_pending_reconcile_seconds{kind="HelmRepository"} 90
+`
+	g.Expect(testutil.CollectAndCompare(r.oldestPending, strings.NewReader(expected))).To(Succeed())
+
+	// Reconciled objects are no longer pending.
+	tracker.dequeue(reconcile.Request{NamespacedName: types.NamespacedName{Name: "foo", Namespace: "bar"}})
+	expected = `
+# HELP source_controller_oldest_pending_reconcile_seconds The time in seconds the most overdue source has been waiting for its reconciliation past the time it was due.
+# TYPE source_controller_oldest_pending_reconcile_seconds gauge
+source_controller_oldest_pending_reconcile_seconds{kind="GitRepository"} 0
+source_controller_oldest_pending_reconcile_seconds{kind="HelmRepository"} 30
+`
+	g.Expect(testutil.CollectAndCompare(r.oldestPending, strings.NewReader(expected))).To(Succeed())
+}
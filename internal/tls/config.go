@@ -159,3 +159,46 @@ func tlsClientConfigFromSecret(secret corev1.Secret, url string, kubernetesTLSKe
 		CABytes:   caBytes,
 	}, nil
 }
+
+// versions maps the supported minimum TLS versions to their tls constants.
+var versions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// WithPolicy returns a clone of the given TLS client config (or a new config
+// if nil) with the given minimum TLS version (e.g. "1.3") and cipher suites
+// (by their IANA names, e.g. "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256").
+// An empty version or list of cipher suites retains the Go defaults. The
+// cipher suites only apply to TLS 1.2 and lower, as the cipher suites of
+// TLS 1.3 are not configurable.
+func WithPolicy(tlsConf *tls.Config, minVersion string, cipherSuites []string) (*tls.Config, error) {
+	conf := &tls.Config{}
+	if tlsConf != nil {
+		conf = tlsConf.Clone()
+	}
+	if minVersion != "" {
+		v, ok := versions[minVersion]
+		if !ok {
+			return nil, fmt.Errorf("unsupported minimum TLS version '%s'", minVersion)
+		}
+		conf.MinVersion = v
+	}
+	if len(cipherSuites) > 0 {
+		ids := make(map[string]uint16)
+		for _, s := range tls.CipherSuites() {
+			ids[s.Name] = s.ID
+		}
+		conf.CipherSuites = make([]uint16, 0, len(cipherSuites))
+		for _, name := range cipherSuites {
+			id, ok := ids[name]
+			if !ok {
+				return nil, fmt.Errorf("unsupported or insecure TLS cipher suite '%s'", name)
+			}
+			conf.CipherSuites = append(conf.CipherSuites, id)
+		}
+	}
+	return conf, nil
+}
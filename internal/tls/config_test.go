@@ -19,10 +19,13 @@ package tls
 import (
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/tls"
 	"crypto/x509"
 	"encoding/pem"
 	"fmt"
 	"math/big"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
 
@@ -187,3 +190,41 @@ func validTlsSecret(t *testing.T, kubernetesTlsKeys bool) corev1.Secret {
 		},
 	}
 }
+
+func TestWithPolicy(t *testing.T) {
+	g := NewWithT(t)
+
+	base := &tls.Config{ServerName: "example.com"}
+	conf, err := WithPolicy(base, "1.3", nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(conf.MinVersion).To(Equal(uint16(tls.VersionTLS13)))
+	g.Expect(conf.ServerName).To(Equal("example.com"))
+	g.Expect(base.MinVersion).To(BeZero())
+
+	conf, err = WithPolicy(nil, "", []string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(conf.MinVersion).To(BeZero())
+	g.Expect(conf.CipherSuites).To(Equal([]uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256}))
+
+	_, err = WithPolicy(nil, "1.4", nil)
+	g.Expect(err).To(HaveOccurred())
+	_, err = WithPolicy(nil, "", []string{"TLS_RSA_WITH_RC4_128_SHA"})
+	g.Expect(err).To(HaveOccurred())
+
+	// The handshake fails when the server does not support the minimum
+	// version.
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	srv.StartTLS()
+	defer srv.Close()
+
+	conf, err = WithPolicy(srv.Client().Transport.(*http.Transport).TLSClientConfig, "1.3", nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	_, err = (&http.Client{Transport: &http.Transport{TLSClientConfig: conf}}).Get(srv.URL)
+	g.Expect(err).To(MatchError(ContainSubstring("tls: ")))
+
+	conf, err = WithPolicy(srv.Client().Transport.(*http.Transport).TLSClientConfig, "1.2", nil)
+	g.Expect(err).ToNot(HaveOccurred())
+	_, err = (&http.Client{Transport: &http.Transport{TLSClientConfig: conf}}).Get(srv.URL)
+	g.Expect(err).ToNot(HaveOccurred())
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"net/http"
+)
+
+// WithBearerToken returns a new http.Transport which sets the given token as
+// the bearer token of the Authorization header of the requests it performs
+// using the given http.Transport. When host is not empty, the token is only
+// set for requests to that host.
+//
+// Like WithResponseObserver, the token is applied by registering a wrapping
+// round tripper for the "http" and "https" protocols on the returned
+// transport.
+func WithBearerToken(t *http.Transport, token, host string) *http.Transport {
+	rt := &bearerTokenRoundTripper{
+		next:  t,
+		token: token,
+		host:  host,
+	}
+	dispatcher := &http.Transport{}
+	dispatcher.RegisterProtocol("http", rt)
+	dispatcher.RegisterProtocol("https", rt)
+	return dispatcher
+}
+
+type bearerTokenRoundTripper struct {
+	next  http.RoundTripper
+	token string
+	host  string
+}
+
+func (rt *bearerTokenRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if rt.host == "" || rt.host == req.URL.Host {
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", "Bearer "+rt.token)
+	}
+	return rt.next.RoundTrip(req)
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// WithContext returns a new http.Transport which performs the requests
+// using the given http.Transport, aborting them once the given context is
+// done, in addition to the context of the request itself. This includes
+// dialing, the TLS handshake and reading the response body.
+//
+// This allows a deadline to be enforced on the requests of clients which do
+// not accept a context, such as the Helm getters.
+//
+// Like WithResponseObserver, the context is applied by registering a
+// wrapping round tripper for the "http" and "https" protocols on the
+// returned transport.
+func WithContext(t *http.Transport, ctx context.Context) *http.Transport {
+	rt := &contextRoundTripper{
+		next: t,
+		ctx:  ctx,
+	}
+	dispatcher := &http.Transport{}
+	dispatcher.RegisterProtocol("http", rt)
+	dispatcher.RegisterProtocol("https", rt)
+	return dispatcher
+}
+
+type contextRoundTripper struct {
+	next http.RoundTripper
+	ctx  context.Context
+}
+
+func (rt *contextRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithCancel(req.Context())
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-rt.ctx.Done():
+			cancel()
+		case <-stop:
+		}
+	}()
+	release := func() {
+		close(stop)
+		cancel()
+	}
+
+	resp, err := rt.next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		release()
+		if ctxErr := rt.ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, err
+	}
+	// Keep the context alive until the body has been read.
+	resp.Body = &cancelOnCloseBody{ReadCloser: resp.Body, cancel: release}
+	return resp, nil
+}
+
+// cancelOnCloseBody calls cancel once the body is closed.
+type cancelOnCloseBody struct {
+	io.ReadCloser
+	cancel func()
+	closed bool
+}
+
+func (b *cancelOnCloseBody) Close() error {
+	err := b.ReadCloser.Close()
+	if !b.closed {
+		b.closed = true
+		b.cancel()
+	}
+	return err
+}
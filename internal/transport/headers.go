@@ -0,0 +1,127 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// redactedHeaders are the response headers of which the value is never
+// included in the result of FormatHeaders, as they may carry credentials.
+var redactedHeaders = map[string]struct{}{
+	"Authorization":       {},
+	"Proxy-Authorization": {},
+	"Cookie":              {},
+	"Set-Cookie":          {},
+}
+
+// FormatHeaders returns the given names of headers which are present in h,
+// formatted as a sorted, comma separated list of "Name: value" pairs.
+// The values of headers which may carry credentials or cookies are replaced
+// with "[redacted]". It returns an empty string if none of the headers are
+// present.
+func FormatHeaders(h http.Header, names []string) string {
+	var pairs []string
+	seen := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+		if _, ok := seen[name]; ok || name == "" {
+			continue
+		}
+		seen[name] = struct{}{}
+
+		values := h.Values(name)
+		if len(values) == 0 {
+			continue
+		}
+		value := strings.Join(values, ", ")
+		if _, ok := redactedHeaders[name]; ok {
+			value = "[redacted]"
+		}
+		pairs = append(pairs, fmt.Sprintf("%s: %s", name, value))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ", ")
+}
+
+// WithRequestHeaders returns a new http.Transport which sets the given
+// headers on the requests it performs using the given http.Transport,
+// replacing any values already set for them.
+//
+// Like WithBearerToken, the headers are applied by registering a wrapping
+// round tripper for the "http" and "https" protocols on the returned
+// transport.
+func WithRequestHeaders(t *http.Transport, headers http.Header) *http.Transport {
+	return WithHostRequestHeaders(t, headers, "")
+}
+
+// WithHostRequestHeaders returns a new http.Transport like
+// WithRequestHeaders, which only sets the given headers on requests to the
+// given host when it is not empty. This prevents headers carrying
+// credentials from being passed on to a different host on a redirect.
+func WithHostRequestHeaders(t *http.Transport, headers http.Header, host string) *http.Transport {
+	rt := &requestHeadersRoundTripper{
+		next:    t,
+		headers: headers,
+		host:    host,
+	}
+	dispatcher := &http.Transport{}
+	dispatcher.RegisterProtocol("http", rt)
+	dispatcher.RegisterProtocol("https", rt)
+	return dispatcher
+}
+
+type requestHeadersRoundTripper struct {
+	next    http.RoundTripper
+	headers http.Header
+	host    string
+}
+
+// RoundTrip implements http.RoundTripper.
+func (rt *requestHeadersRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if rt.host != "" && rt.host != req.URL.Host {
+		return rt.next.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	for name, values := range rt.headers {
+		req.Header[name] = values
+	}
+	return rt.next.RoundTrip(req)
+}
+
+// AcceptsContentType returns true if the given Content-Type of a response
+// matches any of the media ranges of the given Accept header, including the
+// "*/*" and "<type>/*" wildcards. Parameters are ignored.
+func AcceptsContentType(accept, contentType string) bool {
+	ct := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	ctType, _, _ := strings.Cut(ct, "/")
+	for _, r := range strings.Split(accept, ",") {
+		r = strings.ToLower(strings.TrimSpace(strings.Split(r, ";")[0]))
+		switch {
+		case r == "":
+			continue
+		case r == "*/*", r == ct:
+			return true
+		case strings.HasSuffix(r, "/*") && strings.TrimSuffix(r, "/*") == ctType:
+			return true
+		}
+	}
+	return false
+}
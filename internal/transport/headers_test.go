@@ -0,0 +1,121 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func Test_FormatHeaders(t *testing.T) {
+	h := http.Header{}
+	h.Set("WWW-Authenticate", `Bearer realm="https://auth.example.com/token",scope="repository:foo:pull"`)
+	h.Set("X-Request-Id", "abc123")
+	h.Add("Set-Cookie", "session=secret")
+	h.Set("Server", "nginx")
+
+	tests := []struct {
+		name  string
+		names []string
+		want  string
+	}{
+		{
+			name:  "selected headers",
+			names: []string{"x-request-id", "WWW-Authenticate", "X-Request-Id"},
+			want:  `Www-Authenticate: Bearer realm="https://auth.example.com/token",scope="repository:foo:pull", X-Request-Id: abc123`,
+		},
+		{
+			name:  "redacts cookies",
+			names: []string{"Set-Cookie", "Server"},
+			want:  "Server: nginx, Set-Cookie: [redacted]",
+		},
+		{
+			name:  "absent headers",
+			names: []string{"Authorization"},
+			want:  "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatHeaders(h, tt.names); got != tt.want {
+				t.Errorf("FormatHeaders() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_WithHostRequestHeaders(t *testing.T) {
+	var got string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("X-Api-Key")
+	}))
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		host string
+		want string
+	}{
+		{name: "any host", host: "", want: "secret"},
+		{name: "matching host", host: u.Host, want: "secret"},
+		{name: "other host", host: "example.com", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = ""
+			rt := WithHostRequestHeaders(&http.Transport{}, http.Header{"X-Api-Key": {"secret"}}, tt.host)
+			resp, err := (&http.Client{Transport: rt}).Get(server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if got != tt.want {
+				t.Errorf("expected X-Api-Key header %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func Test_AcceptsContentType(t *testing.T) {
+	tests := []struct {
+		accept      string
+		contentType string
+		want        bool
+	}{
+		{accept: "application/json", contentType: "application/json", want: true},
+		{accept: "application/json", contentType: "Application/JSON; charset=utf-8", want: true},
+		{accept: "application/yaml, application/json;q=0.5", contentType: "application/json", want: true},
+		{accept: "application/*", contentType: "application/x-yaml", want: true},
+		{accept: "*/*", contentType: "", want: true},
+		{accept: "application/json", contentType: "text/html", want: false},
+		{accept: "application/json", contentType: "", want: false},
+		{accept: "text/*", contentType: "application/json", want: false},
+	}
+	for _, tt := range tests {
+		if got := AcceptsContentType(tt.accept, tt.contentType); got != tt.want {
+			t.Errorf("AcceptsContentType(%q, %q) = %v, want %v", tt.accept, tt.contentType, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"crypto/tls"
+	"net/http"
+)
+
+// WithHTTP1 returns a clone of the given http.Transport which only uses
+// HTTP/1.1, by disabling the negotiation of HTTP/2 over TLS.
+//
+// The returned transport does not belong to the pool, and the caller is
+// expected to close its idle connections once done.
+func WithHTTP1(t *http.Transport) *http.Transport {
+	ct := t.Clone()
+	ct.ForceAttemptHTTP2 = false
+	// A non-nil empty map disables HTTP/2.
+	ct.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	if ct.TLSClientConfig != nil {
+		ct.TLSClientConfig.NextProtos = nil
+	}
+	return ct
+}
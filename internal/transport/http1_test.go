@@ -0,0 +1,57 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_WithHTTP1(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	get := func(rt http.RoundTripper) int {
+		resp, err := (&http.Client{Transport: rt}).Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.ProtoMajor
+	}
+
+	tr := server.Client().Transport.(*http.Transport).Clone()
+	tr.ForceAttemptHTTP2 = true
+	defer tr.CloseIdleConnections()
+	if got := get(tr); got != 2 {
+		t.Fatalf("expected the given transport to use HTTP/2, got HTTP/%d", got)
+	}
+
+	rt := WithHTTP1(tr)
+	defer rt.CloseIdleConnections()
+	if got := get(rt); got != 1 {
+		t.Errorf("expected HTTP/1.1, got HTTP/%d", got)
+	}
+	if !tr.ForceAttemptHTTP2 {
+		t.Error("expected given transport to be unmodified")
+	}
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ErrBodyTooLarge is returned when reading a response body which exceeds the
+// limit of a transport returned by WithBodyLimit.
+var ErrBodyTooLarge = errors.New("response body too large")
+
+// WithBodyLimit returns a new http.Transport which fails reading the body of
+// a response of a request it performs using the given http.Transport with
+// ErrBodyTooLarge, once more than limit bytes have been read. The limit is
+// enforced while the body is read, regardless of the Content-Length of the
+// response, as it is absent for responses with a chunked transfer encoding.
+//
+// Like WithRangeResume, the limit is applied by registering a wrapping round
+// tripper for the "http" and "https" protocols on the returned transport.
+func WithBodyLimit(t *http.Transport, limit int64) *http.Transport {
+	rt := &bodyLimitRoundTripper{
+		next:  t,
+		limit: limit,
+	}
+	dispatcher := &http.Transport{}
+	dispatcher.RegisterProtocol("http", rt)
+	dispatcher.RegisterProtocol("https", rt)
+	return dispatcher
+}
+
+type bodyLimitRoundTripper struct {
+	next  http.RoundTripper
+	limit int64
+}
+
+// RoundTrip implements http.RoundTripper.
+func (rt *bodyLimitRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := rt.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.ContentLength > rt.limit {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: Content-Length of %d bytes exceeds the limit of %d bytes",
+			ErrBodyTooLarge, resp.ContentLength, rt.limit)
+	}
+	resp.Body = &limitedBody{
+		body:      resp.Body,
+		remaining: rt.limit,
+		limit:     rt.limit,
+	}
+	return resp, nil
+}
+
+// limitedBody is the body of a response, which fails once more than the
+// limit has been read.
+type limitedBody struct {
+	body      io.ReadCloser
+	remaining int64
+	limit     int64
+}
+
+// Read implements io.Reader.
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, fmt.Errorf("%w: exceeds the limit of %d bytes", ErrBodyTooLarge, b.limit)
+	}
+	// Read up to one byte beyond the limit, to detect that it is exceeded.
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.body.Read(p)
+	b.remaining -= int64(n)
+	if b.remaining < 0 {
+		return n, fmt.Errorf("%w: exceeds the limit of %d bytes", ErrBodyTooLarge, b.limit)
+	}
+	return n, err
+}
+
+// Close implements io.Closer.
+func (b *limitedBody) Close() error {
+	return b.body.Close()
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func Test_WithBodyLimit(t *testing.T) {
+	tests := []struct {
+		name          string
+		size          int
+		contentLength bool
+		wantErr       bool
+	}{
+		{name: "chunked within limit", size: 100},
+		{name: "chunked at limit", size: 1024},
+		{name: "chunked exceeding limit", size: 1025, wantErr: true},
+		{name: "Content-Length exceeding limit", size: 2048, contentLength: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.contentLength {
+					w.Header().Set("Content-Length", strconv.Itoa(tt.size))
+				}
+				// Write the body in parts, to have it sent chunked.
+				for i := 0; i < tt.size; i += 100 {
+					n := 100
+					if tt.size-i < n {
+						n = tt.size - i
+					}
+					_, _ = w.Write([]byte(strings.Repeat("x", n)))
+					w.(http.Flusher).Flush()
+				}
+			}))
+			defer server.Close()
+
+			rt := WithBodyLimit(&http.Transport{}, 1024)
+			resp, err := (&http.Client{Transport: rt}).Get(server.URL)
+			if err == nil {
+				defer resp.Body.Close()
+				var b []byte
+				b, err = io.ReadAll(resp.Body)
+				if err == nil && len(b) != tt.size {
+					t.Errorf("expected %d bytes, got %d", tt.size, len(b))
+				}
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr && !errors.Is(err, ErrBodyTooLarge) {
+				t.Errorf("expected ErrBodyTooLarge, got %v", err)
+			}
+		})
+	}
+}
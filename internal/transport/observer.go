@@ -0,0 +1,54 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"net/http"
+)
+
+// WithResponseObserver returns a new http.Transport which calls the given
+// function with every response received for the requests it performs using
+// the given http.Transport, including redirect responses.
+//
+// Like WithRedirectPolicy, the observer is applied by registering a wrapping
+// round tripper for the "http" and "https" protocols on the returned
+// transport, which allows the two to be combined.
+func WithResponseObserver(t *http.Transport, observe func(*http.Response)) *http.Transport {
+	rt := &observerRoundTripper{
+		next:    t,
+		observe: observe,
+	}
+	dispatcher := &http.Transport{}
+	dispatcher.RegisterProtocol("http", rt)
+	dispatcher.RegisterProtocol("https", rt)
+	return dispatcher
+}
+
+type observerRoundTripper struct {
+	next    http.RoundTripper
+	observe func(*http.Response)
+}
+
+// RoundTrip implements http.RoundTripper.
+func (rt *observerRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := rt.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	rt.observe(resp)
+	return resp, nil
+}
@@ -0,0 +1,52 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_WithResponseObserver(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer target.Close()
+
+	redirect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL, http.StatusFound)
+	}))
+	defer redirect.Close()
+
+	var codes []int
+	observe := func(resp *http.Response) {
+		codes = append(codes, resp.StatusCode)
+	}
+	client := &http.Client{
+		Transport: WithResponseObserver(WithRedirectPolicy(&http.Transport{}, RedirectPolicy{Follow: true}), observe),
+	}
+	resp, err := client.Get(redirect.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if len(codes) != 2 || codes[0] != http.StatusFound || codes[1] != http.StatusNotFound {
+		t.Errorf("expected observed status codes [302 404], got %v", codes)
+	}
+}
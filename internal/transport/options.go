@@ -0,0 +1,106 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+const (
+	flagMaxIdleConns        = "http-max-idle-conns"
+	flagMaxIdleConnsPerHost = "http-max-idle-conns-per-host"
+	flagIdleConnTimeout     = "http-idle-conn-timeout"
+	flagKeepAlive           = "http-keep-alive"
+	flagDisableKeepAlives   = "http-disable-keep-alives"
+)
+
+// Options configures the connection reuse of the transports of the pool.
+type Options struct {
+	// MaxIdleConns is the maximum number of idle connections across all
+	// hosts per transport. Zero means no limit.
+	MaxIdleConns int
+
+	// MaxIdleConnsPerHost is the maximum number of idle connections to keep
+	// per host per transport. Zero means http.DefaultMaxIdleConnsPerHost.
+	MaxIdleConnsPerHost int
+
+	// IdleConnTimeout is the maximum amount of time an idle connection
+	// remains idle before closing itself. Zero means no limit.
+	IdleConnTimeout time.Duration
+
+	// KeepAlive is the interval between TCP keep-alive probes of active
+	// connections. A negative value disables the probes.
+	KeepAlive time.Duration
+
+	// DisableKeepAlives disables HTTP keep-alives, using a connection for a
+	// single request only.
+	DisableKeepAlives bool
+}
+
+// DefaultOptions returns the Options the transports of the pool are created
+// with unless configured otherwise.
+func DefaultOptions() Options {
+	return Options{
+		MaxIdleConnsPerHost: http.DefaultMaxIdleConnsPerHost,
+		// A low value allows the connections of transports which are no
+		// longer used to be closed, and the transports to be garbage
+		// collected.
+		IdleConnTimeout: 60 * time.Second,
+		KeepAlive:       30 * time.Second,
+	}
+}
+
+// BindFlags binds the Options to the given flag set, with the defaults of
+// DefaultOptions.
+func (o *Options) BindFlags(fs *pflag.FlagSet) {
+	d := DefaultOptions()
+	fs.IntVar(&o.MaxIdleConns, flagMaxIdleConns, d.MaxIdleConns,
+		"The maximum number of idle connections across all hosts kept per transport used to fetch Helm repository indexes and charts. Zero means no limit.")
+	fs.IntVar(&o.MaxIdleConnsPerHost, flagMaxIdleConnsPerHost, d.MaxIdleConnsPerHost,
+		"The maximum number of idle connections per host kept per transport used to fetch Helm repository indexes and charts.")
+	fs.DurationVar(&o.IdleConnTimeout, flagIdleConnTimeout, d.IdleConnTimeout,
+		"The duration after which an idle connection used to fetch Helm repository indexes and charts is closed. Zero means no limit.")
+	fs.DurationVar(&o.KeepAlive, flagKeepAlive, d.KeepAlive,
+		"The interval between TCP keep-alive probes of the connections used to fetch Helm repository indexes and charts. A negative value disables the probes.")
+	fs.BoolVar(&o.DisableKeepAlives, flagDisableKeepAlives, d.DisableKeepAlives,
+		"Disable HTTP keep-alives, using a new connection for every request to fetch Helm repository indexes and charts.")
+}
+
+var (
+	optionsMu sync.RWMutex
+	options   = DefaultOptions()
+)
+
+// Configure sets the Options the transports of the pool are created with.
+// It must be called before the first transport is obtained from the pool, as
+// transports which have already been created are not reconfigured.
+func Configure(opts Options) {
+	optionsMu.Lock()
+	defer optionsMu.Unlock()
+	options = opts
+}
+
+// currentOptions returns the configured Options.
+func currentOptions() Options {
+	optionsMu.RLock()
+	defer optionsMu.RUnlock()
+	return options
+}
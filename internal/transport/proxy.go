@@ -0,0 +1,36 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// WithProxy returns a clone of the given http.Transport which sends all
+// requests through the proxy at the given URL, instead of the proxy
+// configured in the environment. When the URL contains user information,
+// it is sent to the proxy in the Proxy-Authorization header of the requests
+// and CONNECT tunnels to the proxy, and never to the upstream server.
+//
+// The returned transport does not belong to the pool, and the caller is
+// expected to close its idle connections once done.
+func WithProxy(t *http.Transport, proxyURL *url.URL) *http.Transport {
+	ct := t.Clone()
+	ct.Proxy = http.ProxyURL(proxyURL)
+	return ct
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func Test_WithProxy(t *testing.T) {
+	var upstreamAuth string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamAuth = r.Header.Get("Proxy-Authorization")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+
+	var proxyAuth string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxyAuth = r.Header.Get("Proxy-Authorization")
+		// Forward the request, like a proxy which strips hop-by-hop headers.
+		req, _ := http.NewRequest(r.Method, r.URL.String(), nil)
+		resp, err := http.DefaultTransport.RoundTrip(req)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		resp.Body.Close()
+		w.WriteHeader(resp.StatusCode)
+	}))
+	defer proxy.Close()
+
+	proxyURL, _ := url.Parse(proxy.URL)
+	proxyURL.User = url.UserPassword("proxy-user", "proxy-pass")
+
+	tr := &http.Transport{}
+	rt := WithProxy(tr, proxyURL)
+	defer rt.CloseIdleConnections()
+
+	resp, err := (&http.Client{Transport: rt}).Get(upstream.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status code 200, got %d", resp.StatusCode)
+	}
+	// "proxy-user:proxy-pass" in base64.
+	if want := "Basic cHJveHktdXNlcjpwcm94eS1wYXNz"; proxyAuth != want {
+		t.Errorf("expected proxy to receive Proxy-Authorization %q, got %q", want, proxyAuth)
+	}
+	if upstreamAuth != "" {
+		t.Errorf("expected upstream to not receive Proxy-Authorization, got %q", upstreamAuth)
+	}
+	if tr.Proxy != nil {
+		t.Error("expected given transport to be unmodified")
+	}
+}
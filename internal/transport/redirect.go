@@ -0,0 +1,107 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// ErrRedirectNotAllowed is returned when a redirect response is received
+// while redirects are not allowed.
+var ErrRedirectNotAllowed = errors.New("redirect not allowed")
+
+// RedirectPolicy configures how redirects are handled by a transport
+// returned by WithRedirectPolicy.
+type RedirectPolicy struct {
+	// Follow allows redirect responses to be followed. When false, a redirect
+	// response results in an ErrRedirectNotAllowed error.
+	Follow bool
+	// PassCredentials allows credentials to be passed on to a different host
+	// than the one of the first request.
+	PassCredentials bool
+}
+
+// WithRedirectPolicy returns a new http.Transport which applies the given
+// RedirectPolicy to the requests it performs using the given http.Transport.
+//
+// An http.Transport is returned rather than an http.RoundTripper, as
+// consumers like the Helm getter only accept an http.Transport. The policy is
+// applied by registering a wrapping round tripper for the "http" and "https"
+// protocols on the returned transport, which itself never establishes any
+// connections.
+//
+// The returned transport keeps track of the host of the first request, and
+// is therefore expected to be used for a single request (and its redirects).
+func WithRedirectPolicy(t *http.Transport, policy RedirectPolicy) *http.Transport {
+	rt := &redirectRoundTripper{
+		next:   t,
+		policy: policy,
+	}
+	dispatcher := &http.Transport{}
+	dispatcher.RegisterProtocol("http", rt)
+	dispatcher.RegisterProtocol("https", rt)
+	return dispatcher
+}
+
+type redirectRoundTripper struct {
+	next   http.RoundTripper
+	policy RedirectPolicy
+
+	host string
+	mu   sync.Mutex
+}
+
+// RoundTrip implements http.RoundTripper.
+func (rt *redirectRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.mu.Lock()
+	if rt.host == "" {
+		rt.host = req.URL.Host
+	}
+	crossHost := req.URL.Host != rt.host
+	rt.mu.Unlock()
+
+	// Never forward credentials to a different host, unless allowed.
+	if crossHost && !rt.policy.PassCredentials && req.Header.Get("Authorization") != "" {
+		req = req.Clone(req.Context())
+		req.Header.Del("Authorization")
+	}
+
+	resp, err := rt.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if !rt.policy.Follow && IsRedirect(resp.StatusCode) {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: received %s from '%s' to '%s'", ErrRedirectNotAllowed,
+			resp.Status, req.URL.Redacted(), resp.Header.Get("Location"))
+	}
+	return resp, nil
+}
+
+// IsRedirect returns true if the given status code is a redirect which is
+// followed by an http.Client.
+func IsRedirect(code int) bool {
+	switch code {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_WithRedirectPolicy(t *testing.T) {
+	// target records the Authorization header it receives.
+	var gotAuth string
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer target.Close()
+
+	// The redirect server uses a different host (127.0.0.1 vs localhost)
+	// to redirect to the target.
+	targetURL := strings.Replace(target.URL, "127.0.0.1", "localhost", 1)
+	redirect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, targetURL, http.StatusFound)
+	}))
+	defer redirect.Close()
+
+	tests := []struct {
+		name     string
+		policy   RedirectPolicy
+		wantErr  bool
+		wantAuth string
+	}{
+		{
+			name:    "rejects redirect",
+			policy:  RedirectPolicy{Follow: false},
+			wantErr: true,
+		},
+		{
+			name:     "follows redirect without credentials",
+			policy:   RedirectPolicy{Follow: true},
+			wantAuth: "",
+		},
+		{
+			name:     "follows redirect with credentials",
+			policy:   RedirectPolicy{Follow: true, PassCredentials: true},
+			wantAuth: "Basic dXNlcjpwYXNz",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotAuth = ""
+
+			client := &http.Client{
+				Transport: WithRedirectPolicy(&http.Transport{}, tt.policy),
+				// Mimic a client which forwards all headers on redirects.
+				CheckRedirect: func(req *http.Request, via []*http.Request) error {
+					req.Header.Set("Authorization", via[0].Header.Get("Authorization"))
+					return nil
+				},
+			}
+			req, err := http.NewRequest(http.MethodGet, redirect.URL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.SetBasicAuth("user", "pass")
+
+			resp, err := client.Do(req)
+			if tt.wantErr {
+				if err == nil || !errors.Is(err, ErrRedirectNotAllowed) {
+					t.Fatalf("expected ErrRedirectNotAllowed, got: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			resp.Body.Close()
+			if gotAuth != tt.wantAuth {
+				t.Errorf("expected Authorization header %q, got %q", tt.wantAuth, gotAuth)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+)
+
+// defaultDNSPort is the port used for a resolver address without a port.
+const defaultDNSPort = "53"
+
+// WithResolver returns a clone of the given http.Transport which resolves
+// host names using the DNS server at the given address, instead of the
+// system resolver. The address is in the form of "host:port", or "host" to
+// use the default DNS port.
+//
+// The returned transport does not belong to the pool, and the caller is
+// expected to close its idle connections once done.
+func WithResolver(t *http.Transport, address string) *http.Transport {
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, defaultDNSPort)
+	}
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, address)
+		},
+	}
+	ct := t.Clone()
+	ct.DialContext = (&net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Resolver:  resolver,
+	}).DialContext
+	return ct
+}
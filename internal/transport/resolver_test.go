@@ -0,0 +1,67 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_WithResolver(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	t.Run("does not use resolver for IP addresses", func(t *testing.T) {
+		rt := WithResolver(&http.Transport{}, "127.0.0.1:1")
+		defer rt.CloseIdleConnections()
+
+		resp, err := (&http.Client{Transport: rt}).Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	})
+
+	t.Run("resolves host names using the resolver", func(t *testing.T) {
+		// Nothing listens on the resolver address, causing the lookup to fail.
+		rt := WithResolver(&http.Transport{}, "127.0.0.1:1")
+		defer rt.CloseIdleConnections()
+
+		u := strings.Replace(server.URL, "127.0.0.1", "example.invalid", 1)
+		_, err := (&http.Client{Transport: rt}).Get(u)
+		if err == nil {
+			t.Fatal("expected lookup error")
+		}
+		if !strings.Contains(err.Error(), "127.0.0.1:1") {
+			t.Errorf("expected error to mention resolver address, got: %s", err)
+		}
+	})
+
+	t.Run("does not modify the given transport", func(t *testing.T) {
+		tr := &http.Transport{}
+		rt := WithResolver(tr, "127.0.0.1")
+		defer rt.CloseIdleConnections()
+
+		if tr.DialContext != nil {
+			t.Error("expected given transport to be unmodified")
+		}
+	})
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// WithRangeResume returns a new http.Transport which resumes reading the
+// body of a response using the given http.Transport when the connection
+// drops, at most maxResumes times per response.
+//
+// A body is only resumed if the response to the GET request is 200 OK,
+// advertises "Accept-Ranges: bytes", and has a strong ETag. The remainder is
+// requested with a Range header starting at the number of bytes read, and an
+// If-Range header with the ETag, so that the server responds with the full
+// content instead of a part of it if the content changed in the meantime.
+// Unless the server responds with the requested part, the original read
+// error is returned.
+//
+// Like WithRedirectPolicy, the resumption is applied by registering a
+// wrapping round tripper for the "http" and "https" protocols on the
+// returned transport, which allows the two to be combined.
+func WithRangeResume(t *http.Transport, maxResumes int) *http.Transport {
+	rt := &resumeRoundTripper{
+		next:       t,
+		maxResumes: maxResumes,
+	}
+	dispatcher := &http.Transport{}
+	dispatcher.RegisterProtocol("http", rt)
+	dispatcher.RegisterProtocol("https", rt)
+	return dispatcher
+}
+
+type resumeRoundTripper struct {
+	next       http.RoundTripper
+	maxResumes int
+}
+
+// RoundTrip implements http.RoundTripper.
+func (rt *resumeRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := rt.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if req.Method != http.MethodGet || resp.StatusCode != http.StatusOK || rt.maxResumes <= 0 ||
+		resp.Header.Get("Accept-Ranges") != "bytes" {
+		return resp, nil
+	}
+	etag := resp.Header.Get("ETag")
+	if etag == "" || strings.HasPrefix(etag, "W/") {
+		return resp, nil
+	}
+	resp.Body = &resumingBody{
+		rt:      rt,
+		req:     req,
+		etag:    etag,
+		body:    resp.Body,
+		resumes: rt.maxResumes,
+	}
+	return resp, nil
+}
+
+// resumingBody is the body of a response, which requests the remainder of
+// the content when reading fails.
+type resumingBody struct {
+	rt      *resumeRoundTripper
+	req     *http.Request
+	etag    string
+	body    io.ReadCloser
+	read    int64
+	resumes int
+}
+
+// Read implements io.Reader.
+func (b *resumingBody) Read(p []byte) (int, error) {
+	for {
+		n, err := b.body.Read(p)
+		b.read += int64(n)
+		if err == nil || errors.Is(err, io.EOF) || b.resumes <= 0 || b.req.Context().Err() != nil {
+			return n, err
+		}
+		b.resumes--
+		if rerr := b.resume(); rerr != nil {
+			return n, fmt.Errorf("%w (failed to resume download: %s)", err, rerr)
+		}
+		if n > 0 {
+			return n, nil
+		}
+	}
+}
+
+// resume requests the remainder of the content, and continues reading from
+// the body of that response.
+func (b *resumingBody) resume() error {
+	req := b.req.Clone(b.req.Context())
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-", b.read))
+	req.Header.Set("If-Range", b.etag)
+	resp, err := b.rt.next.RoundTrip(req)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusPartialContent {
+		resp.Body.Close()
+		return fmt.Errorf("expected partial content, got '%s'", resp.Status)
+	}
+	if etag := resp.Header.Get("ETag"); etag != "" && etag != b.etag {
+		resp.Body.Close()
+		return fmt.Errorf("ETag changed from %s to %s", b.etag, etag)
+	}
+	if start, ok := contentRangeStart(resp.Header.Get("Content-Range")); !ok || start != b.read {
+		resp.Body.Close()
+		return fmt.Errorf("unexpected Content-Range '%s'", resp.Header.Get("Content-Range"))
+	}
+	b.body.Close()
+	b.body = resp.Body
+	return nil
+}
+
+// Close implements io.Closer.
+func (b *resumingBody) Close() error {
+	return b.body.Close()
+}
+
+// contentRangeStart returns the first byte position of the given
+// Content-Range header value, in the format of "bytes <start>-<end>/<size>".
+func contentRangeStart(v string) (int64, bool) {
+	r, ok := strings.CutPrefix(v, "bytes ")
+	if !ok {
+		return 0, false
+	}
+	start, _, ok := strings.Cut(r, "-")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(start, 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n, true
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_WithRangeResume(t *testing.T) {
+	content := []byte(strings.Repeat("0123456789", 1000))
+
+	tests := []struct {
+		name         string
+		acceptRanges string
+		etag         string
+		changedETag  string
+		maxResumes   int
+		wantErr      bool
+		wantRequests int32
+	}{
+		{
+			name:         "resumes dropped connection",
+			acceptRanges: "bytes",
+			etag:         `"v1"`,
+			maxResumes:   3,
+			wantRequests: 2,
+		},
+		{
+			name:         "does not resume without range support",
+			etag:         `"v1"`,
+			maxResumes:   3,
+			wantErr:      true,
+			wantRequests: 1,
+		},
+		{
+			name:         "does not resume with weak ETag",
+			acceptRanges: "bytes",
+			etag:         `W/"v1"`,
+			maxResumes:   3,
+			wantErr:      true,
+			wantRequests: 1,
+		},
+		{
+			name:         "does not resume when disabled",
+			acceptRanges: "bytes",
+			etag:         `"v1"`,
+			wantErr:      true,
+			wantRequests: 1,
+		},
+		{
+			name:         "fails when content changed",
+			acceptRanges: "bytes",
+			etag:         `"v1"`,
+			changedETag:  `"v2"`,
+			maxResumes:   3,
+			wantErr:      true,
+			wantRequests: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&requests, 1)
+				if r.Header.Get("Range") != "" {
+					if tt.changedETag != "" {
+						w.Header().Set("ETag", tt.changedETag)
+					} else {
+						w.Header().Set("ETag", tt.etag)
+					}
+					// ServeContent handles the Range and If-Range headers.
+					http.ServeContent(w, r, "index.yaml", time.Time{}, bytes.NewReader(content))
+					return
+				}
+				if tt.acceptRanges != "" {
+					w.Header().Set("Accept-Ranges", tt.acceptRanges)
+				}
+				w.Header().Set("ETag", tt.etag)
+				w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+				_, _ = w.Write(content[:len(content)/2])
+				w.(http.Flusher).Flush()
+				// Drop the connection halfway the body.
+				panic(http.ErrAbortHandler)
+			}))
+			defer server.Close()
+
+			rt := WithRangeResume(&http.Transport{}, tt.maxResumes)
+			resp, err := (&http.Client{Transport: rt}).Get(server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			b, err := io.ReadAll(resp.Body)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !tt.wantErr && !bytes.Equal(b, content) {
+				t.Errorf("expected resumed content to equal the original content")
+			}
+			if got := atomic.LoadInt32(&requests); got != tt.wantRequests {
+				t.Errorf("expected %d requests, got %d", tt.wantRequests, got)
+			}
+		})
+	}
+}
+
+func Test_contentRangeStart(t *testing.T) {
+	for v, want := range map[string]int64{
+		"bytes 100-199/200": 100,
+		"bytes 0-0/1":       0,
+		"bytes */200":       -1,
+		"items 100-199/200": -1,
+		"bytes -1-199/200":  -1,
+		"":                  -1,
+	} {
+		got, ok := contentRangeStart(v)
+		if want < 0 {
+			if ok {
+				t.Errorf("contentRangeStart(%q) = %d, expected failure", v, got)
+			}
+			continue
+		}
+		if !ok || got != want {
+			t.Errorf("contentRangeStart(%q) = %d, %v, want %d", v, got, ok, want)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SignatureHeader is the name of the header the HTTP Signature of a request
+// is set in.
+const SignatureHeader = "Signature"
+
+// signedHeaders are the (pseudo) headers covered by the HTTP Signature of a
+// request, in order.
+var signedHeaders = []string{"(request-target)", "host", "date"}
+
+// HTTPSigner signs requests with an HTTP Signature, as described in
+// draft-cavage-http-signatures. The signature covers the request target, the
+// host and the date of the request.
+type HTTPSigner struct {
+	keyID     string
+	algorithm string
+	sign      func([]byte) ([]byte, error)
+	now       func() time.Time
+}
+
+// NewHTTPSigner returns an HTTPSigner for the given key ID and key. A PEM
+// encoded RSA, ECDSA or Ed25519 private key results in an 'rsa-sha256',
+// 'ecdsa-sha256' or 'hs2019' signature respectively, while any other key is
+// used as the shared secret of an 'hmac-sha256' signature.
+func NewHTTPSigner(keyID string, key []byte) (*HTTPSigner, error) {
+	if keyID == "" {
+		return nil, errors.New("key ID must not be empty")
+	}
+	if len(key) == 0 {
+		return nil, errors.New("key must not be empty")
+	}
+	s := &HTTPSigner{keyID: keyID, now: time.Now}
+
+	block, _ := pem.Decode(key)
+	if block == nil {
+		s.algorithm = "hmac-sha256"
+		s.sign = func(b []byte) ([]byte, error) {
+			mac := hmac.New(sha256.New, key)
+			mac.Write(b)
+			return mac.Sum(nil), nil
+		}
+		return s, nil
+	}
+
+	privateKey, err := parsePrivateKey(block)
+	if err != nil {
+		return nil, err
+	}
+	switch k := privateKey.(type) {
+	case *rsa.PrivateKey:
+		s.algorithm = "rsa-sha256"
+		s.sign = func(b []byte) ([]byte, error) {
+			h := sha256.Sum256(b)
+			return rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, h[:])
+		}
+	case *ecdsa.PrivateKey:
+		s.algorithm = "ecdsa-sha256"
+		s.sign = func(b []byte) ([]byte, error) {
+			h := sha256.Sum256(b)
+			return ecdsa.SignASN1(rand.Reader, k, h[:])
+		}
+	case ed25519.PrivateKey:
+		s.algorithm = "hs2019"
+		s.sign = func(b []byte) ([]byte, error) {
+			return ed25519.Sign(k, b), nil
+		}
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", privateKey)
+	}
+	return s, nil
+}
+
+// parsePrivateKey parses the PKCS #1, SEC 1 or PKCS #8 private key in the
+// given PEM block.
+func parsePrivateKey(block *pem.Block) (crypto.PrivateKey, error) {
+	if k, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		return k, nil
+	}
+	if k, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return k, nil
+	}
+	if k, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return k, nil
+	}
+	return nil, fmt.Errorf("failed to parse private key of PEM block type '%s'", block.Type)
+}
+
+// Sign sets the Date header of the request if not set, and the HTTP
+// Signature of the request in the SignatureHeader.
+func (s *HTTPSigner) Sign(req *http.Request) error {
+	if req.Header.Get("Date") == "" {
+		req.Header.Set("Date", s.now().UTC().Format(http.TimeFormat))
+	}
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+
+	lines := []string{
+		fmt.Sprintf("(request-target): %s %s", strings.ToLower(req.Method), req.URL.RequestURI()),
+		fmt.Sprintf("host: %s", host),
+		fmt.Sprintf("date: %s", req.Header.Get("Date")),
+	}
+	sig, err := s.sign([]byte(strings.Join(lines, "\n")))
+	if err != nil {
+		return fmt.Errorf("failed to sign request: %w", err)
+	}
+	req.Header.Set(SignatureHeader, fmt.Sprintf(`keyId="%s",algorithm="%s",headers="%s",signature="%s"`,
+		s.keyID, s.algorithm, strings.Join(signedHeaders, " "), base64.StdEncoding.EncodeToString(sig)))
+	return nil
+}
+
+// WithHTTPSigner returns a new http.Transport which signs the requests it
+// performs using the given http.Transport with the given HTTPSigner.
+//
+// Like WithBearerToken, the signature is applied by registering a wrapping
+// round tripper for the "http" and "https" protocols on the returned
+// transport.
+func WithHTTPSigner(t *http.Transport, signer *HTTPSigner) *http.Transport {
+	rt := &signerRoundTripper{
+		next:   t,
+		signer: signer,
+	}
+	dispatcher := &http.Transport{}
+	dispatcher.RegisterProtocol("http", rt)
+	dispatcher.RegisterProtocol("https", rt)
+	return dispatcher
+}
+
+type signerRoundTripper struct {
+	next   http.RoundTripper
+	signer *HTTPSigner
+}
+
+// RoundTrip implements http.RoundTripper.
+func (rt *signerRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	if err := rt.signer.Sign(req); err != nil {
+		return nil, err
+	}
+	return rt.next.RoundTrip(req)
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestWithHTTPSigner(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	signer, err := NewHTTPSigner("test-key", keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2023, time.August, 15, 10, 0, 0, 0, time.UTC)
+	signer.now = func() time.Time { return now }
+
+	var header http.Header
+	var host string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		host = r.Host
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: WithHTTPSigner(&http.Transport{}, signer)}
+	res, err := client.Get(srv.URL + "/charts/index.yaml?v=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if got, want := header.Get("Date"), "Tue, 15 Aug 2023 10:00:00 GMT"; got != want {
+		t.Errorf("Date = %q, want %q", got, want)
+	}
+	m := regexp.MustCompile(`^keyId="test-key",algorithm="rsa-sha256",headers="\(request-target\) host date",signature="([^"]+)"$`).
+		FindStringSubmatch(header.Get(SignatureHeader))
+	if m == nil {
+		t.Fatalf("unexpected %s header: %q", SignatureHeader, header.Get(SignatureHeader))
+	}
+	sig, err := base64.StdEncoding.DecodeString(m[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	signingString := "(request-target): get /charts/index.yaml?v=1\nhost: " + host + "\ndate: Tue, 15 Aug 2023 10:00:00 GMT"
+	h := sha256.Sum256([]byte(signingString))
+	if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, h[:], sig); err != nil {
+		t.Errorf("signature verification failed: %v", err)
+	}
+}
+
+func TestNewHTTPSigner(t *testing.T) {
+	tests := []struct {
+		name          string
+		keyID         string
+		key           []byte
+		wantAlgorithm string
+		wantErr       bool
+	}{
+		{name: "shared secret", keyID: "id", key: []byte("secret"), wantAlgorithm: "hmac-sha256"},
+		{name: "empty key ID", key: []byte("secret"), wantErr: true},
+		{name: "empty key", keyID: "id", wantErr: true},
+		{name: "invalid PEM key", keyID: "id", key: pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("invalid")}), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewHTTPSigner(tt.keyID, tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewHTTPSigner() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && s.algorithm != tt.wantAlgorithm {
+				t.Errorf("NewHTTPSigner() algorithm = %q, want %q", s.algorithm, tt.wantAlgorithm)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/minio/minio-go/v7/pkg/signer"
+)
+
+// emptySHA256 is the hex encoded SHA-256 digest of an empty payload, which
+// is the payload of the signed GET requests.
+const emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// SigV4Signer signs requests to an S3 compatible endpoint with an AWS
+// Signature Version 4.
+type SigV4Signer struct {
+	creds  *credentials.Credentials
+	region string
+}
+
+// NewSigV4Signer returns a SigV4Signer which signs requests for the given
+// region, with the credentials retrieved from the given provider. The
+// credentials are cached by the provider until they expire.
+func NewSigV4Signer(creds *credentials.Credentials, region string) (*SigV4Signer, error) {
+	if creds == nil {
+		return nil, errors.New("credentials must not be nil")
+	}
+	if region == "" {
+		return nil, errors.New("region must not be empty")
+	}
+	return &SigV4Signer{creds: creds, region: region}, nil
+}
+
+// Sign retrieves the credentials and sets the Authorization header of the
+// request to its SigV4 signature, along with the X-Amz-Date,
+// X-Amz-Content-Sha256 and, for temporary credentials, the
+// X-Amz-Security-Token headers. The request must not have a body.
+func (s *SigV4Signer) Sign(req *http.Request) (*http.Request, error) {
+	v, err := s.creds.Get()
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve AWS credentials: %w", err)
+	}
+	if v.AccessKeyID == "" || v.SecretAccessKey == "" {
+		return nil, errors.New("failed to retrieve AWS credentials: no credentials available")
+	}
+	req.Header.Set("X-Amz-Content-Sha256", emptySHA256)
+	return signer.SignV4(*req, v.AccessKeyID, v.SecretAccessKey, v.SessionToken, s.region), nil
+}
+
+// WithSigV4Signer returns a new http.Transport which signs the requests it
+// performs using the given http.Transport with the given SigV4Signer.
+//
+// Like WithHTTPSigner, the signature is applied by registering a wrapping
+// round tripper for the "http" and "https" protocols on the returned
+// transport.
+func WithSigV4Signer(t *http.Transport, signer *SigV4Signer) *http.Transport {
+	rt := &sigV4RoundTripper{
+		next:   t,
+		signer: signer,
+	}
+	dispatcher := &http.Transport{}
+	dispatcher.RegisterProtocol("http", rt)
+	dispatcher.RegisterProtocol("https", rt)
+	return dispatcher
+}
+
+type sigV4RoundTripper struct {
+	next   http.RoundTripper
+	signer *SigV4Signer
+}
+
+// RoundTrip implements http.RoundTripper.
+func (rt *sigV4RoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req, err := rt.signer.Sign(req.Clone(req.Context()))
+	if err != nil {
+		return nil, err
+	}
+	return rt.next.RoundTrip(req)
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+func TestWithSigV4Signer(t *testing.T) {
+	tests := []struct {
+		name         string
+		sessionToken string
+	}{
+		{name: "static credentials"},
+		{name: "temporary credentials", sessionToken: "token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got http.Header
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.Header.Clone()
+			}))
+			defer server.Close()
+
+			s, err := NewSigV4Signer(credentials.NewStaticV4("AKID", "secret", tt.sessionToken), "eu-west-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			req, err := http.NewRequest(http.MethodGet, server.URL+"/charts/index.yaml", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Accept", "application/x-yaml")
+			resp, err := (&http.Client{Transport: WithSigV4Signer(&http.Transport{}, s)}).Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			re := regexp.MustCompile(`^AWS4-HMAC-SHA256 Credential=AKID/\d{8}/eu-west-1/s3/aws4_request, SignedHeaders=([a-z0-9;-]+), Signature=[0-9a-f]{64}$`)
+			m := re.FindStringSubmatch(got.Get("Authorization"))
+			if m == nil {
+				t.Fatalf("unexpected Authorization header: %q", got.Get("Authorization"))
+			}
+			wantSigned := "accept;host;x-amz-content-sha256;x-amz-date"
+			if tt.sessionToken != "" {
+				wantSigned += ";x-amz-security-token"
+			}
+			if m[1] != wantSigned {
+				t.Errorf("expected signed headers %q, got %q", wantSigned, m[1])
+			}
+			if v := got.Get("X-Amz-Content-Sha256"); v != emptySHA256 {
+				t.Errorf("unexpected X-Amz-Content-Sha256 header: %q", v)
+			}
+			if got.Get("X-Amz-Date") == "" {
+				t.Error("expected X-Amz-Date header")
+			}
+			if v := got.Get("X-Amz-Security-Token"); v != tt.sessionToken {
+				t.Errorf("expected X-Amz-Security-Token %q, got %q", tt.sessionToken, v)
+			}
+			// The original request is not modified.
+			if req.Header.Get("Authorization") != "" {
+				t.Error("expected original request to be unsigned")
+			}
+		})
+	}
+}
+
+func TestSigV4Signer_SignWithoutCredentials(t *testing.T) {
+	s, err := NewSigV4Signer(credentials.NewStaticV4("", "", ""), "us-east-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest(http.MethodGet, "https://example.com/index.yaml", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Sign(req); err == nil {
+		t.Fatal("expected error for missing credentials")
+	}
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"io"
+	"net/http"
+)
+
+// WithBodySink returns a new http.Transport which copies the body of every
+// successful (2xx) response received for the requests it performs using the
+// given http.Transport to the given io.Writer, and hands the response on with
+// an empty body. This allows a response to be processed while it is streamed,
+// without the caller buffering it. A failure to read the body is returned as
+// the error of the request.
+//
+// Like WithBodyLimit, the sink is applied by registering a wrapping round
+// tripper for the "http" and "https" protocols on the returned transport.
+func WithBodySink(t *http.Transport, w io.Writer) *http.Transport {
+	rt := &bodySinkRoundTripper{
+		next: t,
+		w:    w,
+	}
+	dispatcher := &http.Transport{}
+	dispatcher.RegisterProtocol("http", rt)
+	dispatcher.RegisterProtocol("https", rt)
+	return dispatcher
+}
+
+type bodySinkRoundTripper struct {
+	next http.RoundTripper
+	w    io.Writer
+}
+
+// RoundTrip implements http.RoundTripper.
+func (rt *bodySinkRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := rt.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp, nil
+	}
+	_, err = io.Copy(rt.w, resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = http.NoBody
+	resp.ContentLength = 0
+	return resp, nil
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_WithBodySink(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		limit      int64
+		wantSink   string
+		wantBody   string
+		wantErr    error
+	}{
+		{name: "successful response", statusCode: http.StatusOK, wantSink: "chart"},
+		{name: "failed response", statusCode: http.StatusNotFound, wantBody: "chart"},
+		{name: "body exceeding limit", statusCode: http.StatusOK, limit: 3, wantErr: ErrBodyTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.statusCode)
+				_, _ = w.Write([]byte("chart"))
+			}))
+			defer server.Close()
+
+			rt := &http.Transport{}
+			if tt.limit > 0 {
+				rt = WithBodyLimit(rt, tt.limit)
+			}
+			var sink bytes.Buffer
+			resp, err := (&http.Client{Transport: WithBodySink(rt, &sink)}).Get(server.URL)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer resp.Body.Close()
+			b, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := string(b); got != tt.wantBody {
+				t.Errorf("expected body %q, got %q", tt.wantBody, got)
+			}
+			if got := sink.String(); got != tt.wantSink {
+				t.Errorf("expected sink %q, got %q", tt.wantSink, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/jcmturner/gokrb5/v8/client"
+	"github.com/jcmturner/gokrb5/v8/config"
+	"github.com/jcmturner/gokrb5/v8/keytab"
+	"github.com/jcmturner/gokrb5/v8/spnego"
+)
+
+// ErrSPNEGO is returned when authenticating a request using SPNEGO fails.
+var ErrSPNEGO = errors.New("SPNEGO authentication failed")
+
+// SPNEGONegotiator authenticates requests using SPNEGO, with a Kerberos
+// client which logs in using a keytab. While the negotiator is not closed,
+// the ticket-granting ticket of the client is renewed in the background, and
+// service tickets are requested again once they expire.
+type SPNEGONegotiator struct {
+	client *client.Client
+	spn    string
+}
+
+// NewSPNEGONegotiator returns an SPNEGONegotiator for the given principal
+// ("<user>@<REALM>"), keytab and krb5.conf. When the principal has no realm,
+// the default realm of the krb5.conf is used. When spn is empty, the service
+// principal name is derived from the host of the request ("HTTP/<host>").
+//
+// The login with the KDC is deferred until the first request is
+// authenticated. The negotiator must be closed after use.
+func NewSPNEGONegotiator(principal string, keytabData []byte, krb5Conf, spn string) (*SPNEGONegotiator, error) {
+	cfg, err := config.NewFromString(krb5Conf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Kerberos configuration: %w", err)
+	}
+	kt := keytab.New()
+	if err := kt.Unmarshal(keytabData); err != nil {
+		return nil, fmt.Errorf("failed to parse Kerberos keytab: %w", err)
+	}
+
+	username, realm := principal, cfg.LibDefaults.DefaultRealm
+	if i := strings.LastIndex(principal, "@"); i >= 0 {
+		username, realm = principal[:i], principal[i+1:]
+	}
+	if username == "" {
+		return nil, errors.New("Kerberos principal must not be empty")
+	}
+	if realm == "" {
+		return nil, fmt.Errorf("no realm for Kerberos principal '%s', and no default realm configured", principal)
+	}
+
+	return &SPNEGONegotiator{
+		// Active Directory KDCs do not support PA-FX-FAST.
+		client: client.NewWithKeytab(username, realm, kt, cfg, client.DisablePAFXFAST(true)),
+		spn:    spn,
+	}, nil
+}
+
+// Negotiate logs in with the KDC if the client does not yet have a valid
+// ticket-granting ticket, and sets the SPNEGO Authorization header of the
+// request. Errors wrap ErrSPNEGO.
+func (n *SPNEGONegotiator) Negotiate(req *http.Request) error {
+	if err := n.client.AffirmLogin(); err != nil {
+		return fmt.Errorf("%w: %w", ErrSPNEGO, err)
+	}
+	if err := spnego.SetSPNEGOHeader(n.client, req, n.spn); err != nil {
+		return fmt.Errorf("%w: %w", ErrSPNEGO, err)
+	}
+	return nil
+}
+
+// Close destroys the Kerberos client of the negotiator, stopping the renewal
+// of its tickets.
+func (n *SPNEGONegotiator) Close() {
+	n.client.Destroy()
+}
+
+// WithSPNEGO returns a new http.Transport which authenticates the requests
+// it performs using the given http.Transport with the given
+// SPNEGONegotiator.
+//
+// Like WithBearerToken, the authentication is applied by registering a
+// wrapping round tripper for the "http" and "https" protocols on the returned
+// transport.
+func WithSPNEGO(t *http.Transport, n *SPNEGONegotiator) *http.Transport {
+	rt := &spnegoRoundTripper{
+		next:       t,
+		negotiator: n,
+	}
+	dispatcher := &http.Transport{}
+	dispatcher.RegisterProtocol("http", rt)
+	dispatcher.RegisterProtocol("https", rt)
+	return dispatcher
+}
+
+type spnegoRoundTripper struct {
+	next       http.RoundTripper
+	negotiator *SPNEGONegotiator
+}
+
+// RoundTrip implements http.RoundTripper.
+func (rt *spnegoRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	if err := rt.negotiator.Negotiate(req); err != nil {
+		return nil, err
+	}
+	return rt.next.RoundTrip(req)
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2023 The Flux authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jcmturner/gokrb5/v8/iana/etypeID"
+	"github.com/jcmturner/gokrb5/v8/keytab"
+)
+
+func testKeytab(t *testing.T) []byte {
+	t.Helper()
+	kt := keytab.New()
+	if err := kt.AddEntry("flux", "EXAMPLE.COM", "secret", time.Now(), 1, etypeID.AES256_CTS_HMAC_SHA1_96); err != nil {
+		t.Fatal(err)
+	}
+	b, err := kt.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func testKrb5Conf(t *testing.T) string {
+	t.Helper()
+	// A listener which is closed right away provides an address no KDC
+	// listens on.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	return `[libdefaults]
+  default_realm = EXAMPLE.COM
+  udp_preference_limit = 1
+
+[realms]
+  EXAMPLE.COM = {
+    kdc = ` + addr + `
+  }
+`
+}
+
+func TestWithSPNEGO(t *testing.T) {
+	n, err := NewSPNEGONegotiator("flux@EXAMPLE.COM", testKeytab(t), testKrb5Conf(t), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer n.Close()
+
+	var called bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: WithSPNEGO(&http.Transport{}, n)}
+	_, err = client.Get(srv.URL + "/index.yaml")
+	if !errors.Is(err, ErrSPNEGO) {
+		t.Errorf("expected error to wrap ErrSPNEGO, got %v", err)
+	}
+	if called {
+		t.Error("expected no request to be made without SPNEGO authentication")
+	}
+}
+
+func TestNewSPNEGONegotiator(t *testing.T) {
+	kt, conf := testKeytab(t), testKrb5Conf(t)
+	tests := []struct {
+		name      string
+		principal string
+		keytab    []byte
+		conf      string
+		wantRealm string
+		wantErr   bool
+	}{
+		{name: "principal with realm", principal: "flux@OTHER.COM", keytab: kt, conf: conf, wantRealm: "OTHER.COM"},
+		{name: "principal with default realm", principal: "flux", keytab: kt, conf: conf, wantRealm: "EXAMPLE.COM"},
+		{name: "no realm", principal: "flux", keytab: kt, conf: "[libdefaults]\n", wantErr: true},
+		{name: "empty principal", principal: "@EXAMPLE.COM", keytab: kt, conf: conf, wantErr: true},
+		{name: "invalid keytab", principal: "flux", keytab: []byte("invalid"), conf: conf, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := NewSPNEGONegotiator(tt.principal, tt.keytab, tt.conf, "")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewSPNEGONegotiator() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			defer n.Close()
+			if got := n.client.Credentials.Domain(); got != tt.wantRealm {
+				t.Errorf("NewSPNEGONegotiator() realm = %q, want %q", got, tt.wantRealm)
+			}
+		})
+	}
+}
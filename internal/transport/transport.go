@@ -53,29 +53,38 @@ type TransportPool struct {
 
 var pool = &sync.Pool{
 	New: func() interface{} {
-		return &http.Transport{
-			DisableCompression: true,
-			Proxy:              http.ProxyFromEnvironment,
+		return newTransport(currentOptions())
+	},
+}
 
-			// Due to the non blocking nature of this approach,
-			// at peak usage a higher number of transport objects
-			// may be created. sync.Pool will ensure they are
-			// gargage collected when/if needed.
-			//
-			// By setting a low value to IdleConnTimeout the connections
-			// will be closed after that period of inactivity, allowing the
-			// transport to be garbage collected.
-			IdleConnTimeout: 60 * time.Second,
+// newTransport returns a new http.Transport configured with the given
+// Options.
+func newTransport(opts Options) *http.Transport {
+	return &http.Transport{
+		DisableCompression: true,
+		Proxy:              http.ProxyFromEnvironment,
 
-			// use safe defaults based off http.DefaultTransport
-			DialContext: (&net.Dialer{
-				Timeout:   30 * time.Second,
-				KeepAlive: 30 * time.Second,
-			}).DialContext,
-			TLSHandshakeTimeout:   10 * time.Second,
-			ExpectContinueTimeout: 1 * time.Second,
-		}
-	},
+		// Due to the non blocking nature of this approach,
+		// at peak usage a higher number of transport objects
+		// may be created. sync.Pool will ensure they are
+		// gargage collected when/if needed.
+		//
+		// By setting a low value to IdleConnTimeout the connections
+		// will be closed after that period of inactivity, allowing the
+		// transport to be garbage collected.
+		IdleConnTimeout:     opts.IdleConnTimeout,
+		MaxIdleConns:        opts.MaxIdleConns,
+		MaxIdleConnsPerHost: opts.MaxIdleConnsPerHost,
+		DisableKeepAlives:   opts.DisableKeepAlives,
+
+		// use safe defaults based off http.DefaultTransport
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: opts.KeepAlive,
+		}).DialContext,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
 }
 
 // NewOrIdle tries to return an existing transport that is not currently being used.
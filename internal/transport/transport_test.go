@@ -18,7 +18,9 @@ package transport
 
 import (
 	"crypto/tls"
+	"net/http"
 	"testing"
+	"time"
 )
 
 func Test_TransportReuse(t *testing.T) {
@@ -56,3 +58,21 @@ func Test_TransportReuse(t *testing.T) {
 		t.Errorf("wanted error message: 'cannot release nil transport' got: %q", err.Error())
 	}
 }
+
+func Test_newTransport(t *testing.T) {
+	d := newTransport(DefaultOptions())
+	if d.MaxIdleConnsPerHost != http.DefaultMaxIdleConnsPerHost || d.IdleConnTimeout != 60*time.Second ||
+		d.MaxIdleConns != 0 || d.DisableKeepAlives {
+		t.Errorf("unexpected default transport settings: %+v", d)
+	}
+
+	tr := newTransport(Options{
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 20,
+		IdleConnTimeout:     5 * time.Minute,
+		DisableKeepAlives:   true,
+	})
+	if tr.MaxIdleConns != 100 || tr.MaxIdleConnsPerHost != 20 || tr.IdleConnTimeout != 5*time.Minute || !tr.DisableKeepAlives {
+		t.Errorf("transport not configured with options: %+v", tr)
+	}
+}
@@ -17,16 +17,28 @@ limitations under the License.
 package main
 
 import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	flag "github.com/spf13/pflag"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
 	"helm.sh/helm/v3/pkg/getter"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
@@ -36,6 +48,8 @@ import (
 	ctrlcache "sigs.k8s.io/controller-runtime/pkg/cache"
 	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
 	ctrlcfg "sigs.k8s.io/controller-runtime/pkg/config"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 
 	"github.com/fluxcd/pkg/git"
 	"github.com/fluxcd/pkg/runtime/client"
@@ -59,7 +73,10 @@ import (
 	intdigest "github.com/fluxcd/source-controller/internal/digest"
 	"github.com/fluxcd/source-controller/internal/features"
 	"github.com/fluxcd/source-controller/internal/helm"
+	helmgetter "github.com/fluxcd/source-controller/internal/helm/getter"
 	"github.com/fluxcd/source-controller/internal/helm/registry"
+	smetrics "github.com/fluxcd/source-controller/internal/metrics"
+	"github.com/fluxcd/source-controller/internal/transport"
 )
 
 const controllerName = "source-controller"
@@ -93,8 +110,12 @@ func main() {
 		eventsAddr               string
 		healthAddr               string
 		storagePath              string
+		storageBackupPath        string
 		storageAddr              string
 		storageAdvAddr           string
+		endpointsAddr            string
+		endpointsCertFile        string
+		endpointsKeyFile         string
 		concurrent               int
 		requeueDependency        time.Duration
 		helmIndexLimit           int64
@@ -107,12 +128,50 @@ func main() {
 		featureGates             feathelper.FeatureGates
 		watchOptions             helper.WatchOptions
 		intervalJitterOptions    jitter.IntervalOptions
+		transportOptions         transport.Options
+		tracingEndpoint          string
+		tracingInsecure          bool
+		tracingSampleRatio       float64
 		helmCacheMaxSize         int
+		helmCacheMaxBytes        int64
 		helmCacheTTL             string
 		helmCachePurgeInterval   string
 		artifactRetentionTTL     time.Duration
 		artifactRetentionRecords int
 		artifactDigestAlgo       string
+		helmRepoMinRefresh       time.Duration
+		helmIndexParseTimeout    time.Duration
+		artifactMetadataPrefixes []string
+		helmIndexDebugTokenFile  string
+		enableReconcileTrigger   bool
+		enableHelmVirtualIndex   bool
+		artifactCompression      string
+		denyHTTPCredentials      bool
+		allowInsecureTLS         bool
+		helmRepoLockBackoff      time.Duration
+		helmRepoLockBackoffMax   time.Duration
+		failureBackoffThreshold  int64
+		failureBackoffMax        time.Duration
+		helmRepoFailureHeaders   []string
+		helmDefaultSecretName    string
+		failureEventInterval     time.Duration
+		indexDeltaLimit          int
+		reconcileOnStartup       bool
+		reconcileOnStartupRate   float64
+		artifactFileMode         string
+		artifactDirMode          string
+		helmRepoHistoryLimit     int
+		helmRepoConditionsLimit  int
+		artifactGCGracePeriod    time.Duration
+		artifactTokenKeyFile     string
+		artifactSigningKeyFile   string
+		disableArtifactSigning   bool
+		helmRepoCacheControlMin  time.Duration
+		helmRepoCacheControlMax  time.Duration
+		allowFileScheme          bool
+		allowedHostsConfigMap    string
+		gracefulShutdownTimeout  time.Duration
+		manageFinalizers         bool
 	)
 
 	flag.StringVar(&metricsAddr, "metrics-addr", envOrDefault("METRICS_ADDR", ":8080"),
@@ -122,10 +181,18 @@ func main() {
 	flag.StringVar(&healthAddr, "health-addr", ":9440", "The address the health endpoint binds to.")
 	flag.StringVar(&storagePath, "storage-path", envOrDefault("STORAGE_PATH", ""),
 		"The local storage path.")
+	flag.StringVar(&storageBackupPath, "storage-backup-path", envOrDefault("STORAGE_BACKUP_PATH", ""),
+		"The local path of the backup storage the artifacts are mirrored to. Mirroring is disabled when not set.")
 	flag.StringVar(&storageAddr, "storage-addr", envOrDefault("STORAGE_ADDR", ":9090"),
 		"The address the static file server binds to.")
 	flag.StringVar(&storageAdvAddr, "storage-adv-addr", envOrDefault("STORAGE_ADV_ADDR", ""),
 		"The advertised address of the static file server.")
+	flag.StringVar(&endpointsAddr, "endpoints-addr", envOrDefault("ENDPOINTS_ADDR", ":9091"),
+		"The address the server of the authenticated endpoints binds to, e.g. the reconcile trigger and the Helm virtual index.")
+	flag.StringVar(&endpointsCertFile, "endpoints-tls-cert-file", "",
+		"The path to the TLS certificate of the server of the authenticated endpoints. The endpoints are served over plaintext HTTP when not set.")
+	flag.StringVar(&endpointsKeyFile, "endpoints-tls-key-file", "",
+		"The path to the TLS private key of the server of the authenticated endpoints.")
 	flag.IntVar(&concurrent, "concurrent", 2, "The number of concurrent reconciles per controller.")
 	flag.Int64Var(&helmIndexLimit, "helm-index-max-size", helm.MaxIndexSize,
 		"The max allowed size in bytes of a Helm repository index file.")
@@ -137,6 +204,8 @@ func main() {
 		"The interval at which failing dependencies are reevaluated.")
 	flag.IntVar(&helmCacheMaxSize, "helm-cache-max-size", 0,
 		"The maximum size of the cache in number of indexes.")
+	flag.Int64Var(&helmCacheMaxBytes, "helm-cache-max-bytes", 0,
+		"The maximum total size of the indexes in the cache in bytes, measured by their serialized size, after which the least recently used indexes are evicted. Zero means the size is not bounded.")
 	flag.StringVar(&helmCacheTTL, "helm-cache-ttl", "15m",
 		"The TTL of an index in the cache. Valid time units are ns, us (or µs), ms, s, m, h.")
 	flag.StringVar(&helmCachePurgeInterval, "helm-cache-purge-interval", "1m",
@@ -151,6 +220,78 @@ func main() {
 		"The maximum number of artifacts to be kept in storage after a garbage collection.")
 	flag.StringVar(&artifactDigestAlgo, "artifact-digest-algo", intdigest.Canonical.String(),
 		"The algorithm to use to calculate the digest of artifacts.")
+	flag.DurationVar(&helmRepoMinRefresh, "helm-repo-min-refresh", 0,
+		"The window after the last successful fetch of the index of a Ready HelmRepository in which its index is not fetched again, unless the object changed or a reconcile was requested. Zero disables the window.")
+	flag.StringSliceVar(&artifactMetadataPrefixes, "artifact-metadata-prefixes", nil,
+		"The label and annotation key prefixes of HelmRepository objects to embed in the Artifact metadata and emitted events.")
+	flag.StringVar(&helmIndexDebugTokenFile, "helm-index-debug-token-file", "",
+		"The path to a file containing the bearer token to authenticate requests to the cached HelmRepository index debug endpoint. The endpoint is disabled when not set.")
+	flag.BoolVar(&enableReconcileTrigger, "enable-reconcile-trigger", false,
+		"Enable the endpoint to trigger a reconcile of an object, for callers allowed to patch the object.")
+	flag.BoolVar(&enableHelmVirtualIndex, "enable-helm-virtual-index", false,
+		"Enable the endpoint serving a merged index of the HelmRepositories in the namespaces the caller is allowed to list.")
+	flag.BoolVar(&reconcileOnStartup, "reconcile-on-startup", false,
+		"Fully reconcile every HelmRepository once after the controller started, regardless of its schedule and the minimum refresh interval.")
+	flag.Float64Var(&reconcileOnStartupRate, "reconcile-on-startup-rate", 10,
+		"The maximum number of HelmRepository reconciliations per second performed due to --reconcile-on-startup. Zero means no limit.")
+	flag.DurationVar(&helmIndexParseTimeout, "helm-index-parse-timeout", 0,
+		"The maximum duration for parsing a fetched HelmRepository index, separate from the fetch timeout. Zero disables the timeout.")
+	flag.StringVar(&artifactCompression, "artifact-compression", "",
+		"The compression algorithm to store HelmRepository index artifacts with on disk. Supported values are 'zstd', or empty to disable compression.")
+	flag.BoolVar(&denyHTTPCredentials, "deny-http-credentials", false,
+		"Refuse to send the credentials of HelmRepository objects over plaintext HTTP, unless they opt in with spec.passCredentials or spec.insecureSkipTLSVerify.")
+	flag.BoolVar(&allowInsecureTLS, "allow-insecure-tls", false,
+		"Allow HelmRepository objects to skip the verification of TLS certificates using spec.insecureSkipTLSVerify.")
+	flag.DurationVar(&gracefulShutdownTimeout, "graceful-shutdown-timeout", 30*time.Second,
+		"The duration given to in-flight reconciliations to complete on shutdown, including the artifact writes holding a storage lock, before the controller exits.")
+	flag.BoolVar(&manageFinalizers, "manage-finalizers", true,
+		"Add the source finalizer to objects and remove it on deletion. When disabled, artifacts are still garbage collected on deletion, but the finalizers of objects are left to be managed externally.")
+	flag.StringVar(&allowedHostsConfigMap, "allowed-hosts-configmap", "",
+		"The '<namespace>/<name>' of a ConfigMap holding the host patterns HelmRepository URLs are allowed to point at, one per line in its 'hosts' key. Any host is allowed when not set.")
+	flag.DurationVar(&helmRepoLockBackoff, "helm-repo-lock-backoff", 0,
+		"The backoff after which a HelmRepository is requeued when the storage lock for its artifact could not be acquired, doubling with every consecutive failure. Zero disables the backoff.")
+	flag.DurationVar(&helmRepoLockBackoffMax, "helm-repo-lock-backoff-max", 5*time.Minute,
+		"The upper bound of the HelmRepository storage lock backoff. Zero means the backoff is not bounded.")
+	flag.Int64Var(&failureBackoffThreshold, "helm-repo-failure-backoff-threshold", 0,
+		"The number of consecutive failures of a HelmRepository after which its requeue interval is doubled with every further failure, until it succeeds. Zero disables the backoff.")
+	flag.DurationVar(&failureBackoffMax, "helm-repo-failure-backoff-max", time.Hour,
+		"The upper bound of the requeue interval of a HelmRepository backing off from consecutive failures. Zero means the interval is not bounded.")
+	flag.StringVar(&artifactFileMode, "artifact-file-mode", fmt.Sprintf("%#o", controller.DefaultArtifactFileMode),
+		"The octal permission mode of the artifact files written to the storage.")
+	flag.StringVar(&artifactDirMode, "artifact-dir-mode", fmt.Sprintf("%#o", controller.DefaultArtifactDirMode),
+		"The octal permission mode of the artifact directories created in the storage.")
+	flag.DurationVar(&artifactGCGracePeriod, "artifact-gc-grace-period", 0,
+		"The duration of time that the artifact replaced by a new artifact is kept in storage, regardless of the retention options, to allow in-progress downloads to complete. Zero disables the grace period.")
+	flag.StringVar(&artifactTokenKeyFile, "artifact-token-key-file", "",
+		"The path to a file containing the key the bearer tokens of private HelmRepository artifacts are derived from. A random key is generated on startup when not set, which invalidates the tokens on every restart.")
+	flag.StringVar(&artifactSigningKeyFile, "artifact-signing-key-file", "",
+		"The path to a file containing the PEM encoded RSA, ECDSA or Ed25519 private key HelmRepository artifacts are signed with. Artifacts are not signed when not set.")
+	flag.BoolVar(&disableArtifactSigning, "disable-artifact-signing", false,
+		"Disable the signing of HelmRepository artifacts, even when --artifact-signing-key-file is set.")
+	flag.DurationVar(&helmRepoCacheControlMin, "helm-repo-cache-control-min-interval", time.Minute,
+		"The lower bound of the requeue interval of a HelmRepository deferring its interval to the Cache-Control max-age of its index.")
+	flag.DurationVar(&helmRepoCacheControlMax, "helm-repo-cache-control-max-interval", 24*time.Hour,
+		"The upper bound of the requeue interval of a HelmRepository deferring its interval to the Cache-Control max-age of its index. Zero means the interval is not bounded.")
+	flag.BoolVar(&allowFileScheme, "allow-file-scheme", false,
+		"Allow HelmRepository objects to read their index and charts from the local file system of the controller using file:// URLs. Intended for testing only, as it allows any file the controller has access to be read.")
+	flag.IntVar(&helmRepoHistoryLimit, "helm-repo-history-limit", 5,
+		"The number of fetch failures retained in the status history of a HelmRepository. Zero disables the history.")
+	flag.IntVar(&helmRepoConditionsLimit, "helm-repo-conditions-limit", 0,
+		"The maximum number of Conditions of the controller retained in the status of a HelmRepository. Informational Conditions which transitioned the longest ago are removed first, while the Ready Condition and the Conditions it is summarized from are always retained. Zero means no limit.")
+	flag.StringSliceVar(&helmRepoFailureHeaders, "helm-repo-failure-response-headers", nil,
+		"The names of the response headers to include in the FetchFailed condition message of a HelmRepository when fetching its index fails, e.g. 'WWW-Authenticate,X-Request-Id'. Credentials and cookies are redacted.")
+	flag.StringVar(&helmDefaultSecretName, "helm-default-secret-name", "",
+		"The name of the Secret in the namespace of a HelmRepository used for authentication when it has no .spec.secretRef, for both the index and its charts. Empty disables the fallback.")
+	flag.StringVar(&tracingEndpoint, "tracing-endpoint", "",
+		"The host and port of the OTLP/HTTP endpoint to export OpenTelemetry traces of the HelmRepository reconciliations to. Tracing is disabled when not set.")
+	flag.BoolVar(&tracingInsecure, "tracing-insecure", false,
+		"Export OpenTelemetry traces over plaintext HTTP instead of HTTPS.")
+	flag.Float64Var(&tracingSampleRatio, "tracing-sample-ratio", 1,
+		"The ratio of reconciliations of which the OpenTelemetry traces are sampled, between 0 and 1.")
+	flag.DurationVar(&failureEventInterval, "helm-repo-failure-event-interval", 0,
+		"The interval at which repeated identical warning events of a HelmRepository are emitted, with the number of times they occurred in the meantime. Zero emits every warning event.")
+	flag.IntVar(&indexDeltaLimit, "helm-repo-index-delta-limit", 0,
+		"The maximum number of added and removed chart versions listed when the index of a HelmRepository changes. Zero disables logging the changes.")
 
 	clientOptions.BindFlags(flag.CommandLine)
 	logOptions.BindFlags(flag.CommandLine)
@@ -159,6 +300,7 @@ func main() {
 	featureGates.BindFlags(flag.CommandLine)
 	watchOptions.BindFlags(flag.CommandLine)
 	intervalJitterOptions.BindFlags(flag.CommandLine)
+	transportOptions.BindFlags(flag.CommandLine)
 
 	flag.Parse()
 
@@ -174,30 +316,58 @@ func main() {
 		os.Exit(1)
 	}
 
-	mgr := mustSetupManager(metricsAddr, healthAddr, concurrent, watchOptions, clientOptions, leaderElectionOptions)
+	transport.Configure(transportOptions)
+
+	mgr := mustSetupManager(metricsAddr, healthAddr, concurrent, gracefulShutdownTimeout, watchOptions, clientOptions, leaderElectionOptions)
 
 	probes.SetupChecks(mgr, setupLog)
 	pprof.SetupHandlers(mgr, setupLog)
 
+	mustSetupTracing(mgr, tracingEndpoint, tracingInsecure, tracingSampleRatio)
+
 	metrics := helper.NewMetrics(mgr, metrics.MustMakeRecorder(), v1.SourceFinalizer)
 	cacheRecorder := cache.MustMakeMetrics()
+	sourceMetrics := smetrics.MustMakeRecorder()
 	eventRecorder := mustSetupEventRecorder(mgr, eventsAddr, controllerName)
 	storage := mustInitStorage(storagePath, storageAdvAddr, artifactRetentionTTL, artifactRetentionRecords, artifactDigestAlgo)
-
+	storage.FileMode = mustParseFileMode("artifact-file-mode", artifactFileMode)
+	storage.DirMode = mustParseFileMode("artifact-dir-mode", artifactDirMode)
+	storage.ArtifactReplacementGracePeriod = artifactGCGracePeriod
+	storage.Backup = mustInitBackupStorage(storageBackupPath, storage)
+	mustSetupStorageDrain(mgr, storage, gracefulShutdownTimeout)
+
+	mustValidateArtifactCompression(artifactCompression)
+	if allowFileScheme {
+		setupLog.Info("warning: reading Helm repositories from the local file system is enabled")
+		getters = append(getters, helmgetter.FileProvider)
+	}
 	mustSetupHelmLimits(helmIndexLimit, helmChartLimit, helmChartFileLimit)
-	helmIndexCache, helmIndexCacheItemTTL := mustInitHelmCache(helmCacheMaxSize, helmCacheTTL, helmCachePurgeInterval)
+	helmIndexCache, helmIndexCacheItemTTL := mustInitHelmCache(helmCacheMaxSize, helmCacheMaxBytes, helmCacheTTL, helmCachePurgeInterval)
+
+	mustValidateEndpointsTLS(endpointsCertFile, endpointsKeyFile)
+	endpoints := http.NewServeMux()
+	mustSetupHelmIndexDebugHandler(mgr, endpoints, helmIndexCache, helmIndexDebugTokenFile)
+	reconcileTrigger := mustSetupReconcileTriggerHandler(mgr, endpoints, enableReconcileTrigger)
+	mustSetupHelmVirtualIndexHandler(mgr, endpoints, storage, enableHelmVirtualIndex)
+	enableEndpoints := helmIndexDebugTokenFile != "" || enableReconcileTrigger || enableHelmVirtualIndex
+	artifactTokens := mustInitArtifactTokens(artifactTokenKeyFile)
+	artifactSigner := mustInitArtifactSigner(artifactSigningKeyFile, disableArtifactSigning)
+	hostAllowlist := mustInitHostAllowlist(mgr, allowedHostsConfigMap)
 
 	ctx := ctrl.SetupSignalHandler()
 
 	if err := (&controller.GitRepositoryReconciler{
-		Client:         mgr.GetClient(),
-		EventRecorder:  eventRecorder,
-		Metrics:        metrics,
-		Storage:        storage,
-		ControllerName: controllerName,
+		Client:            mgr.GetClient(),
+		EventRecorder:     eventRecorder,
+		Metrics:           metrics,
+		SourceMetrics:     sourceMetrics,
+		Storage:           storage,
+		ControllerName:    controllerName,
+		DisableFinalizers: !manageFinalizers,
 	}).SetupWithManagerAndOptions(mgr, controller.GitRepositoryReconcilerOptions{
 		DependencyRequeueInterval: requeueDependency,
 		RateLimiter:               helper.GetRateLimiter(rateLimiterOptions),
+		ReconcileTrigger:          reconcileTrigger,
 	}); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", v1beta2.GitRepositoryKind)
 		os.Exit(1)
@@ -207,27 +377,56 @@ func main() {
 		Client:                  mgr.GetClient(),
 		EventRecorder:           eventRecorder,
 		Metrics:                 metrics,
+		SourceMetrics:           sourceMetrics,
 		ControllerName:          controllerName,
 		RegistryClientGenerator: registry.ClientGenerator,
+		DisableFinalizers:       !manageFinalizers,
 	}).SetupWithManagerAndOptions(mgr, controller.HelmRepositoryReconcilerOptions{
-		RateLimiter: helper.GetRateLimiter(rateLimiterOptions),
+		RateLimiter:      helper.GetRateLimiter(rateLimiterOptions),
+		ReconcileTrigger: reconcileTrigger,
 	}); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", v1beta2.HelmRepositoryKind, "type", "OCI")
 		os.Exit(1)
 	}
 
 	if err := (&controller.HelmRepositoryReconciler{
-		Client:         mgr.GetClient(),
-		EventRecorder:  eventRecorder,
-		Metrics:        metrics,
-		Storage:        storage,
-		Getters:        getters,
-		ControllerName: controllerName,
-		Cache:          helmIndexCache,
-		TTL:            helmIndexCacheItemTTL,
-		CacheRecorder:  cacheRecorder,
+		Client:                   mgr.GetClient(),
+		EventRecorder:            eventRecorder,
+		Metrics:                  metrics,
+		SourceMetrics:            sourceMetrics,
+		Storage:                  storage,
+		Getters:                  getters,
+		ControllerName:           controllerName,
+		Cache:                    helmIndexCache,
+		TTL:                      helmIndexCacheItemTTL,
+		CacheRecorder:            cacheRecorder,
+		MinRefreshInterval:       helmRepoMinRefresh,
+		IndexParseTimeout:        helmIndexParseTimeout,
+		ArtifactMetadataPrefixes: artifactMetadataPrefixes,
+		ArtifactCompression:      artifactCompression,
+		DenyHTTPCredentials:      denyHTTPCredentials,
+		AllowInsecureTLS:         allowInsecureTLS,
+		HostAllowlist:            hostAllowlist,
+		LockBackoff:              helmRepoLockBackoff,
+		LockBackoffMax:           helmRepoLockBackoffMax,
+		FailureBackoffThreshold:  failureBackoffThreshold,
+		FailureBackoffMax:        failureBackoffMax,
+		FailureResponseHeaders:   helmRepoFailureHeaders,
+		DefaultSecretName:        helmDefaultSecretName,
+		FailureEventInterval:     failureEventInterval,
+		IndexDeltaLimit:          indexDeltaLimit,
+		ReconcileOnStartup:       reconcileOnStartup,
+		ReconcileOnStartupRate:   reconcileOnStartupRate,
+		HistoryLimit:             helmRepoHistoryLimit,
+		ConditionsLimit:          helmRepoConditionsLimit,
+		ArtifactTokens:           artifactTokens,
+		ArtifactSigner:           artifactSigner,
+		CacheControlMinInterval:  helmRepoCacheControlMin,
+		CacheControlMaxInterval:  helmRepoCacheControlMax,
+		DisableFinalizers:        !manageFinalizers,
 	}).SetupWithManagerAndOptions(mgr, controller.HelmRepositoryReconcilerOptions{
-		RateLimiter: helper.GetRateLimiter(rateLimiterOptions),
+		RateLimiter:      helper.GetRateLimiter(rateLimiterOptions),
+		ReconcileTrigger: reconcileTrigger,
 	}); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", v1beta2.HelmRepositoryKind)
 		os.Exit(1)
@@ -240,38 +439,48 @@ func main() {
 		Getters:                 getters,
 		EventRecorder:           eventRecorder,
 		Metrics:                 metrics,
+		SourceMetrics:           sourceMetrics,
 		ControllerName:          controllerName,
+		DefaultSecretName:       helmDefaultSecretName,
 		Cache:                   helmIndexCache,
 		TTL:                     helmIndexCacheItemTTL,
 		CacheRecorder:           cacheRecorder,
+		DisableFinalizers:       !manageFinalizers,
 	}).SetupWithManagerAndOptions(ctx, mgr, controller.HelmChartReconcilerOptions{
-		RateLimiter: helper.GetRateLimiter(rateLimiterOptions),
+		RateLimiter:      helper.GetRateLimiter(rateLimiterOptions),
+		ReconcileTrigger: reconcileTrigger,
 	}); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", v1beta2.HelmChartKind)
 		os.Exit(1)
 	}
 
 	if err := (&controller.BucketReconciler{
-		Client:         mgr.GetClient(),
-		EventRecorder:  eventRecorder,
-		Metrics:        metrics,
-		Storage:        storage,
-		ControllerName: controllerName,
+		Client:            mgr.GetClient(),
+		EventRecorder:     eventRecorder,
+		Metrics:           metrics,
+		SourceMetrics:     sourceMetrics,
+		Storage:           storage,
+		ControllerName:    controllerName,
+		DisableFinalizers: !manageFinalizers,
 	}).SetupWithManagerAndOptions(mgr, controller.BucketReconcilerOptions{
-		RateLimiter: helper.GetRateLimiter(rateLimiterOptions),
+		RateLimiter:      helper.GetRateLimiter(rateLimiterOptions),
+		ReconcileTrigger: reconcileTrigger,
 	}); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Bucket")
 		os.Exit(1)
 	}
 
 	if err := (&controller.OCIRepositoryReconciler{
-		Client:         mgr.GetClient(),
-		Storage:        storage,
-		EventRecorder:  eventRecorder,
-		ControllerName: controllerName,
-		Metrics:        metrics,
+		Client:            mgr.GetClient(),
+		Storage:           storage,
+		EventRecorder:     eventRecorder,
+		ControllerName:    controllerName,
+		Metrics:           metrics,
+		SourceMetrics:     sourceMetrics,
+		DisableFinalizers: !manageFinalizers,
 	}).SetupWithManagerAndOptions(mgr, controller.OCIRepositoryReconcilerOptions{
-		RateLimiter: helper.GetRateLimiter(rateLimiterOptions),
+		RateLimiter:      helper.GetRateLimiter(rateLimiterOptions),
+		ReconcileTrigger: reconcileTrigger,
 	}); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "OCIRepository")
 		os.Exit(1)
@@ -284,9 +493,17 @@ func main() {
 		// to handle that.
 		<-mgr.Elected()
 
-		startFileServer(storage.BasePath, storageAddr)
+		startFileServer(storage.BasePath, storageAddr, mgr.GetClient(), artifactTokens)
 	}()
 
+	if enableEndpoints {
+		go func() {
+			<-mgr.Elected()
+
+			startEndpointsServer(endpoints, endpointsAddr, endpointsCertFile, endpointsKeyFile)
+		}()
+	}
+
 	setupLog.Info("starting manager")
 	if err := mgr.Start(ctx); err != nil {
 		setupLog.Error(err, "problem running manager")
@@ -294,9 +511,9 @@ func main() {
 	}
 }
 
-func startFileServer(path string, address string) {
+func startFileServer(path string, address string, c ctrlclient.Reader, tokens *controller.ArtifactTokens) {
 	setupLog.Info("starting file server")
-	fs := http.FileServer(http.Dir(path))
+	fs := controller.WithPrivateArtifacts(controller.NewFileServer(path), c, tokens)
 	mux := http.NewServeMux()
 	mux.Handle("/", fs)
 	err := http.ListenAndServe(address, mux)
@@ -305,6 +522,30 @@ func startFileServer(path string, address string) {
 	}
 }
 
+// startEndpointsServer serves the authenticated endpoints, over TLS if a
+// certificate is configured. As the requests carry bearer tokens, serving
+// them over plaintext HTTP is only safe on a trusted network.
+func startEndpointsServer(mux *http.ServeMux, address, certFile, keyFile string) {
+	setupLog.Info("starting endpoints server", "tls", certFile != "")
+	if certFile == "" {
+		setupLog.Info("warning: the endpoints are served over plaintext HTTP, bearer tokens are sent unencrypted")
+	}
+	srv := &http.Server{
+		Addr:              address,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	var err error
+	if certFile != "" {
+		err = srv.ListenAndServeTLS(certFile, keyFile)
+	} else {
+		err = srv.ListenAndServe()
+	}
+	if err != nil {
+		setupLog.Error(err, "endpoints server error")
+	}
+}
+
 func mustSetupEventRecorder(mgr ctrl.Manager, eventsAddr, controllerName string) record.EventRecorder {
 	eventRecorder, err := events.NewRecorder(mgr, ctrl.Log, eventsAddr, controllerName)
 	if err != nil {
@@ -314,7 +555,68 @@ func mustSetupEventRecorder(mgr ctrl.Manager, eventsAddr, controllerName string)
 	return eventRecorder
 }
 
-func mustSetupManager(metricsAddr, healthAddr string, maxConcurrent int,
+// mustSetupTracing configures the global OpenTelemetry TracerProvider to
+// export the traces to the given OTLP/HTTP endpoint, if not empty. The
+// provider is flushed and shut down when the manager stops.
+func mustSetupTracing(mgr ctrl.Manager, endpoint string, insecure bool, sampleRatio float64) {
+	if endpoint == "" {
+		return
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(endpoint)}
+	if insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(context.Background(), opts...)
+	if err != nil {
+		setupLog.Error(err, "unable to create trace exporter")
+		os.Exit(1)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(controllerName))),
+	)
+	otel.SetTracerProvider(provider)
+
+	if err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		return provider.Shutdown(context.Background())
+	})); err != nil {
+		setupLog.Error(err, "unable to set up trace provider shutdown")
+		os.Exit(1)
+	}
+}
+
+// mustSetupStorageDrain makes the manager wait on shutdown for the artifact
+// locks held by in-flight reconciliations to be released, so that artifacts
+// which are being written are not left behind partially written.
+func mustSetupStorageDrain(mgr ctrl.Manager, storage *controller.Storage, timeout time.Duration) {
+	if err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+
+		drainCtx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		for _, s := range []*controller.Storage{storage, storage.Backup} {
+			if s == nil {
+				continue
+			}
+			if n := s.Held(); n > 0 {
+				setupLog.Info("waiting for in-flight artifact writes to complete", "path", s.BasePath, "locks", n)
+			}
+			if err := s.Drain(drainCtx); err != nil {
+				return fmt.Errorf("failed to drain storage '%s': %w", s.BasePath, err)
+			}
+		}
+		return nil
+	})); err != nil {
+		setupLog.Error(err, "unable to set up storage drain")
+		os.Exit(1)
+	}
+}
+
+func mustSetupManager(metricsAddr, healthAddr string, maxConcurrent int, gracefulShutdownTimeout time.Duration,
 	watchOpts helper.WatchOptions, clientOpts client.Options, leaderOpts leaderelection.Options) ctrl.Manager {
 
 	watchNamespace := ""
@@ -354,6 +656,7 @@ func mustSetupManager(metricsAddr, healthAddr string, maxConcurrent int,
 		RenewDeadline:                 &leaderOpts.RenewDeadline,
 		RetryPeriod:                   &leaderOpts.RetryPeriod,
 		LeaderElectionID:              leaderElectionId,
+		GracefulShutdownTimeout:       &gracefulShutdownTimeout,
 		Logger:                        ctrl.Log,
 		Client: ctrlclient.Options{
 			Cache: &ctrlclient.CacheOptions{
@@ -388,7 +691,112 @@ func mustSetupHelmLimits(indexLimit, chartLimit, chartFileLimit int64) {
 	helm.MaxChartFileSize = chartFileLimit
 }
 
-func mustInitHelmCache(maxSize int, itemTTL, purgeInterval string) (*cache.Cache, time.Duration) {
+func mustSetupHelmIndexDebugHandler(mgr ctrl.Manager, mux *http.ServeMux, indexCache *cache.Cache, tokenFile string) {
+	if tokenFile == "" {
+		return
+	}
+
+	b, err := os.ReadFile(tokenFile)
+	if err != nil {
+		setupLog.Error(err, "unable to read Helm index debug token file")
+		os.Exit(1)
+	}
+	token := strings.TrimSpace(string(b))
+	if token == "" {
+		setupLog.Error(errors.New("empty token"), "invalid Helm index debug token file")
+		os.Exit(1)
+	}
+
+	handler := &controller.HelmRepositoryIndexHandler{
+		Client: mgr.GetClient(),
+		Cache:  indexCache,
+		Token:  token,
+	}
+	mux.Handle(controller.HelmRepositoryIndexHandlerPath, handler)
+}
+
+func mustSetupReconcileTriggerHandler(mgr ctrl.Manager, mux *http.ServeMux, enabled bool) *controller.ReconcileTriggerHandler {
+	if !enabled {
+		return nil
+	}
+
+	handler := &controller.ReconcileTriggerHandler{
+		Client: mgr.GetClient(),
+	}
+	mux.Handle(controller.ReconcileTriggerHandlerPath, handler)
+	return handler
+}
+
+func mustSetupHelmVirtualIndexHandler(mgr ctrl.Manager, mux *http.ServeMux, storage *controller.Storage, enabled bool) {
+	if !enabled {
+		return
+	}
+
+	handler := &controller.HelmRepositoryVirtualIndexHandler{
+		Client:  mgr.GetClient(),
+		Storage: storage,
+	}
+	mux.Handle(controller.HelmRepositoryVirtualIndexHandlerPath, handler)
+}
+
+func mustInitArtifactTokens(keyFile string) *controller.ArtifactTokens {
+	if keyFile == "" {
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			setupLog.Error(err, "unable to generate artifact token key")
+			os.Exit(1)
+		}
+		return &controller.ArtifactTokens{Key: key}
+	}
+
+	b, err := os.ReadFile(keyFile)
+	if err != nil {
+		setupLog.Error(err, "unable to read artifact token key file")
+		os.Exit(1)
+	}
+	key := bytes.TrimSpace(b)
+	if len(key) == 0 {
+		setupLog.Error(errors.New("empty key"), "invalid artifact token key file")
+		os.Exit(1)
+	}
+	return &controller.ArtifactTokens{Key: key}
+}
+
+func mustInitArtifactSigner(keyFile string, disabled bool) *controller.ArtifactSigner {
+	if keyFile == "" || disabled {
+		return nil
+	}
+
+	b, err := os.ReadFile(keyFile)
+	if err != nil {
+		setupLog.Error(err, "unable to read artifact signing key file")
+		os.Exit(1)
+	}
+	signer, err := controller.NewArtifactSigner(b)
+	if err != nil {
+		setupLog.Error(err, "invalid artifact signing key file")
+		os.Exit(1)
+	}
+	return signer
+}
+
+func mustInitHostAllowlist(mgr ctrl.Manager, ref string) *controller.HostAllowlist {
+	if ref == "" {
+		return nil
+	}
+
+	namespace, name, ok := strings.Cut(ref, "/")
+	if !ok || namespace == "" || name == "" {
+		setupLog.Error(fmt.Errorf("expected '<namespace>/<name>', got '%s'", ref), "invalid allowed hosts ConfigMap")
+		os.Exit(1)
+	}
+	return &controller.HostAllowlist{
+		Reader:    mgr.GetClient(),
+		ConfigMap: types.NamespacedName{Namespace: namespace, Name: name},
+	}
+}
+
+func mustInitHelmCache(maxSize int, maxBytes int64, itemTTL, purgeInterval string) (*cache.Cache, time.Duration) {
 	if maxSize <= 0 {
 		setupLog.Info("caching of Helm index files is disabled")
 		return nil, -1
@@ -406,7 +814,10 @@ func mustInitHelmCache(maxSize int, itemTTL, purgeInterval string) (*cache.Cache
 		os.Exit(1)
 	}
 
-	return cache.New(maxSize, interval), ttl
+	c := cache.New(maxSize, interval)
+	c.MaxBytes = maxBytes
+	ctrlmetrics.Registry.MustRegister(cache.NewBytesCollector(c))
+	return c, ttl
 }
 
 func mustInitStorage(path string, storageAdvAddr string, artifactRetentionTTL time.Duration, artifactRetentionRecords int, artifactDigestAlgo string) *controller.Storage {
@@ -431,6 +842,47 @@ func mustInitStorage(path string, storageAdvAddr string, artifactRetentionTTL ti
 	return storage
 }
 
+func mustInitBackupStorage(path string, storage *controller.Storage) *controller.Storage {
+	if path == "" {
+		return nil
+	}
+	backup, err := controller.NewStorage(path, storage.Hostname, storage.ArtifactRetentionTTL, storage.ArtifactRetentionRecords)
+	if err != nil {
+		setupLog.Error(err, "unable to initialise backup storage")
+		os.Exit(1)
+	}
+	backup.FileMode = storage.FileMode
+	backup.DirMode = storage.DirMode
+	backup.ArtifactReplacementGracePeriod = storage.ArtifactReplacementGracePeriod
+	return backup
+}
+
+func mustParseFileMode(name, mode string) os.FileMode {
+	m, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil || m == 0 || m&^uint64(os.ModePerm) != 0 {
+		setupLog.Error(fmt.Errorf("invalid permission mode '%s'", mode), "unable to configure "+name)
+		os.Exit(1)
+	}
+	return os.FileMode(m)
+}
+
+func mustValidateEndpointsTLS(certFile, keyFile string) {
+	if (certFile == "") != (keyFile == "") {
+		setupLog.Error(errors.New("both or neither of --endpoints-tls-cert-file and --endpoints-tls-key-file must be set"),
+			"invalid endpoints TLS configuration")
+		os.Exit(1)
+	}
+}
+
+func mustValidateArtifactCompression(compression string) {
+	switch compression {
+	case "", controller.ArtifactCompressionZstd:
+	default:
+		setupLog.Error(fmt.Errorf("unsupported compression '%s'", compression), "unable to configure artifact compression")
+		os.Exit(1)
+	}
+}
+
 func determineAdvStorageAddr(storageAddr string) string {
 	host, port, err := net.SplitHostPort(storageAddr)
 	if err != nil {
@@ -34,6 +34,7 @@ import (
 	_ "github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
 	corev1 "k8s.io/api/core/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 
@@ -267,7 +268,24 @@ func (c *BlobClient) FGetObject(ctx context.Context, bucketName, objectName, loc
 // If the underlying client or the visit callback returns an error,
 // it returns early.
 func (c *BlobClient) VisitObjects(ctx context.Context, bucketName string, visit func(path, etag string) error) error {
-	items := c.NewListBlobsFlatPager(bucketName, nil)
+	return c.VisitObjectsWithTags(ctx, bucketName, nil, visit)
+}
+
+// VisitObjectsWithTags iterates over the items in the provided object storage
+// bucket which have all the provided blob index tags, calling visit for every
+// item. The tags are returned as part of the listing of the blobs, and matched
+// client-side as the Find Blobs by Tags results do not carry the ETag of a blob.
+// If the underlying client or the visit callback returns an error,
+// it returns early.
+func (c *BlobClient) VisitObjectsWithTags(ctx context.Context, bucketName string, tags map[string]string, visit func(path, etag string) error) error {
+	var opts *azblob.ListBlobsFlatOptions
+	if len(tags) > 0 {
+		opts = &azblob.ListBlobsFlatOptions{
+			Include: azblob.ListBlobsInclude{Tags: true},
+		}
+	}
+
+	items := c.NewListBlobsFlatPager(bucketName, opts)
 	for items.More() {
 		resp, err := items.NextPage(ctx)
 		if err != nil {
@@ -275,6 +293,9 @@ func (c *BlobClient) VisitObjects(ctx context.Context, bucketName string, visit
 			return err
 		}
 		for _, blob := range resp.Segment.BlobItems {
+			if len(tags) > 0 && !hasTags(blob.BlobTags, tags) {
+				continue
+			}
 			if err := visit(*blob.Name, fmt.Sprintf("%x", *blob.Properties.ETag)); err != nil {
 				err = fmt.Errorf("listing objects from bucket '%s' failed: %w", bucketName, err)
 				return err
@@ -285,6 +306,24 @@ func (c *BlobClient) VisitObjects(ctx context.Context, bucketName string, visit
 	return nil
 }
 
+// hasTags returns if the blob tags contain all the tags.
+func hasTags(blobTags *container.BlobTags, tags map[string]string) bool {
+	found := make(map[string]string)
+	if blobTags != nil {
+		for _, t := range blobTags.BlobTagSet {
+			if t != nil && t.Key != nil && t.Value != nil {
+				found[*t.Key] = *t.Value
+			}
+		}
+	}
+	for k, v := range tags {
+		if f, ok := found[k]; !ok || f != v {
+			return false
+		}
+	}
+	return true
+}
+
 // Close has no effect on BlobClient.
 func (c *BlobClient) Close(_ context.Context) {
 	return
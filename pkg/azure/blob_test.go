@@ -29,9 +29,11 @@ import (
 	"testing"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
 )
@@ -160,6 +162,53 @@ func TestBlobClient_ObjectIsNotFound(t *testing.T) {
 	}
 }
 
+func Test_hasTags(t *testing.T) {
+	blobTags := &container.BlobTags{
+		BlobTagSet: []*container.BlobTag{
+			{Key: to.Ptr("env"), Value: to.Ptr("prod")},
+			{Key: to.Ptr("team"), Value: to.Ptr("a")},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		blobTags *container.BlobTags
+		tags     map[string]string
+		want     bool
+	}{
+		{
+			name:     "all tags match",
+			blobTags: blobTags,
+			tags:     map[string]string{"env": "prod", "team": "a"},
+			want:     true,
+		},
+		{
+			name:     "tag value differs",
+			blobTags: blobTags,
+			tags:     map[string]string{"env": "dev"},
+		},
+		{
+			name:     "tag missing",
+			blobTags: blobTags,
+			tags:     map[string]string{"region": "eu"},
+		},
+		{
+			name: "no blob tags",
+			tags: map[string]string{"env": "prod"},
+		},
+		{
+			name: "no tags",
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(hasTags(tt.blobTags, tt.tags)).To(Equal(tt.want))
+		})
+	}
+}
+
 func Test_extractAccountNameFromEndpoint(t *testing.T) {
 	tests := []struct {
 		name     string
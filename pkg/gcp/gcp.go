@@ -166,6 +166,15 @@ func (c *GCSClient) FGetObject(ctx context.Context, bucketName, objectName, loca
 // If the underlying client or the visit callback returns an error,
 // it returns early.
 func (c *GCSClient) VisitObjects(ctx context.Context, bucketName string, visit func(path, etag string) error) error {
+	return c.VisitObjectsWithTags(ctx, bucketName, nil, visit)
+}
+
+// VisitObjectsWithTags iterates over the items in the provided object storage
+// bucket which have all the provided tags in their custom metadata, calling
+// visit for every item.
+// If the underlying client or the visit callback returns an error,
+// it returns early.
+func (c *GCSClient) VisitObjectsWithTags(ctx context.Context, bucketName string, tags map[string]string, visit func(path, etag string) error) error {
 	items := c.Client.Bucket(bucketName).Objects(ctx, nil)
 	for {
 		object, err := items.Next()
@@ -176,6 +185,9 @@ func (c *GCSClient) VisitObjects(ctx context.Context, bucketName string, visit f
 			err = fmt.Errorf("listing objects from bucket '%s' failed: %w", bucketName, err)
 			return err
 		}
+		if !hasTags(object.Metadata, tags) {
+			continue
+		}
 		if err = visit(object.Name, object.Etag); err != nil {
 			return err
 		}
@@ -183,6 +195,16 @@ func (c *GCSClient) VisitObjects(ctx context.Context, bucketName string, visit f
 	return nil
 }
 
+// hasTags returns if the metadata contains all the tags.
+func hasTags(metadata, tags map[string]string) bool {
+	for k, v := range tags {
+		if m, ok := metadata[k]; !ok || m != v {
+			return false
+		}
+	}
+	return true
+}
+
 // Close closes the GCP Client and logs any useful errors.
 func (c *GCSClient) Close(ctx context.Context) {
 	log := logr.FromContextOrDiscard(ctx)
@@ -180,6 +180,27 @@ func TestVisitObjects(t *testing.T) {
 	assert.DeepEqual(t, etags, []string{objectEtag})
 }
 
+func TestVisitObjectsWithTags(t *testing.T) {
+	gcpClient := &GCSClient{
+		Client: client,
+	}
+	keys := []string{}
+	err := gcpClient.VisitObjectsWithTags(context.Background(), bucketName, map[string]string{"env": "prod"}, func(key, etag string) error {
+		keys = append(keys, key)
+		return nil
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, keys, []string{objectName})
+
+	keys = []string{}
+	err = gcpClient.VisitObjectsWithTags(context.Background(), bucketName, map[string]string{"env": "dev"}, func(key, etag string) error {
+		keys = append(keys, key)
+		return nil
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, keys, []string{})
+}
+
 func TestVisitObjectsErr(t *testing.T) {
 	gcpClient := &GCSClient{
 		Client: client,
@@ -303,6 +324,7 @@ func getObject() *raw.Object {
 		Metageneration:          3,
 		Etag:                    objectEtag,
 		Md5Hash:                 objectEtag,
+		Metadata:                map[string]string{"env": "prod"},
 	}
 }
 
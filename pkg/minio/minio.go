@@ -122,6 +122,17 @@ func (c *MinioClient) VisitObjects(ctx context.Context, bucketName string, visit
 	return nil
 }
 
+// ObjectTags returns the tags of the object in the provided object storage
+// bucket. As S3 compatible storage APIs do not return the tags of objects
+// while listing them, the tags are retrieved for every object separately.
+func (c *MinioClient) ObjectTags(ctx context.Context, bucketName, objectKey string) (map[string]string, error) {
+	t, err := c.Client.GetObjectTagging(ctx, bucketName, objectKey, minio.GetObjectTaggingOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return t.ToMap(), nil
+}
+
 // ObjectIsNotFound checks if the error provided is a minio.ErrResponse
 // with "NoSuchKey" code.
 func (c *MinioClient) ObjectIsNotFound(err error) bool {